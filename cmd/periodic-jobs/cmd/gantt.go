@@ -3,12 +3,15 @@ package cmd
 import (
 	_ "embed"
 	"fmt"
+	"io"
 	"os"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/nao1215/markdown/mermaid/gantt"
+	"github.com/robfig/cron/v3"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 )
@@ -22,8 +25,14 @@ type runtimeConfig struct {
 	Default  float64            `yaml:"default"`
 }
 
+const (
+	ganttFormatMermaid = "mermaid"
+	ganttFormatHTML    = "html"
+)
+
 type ganttOptions struct {
 	runtimesFile string
+	format       string
 }
 
 var ganttOpts ganttOptions
@@ -32,11 +41,13 @@ var ganttOpts ganttOptions
 func GanttCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "gantt",
-		Short: "Generate a Mermaid Gantt chart of periodic job schedules",
+		Short: "Generate a Mermaid or HTML Gantt chart of periodic job schedules",
 		RunE:  runGantt,
 	}
 	cmd.Flags().StringVar(&ganttOpts.runtimesFile, "runtimes", "",
 		"Custom runtimes YAML file (optional, uses embedded defaults)")
+	cmd.Flags().StringVar(&ganttOpts.format, "format", ganttFormatMermaid,
+		fmt.Sprintf("Output format, one of %q, %q", ganttFormatMermaid, ganttFormatHTML))
 	return cmd
 }
 
@@ -127,19 +138,44 @@ func durationStr(h float64) string {
 
 type prowConfig struct {
 	Periodics []struct {
-		Name string `yaml:"name"`
-		Cron string `yaml:"cron"`
+		Name    string `yaml:"name"`
+		Cron    string `yaml:"cron"`
+		Cluster string `yaml:"cluster"`
 	} `yaml:"periodics"`
 }
 
 type jobRun struct {
-	version string
-	hour    int
-	minute  int
-	runtime float64
+	name     string
+	cron     string
+	cluster  string
+	version  string
+	hour     int
+	minute   int
+	runtime  float64
+	weekdays []time.Weekday
+}
+
+// parseGanttWeekdays returns the weekdays on which the cron fires.
+// Returns all weekdays for a wildcard or unparseable day-of-week field.
+func parseGanttWeekdays(cronExpr string) []time.Weekday {
+	var weekdays []time.Weekday
+	schedule, err := cron.ParseStandard(cronExpr)
+	spec, ok := schedule.(*cron.SpecSchedule)
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if err != nil || !ok || spec.Dow&(1<<uint(day)) != 0 {
+			weekdays = append(weekdays, day)
+		}
+	}
+	return weekdays
 }
 
 func runGantt(cmd *cobra.Command, args []string) error {
+	switch ganttOpts.format {
+	case ganttFormatMermaid, ganttFormatHTML:
+	default:
+		return fmt.Errorf("unsupported format %q", ganttOpts.format)
+	}
+
 	rtCfg, err := loadRuntimes(ganttOpts.runtimesFile)
 	if err != nil {
 		return fmt.Errorf("error loading runtimes: %w", err)
@@ -154,6 +190,18 @@ func runGantt(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("error parsing yaml: %w", err)
 	}
 
+	sections, sectionOrder := collectGanttRuns(cfg, rtCfg)
+
+	out := cmd.OutOrStdout()
+	if ganttOpts.format == ganttFormatHTML {
+		return writeGanttHTML(out, newGanttHTMLData(pattern, sections, sectionOrder))
+	}
+	return writeGanttMermaid(out, sections, sectionOrder)
+}
+
+// collectGanttRuns groups the runs of all periodics matching the pattern by
+// section. Runs are sorted by start time, sections alphabetically.
+func collectGanttRuns(cfg prowConfig, rtCfg runtimeConfig) (map[string][]jobRun, []string) {
 	// Collect runs per section, preserving first-seen section order.
 	sections := map[string][]jobRun{}
 	var sectionOrder []string
@@ -165,16 +213,21 @@ func runGantt(cmd *cobra.Command, args []string) error {
 		trimmed := strings.TrimPrefix(p.Name, pattern)
 		version, sec := extractParts(trimmed)
 		rt := runtimeFor(rtCfg, sec)
+		weekdays := parseGanttWeekdays(p.Cron)
 
 		if _, seen := sections[sec]; !seen {
 			sectionOrder = append(sectionOrder, sec)
 		}
 		for _, start := range parseGanttCron(p.Cron) {
 			sections[sec] = append(sections[sec], jobRun{
-				version: version,
-				hour:    start[0],
-				minute:  start[1],
-				runtime: rt,
+				name:     p.Name,
+				cron:     p.Cron,
+				cluster:  p.Cluster,
+				version:  version,
+				hour:     start[0],
+				minute:   start[1],
+				runtime:  rt,
+				weekdays: weekdays,
 			})
 		}
 	}
@@ -193,7 +246,10 @@ func runGantt(cmd *cobra.Command, args []string) error {
 		return sectionOrder[i] < sectionOrder[j]
 	})
 
-	out := cmd.OutOrStdout()
+	return sections, sectionOrder
+}
+
+func writeGanttMermaid(out io.Writer, sections map[string][]jobRun, sectionOrder []string) error {
 	fmt.Fprintln(out, "```mermaid")
 	title := fmt.Sprintf("%s* Schedule (24h)", pattern)
 	chart := gantt.NewChart(
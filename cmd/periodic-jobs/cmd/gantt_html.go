@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"kubevirt.io/project-infra/pkg/flakefinder"
+)
+
+//go:embed gantt.gohtml
+var ganttHTMLTemplate string
+
+const (
+	minutesPerDay = 24 * 60
+	// slotMinutes is the resolution of the concurrency histogram.
+	slotMinutes = 15
+	slotsPerDay = minutesPerDay / slotMinutes
+)
+
+// ganttHTMLData is the data rendered by gantt.gohtml.
+type ganttHTMLData struct {
+	Title    string
+	Date     time.Time
+	Sections []ganttHTMLSection
+	Weekdays []time.Weekday
+	Ticks    []ganttHTMLTick
+	// Slots holds the start time of each concurrency histogram slot.
+	Slots []string
+	// Concurrency holds the number of runs active per weekday and slot.
+	Concurrency [7][slotsPerDay]int
+}
+
+// ganttHTMLTick is a label on the time axis.
+type ganttHTMLTick struct {
+	Label string
+	Left  float64
+}
+
+// ganttHTMLSection groups the lanes of one sig section.
+type ganttHTMLSection struct {
+	Name  string
+	Lanes []ganttHTMLLane
+}
+
+// ganttHTMLLane holds all runs of one k8s version within a section.
+type ganttHTMLLane struct {
+	Version string
+	Runs    []ganttHTMLRun
+}
+
+// ganttHTMLRun is a single scheduled run of a job.
+type ganttHTMLRun struct {
+	Name     string
+	Cron     string
+	Cluster  string
+	Start    string
+	Runtime  string
+	Weekdays string
+	Segments []ganttHTMLSegment
+}
+
+// ganttHTMLSegment is the visible part of a run bar, in percent of the day.
+// Runs crossing midnight are split into two segments.
+type ganttHTMLSegment struct {
+	Left  float64
+	Width float64
+	// DayMask lists the weekday numbers (Sunday=0) the segment is active on, space separated.
+	DayMask string
+}
+
+func newGanttHTMLData(pattern string, sections map[string][]jobRun, sectionOrder []string) ganttHTMLData {
+	data := ganttHTMLData{
+		Title: fmt.Sprintf("%s* Schedule (24h)", pattern),
+		Date:  time.Now(),
+	}
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		data.Weekdays = append(data.Weekdays, day)
+	}
+	for hour := 0; hour < 24; hour += 3 {
+		data.Ticks = append(data.Ticks, ganttHTMLTick{Label: timeStr(hour, 0), Left: percentOfDay(hour * 60)})
+	}
+	for slot := 0; slot < slotsPerDay; slot++ {
+		data.Slots = append(data.Slots, timeStr(slot*slotMinutes/60, slot*slotMinutes%60))
+	}
+
+	for _, sec := range sectionOrder {
+		section := ganttHTMLSection{Name: sec}
+		lanes := map[string]*ganttHTMLLane{}
+		var versions []string
+		for _, r := range sections[sec] {
+			lane, exists := lanes[r.version]
+			if !exists {
+				lane = &ganttHTMLLane{Version: r.version}
+				lanes[r.version] = lane
+				versions = append(versions, r.version)
+			}
+			lane.Runs = append(lane.Runs, newGanttHTMLRun(r))
+			addConcurrency(&data.Concurrency, r)
+		}
+		sort.Strings(versions)
+		for _, version := range versions {
+			section.Lanes = append(section.Lanes, *lanes[version])
+		}
+		data.Sections = append(data.Sections, section)
+	}
+	return data
+}
+
+func newGanttHTMLRun(r jobRun) ganttHTMLRun {
+	var dayNames []string
+	for _, day := range r.weekdays {
+		dayNames = append(dayNames, day.String()[:3])
+	}
+	weekdays := strings.Join(dayNames, ",")
+	if len(r.weekdays) == 7 {
+		weekdays = "daily"
+	}
+
+	start := r.hour*60 + r.minute
+	end := start + int(r.runtime*60)
+	var segments []ganttHTMLSegment
+	for dayOffset := 0; dayOffset*minutesPerDay < end; dayOffset++ {
+		segStart := max(start, dayOffset*minutesPerDay) - dayOffset*minutesPerDay
+		segEnd := min(end, (dayOffset+1)*minutesPerDay) - dayOffset*minutesPerDay
+		if segEnd <= segStart {
+			continue
+		}
+		var dayNumbers []string
+		for _, day := range r.weekdays {
+			dayNumbers = append(dayNumbers, fmt.Sprintf("%d", (int(day)+dayOffset)%7))
+		}
+		segments = append(segments, ganttHTMLSegment{
+			Left:    percentOfDay(segStart),
+			Width:   percentOfDay(segEnd - segStart),
+			DayMask: strings.Join(dayNumbers, " "),
+		})
+	}
+
+	return ganttHTMLRun{
+		Name:     r.name,
+		Cron:     r.cron,
+		Cluster:  r.cluster,
+		Start:    timeStr(r.hour, r.minute),
+		Runtime:  durationStr(r.runtime),
+		Weekdays: weekdays,
+		Segments: segments,
+	}
+}
+
+func percentOfDay(minutes int) float64 {
+	return float64(minutes) * 100 / minutesPerDay
+}
+
+// addConcurrency counts the run in every slot it overlaps, on every weekday
+// it starts on. Runs crossing midnight are counted on the following weekday.
+func addConcurrency(concurrency *[7][slotsPerDay]int, r jobRun) {
+	start := r.hour*60 + r.minute
+	end := start + int(r.runtime*60)
+	firstSlot := start / slotMinutes
+	lastSlot := (end + slotMinutes - 1) / slotMinutes
+	for _, day := range r.weekdays {
+		for slot := firstSlot; slot < lastSlot; slot++ {
+			weekSlot := (int(day)*slotsPerDay + slot) % (7 * slotsPerDay)
+			concurrency[weekSlot/slotsPerDay][weekSlot%slotsPerDay]++
+		}
+	}
+}
+
+func writeGanttHTML(out io.Writer, data ganttHTMLData) error {
+	if err := flakefinder.WriteTemplateToOutput(ganttHTMLTemplate, data, out); err != nil {
+		return fmt.Errorf("failed to write html: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseGanttWeekdays(t *testing.T) {
+	tests := []struct {
+		name     string
+		cron     string
+		expected []time.Weekday
+	}{
+		{
+			name:     "daily",
+			cron:     "30 4,12,20 * * *",
+			expected: []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday},
+		},
+		{
+			name:     "sunday only",
+			cron:     "15 22 * * 0",
+			expected: []time.Weekday{time.Sunday},
+		},
+		{
+			name:     "range and list",
+			cron:     "5 7 * * 1-2,6",
+			expected: []time.Weekday{time.Monday, time.Tuesday, time.Saturday},
+		},
+		{
+			name:     "unparseable falls back to daily",
+			cron:     "5 7 * * funday",
+			expected: []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseGanttWeekdays(tt.cron)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("parseGanttWeekdays() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNewGanttHTMLRunSplitsAtMidnight(t *testing.T) {
+	run := newGanttHTMLRun(jobRun{
+		name:     "periodic-kubevirt-e2e-k8s-1.35-sig-compute",
+		hour:     22,
+		minute:   0,
+		runtime:  3,
+		weekdays: []time.Weekday{time.Saturday},
+	})
+
+	expected := []ganttHTMLSegment{
+		{Left: percentOfDay(22 * 60), Width: percentOfDay(2 * 60), DayMask: "6"},
+		{Left: 0, Width: percentOfDay(60), DayMask: "0"},
+	}
+	if !reflect.DeepEqual(run.Segments, expected) {
+		t.Errorf("newGanttHTMLRun() segments = %v, want %v", run.Segments, expected)
+	}
+	if run.Weekdays != "Sat" {
+		t.Errorf("newGanttHTMLRun() weekdays = %q, want %q", run.Weekdays, "Sat")
+	}
+}
+
+func TestAddConcurrency(t *testing.T) {
+	var concurrency [7][slotsPerDay]int
+	addConcurrency(&concurrency, jobRun{hour: 23, minute: 30, runtime: 1, weekdays: []time.Weekday{time.Saturday}})
+	addConcurrency(&concurrency, jobRun{hour: 0, minute: 0, runtime: 0.5, weekdays: []time.Weekday{time.Sunday}})
+
+	tests := []struct {
+		day      time.Weekday
+		slot     int
+		expected int
+	}{
+		{day: time.Saturday, slot: slotsPerDay - 3, expected: 0},
+		{day: time.Saturday, slot: slotsPerDay - 2, expected: 1},
+		{day: time.Saturday, slot: slotsPerDay - 1, expected: 1},
+		{day: time.Sunday, slot: 0, expected: 2},
+		{day: time.Sunday, slot: 1, expected: 2},
+		{day: time.Sunday, slot: 2, expected: 0},
+	}
+	for _, tt := range tests {
+		if got := concurrency[tt.day][tt.slot]; got != tt.expected {
+			t.Errorf("concurrency[%s][%d] = %d, want %d", tt.day, tt.slot, got, tt.expected)
+		}
+	}
+}
+
+func TestWriteGanttHTML(t *testing.T) {
+	sections := map[string][]jobRun{
+		"sig-network": {
+			{name: "periodic-kubevirt-e2e-k8s-1.34-sig-network", cron: "0 3 * * *", cluster: "prow-workloads",
+				version: "1.34", hour: 3, runtime: 2.5, weekdays: parseGanttWeekdays("0 3 * * *")},
+			{name: "periodic-kubevirt-e2e-k8s-1.35-sig-network", cron: "0 4 * * *", cluster: "prow-workloads",
+				version: "1.35", hour: 4, runtime: 2.5, weekdays: parseGanttWeekdays("0 4 * * *")},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := writeGanttHTML(&buf, newGanttHTMLData("periodic-kubevirt-e2e-k8s-", sections, []string{"sig-network"})); err != nil {
+		t.Fatalf("writeGanttHTML() error = %v", err)
+	}
+
+	html := buf.String()
+	for _, expected := range []string{
+		`data-name="periodic-kubevirt-e2e-k8s-1.34-sig-network"`,
+		`data-cluster="prow-workloads"`,
+		`data-runtime="2h30m"`,
+		`<div class="laneName">1.35</div>`,
+	} {
+		if !strings.Contains(html, expected) {
+			t.Errorf("writeGanttHTML() output does not contain %q", expected)
+		}
+	}
+}
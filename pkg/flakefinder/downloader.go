@@ -32,6 +32,7 @@ import (
 	"time"
 
 	"kubevirt.io/project-infra/pkg/flakefinder/api"
+	junitMerge "kubevirt.io/project-infra/pkg/flakefinder/junit-merge"
 
 	"cloud.google.com/go/storage"
 	"github.com/joshdk/go-junit"
@@ -152,7 +153,7 @@ func findUnitTestFileForJob(ctx context.Context, client *storage.Client, bucket
 				logrus.Warningf("Failed to read clone-records.json for %s/%d: %v", job, build, err)
 				commitID = ""
 			}
-			report, err := junit.Ingest(data)
+			report, err := ingestJUnit(data)
 			if err != nil {
 				return nil, err
 			}
@@ -244,7 +245,7 @@ func FindUnitTestFilesForPeriodicJob(ctx context.Context, client *storage.Client
 				logrus.Warningf("Failed to read clone-records.json for periodic job %s/%d: %v", buildDirPath, build, err)
 				commitID = ""
 			}
-			report, err := junit.Ingest(data)
+			report, err := ingestJUnit(data)
 			if err != nil {
 				return nil, err
 			}
@@ -368,7 +369,7 @@ func FindUnitTestFilesForBatchJobs(ctx context.Context, client *storage.Client,
 				if err != nil {
 					return nil, err
 				}
-				report, err := junit.Ingest(data)
+				report, err := ingestJUnit(data)
 				if err != nil {
 					return nil, err
 				}
@@ -380,6 +381,16 @@ func FindUnitTestFilesForBatchJobs(ctx context.Context, client *storage.Client,
 	return reports, nil
 }
 
+// ingestJUnit parses the junit data and merges the attempts per test, so that the Ginkgo flake attempts of a test that
+// passed on retry are taken into account, see junitMerge.PropertyPassedOnRetry.
+func ingestJUnit(data []byte) ([]junit.Suite, error) {
+	suites, err := junitMerge.Ingest(data)
+	if err != nil {
+		return nil, err
+	}
+	return junitMerge.ToSuites(junitMerge.MergeAttempts([]junitMerge.Source{{Name: "junit.functest.xml", Suites: suites}})), nil
+}
+
 func readGcsObject(ctx context.Context, client *storage.Client, bucket, object string) ([]byte, error) {
 	logrus.Infof("Trying to read gcs object '%s' in bucket '%s'\n", object, bucket)
 	o := client.Bucket(bucket).Object(object)
@@ -0,0 +1,186 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package junit_merge
+
+import (
+	"sort"
+	"time"
+
+	"github.com/joshdk/go-junit"
+)
+
+// Source is a set of junit suites together with where they came from, i.e. the junit file or the lane
+// that produced them.
+type Source struct {
+	Name   string
+	Suites []junit.Suite
+}
+
+// Attempt is a single execution of a test.
+type Attempt struct {
+	Source   string        `json:"source"`
+	Suite    string        `json:"suite,omitempty"`
+	Status   junit.Status  `json:"status"`
+	Duration time.Duration `json:"duration"`
+	Message  string        `json:"message,omitempty"`
+}
+
+// TestResult is the merged result over all attempts of a test.
+type TestResult struct {
+	Name      string       `json:"name"`
+	Classname string       `json:"classname,omitempty"`
+	Status    junit.Status `json:"status"`
+	Attempts  []Attempt    `json:"attempts"`
+}
+
+// PassedOnRetry returns whether the test passed, but only after at least one attempt did fail.
+func (r TestResult) PassedOnRetry() bool {
+	if r.Status != junit.StatusPassed {
+		return false
+	}
+	for _, attempt := range r.Attempts {
+		if isFailure(attempt.Status) {
+			return true
+		}
+	}
+	return false
+}
+
+// Duration returns the sum of the durations of all attempts.
+func (r TestResult) Duration() time.Duration {
+	var duration time.Duration
+	for _, attempt := range r.Attempts {
+		duration += attempt.Duration
+	}
+	return duration
+}
+
+// MergeAttempts merges the tests of all sources into one result per test name while keeping every attempt
+// of each test in the order they appear in the sources.
+//
+// A test counts as passed if any of its attempts passed (which matches how Ginkgo treats flake attempts),
+// as failed or errored if none passed but any failed or errored, and as skipped otherwise.
+func MergeAttempts(sources []Source) []TestResult {
+	var testNames []string
+	resultsByName := map[string]*TestResult{}
+	for _, source := range sources {
+		for _, suite := range source.Suites {
+			for _, test := range suite.Tests {
+				result, exists := resultsByName[test.Name]
+				if !exists {
+					result = &TestResult{Name: test.Name, Classname: test.Classname}
+					resultsByName[test.Name] = result
+					testNames = append(testNames, test.Name)
+				}
+				attempt := Attempt{
+					Source:   source.Name,
+					Suite:    suite.Name,
+					Status:   test.Status,
+					Duration: test.Duration,
+				}
+				if test.Error != nil {
+					attempt.Message = test.Error.Error()
+				}
+				result.Attempts = append(result.Attempts, attempt)
+			}
+		}
+	}
+
+	sort.Strings(testNames)
+	results := make([]TestResult, 0, len(testNames))
+	for _, testName := range testNames {
+		result := resultsByName[testName]
+		result.Status = mergedStatus(result.Attempts)
+		results = append(results, *result)
+	}
+	return results
+}
+
+func mergedStatus(attempts []Attempt) junit.Status {
+	statusCounts := map[junit.Status]int{}
+	for _, attempt := range attempts {
+		statusCounts[attempt.Status]++
+	}
+	for _, status := range []junit.Status{junit.StatusPassed, junit.StatusFailed, junit.StatusError} {
+		if statusCounts[status] > 0 {
+			return status
+		}
+	}
+	return junit.StatusSkipped
+}
+
+func isFailure(status junit.Status) bool {
+	return status == junit.StatusFailed || status == junit.StatusError
+}
+
+// Totals holds the number of merged tests per status.
+type Totals struct {
+	Tests         int           `json:"tests"`
+	Passed        int           `json:"passed"`
+	Skipped       int           `json:"skipped"`
+	Failed        int           `json:"failed"`
+	Error         int           `json:"error"`
+	PassedOnRetry int           `json:"passedOnRetry"`
+	Attempts      int           `json:"attempts"`
+	Duration      time.Duration `json:"duration"`
+}
+
+// Summary is the JSON representation of a merge that keeps all attempts.
+type Summary struct {
+	Sources []string     `json:"sources"`
+	Totals  Totals       `json:"totals"`
+	Tests   []TestResult `json:"tests"`
+}
+
+// NewSummary creates the summary for the results merged from the given sources.
+func NewSummary(sources []Source, results []TestResult) Summary {
+	summary := Summary{
+		Sources: []string{},
+		Tests:   results,
+	}
+	for _, source := range sources {
+		summary.Sources = append(summary.Sources, source.Name)
+	}
+	summary.Totals = totals(results)
+	return summary
+}
+
+func totals(results []TestResult) Totals {
+	t := Totals{}
+	for _, result := range results {
+		t.Tests++
+		switch result.Status {
+		case junit.StatusPassed:
+			t.Passed++
+		case junit.StatusSkipped:
+			t.Skipped++
+		case junit.StatusFailed:
+			t.Failed++
+		case junit.StatusError:
+			t.Error++
+		}
+		if result.PassedOnRetry() {
+			t.PassedOnRetry++
+		}
+		t.Attempts += len(result.Attempts)
+		t.Duration += result.Duration()
+	}
+	return t
+}
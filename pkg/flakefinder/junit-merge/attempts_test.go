@@ -0,0 +1,330 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package junit_merge
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joshdk/go-junit"
+)
+
+func TestMergeAttempts(t *testing.T) {
+	tests := []struct {
+		name              string
+		sources           []Source
+		wantStatus        map[string]junit.Status
+		wantAttempts      map[string][]Attempt
+		wantPassedOnRetry map[string]bool
+	}{
+		{
+			name: "flake attempts in one source are kept",
+			sources: []Source{
+				{
+					Name: "junit.functest.xml",
+					Suites: []junit.Suite{
+						{
+							Name: "Tests Suite",
+							Tests: []junit.Test{
+								{Name: "flaky test", Status: junit.StatusFailed, Duration: time.Second, Error: errors.New("timed out")},
+								{Name: "flaky test", Status: junit.StatusPassed, Duration: 2 * time.Second},
+							},
+						},
+					},
+				},
+			},
+			wantStatus: map[string]junit.Status{"flaky test": junit.StatusPassed},
+			wantAttempts: map[string][]Attempt{
+				"flaky test": {
+					{Source: "junit.functest.xml", Suite: "Tests Suite", Status: junit.StatusFailed, Duration: time.Second, Message: "timed out"},
+					{Source: "junit.functest.xml", Suite: "Tests Suite", Status: junit.StatusPassed, Duration: 2 * time.Second},
+				},
+			},
+			wantPassedOnRetry: map[string]bool{"flaky test": true},
+		},
+		{
+			name: "retries across sources keep their provenance",
+			sources: []Source{
+				{
+					Name:   "partial.junit.functest.1.xml",
+					Suites: []junit.Suite{{Name: "Tests Suite", Tests: []junit.Test{{Name: "test", Status: junit.StatusError}}}},
+				},
+				{
+					Name:   "partial.junit.functest.2.xml",
+					Suites: []junit.Suite{{Name: "Tests Suite", Tests: []junit.Test{{Name: "test", Status: junit.StatusFailed}}}},
+				},
+			},
+			wantStatus: map[string]junit.Status{"test": junit.StatusFailed},
+			wantAttempts: map[string][]Attempt{
+				"test": {
+					{Source: "partial.junit.functest.1.xml", Suite: "Tests Suite", Status: junit.StatusError},
+					{Source: "partial.junit.functest.2.xml", Suite: "Tests Suite", Status: junit.StatusFailed},
+				},
+			},
+			wantPassedOnRetry: map[string]bool{"test": false},
+		},
+		{
+			name: "skipped attempts do not make a test flaky",
+			sources: []Source{
+				{
+					Name: "lane-a",
+					Suites: []junit.Suite{{Name: "Tests Suite", Tests: []junit.Test{
+						{Name: "skipped test", Status: junit.StatusSkipped},
+						{Name: "passed test", Status: junit.StatusSkipped},
+					}}},
+				},
+				{
+					Name:   "lane-b",
+					Suites: []junit.Suite{{Name: "Tests Suite", Tests: []junit.Test{{Name: "passed test", Status: junit.StatusPassed}}}},
+				},
+			},
+			wantStatus: map[string]junit.Status{
+				"skipped test": junit.StatusSkipped,
+				"passed test":  junit.StatusPassed,
+			},
+			wantPassedOnRetry: map[string]bool{
+				"skipped test": false,
+				"passed test":  false,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MergeAttempts(tt.sources)
+			if len(got) != len(tt.wantStatus) {
+				t.Errorf("MergeAttempts() got %d results, want %d", len(got), len(tt.wantStatus))
+			}
+			for _, result := range got {
+				if result.Status != tt.wantStatus[result.Name] {
+					t.Errorf("MergeAttempts() %q status = %v, want %v", result.Name, result.Status, tt.wantStatus[result.Name])
+				}
+				if result.PassedOnRetry() != tt.wantPassedOnRetry[result.Name] {
+					t.Errorf("MergeAttempts() %q PassedOnRetry() = %v, want %v", result.Name, result.PassedOnRetry(), tt.wantPassedOnRetry[result.Name])
+				}
+				if wantAttempts, ok := tt.wantAttempts[result.Name]; ok && !reflect.DeepEqual(result.Attempts, wantAttempts) {
+					t.Errorf("MergeAttempts() %q attempts = %v, want %v", result.Name, result.Attempts, wantAttempts)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeAttemptsKeepsEveryTestRunOfTestData(t *testing.T) {
+	var sources []Source
+	totalTestRuns := 0
+	for i, suites := range loadTestData("testdata/conflict") {
+		sources = append(sources, Source{Name: string(rune('a' + i)), Suites: suites})
+		for _, suite := range suites {
+			totalTestRuns += len(suite.Tests)
+		}
+	}
+
+	summary := NewSummary(sources, MergeAttempts(sources))
+
+	if summary.Totals.Attempts != totalTestRuns {
+		t.Errorf("NewSummary() attempts = %d, want %d", summary.Totals.Attempts, totalTestRuns)
+	}
+	if summary.Totals.Tests != summary.Totals.Passed+summary.Totals.Skipped+summary.Totals.Failed+summary.Totals.Error {
+		t.Errorf("NewSummary() totals do not add up: %+v", summary.Totals)
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	sources := []Source{
+		{
+			Name: "lane-a",
+			Suites: []junit.Suite{{Name: "Tests Suite", Tests: []junit.Test{
+				{Name: "flaky test", Status: junit.StatusFailed, Error: errors.New("boom")},
+				{Name: "failed test", Status: junit.StatusFailed, Error: errors.New("failed <again>")},
+			}}},
+		},
+		{
+			Name: "lane-b",
+			Suites: []junit.Suite{{Name: "Tests Suite", Tests: []junit.Test{
+				{Name: "flaky test", Status: junit.StatusPassed},
+				{Name: "skipped test", Status: junit.StatusSkipped},
+			}}},
+		},
+	}
+	results := MergeAttempts(sources)
+
+	var buffer bytes.Buffer
+	if err := WriteJUnit(&buffer, sources, results); err != nil {
+		t.Fatalf("WriteJUnit() error = %v", err)
+	}
+
+	suites, err := junit.Ingest(buffer.Bytes())
+	if err != nil {
+		t.Fatalf("failed to ingest written junit: %v", err)
+	}
+	if len(suites) != 1 {
+		t.Fatalf("got %d suites, want 1", len(suites))
+	}
+	testsByName := map[string]junit.Test{}
+	for _, test := range suites[0].Tests {
+		testsByName[test.Name] = test
+	}
+	for name, want := range map[string]junit.Status{
+		"flaky test":   junit.StatusPassed,
+		"failed test":  junit.StatusFailed,
+		"skipped test": junit.StatusSkipped,
+	} {
+		if testsByName[name].Status != want {
+			t.Errorf("%q status = %v, want %v", name, testsByName[name].Status, want)
+		}
+	}
+	flakyTest := testsByName["flaky test"]
+	if flakyTest.Properties[PropertyPassedOnRetry] != "true" {
+		t.Errorf("%q properties = %v, want %s=true", flakyTest.Name, flakyTest.Properties, PropertyPassedOnRetry)
+	}
+	if flakyTest.Properties[PropertyAttempts] != "2" {
+		t.Errorf("%q properties = %v, want %s=2", flakyTest.Name, flakyTest.Properties, PropertyAttempts)
+	}
+	if _, exists := testsByName["failed test"].Properties[PropertyPassedOnRetry]; exists {
+		t.Errorf("%q must not have property %s", "failed test", PropertyPassedOnRetry)
+	}
+	if !bytes.Contains(buffer.Bytes(), []byte(`<property name="attempt.2.source" value="lane-b"></property>`)) {
+		t.Errorf("attempt provenance missing from output:\n%s", buffer.String())
+	}
+}
+
+func TestToSuites(t *testing.T) {
+	results := MergeAttempts([]Source{
+		{
+			Name: "junit.functest.xml",
+			Suites: []junit.Suite{{Name: "Tests Suite", Tests: []junit.Test{
+				{Name: "flaky test", Status: junit.StatusFailed, Error: errors.New("boom")},
+				{Name: "flaky test", Status: junit.StatusPassed},
+				{Name: "failed test", Status: junit.StatusFailed, Error: errors.New("boom")},
+			}}},
+		},
+	})
+
+	suites := ToSuites(results)
+
+	if len(suites) != 1 || suites[0].Name != MergedSuiteName {
+		t.Fatalf("ToSuites() = %v, want one suite named %q", suites, MergedSuiteName)
+	}
+	if suites[0].Totals.Tests != 2 || suites[0].Totals.Passed != 1 || suites[0].Totals.Failed != 1 {
+		t.Errorf("ToSuites() totals = %+v", suites[0].Totals)
+	}
+	for _, test := range suites[0].Tests {
+		switch test.Name {
+		case "flaky test":
+			if test.Properties[PropertyPassedOnRetry] != "true" || test.Error != nil {
+				t.Errorf("ToSuites() %q = %+v", test.Name, test)
+			}
+		case "failed test":
+			if test.Error == nil || test.Error.Error() != "boom" {
+				t.Errorf("ToSuites() %q error = %v, want boom", test.Name, test.Error)
+			}
+		}
+	}
+}
+
+func TestMergeAttemptsDetectsGinkgoFlakeAttempts(t *testing.T) {
+	suites, err := IngestFile("testdata/flakeattempts/junit.functest.xml")
+	if err != nil {
+		t.Fatalf("IngestFile() error = %v", err)
+	}
+	results := MergeAttempts([]Source{{Name: "junit.functest.xml", Suites: suites}})
+
+	resultsByName := map[string]TestResult{}
+	for _, result := range results {
+		resultsByName[result.Name] = result
+	}
+	tests := []struct {
+		name              string
+		wantStatus        junit.Status
+		wantStatuses      []junit.Status
+		wantPassedOnRetry bool
+	}{
+		{
+			name:              "[It] [sig-compute] VirtualMachine [test_id:3007] should force restart a VM",
+			wantStatus:        junit.StatusPassed,
+			wantStatuses:      []junit.Status{junit.StatusFailed, junit.StatusFailed, junit.StatusPassed},
+			wantPassedOnRetry: true,
+		},
+		{
+			name:         "[It] [sig-compute] VirtualMachine should start a VM",
+			wantStatus:   junit.StatusPassed,
+			wantStatuses: []junit.Status{junit.StatusPassed},
+		},
+		{
+			name:         "[It] [sig-compute] VirtualMachine [test_id:1527] should fail to start a VM",
+			wantStatus:   junit.StatusFailed,
+			wantStatuses: []junit.Status{junit.StatusFailed, junit.StatusFailed, junit.StatusFailed},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, exists := resultsByName[tt.name]
+			if !exists {
+				t.Fatalf("MergeAttempts() has no result for %q", tt.name)
+			}
+			if result.Status != tt.wantStatus {
+				t.Errorf("MergeAttempts() status = %v, want %v", result.Status, tt.wantStatus)
+			}
+			var statuses []junit.Status
+			for _, attempt := range result.Attempts {
+				statuses = append(statuses, attempt.Status)
+				if attempt.Duration < 0 {
+					t.Errorf("MergeAttempts() attempt duration = %v, want >= 0", attempt.Duration)
+				}
+			}
+			if !reflect.DeepEqual(statuses, tt.wantStatuses) {
+				t.Errorf("MergeAttempts() attempt statuses = %v, want %v", statuses, tt.wantStatuses)
+			}
+			if result.PassedOnRetry() != tt.wantPassedOnRetry {
+				t.Errorf("MergeAttempts() passed on retry = %v, want %v", result.PassedOnRetry(), tt.wantPassedOnRetry)
+			}
+		})
+	}
+
+	flakyAttempt := resultsByName["[It] [sig-compute] VirtualMachine [test_id:3007] should force restart a VM"].Attempts[0]
+	if !strings.HasPrefix(flakyAttempt.Message, "VM did not start") {
+		t.Errorf("MergeAttempts() failed attempt message = %q, want the failure of the attempt", flakyAttempt.Message)
+	}
+}
+
+func Test_ginkgoFailedAttemptsDurations(t *testing.T) {
+	systemErr := `> Enter [It] flaky test - tests/flaky.go:10 @ 10/19/26 10:00:00
+[FAILED] Failure recorded during attempt 1:
+timed out
+In [It] at: tests/flaky.go:12 @ 10/19/26 10:04:00
+< Exit [It] flaky test - tests/flaky.go:10 @ 10/19/26 10:04:00 (4m0s)
+
+Attempt #1 Failed.  Retrying ↺ @ 10/19/26 10:05:00
+
+> Enter [It] flaky test - tests/flaky.go:10 @ 10/19/26 10:05:00
+< Exit [It] flaky test - tests/flaky.go:10 @ 10/19/26 10:06:00 (1m0s)
+`
+
+	attempts := ginkgoFailedAttempts(systemErr)
+
+	want := []Attempt{{Status: junit.StatusFailed, Duration: 5 * time.Minute, Message: "timed out"}}
+	if !reflect.DeepEqual(attempts, want) {
+		t.Errorf("ginkgoFailedAttempts() = %+v, want %+v", attempts, want)
+	}
+}
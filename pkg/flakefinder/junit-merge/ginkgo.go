@@ -0,0 +1,207 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package junit_merge
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/joshdk/go-junit"
+)
+
+// ginkgoTimeFormat is the default format of the timestamps in the Ginkgo timeline, see types.GINKGO_TIME_FORMAT
+const ginkgoTimeFormat = "01/02/06 15:04:05.999"
+
+var (
+	ginkgoTimestamp       = regexp.MustCompile(`@ (\d\d/\d\d/\d\d \d\d:\d\d:\d\d(\.\d+)?)`)
+	ginkgoAttemptFailure  = regexp.MustCompile(`^\[FAILED] Failure recorded during attempt (\d+):$`)
+	ginkgoAttemptRetrying = regexp.MustCompile(`^Attempt #(\d+) Failed\.\s+Retrying`)
+)
+
+// Ingest parses the junit data like junit.Ingest, but expands every Ginkgo spec that failed before it was retried,
+// i.e. when running with --flake-attempts, into one test per attempt, so that MergeAttempts detects the flake.
+// Ginkgo reports a retried spec as one testcase with the status of the last attempt, the earlier attempts are only
+// visible in the timeline it writes into the system-err of the testcase, which junit.Ingest drops.
+func Ingest(data []byte) ([]junit.Suite, error) {
+	suites, err := junit.Ingest(data)
+	if err != nil {
+		return nil, err
+	}
+	systemErrs, err := testcaseSystemErrs(data)
+	if err != nil {
+		return nil, err
+	}
+	for i := range suites {
+		var tests []junit.Test
+		for _, test := range suites[i].Tests {
+			var systemErr string
+			if remaining := systemErrs[test.Name]; len(remaining) > 0 {
+				systemErr, systemErrs[test.Name] = remaining[0], remaining[1:]
+			}
+			tests = append(tests, expandGinkgoAttempts(test, systemErr)...)
+		}
+		suites[i].Tests = tests
+	}
+	return suites, nil
+}
+
+// IngestFile parses the junit file like Ingest.
+func IngestFile(path string) ([]junit.Suite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Ingest(data)
+}
+
+// testcaseSystemErrs returns the system-err of the testcases per name in document order, which is the order
+// junit.Ingest returns the tests in.
+func testcaseSystemErrs(data []byte) (map[string][]string, error) {
+	systemErrs := map[string][]string{}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var testcase *string
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return systemErrs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		element, ok := token.(xml.StartElement)
+		if !ok {
+			if end, ok := token.(xml.EndElement); ok && end.Name.Local == "testcase" {
+				testcase = nil
+			}
+			continue
+		}
+		switch element.Name.Local {
+		case "testcase":
+			for _, attr := range element.Attr {
+				if attr.Name.Local == "name" {
+					name := attr.Value
+					testcase = &name
+				}
+			}
+			if testcase != nil {
+				systemErrs[*testcase] = append(systemErrs[*testcase], "")
+			}
+		case "system-err":
+			if testcase == nil {
+				continue
+			}
+			var content string
+			if err := decoder.DecodeElement(&content, &element); err != nil {
+				return nil, err
+			}
+			testcaseSystemErrs := systemErrs[*testcase]
+			testcaseSystemErrs[len(testcaseSystemErrs)-1] = content
+		}
+	}
+}
+
+// expandGinkgoAttempts returns the failed attempts of the test found in the Ginkgo timeline, followed by the test
+// itself as the last attempt, which keeps the remaining duration.
+func expandGinkgoAttempts(test junit.Test, systemErr string) []junit.Test {
+	var tests []junit.Test
+	for _, attempt := range ginkgoFailedAttempts(systemErr) {
+		tests = append(tests, junit.Test{
+			Name:      test.Name,
+			Classname: test.Classname,
+			Duration:  attempt.Duration,
+			Status:    attempt.Status,
+			Error:     junit.Error{Message: attempt.Message, Body: attempt.Message},
+		})
+		test.Duration -= attempt.Duration
+	}
+	test.Duration = max(test.Duration, 0)
+	return append(tests, test)
+}
+
+// ginkgoFailedAttempts returns the attempts of a Ginkgo spec that failed before the spec was retried, parsed from
+// its timeline:
+//
+//	> Enter [It] ... @ 10/19/26 10:20:50.446
+//	[FAILED] Failure recorded during attempt 1:
+//	<message>
+//	In [It] at: ... @ 10/19/26 10:20:50.446
+//	< Exit [It] ... @ 10/19/26 10:20:50.446 (0s)
+//
+//	Attempt #1 Failed.  Retrying ↺ @ 10/19/26 10:20:50.446
+//
+// The duration of a failed attempt is the time from the first timestamp after the previous retry up to its retry.
+func ginkgoFailedAttempts(systemErr string) []Attempt {
+	if !strings.Contains(systemErr, "Retrying") {
+		return nil
+	}
+	var attempts []Attempt
+	messages := map[string]string{}
+	var attemptStarted time.Time
+	var failureAttempt string
+	var failureLines []string
+	for _, line := range strings.Split(systemErr, "\n") {
+		line = strings.TrimSpace(line)
+		if failureAttempt != "" {
+			if !strings.HasPrefix(line, "In [") && line != "" {
+				failureLines = append(failureLines, line)
+				continue
+			}
+			messages[failureAttempt] = strings.Join(failureLines, "\n")
+			failureAttempt, failureLines = "", nil
+		}
+		if match := ginkgoAttemptFailure.FindStringSubmatch(line); match != nil {
+			failureAttempt = match[1]
+			continue
+		}
+		timestamp := ginkgoLineTime(line)
+		if match := ginkgoAttemptRetrying.FindStringSubmatch(line); match != nil {
+			attempt := Attempt{Status: junit.StatusFailed, Message: messages[match[1]]}
+			if !attemptStarted.IsZero() && timestamp.After(attemptStarted) {
+				attempt.Duration = timestamp.Sub(attemptStarted)
+			}
+			attempts = append(attempts, attempt)
+			attemptStarted = time.Time{}
+			continue
+		}
+		if attemptStarted.IsZero() {
+			attemptStarted = timestamp
+		}
+	}
+	return attempts
+}
+
+// ginkgoLineTime returns the timestamp of the line in the Ginkgo timeline, or the zero time if it has none.
+func ginkgoLineTime(line string) time.Time {
+	match := ginkgoTimestamp.FindStringSubmatch(line)
+	if match == nil {
+		return time.Time{}
+	}
+	timestamp, err := time.Parse(ginkgoTimeFormat, match[1])
+	if err != nil {
+		return time.Time{}
+	}
+	return timestamp
+}
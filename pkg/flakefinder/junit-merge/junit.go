@@ -0,0 +1,212 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package junit_merge
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/joshdk/go-junit"
+)
+
+const (
+	// MergedSuiteName is the name of the suite that contains the merged tests
+	MergedSuiteName = "Tests Suite (merged)"
+
+	// PropertyAttempts is the test property holding the number of attempts of a merged test
+	PropertyAttempts = "attempts"
+
+	// PropertyPassedOnRetry is the test property that is set to "true" if a merged test passed after an attempt failed
+	PropertyPassedOnRetry = "passed-on-retry"
+)
+
+// ToSuites converts the merged results into a single suite, where each test carries the number of attempts and
+// whether it passed on retry as properties.
+func ToSuites(results []TestResult) []junit.Suite {
+	t := totals(results)
+	suite := junit.Suite{
+		Name: MergedSuiteName,
+		Totals: junit.Totals{
+			Tests:    t.Tests,
+			Passed:   t.Passed,
+			Skipped:  t.Skipped,
+			Failed:   t.Failed,
+			Error:    t.Error,
+			Duration: t.Duration,
+		},
+	}
+	for _, result := range results {
+		test := junit.Test{
+			Name:      result.Name,
+			Classname: result.Classname,
+			Duration:  result.Duration(),
+			Status:    result.Status,
+			Properties: map[string]string{
+				PropertyAttempts: strconv.Itoa(len(result.Attempts)),
+			},
+		}
+		if result.PassedOnRetry() {
+			test.Properties[PropertyPassedOnRetry] = "true"
+		}
+		if isFailure(result.Status) {
+			test.Error = errors.New(lastFailureMessage(result))
+		}
+		suite.Tests = append(suite.Tests, test)
+	}
+	return []junit.Suite{suite}
+}
+
+func lastFailureMessage(result TestResult) string {
+	for i := len(result.Attempts) - 1; i >= 0; i-- {
+		if isFailure(result.Attempts[i].Status) {
+			return result.Attempts[i].Message
+		}
+	}
+	return ""
+}
+
+type xmlTestSuites struct {
+	XMLName  xml.Name       `xml:"testsuites"`
+	Tests    int            `xml:"tests,attr"`
+	Failures int            `xml:"failures,attr"`
+	Errors   int            `xml:"errors,attr"`
+	Skipped  int            `xml:"skipped,attr"`
+	Time     string         `xml:"time,attr"`
+	Suites   []xmlTestSuite `xml:"testsuite"`
+}
+
+type xmlTestSuite struct {
+	Name       string         `xml:"name,attr"`
+	Tests      int            `xml:"tests,attr"`
+	Failures   int            `xml:"failures,attr"`
+	Errors     int            `xml:"errors,attr"`
+	Skipped    int            `xml:"skipped,attr"`
+	Time       string         `xml:"time,attr"`
+	Properties *xmlProperties `xml:"properties,omitempty"`
+	TestCases  []xmlTestCase  `xml:"testcase"`
+}
+
+type xmlTestCase struct {
+	Name          string         `xml:"name,attr"`
+	Classname     string         `xml:"classname,attr"`
+	Time          string         `xml:"time,attr"`
+	Attempts      int            `xml:"attempts,attr"`
+	PassedOnRetry string         `xml:"passed-on-retry,attr,omitempty"`
+	Properties    *xmlProperties `xml:"properties,omitempty"`
+	Skipped       *xmlMessage    `xml:"skipped,omitempty"`
+	Failure       *xmlMessage    `xml:"failure,omitempty"`
+	Error         *xmlMessage    `xml:"error,omitempty"`
+}
+
+type xmlProperties struct {
+	Properties []xmlProperty `xml:"property"`
+}
+
+func (p *xmlProperties) add(name, value string) {
+	p.Properties = append(p.Properties, xmlProperty{Name: name, Value: value})
+}
+
+type xmlProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type xmlMessage struct {
+	Message string `xml:"message,attr"`
+}
+
+// WriteJUnit writes the merged results as a junit xml document to the writer. The document contains one suite
+// with one testcase per test, where every attempt is kept as a set of numbered testcase properties, i.e.
+// attempt.1.source, attempt.1.status, attempt.1.time and, for failed attempts, attempt.1.message.
+// The names of the sources are kept as suite properties source.1, source.2, ...
+func WriteJUnit(w io.Writer, sources []Source, results []TestResult) error {
+	t := totals(results)
+	suite := xmlTestSuite{
+		Name:     MergedSuiteName,
+		Tests:    t.Tests,
+		Failures: t.Failed,
+		Errors:   t.Error,
+		Skipped:  t.Skipped,
+		Time:     formatSeconds(t.Duration),
+	}
+	if len(sources) > 0 {
+		suite.Properties = &xmlProperties{}
+		for i, source := range sources {
+			suite.Properties.add(fmt.Sprintf("source.%d", i+1), source.Name)
+		}
+	}
+	for _, result := range results {
+		testCase := xmlTestCase{
+			Name:       result.Name,
+			Classname:  result.Classname,
+			Time:       formatSeconds(result.Duration()),
+			Attempts:   len(result.Attempts),
+			Properties: &xmlProperties{},
+		}
+		if result.PassedOnRetry() {
+			testCase.PassedOnRetry = "true"
+		}
+		for i, attempt := range result.Attempts {
+			prefix := fmt.Sprintf("attempt.%d.", i+1)
+			testCase.Properties.add(prefix+"source", attempt.Source)
+			testCase.Properties.add(prefix+"status", string(attempt.Status))
+			testCase.Properties.add(prefix+"time", formatSeconds(attempt.Duration))
+			if attempt.Message != "" {
+				testCase.Properties.add(prefix+"message", attempt.Message)
+			}
+		}
+		switch result.Status {
+		case junit.StatusSkipped:
+			testCase.Skipped = &xmlMessage{Message: "skipped"}
+		case junit.StatusFailed:
+			testCase.Failure = &xmlMessage{Message: lastFailureMessage(result)}
+		case junit.StatusError:
+			testCase.Error = &xmlMessage{Message: lastFailureMessage(result)}
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	document := xmlTestSuites{
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Errors:   suite.Errors,
+		Skipped:  suite.Skipped,
+		Time:     suite.Time,
+		Suites:   []xmlTestSuite{suite},
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return fmt.Errorf("failed to encode junit: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
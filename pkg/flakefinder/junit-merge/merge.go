@@ -60,12 +60,12 @@ func Merge(suitesOfSuites [][]junit.Suite) (mergedSuites []junit.Suite, hasConfl
 	}
 	return []junit.Suite{
 		{
-			Name:       "Tests Suite (merged)",
+			Name:       MergedSuiteName,
 			Package:    "",
 			Properties: nil,
 			Tests:      allTests,
 			SystemOut:  "",
-			SystemErr:  strings.Join(conflicts, "/n"),
+			SystemErr:  strings.Join(conflicts, "\n"),
 			Totals: junit.Totals{
 				Tests:    testStatus[junit.StatusPassed] + testStatus[junit.StatusSkipped] + testStatus[junit.StatusFailed] + testStatus[junit.StatusError],
 				Passed:   testStatus[junit.StatusPassed],
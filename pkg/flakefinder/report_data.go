@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/joshdk/go-junit"
+
+	junitMerge "kubevirt.io/project-infra/pkg/flakefinder/junit-merge"
 )
 
 type Params struct {
@@ -29,6 +31,7 @@ type Details struct {
 	Succeeded        int    `json:"succeeded"`
 	Skipped          int    `json:"skipped"`
 	Failed           int    `json:"failed"`
+	PassedOnRetry    int    `json:"passedOnRetry"`
 	Severity         string `json:"severity"`
 	Jobs             []*Job `json:"jobs"`
 	NonDeterministic bool   `json:"nonDeterministic"`
@@ -71,12 +74,35 @@ func CreateFlakeReportData(results []*JobResult, prNumbers []int, endOfReport ti
 		return fmt.Sprintf("%s-%d", result.Job, result.BuildNumber)
 	}
 
+	// results that either have failing tests or tests that passed on retry
+	resultsWithFlakeEvidence := map[string]struct{}{}
+
 	for _, result := range results {
 
 		// first find failing tests to isolate tests which interest us
 		for _, suite := range result.JUnit {
 			for _, test := range suite.Tests {
+				if hasPassedOnRetry(test) {
+					resultsWithFlakeEvidence[createFailuresForJobsKey(result)] = struct{}{}
+
+					testEntry := data[test.Name]
+					if testEntry == nil {
+						tests = append(tests, test.Name)
+						testEntry = map[string]*Details{}
+						data[test.Name] = testEntry
+					}
+
+					if _, exists := testEntry[result.Job]; !exists {
+						testEntry[result.Job] = &Details{}
+					}
+					if _, exists := headerMap[result.Job]; !exists {
+						headerMap[result.Job] = struct{}{}
+						headers = append(headers, result.Job)
+					}
+					testEntry[result.Job].PassedOnRetry = testEntry[result.Job].PassedOnRetry + 1
+				}
 				if test.Status == junit.StatusFailed || test.Status == junit.StatusError {
+					resultsWithFlakeEvidence[createFailuresForJobsKey(result)] = struct{}{}
 
 					failuresForJobsKey := createFailuresForJobsKey(result)
 					_, exists := failuresForJobs[failuresForJobsKey]
@@ -114,7 +140,7 @@ func CreateFlakeReportData(results []*JobResult, prNumbers []int, endOfReport ti
 
 	// second enrich failed tests with additional information
 	for _, result := range results {
-		if _, exists := failuresForJobs[createFailuresForJobsKey(result)]; !exists {
+		if _, exists := resultsWithFlakeEvidence[createFailuresForJobsKey(result)]; !exists {
 			// if not in the map now, then skip it
 			continue
 		}
@@ -128,6 +154,9 @@ func CreateFlakeReportData(results []*JobResult, prNumbers []int, endOfReport ti
 				}
 				if test.Status == junit.StatusSkipped {
 					data[test.Name][result.Job].Skipped = data[test.Name][result.Job].Skipped + 1
+				} else if hasPassedOnRetry(test) {
+					data[test.Name][result.Job].Succeeded = data[test.Name][result.Job].Succeeded + 1
+					data[test.Name][result.Job].Jobs = append(data[test.Name][result.Job].Jobs, &Job{Severity: "yellow", BuildNumber: result.BuildNumber, Job: result.Job, PR: result.PR, BatchPRs: result.BatchPRs, CommitID: result.CommitID})
 				} else if test.Status == junit.StatusPassed {
 					data[test.Name][result.Job].Succeeded = data[test.Name][result.Job].Succeeded + 1
 					data[test.Name][result.Job].Jobs = append(data[test.Name][result.Job].Jobs, &Job{Severity: "green", BuildNumber: result.BuildNumber, Job: result.Job, PR: result.PR, BatchPRs: result.BatchPRs, CommitID: result.CommitID})
@@ -141,7 +170,7 @@ func CreateFlakeReportData(results []*JobResult, prNumbers []int, endOfReport ti
 	// third, calculate the severity
 	// second enrich failed tests with additional information
 	for _, result := range results {
-		if _, exists := failuresForJobs[createFailuresForJobsKey(result)]; !exists {
+		if _, exists := resultsWithFlakeEvidence[createFailuresForJobsKey(result)]; !exists {
 			// if not in the map now, then skip it
 			continue
 		}
@@ -193,6 +222,12 @@ func CreateFlakeReportData(results []*JobResult, prNumbers []int, endOfReport ti
 	return parameters
 }
 
+// hasPassedOnRetry returns whether the test passed, but only after a failed attempt, which is evidence for a flake.
+// The information is available for results that have been merged with junit_merge.MergeAttempts.
+func hasPassedOnRetry(test junit.Test) bool {
+	return test.Status == junit.StatusPassed && test.Properties[junitMerge.PropertyPassedOnRetry] == "true"
+}
+
 type TestAttributes []TestAttribute
 
 func (t TestAttributes) Sort() {
@@ -267,13 +302,15 @@ func NewTestAttributes(testName string) TestAttributes {
 // SetSeverity sets the field Severity on the passed details according to the ratio of failed vs succeeded tests,
 // where test results are the more severe the more test failures they contain in relation to succeeded tests.
 func SetSeverity(entry *Details) {
+	// a test that passed on retry did fail before, thus it counts as a failure
+	failed := entry.Failed + entry.PassedOnRetry
 	var ratio float32 = 1.0
 	if entry.Succeeded > 0 {
-		ratio = float32(entry.Failed) / float32(entry.Succeeded)
+		ratio = float32(failed) / float32(entry.Succeeded)
 	}
 
 	entry.Severity = Fine
-	if entry.Succeeded == 0 && entry.Failed == 0 {
+	if entry.Succeeded == 0 && failed == 0 {
 		entry.Severity = Unimportant
 	} else if ratio > 0.5 {
 		entry.Severity = HeavilyFlaky
@@ -7,6 +7,8 @@ import (
 	"github.com/joshdk/go-junit"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+
+	junitMerge "kubevirt.io/project-infra/pkg/flakefinder/junit-merge"
 )
 
 var _ = Describe("report_data.go", func() {
@@ -541,6 +543,67 @@ var _ = Describe("report_data.go", func() {
 				}))
 		})
 
+		It("counts tests that passed on retry as flake evidence", func() {
+			startOfReport := time.Now().Add(minusDay)
+			endOfReport := time.Now()
+			Expect(CreateFlakeReportData(
+				[]*JobResult{
+					{
+						Job: "job",
+						JUnit: []junit.Suite{
+							{
+								Name: "suite",
+								Tests: []junit.Test{
+									{
+										Name:   "test1",
+										Status: junit.StatusPassed,
+									},
+									{
+										Name:       "test2",
+										Status:     junit.StatusPassed,
+										Properties: map[string]string{junitMerge.PropertyAttempts: "2", junitMerge.PropertyPassedOnRetry: "true"},
+									},
+								},
+							},
+						},
+						BuildNumber: buildNumber,
+						PR:          pr,
+					},
+				},
+				[]int{pr},
+				endOfReport,
+				org,
+				repo,
+				startOfReport,
+			)).To(BeEquivalentTo(
+				Params{
+					StartOfReport: startOfReport.Format(time.RFC3339),
+					EndOfReport:   endOfReport.Format(time.RFC3339),
+					Headers:       []string{"job"},
+					Tests:         []string{"test2"},
+					TestAttributes: map[string]TestAttributes{
+						"test2": nil,
+					},
+					Data: map[string]map[string]*Details{
+						"test2": {
+							"job": {
+								Succeeded:     1,
+								PassedOnRetry: 1,
+								Severity:      "red",
+								Jobs: []*Job{
+									{BuildNumber: buildNumber, Severity: "yellow", PR: pr, BatchPRs: nil, Job: "job"},
+								},
+							},
+						},
+					},
+					PrNumbers:       []int{pr},
+					Org:             org,
+					Repo:            repo,
+					FailuresForJobs: map[string]*JobFailures{},
+					BareTestNames:   map[string]string{"test2": "test2"},
+				}))
+		})
+
 		It("adds test attributes for failing tests", func() {
 			startOfReport := time.Now().Add(minusDay)
 			endOfReport := time.Now()
@@ -154,6 +154,28 @@ func ByName(testName string) func(r types.SpecReport) bool {
 	}
 }
 
+// quarantineLabel is the Ginkgo label that decorators.Quarantine attaches to a node
+const quarantineLabel = "Quarantine"
+
+// IsQuarantined checks whether the types.SpecReport or any of its containers is quarantined, either by the
+// quarantine prefix in the node text or by the quarantine label
+func IsQuarantined(r types.SpecReport) bool {
+	if strings.Contains(r.LeafNodeText, quarantinePrefix) {
+		return true
+	}
+	for _, text := range r.ContainerHierarchyTexts {
+		if strings.Contains(text, quarantinePrefix) {
+			return true
+		}
+	}
+	for _, label := range r.Labels() {
+		if label == quarantineLabel {
+			return true
+		}
+	}
+	return false
+}
+
 func FilterSpecReports(reports []types.Report, f SpecReportFilter, maxResults int) []types.SpecReport {
 	if maxResults == 0 || maxResults < -1 {
 		return nil
@@ -84,3 +84,31 @@ var _ = Describe("extract", func() {
 		)
 	})
 })
+
+var _ = Describe("quarantined", func() {
+	DescribeTable("IsQuarantined",
+		func(r SpecReport, expected bool) {
+			Expect(IsQuarantined(r)).To(Equal(expected))
+		},
+		Entry("not quarantined",
+			SpecReport{LeafNodeText: "some test", LeafNodeLabels: []string{"sig-compute"}},
+			false,
+		),
+		Entry("leaf node text",
+			SpecReport{LeafNodeText: "[QUARANTINE]some test"},
+			true,
+		),
+		Entry("container text",
+			SpecReport{LeafNodeText: "some test", ContainerHierarchyTexts: []string{"[QUARANTINE]some container"}},
+			true,
+		),
+		Entry("leaf node label",
+			SpecReport{LeafNodeText: "some test", LeafNodeLabels: []string{"Quarantine"}},
+			true,
+		),
+		Entry("container label",
+			SpecReport{LeafNodeText: "some test", ContainerHierarchyLabels: [][]string{{"Quarantine"}}},
+			true,
+		),
+	)
+})
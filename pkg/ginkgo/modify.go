@@ -20,63 +20,342 @@
 package ginkgo
 
 import (
+	"bytes"
 	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/onsi/ginkgo/v2/types"
+	"golang.org/x/tools/go/ast/astutil"
 	"golang.org/x/tools/imports"
 )
 
-const decoratorsImport = "kubevirt.io/kubevirt/tests/decorators"
+const (
+	decoratorsImport       = "kubevirt.io/kubevirt/tests/decorators"
+	decoratorsPackageName  = "decorators"
+	quarantinePrefix       = "[QUARANTINE]"
+	quarantineDecoratorSel = "Quarantine"
+)
+
+// specNodeNames holds the names of the ginkgo nodes that create a spec
+var specNodeNames = map[string]struct{}{
+	"It": {}, "FIt": {}, "PIt": {}, "XIt": {},
+	"Specify": {}, "FSpecify": {}, "PSpecify": {}, "XSpecify": {},
+	"Entry": {}, "FEntry": {}, "PEntry": {}, "XEntry": {},
+}
 
+// specModification modifies the call expression of a spec node. It returns the
+// node text that the ginkgo outline is expected to show afterward, or an empty
+// string if the text can not be derived statically.
+type specModification func(call *ast.CallExpr) (string, error)
+
+// QuarantineTest adds the quarantine prefix to the node text and the
+// quarantine decorator to the spec, so that it is recognized by automation as
+// being quarantined.
 func QuarantineTest(report *types.SpecReport) error {
-	content, err := os.ReadFile(report.LeafNodeLocation.FileName)
+	err := modifyTestFile(report, quarantine)
 	if err != nil {
-		return fmt.Errorf("could not read file for quarantine test %q: %w", report.FullText(), err)
+		return fmt.Errorf("could not quarantine test %q: %w", report.FullText(), err)
 	}
-	code, err := quarantine(string(content), report.LeafNodeText)
+	return nil
+}
+
+// DequarantineTest removes the quarantine prefix from the node text and the
+// quarantine decorator from the spec, reverting what QuarantineTest did.
+func DequarantineTest(report *types.SpecReport) error {
+	err := modifyTestFile(report, dequarantine)
 	if err != nil {
-		return fmt.Errorf("could not quarantine test %q: %w", report.FullText(), err)
+		return fmt.Errorf("could not dequarantine test %q: %w", report.FullText(), err)
 	}
-	code = ensureImport(code, decoratorsImport)
-	formatted, err := imports.Process(report.LeafNodeLocation.FileName, []byte(code), &imports.Options{
-		FormatOnly: true,
-		Comments:   true,
-		TabIndent:  true,
-		TabWidth:   8,
-	})
+	return nil
+}
+
+// modifyTestFile applies the modification to the spec node found at the leaf
+// node location of the report, then verifies the result by comparing the
+// ginkgo outlines before and after. If verification fails, the original file
+// content is restored.
+func modifyTestFile(report *types.SpecReport, modification specModification) error {
+	fileName := report.LeafNodeLocation.FileName
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return fmt.Errorf("could not read file %q: %w", fileName, err)
+	}
+	outlineBefore, err := OutlineFromFile(fileName)
+	if err != nil {
+		return fmt.Errorf("could not create outline for %q: %w", fileName, err)
+	}
+	code, expectedOutlineText, err := modifySpec(fileName, content, report.LeafNodeLocation.LineNumber, report.LeafNodeText, modification)
 	if err != nil {
-		return fmt.Errorf("could not format file for quarantined test %q: %w", report.FullText(), err)
+		return err
+	}
+	err = writeFormatted(fileName, code)
+	if err != nil {
+		return err
+	}
+	outlineAfter, err := OutlineFromFile(fileName)
+	if err == nil {
+		err = verifyOutline(outlineBefore, outlineAfter, expectedOutlineText)
 	}
-	err = os.WriteFile(report.LeafNodeLocation.FileName, formatted, os.ModePerm)
 	if err != nil {
-		return fmt.Errorf("could not write file for quarantined test %q: %w", report.FullText(), err)
+		restoreErr := os.WriteFile(fileName, content, os.ModePerm)
+		if restoreErr != nil {
+			return fmt.Errorf("could not restore file %q after failed verification (%v): %w", fileName, err, restoreErr)
+		}
+		return fmt.Errorf("could not verify modification of %q: %w", fileName, err)
 	}
 	return nil
 }
 
-func modify(input string, substring string, replacement string) (string, error) {
-	if substring == "" {
-		return "", fmt.Errorf("substring must not be empty")
+// modifySpec parses the source, applies the modification to the spec node at
+// the given line and updates the decorators import as required.
+func modifySpec(fileName string, src []byte, line int, nodeText string, modification specModification) ([]byte, string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, fileName, src, parser.ParseComments)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not parse file %q: %w", fileName, err)
+	}
+	call, err := findSpecCallExpr(fset, file, line, nodeText)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not find spec in %q: %w", fileName, err)
+	}
+	expectedOutlineText, err := modification(call)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if usesDecorators(file) {
+		astutil.AddImport(fset, file, decoratorsImport)
+	} else {
+		astutil.DeleteImport(fset, file, decoratorsImport)
+	}
+
+	var buf bytes.Buffer
+	err = format.Node(&buf, fset, file)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not print file %q: %w", fileName, err)
 	}
-	return strings.Replace(input, substring, replacement, 1), nil
+	return buf.Bytes(), expectedOutlineText, nil
 }
 
-func quarantine(input string, nodeText string) (string, error) {
-	replacement := fmt.Sprintf(`"[QUARANTINE]%s", decorators.Quarantine`, nodeText)
-	return modify(input, fmt.Sprintf(`%q`, nodeText), replacement)
+// findSpecCallExpr returns the call expression of the spec node starting at
+// the given line. If there are several on that line, the one whose text
+// matches is preferred, otherwise the innermost one is returned.
+func findSpecCallExpr(fset *token.FileSet, file *ast.File, line int, nodeText string) (*ast.CallExpr, error) {
+	var candidates []*ast.CallExpr
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+		if _, isSpecNode := specNodeNames[callName(call)]; !isSpecNode {
+			return true
+		}
+		if fset.Position(call.Pos()).Line <= line && line <= fset.Position(call.Lparen).Line {
+			candidates = append(candidates, call)
+		}
+		return true
+	})
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no spec node found at line %d", line)
+	}
+	for _, call := range candidates {
+		if text, isLiteral := stringLiteralValue(call.Args[0]); isLiteral && text == nodeText {
+			return call, nil
+		}
+	}
+	return candidates[len(candidates)-1], nil
+}
+
+func callName(call *ast.CallExpr) string {
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		return fun.Name
+	case *ast.SelectorExpr:
+		return fun.Sel.Name
+	default:
+		return ""
+	}
 }
 
-func ensureImport(code string, importPath string) string {
-	quoted := fmt.Sprintf("%q", importPath)
-	if strings.Contains(code, quoted) {
-		return code
+func quarantine(call *ast.CallExpr) (string, error) {
+	textLiteral := leftmostStringLiteral(call.Args[0])
+	switch {
+	case textLiteral == nil:
+		call.Args[0] = &ast.BinaryExpr{X: newStringLiteral(quarantinePrefix, false), Op: token.ADD, Y: call.Args[0]}
+	default:
+		text, _ := stringLiteralValue(textLiteral)
+		if strings.Contains(text, quarantinePrefix) {
+			return "", fmt.Errorf("node text %q is already quarantined", text)
+		}
+		textLiteral.Value = newStringLiteral(quarantinePrefix+text, isRawStringLiteral(textLiteral)).Value
 	}
-	idx := strings.Index(code, "import (")
-	if idx < 0 {
-		return code
+
+	if indexOfQuarantineDecorator(call) < 0 {
+		decorator := &ast.SelectorExpr{X: ast.NewIdent(decoratorsPackageName), Sel: ast.NewIdent(quarantineDecoratorSel)}
+		call.Args = append(call.Args[:1], append([]ast.Expr{decorator}, call.Args[1:]...)...)
 	}
-	insertPos := idx + len("import (")
-	return code[:insertPos] + "\n\t" + quoted + code[insertPos:]
+
+	text, _ := stringLiteralValue(call.Args[0])
+	return text, nil
+}
+
+func dequarantine(call *ast.CallExpr) (string, error) {
+	textExpr := call.Args[0]
+	if binaryExpr, ok := textExpr.(*ast.BinaryExpr); ok && binaryExpr.Op == token.ADD {
+		// drop a standalone prefix literal, i.e. `"[QUARANTINE]" + text`
+		if prefix, isLiteral := stringLiteralValue(binaryExpr.X); isLiteral && strings.TrimSpace(prefix) == quarantinePrefix {
+			call.Args[0] = binaryExpr.Y
+			textExpr = nil
+		}
+	}
+	if textExpr != nil {
+		textLiteral := leftmostStringLiteral(textExpr)
+		if textLiteral == nil {
+			return "", fmt.Errorf("could not find node text literal")
+		}
+		text, _ := stringLiteralValue(textLiteral)
+		if !strings.Contains(text, quarantinePrefix) {
+			return "", fmt.Errorf("node text %q is not quarantined", text)
+		}
+		if strings.HasPrefix(text, quarantinePrefix) {
+			text = strings.TrimLeft(strings.TrimPrefix(text, quarantinePrefix), " ")
+		} else {
+			text = strings.Replace(text, quarantinePrefix, "", 1)
+		}
+		textLiteral.Value = newStringLiteral(text, isRawStringLiteral(textLiteral)).Value
+	}
+
+	for i := indexOfQuarantineDecorator(call); i >= 0; i = indexOfQuarantineDecorator(call) {
+		call.Args = append(call.Args[:i], call.Args[i+1:]...)
+	}
+
+	text, _ := stringLiteralValue(call.Args[0])
+	return text, nil
+}
+
+func indexOfQuarantineDecorator(call *ast.CallExpr) int {
+	for i, arg := range call.Args {
+		selector, ok := arg.(*ast.SelectorExpr)
+		if !ok || selector.Sel.Name != quarantineDecoratorSel {
+			continue
+		}
+		if pkg, ok := selector.X.(*ast.Ident); ok && pkg.Name == decoratorsPackageName {
+			return i
+		}
+	}
+	return -1
+}
+
+// leftmostStringLiteral returns the string literal that the expression starts
+// with, descending into string concatenations, or nil if there is none.
+func leftmostStringLiteral(expr ast.Expr) *ast.BasicLit {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind == token.STRING {
+			return e
+		}
+	case *ast.BinaryExpr:
+		if e.Op == token.ADD {
+			return leftmostStringLiteral(e.X)
+		}
+	case *ast.ParenExpr:
+		return leftmostStringLiteral(e.X)
+	}
+	return nil
+}
+
+func stringLiteralValue(expr ast.Expr) (string, bool) {
+	literal, ok := expr.(*ast.BasicLit)
+	if !ok || literal.Kind != token.STRING {
+		return "", false
+	}
+	value, err := strconv.Unquote(literal.Value)
+	if err != nil {
+		return "", false
+	}
+	return value, true
+}
+
+func isRawStringLiteral(literal *ast.BasicLit) bool {
+	return strings.HasPrefix(literal.Value, "`")
+}
+
+func newStringLiteral(value string, raw bool) *ast.BasicLit {
+	if raw && !strings.Contains(value, "`") {
+		return &ast.BasicLit{Kind: token.STRING, Value: "`" + value + "`"}
+	}
+	return &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(value)}
+}
+
+func usesDecorators(file *ast.File) bool {
+	used := false
+	ast.Inspect(file, func(n ast.Node) bool {
+		if selector, ok := n.(*ast.SelectorExpr); ok {
+			if pkg, ok := selector.X.(*ast.Ident); ok && pkg.Name == decoratorsPackageName {
+				used = true
+			}
+		}
+		return !used
+	})
+	return used
+}
+
+// verifyOutline checks that the modification did neither add nor remove any
+// specs, and, if the node text can be derived, that the modified spec shows up
+// with the expected text.
+func verifyOutline(outlineBefore, outlineAfter []*Node, expectedText string) error {
+	specTextsBefore, specTextsAfter := specTexts(outlineBefore), specTexts(outlineAfter)
+	if len(specTextsBefore) != len(specTextsAfter) {
+		return fmt.Errorf("number of specs changed from %d to %d", len(specTextsBefore), len(specTextsAfter))
+	}
+	if expectedText == "" {
+		return nil
+	}
+	if countOf(specTextsAfter, expectedText) != countOf(specTextsBefore, expectedText)+1 {
+		return fmt.Errorf("spec with text %q not found in outline", expectedText)
+	}
+	return nil
+}
+
+func specTexts(nodes []*Node) []string {
+	var texts []string
+	for _, node := range nodes {
+		if node.Spec {
+			texts = append(texts, node.Text)
+		}
+		texts = append(texts, specTexts(node.Nodes)...)
+	}
+	return texts
+}
+
+func countOf(values []string, value string) int {
+	count := 0
+	for _, v := range values {
+		if v == value {
+			count++
+		}
+	}
+	return count
+}
+
+func writeFormatted(fileName string, code []byte) error {
+	formatted, err := imports.Process(fileName, code, &imports.Options{
+		FormatOnly: true,
+		Comments:   true,
+		TabIndent:  true,
+		TabWidth:   8,
+	})
+	if err != nil {
+		return fmt.Errorf("could not format file %q: %w", fileName, err)
+	}
+	err = os.WriteFile(fileName, formatted, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("could not write file %q: %w", fileName, err)
+	}
+	return nil
 }
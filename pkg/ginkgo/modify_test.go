@@ -20,114 +20,212 @@
 package ginkgo
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
+	"github.com/onsi/ginkgo/v2/types"
 	. "github.com/onsi/gomega"
 	"golang.org/x/tools/imports"
 )
 
 var _ = Describe("modify", func() {
 
-	var (
-		text = `
-			Entry("with RunStrategyOnce", v1.RunStrategyOnce, func(vm *v1.VirtualMachine) {
-				By("Starting the VM")
-				err = virtClient.VirtualMachine(vm.Namespace).Start(context.Background(), vm.Name, &v1.StartOptions{})
-				Expect(err).To(MatchError(ContainSubstring("Once does not support manual start requests")))
-			}),
-			Entry("[test_id:2190] with RunStrategyManual", v1.RunStrategyManual, func(vm *v1.VirtualMachine) {
-				// At this point, explicitly test that a start command will delete an existing
-				// VMI in the Succeeded phase.
-				By("Starting the VM")
-				err = virtClient.VirtualMachine(vm.Namespace).Start(context.Background(), vm.Name, &v1.StartOptions{})
-				Expect(err).ToNot(HaveOccurred())
+	const (
+		source = `package tests
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("VM", func() {
+	DescribeTable("should start", func(runStrategy string) {
+		Expect(runStrategy).ToNot(BeEmpty())
+	},
+		Entry("with RunStrategyOnce", "Once"),
+		Entry("[test_id:2190] with RunStrategyManual", "Manual"),
+	)
+
+	It("[test_id:1234]is a duplicate", func() {})
 
-				By("Waiting for StartRequest to be cleared")
-				Eventually(ThisVM(vm), 240*time.Second, 1*time.Second).Should(Not(HaveStateChangeRequests()))
+	Context("in another context", func() {
+		It("[test_id:1234]is a duplicate", func() {})
+	})
+
+	It("[test_id:5678]is "+"concatenated", func() {})
 
-				By("Waiting for VM to be ready")
-				Eventually(ThisVM(vm), 360*time.Second, 1*time.Second).Should(BeReady())
-			}),
+	It(fmt.Sprintf("is %s", "formatted"), func() {})
+})
 `
-		expectedModification = `
-			Entry("with RunStrategyOnce", v1.RunStrategyOnce, func(vm *v1.VirtualMachine) {
-				By("Starting the VM")
-				err = virtClient.VirtualMachine(vm.Namespace).Start(context.Background(), vm.Name, &v1.StartOptions{})
-				Expect(err).To(MatchError(ContainSubstring("Once does not support manual start requests")))
-			}),
-			Entry("[QUARANTINE][test_id:2190] with RunStrategyManual", decorators.Quarantine, v1.RunStrategyManual, func(vm *v1.VirtualMachine) {
-				// At this point, explicitly test that a start command will delete an existing
-				// VMI in the Succeeded phase.
-				By("Starting the VM")
-				err = virtClient.VirtualMachine(vm.Namespace).Start(context.Background(), vm.Name, &v1.StartOptions{})
-				Expect(err).ToNot(HaveOccurred())
+		quarantinedSource = `package tests
+
+import (
+	"kubevirt.io/kubevirt/tests/decorators"
+
+	. "github.com/onsi/ginkgo/v2"
+)
+
+var _ = Describe("VM", func() {
+	It("[QUARANTINE]should be stable", decorators.Quarantine, func() {})
+
+	It("[QUARANTINE]should stay", decorators.Conformance, decorators.Quarantine, func() {})
+})
+`
+		singleImportSource = `package tests
 
-				By("Waiting for StartRequest to be cleared")
-				Eventually(ThisVM(vm), 240*time.Second, 1*time.Second).Should(Not(HaveStateChangeRequests()))
+import . "github.com/onsi/ginkgo/v2"
 
-				By("Waiting for VM to be ready")
-				Eventually(ThisVM(vm), 360*time.Second, 1*time.Second).Should(BeReady())
-			}),
+var _ = Describe("VM", func() {
+	It("should be quarantined", func() {})
+})
 `
 	)
 
-	When("modify is used", func() {
+	lineOf := func(source, substring string, occurrence int) int {
+		index := -1
+		for i := 0; i < occurrence; i++ {
+			next := strings.Index(source[index+1:], substring)
+			Expect(next).To(BeNumerically(">=", 0), "substring %q not found", substring)
+			index += next + 1
+		}
+		return strings.Count(source[:index], "\n") + 1
+	}
 
-		It("doesn't modify anything if input is empty", func() {
-			_, err := modify(text, "", "")
-			Expect(err).To(HaveOccurred())
+	When("quarantine is used", func() {
+
+		DescribeTable("does quarantine a test",
+			func(src, nodeText string, occurrence int, expectedSubstrings []string, expectedOutlineText string) {
+				line := lineOf(src, nodeText, occurrence)
+				code, outlineText, err := modifySpec("test.go", []byte(src), line, nodeText, quarantine)
+				Expect(err).ToNot(HaveOccurred())
+				for _, expectedSubstring := range expectedSubstrings {
+					Expect(string(code)).To(ContainSubstring(expectedSubstring))
+				}
+				Expect(outlineText).To(Equal(expectedOutlineText))
+				Expect(string(code)).To(ContainSubstring(`"kubevirt.io/kubevirt/tests/decorators"`))
+			},
+			Entry("table entry",
+				source, "[test_id:2190] with RunStrategyManual", 1,
+				[]string{`Entry("[QUARANTINE][test_id:2190] with RunStrategyManual", decorators.Quarantine, "Manual"),`,
+					`Entry("with RunStrategyOnce", "Once"),`},
+				"[QUARANTINE][test_id:2190] with RunStrategyManual",
+			),
+			Entry("second of two specs with the same text",
+				source, "[test_id:1234]is a duplicate", 2,
+				[]string{`It("[test_id:1234]is a duplicate", func() {})`,
+					`It("[QUARANTINE][test_id:1234]is a duplicate", decorators.Quarantine, func() {})`},
+				"[QUARANTINE][test_id:1234]is a duplicate",
+			),
+			Entry("concatenated text",
+				source, `[test_id:5678]is "+"concatenated`, 1,
+				[]string{`It("[QUARANTINE][test_id:5678]is "+"concatenated", decorators.Quarantine, func() {})`},
+				"",
+			),
+			Entry("formatted text",
+				source, `fmt.Sprintf("is %s", "formatted")`, 1,
+				[]string{`It("[QUARANTINE]"+fmt.Sprintf("is %s", "formatted"), decorators.Quarantine, func() {})`},
+				"",
+			),
+			Entry("file with a single line import",
+				singleImportSource, "should be quarantined", 1,
+				[]string{`It("[QUARANTINE]should be quarantined", decorators.Quarantine, func() {})`,
+					`. "github.com/onsi/ginkgo/v2"`},
+				"[QUARANTINE]should be quarantined",
+			),
+		)
+
+		It("does not duplicate an existing import", func() {
+			src := strings.Replace(quarantinedSource, `It("[QUARANTINE]should be stable", decorators.Quarantine,`, `It("should be stable",`, 1)
+			code, _, err := modifySpec("test.go", []byte(src), lineOf(src, "should be stable", 1), "should be stable", quarantine)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(strings.Count(string(code), `"kubevirt.io/kubevirt/tests/decorators"`)).To(Equal(1))
 		})
 
-		It("modifies it as expected", func() {
-			Expect(modify(text, `"[test_id:2190] with RunStrategyManual"`, `"[QUARANTINE][test_id:2190] with RunStrategyManual", decorators.Quarantine`)).To(BeEquivalentTo(expectedModification))
+		It("fails if the test is already quarantined", func() {
+			_, _, err := modifySpec("test.go", []byte(quarantinedSource), lineOf(quarantinedSource, "should be stable", 1), "[QUARANTINE]should be stable", quarantine)
+			Expect(err).To(HaveOccurred())
 		})
-	})
 
-	When("quarantine is used", func() {
-		It("does quarantine a test", func() {
-			Expect(quarantine(text, "[test_id:2190] with RunStrategyManual")).To(BeEquivalentTo(expectedModification))
+		It("fails if there is no spec at the line", func() {
+			_, _, err := modifySpec("test.go", []byte(source), 1, "with RunStrategyOnce", quarantine)
+			Expect(err).To(HaveOccurred())
 		})
 	})
 
-	When("ensureImport is used", func() {
+	When("dequarantine is used", func() {
 
-		It("adds the import when not present", func() {
-			code := `package foo
+		It("reverts quarantine", func() {
+			nodeText := "[test_id:2190] with RunStrategyManual"
+			line := lineOf(source, nodeText, 1)
+			quarantined, _, err := modifySpec("test.go", []byte(source), line, nodeText, quarantine)
+			Expect(err).ToNot(HaveOccurred())
+			dequarantined, outlineText, err := modifySpec("test.go", quarantined, line+1, "[QUARANTINE]"+nodeText, dequarantine)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(outlineText).To(Equal(nodeText))
+			formatted, err := imports.Process("test.go", dequarantined, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(formatted)).To(Equal(source))
+		})
 
-import (
-	"fmt"
-	"os"
-)
+		It("keeps the import if other decorators are used", func() {
+			code, outlineText, err := modifySpec("test.go", []byte(quarantinedSource), lineOf(quarantinedSource, "should stay", 1), "[QUARANTINE]should stay", dequarantine)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(outlineText).To(Equal("should stay"))
+			Expect(string(code)).To(ContainSubstring(`It("should stay", decorators.Conformance, func() {})`))
+			Expect(string(code)).To(ContainSubstring(`"kubevirt.io/kubevirt/tests/decorators"`))
+		})
 
-func main() {}
-`
-			result := ensureImport(code, "kubevirt.io/kubevirt/tests/decorators")
-			Expect(result).To(ContainSubstring(`"kubevirt.io/kubevirt/tests/decorators"`))
-			Expect(result).To(ContainSubstring("import (\n\t\"kubevirt.io/kubevirt/tests/decorators\"\n\t\"fmt\""))
+		It("removes a standalone prefix literal", func() {
+			code, _, err := modifySpec("test.go", []byte(source), lineOf(source, "fmt.Sprintf", 1), "is formatted", quarantine)
+			Expect(err).ToNot(HaveOccurred())
+			code, outlineText, err := modifySpec("test.go", code, lineOf(string(code), "fmt.Sprintf", 1), "[QUARANTINE]is formatted", dequarantine)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(outlineText).To(BeEmpty())
+			Expect(string(code)).To(ContainSubstring(`It(fmt.Sprintf("is %s", "formatted"), func() {})`))
+			Expect(string(code)).ToNot(ContainSubstring(`decorators`))
 		})
 
-		It("does not duplicate an existing import", func() {
-			code := `package foo
+		It("fails if the test is not quarantined", func() {
+			_, _, err := modifySpec("test.go", []byte(source), lineOf(source, "with RunStrategyOnce", 1), "with RunStrategyOnce", dequarantine)
+			Expect(err).To(HaveOccurred())
+		})
+	})
 
-import (
-	"fmt"
-	"kubevirt.io/kubevirt/tests/decorators"
-)
+	When("QuarantineTest and DequarantineTest are used on a file", func() {
 
-func main() {}
-`
-			result := ensureImport(code, "kubevirt.io/kubevirt/tests/decorators")
-			Expect(result).To(BeEquivalentTo(code))
+		var fileName string
+
+		BeforeEach(func() {
+			fileName = filepath.Join(GinkgoT().TempDir(), "modify_test.go")
+			Expect(os.WriteFile(fileName, []byte(source), 0o644)).To(Succeed())
 		})
 
-		It("returns unchanged code when there is no import block", func() {
-			code := `package foo
+		It("modifies and verifies the file", func() {
+			nodeText := "[test_id:1234]is a duplicate"
+			report := &types.SpecReport{
+				LeafNodeText: nodeText,
+				LeafNodeLocation: types.CodeLocation{
+					FileName:   fileName,
+					LineNumber: lineOf(source, nodeText, 2),
+				},
+			}
+			Expect(QuarantineTest(report)).To(Succeed())
+
+			content, err := os.ReadFile(fileName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(content)).To(ContainSubstring(`It("[QUARANTINE][test_id:1234]is a duplicate", decorators.Quarantine, func() {})`))
 
-func main() {}
-`
-			result := ensureImport(code, "kubevirt.io/kubevirt/tests/decorators")
-			Expect(result).To(BeEquivalentTo(code))
+			report.LeafNodeText = "[QUARANTINE]" + nodeText
+			report.LeafNodeLocation.LineNumber = lineOf(string(content), report.LeafNodeText, 1)
+			Expect(DequarantineTest(report)).To(Succeed())
+
+			content, err = os.ReadFile(fileName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(content)).To(Equal(source))
 		})
 	})
 
@@ -192,70 +290,4 @@ func main() {
 		})
 	})
 
-	When("quarantine and ensureImport are used together on a real file", func() {
-
-		It("adds both the decorator and the import to cluster-profiler.go", func() {
-			input := `package infrastructure
-
-import (
-	"kubevirt.io/kubevirt/tests/framework/kubevirt"
-	"kubevirt.io/kubevirt/tests/libkubevirt"
-	"kubevirt.io/kubevirt/tests/libkubevirt/config"
-
-	. "github.com/onsi/ginkgo/v2"
-	. "github.com/onsi/gomega"
-	v1 "kubevirt.io/api/core/v1"
-	"kubevirt.io/client-go/kubecli"
-)
-
-var _ = Describe(SIGSerial("cluster profiler", func() {
-	Context("when ClusterProfiler configuration", func() {
-		It("is enabled it should allow subresource access", func() {
-			// test body
-		})
-	})
-}))
-`
-			code, err := quarantine(input, "is enabled it should allow subresource access")
-			Expect(err).ToNot(HaveOccurred())
-			code = ensureImport(code, decoratorsImport)
-
-			By("verifying the decorator was inserted")
-			Expect(code).To(ContainSubstring(`"[QUARANTINE]is enabled it should allow subresource access", decorators.Quarantine`))
-
-			By("verifying the import was added")
-			Expect(code).To(ContainSubstring(`"kubevirt.io/kubevirt/tests/decorators"`))
-
-			By("verifying existing imports are preserved")
-			Expect(code).To(ContainSubstring(`"kubevirt.io/kubevirt/tests/framework/kubevirt"`))
-			Expect(code).To(ContainSubstring(`"kubevirt.io/kubevirt/tests/libkubevirt"`))
-		})
-
-		It("does not duplicate the import if already present", func() {
-			input := `package infrastructure
-
-import (
-	"kubevirt.io/kubevirt/tests/decorators"
-	"kubevirt.io/kubevirt/tests/framework/kubevirt"
-
-	. "github.com/onsi/ginkgo/v2"
-)
-
-var _ = Describe(SIGSerial("cluster profiler", func() {
-	It("some test", func() {})
-}))
-`
-			code, err := quarantine(input, "some test")
-			Expect(err).ToNot(HaveOccurred())
-			code = ensureImport(code, decoratorsImport)
-
-			By("verifying the decorator was inserted")
-			Expect(code).To(ContainSubstring(`decorators.Quarantine`))
-
-			By("verifying the import appears exactly once")
-			count := strings.Count(code, `"kubevirt.io/kubevirt/tests/decorators"`)
-			Expect(count).To(Equal(1))
-		})
-	})
-
 })
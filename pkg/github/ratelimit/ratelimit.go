@@ -0,0 +1,108 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+// Package ratelimit retries GitHub API requests that hit a rate limit. It
+// understands the errors of the go-github versions used by the robots.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/go-github/github"
+	githubv28 "github.com/google/go-github/v28/github"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// MaxRetries is the number of times a request is retried after hitting a
+	// rate limit
+	MaxRetries = 5
+	// DefaultRetryAfter is the time waited after hitting a secondary rate
+	// limit that doesn't tell when to retry
+	DefaultRetryAfter = time.Minute
+	// MinRetryAfter is the minimum time waited before retrying
+	MinRetryAfter = time.Second
+)
+
+// Sleep waits for the duration or until the context is done. It is replaced
+// in tests.
+var Sleep = func(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Retry calls the request until it doesn't fail because of a rate limit,
+// waiting until the limit resets, or as long as GitHub asks for secondary
+// rate limits, in between. It gives up after MaxRetries retries.
+func Retry(ctx context.Context, request func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := request()
+		wait, rateLimited := retryAfter(err)
+		if !rateLimited {
+			return err
+		}
+		if attempt == MaxRetries {
+			return fmt.Errorf("giving up after %d retries: %w", attempt, err)
+		}
+		if wait < MinRetryAfter {
+			wait = MinRetryAfter
+		}
+		log.WithError(err).Warnf("rate limit hit, retrying in %v", wait)
+		if err := Sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// retryAfter returns whether the error is caused by a rate limit, and if so,
+// how long to wait before retrying.
+func retryAfter(err error) (time.Duration, bool) {
+	var rateLimitErr *github.RateLimitError
+	var abuseRateLimitErr *github.AbuseRateLimitError
+	var rateLimitErrV28 *githubv28.RateLimitError
+	var abuseRateLimitErrV28 *githubv28.AbuseRateLimitError
+	switch {
+	case errors.As(err, &rateLimitErr):
+		return time.Until(rateLimitErr.Rate.Reset.Time), true
+	case errors.As(err, &rateLimitErrV28):
+		return time.Until(rateLimitErrV28.Rate.Reset.Time), true
+	case errors.As(err, &abuseRateLimitErr):
+		return secondaryRetryAfter(abuseRateLimitErr.GetRetryAfter()), true
+	case errors.As(err, &abuseRateLimitErrV28):
+		return secondaryRetryAfter(abuseRateLimitErrV28.GetRetryAfter()), true
+	default:
+		return 0, false
+	}
+}
+
+func secondaryRetryAfter(retryAfter time.Duration) time.Duration {
+	if retryAfter == 0 {
+		return DefaultRetryAfter
+	}
+	return retryAfter
+}
@@ -32,13 +32,6 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-func requireSkopeo() error {
-	if _, err := exec.LookPath("skopeo"); err != nil {
-		return fmt.Errorf("skopeo is required for this command: %w", err)
-	}
-	return nil
-}
-
 const kubevirtCIPrefix = "quay.io/kubevirtci/"
 
 func imageRepoName(imageRef string) string {
@@ -56,9 +49,6 @@ var deploymentRelDirs = []string{
 // ResolveKubevirtCITagMap returns quay.io/kubevirtci/<repo> -> latest matching tag
 // for each repository discovered under github/ci/prow-deploy.
 func ResolveKubevirtCITagMap(repoRoot string) (map[string]string, error) {
-	if err := requireSkopeo(); err != nil {
-		return nil, err
-	}
 	repos, err := KubevirtCIRepoNames(repoRoot)
 	if err != nil {
 		return nil, err
@@ -66,7 +56,7 @@ func ResolveKubevirtCITagMap(repoRoot string) (map[string]string, error) {
 	m := make(map[string]string, len(repos))
 	for _, repoName := range repos {
 		imageRef := kubevirtCIPrefix + repoName
-		tag, err := LatestSkopeoTag(imageRef)
+		tag, err := LatestTag(imageRef)
 		if err != nil {
 			if errors.Is(err, ErrNoMatchingTag) {
 				logrus.WithError(err).Warn("skipping image bump for repository with no matching tags")
@@ -79,16 +69,33 @@ func ResolveKubevirtCITagMap(repoRoot string) (map[string]string, error) {
 	return m, nil
 }
 
+// PinDigests returns a copy of the tag map where each tag is followed by the
+// digest it currently points to, i.e. <tag>@sha256:<digest>, so that applying
+// the map pins the images.
+func PinDigests(imageToTag map[string]string) (map[string]string, error) {
+	pinned := make(map[string]string, len(imageToTag))
+	for _, imageRef := range sortedImageRefs(imageToTag) {
+		tag := imageToTag[imageRef]
+		digest, err := registryClient.Digest(imageRef, tag)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", imageRef, err)
+		}
+		pinned[imageRef] = tag + "@" + digest
+	}
+	return pinned, nil
+}
+
 // BumpJobImagesWithTagMap applies the given tag map to prow job configs. It
 // matches BumpJobImages semantics (bootstrap-legacy is skipped in job YAML).
-func BumpJobImagesWithTagMap(repoRoot string, imageToTag map[string]string) error {
+// Changes are recorded in the changelog if it is not nil.
+func BumpJobImagesWithTagMap(repoRoot string, imageToTag map[string]string, changelog *Changelog) error {
 	jobDir := filepath.Join(repoRoot, "github/ci/prow-deploy/files/jobs")
 	for _, imageRef := range sortedImageRefs(imageToTag) {
 		if imageRepoName(imageRef) == "bootstrap-legacy" {
 			continue
 		}
 		tag := imageToTag[imageRef]
-		if err := replaceInJobYAMLs(jobDir, imageRef, tag); err != nil {
+		if err := replaceInJobYAMLs(jobDir, imageRef, tag, changelog); err != nil {
 			return err
 		}
 	}
@@ -96,13 +103,14 @@ func BumpJobImagesWithTagMap(repoRoot string, imageToTag map[string]string) erro
 }
 
 // BumpProwDeploymentImagesWithTagMap updates kustom deployment YAML for each
-// quay.io/kubevirtci/ image in the map.
-func BumpProwDeploymentImagesWithTagMap(repoRoot string, imageToTag map[string]string) error {
+// quay.io/kubevirtci/ image in the map. Changes are recorded in the changelog
+// if it is not nil.
+func BumpProwDeploymentImagesWithTagMap(repoRoot string, imageToTag map[string]string, changelog *Changelog) error {
 	for _, imageRef := range sortedImageRefs(imageToTag) {
 		tag := imageToTag[imageRef]
 		for _, rel := range deploymentRelDirs {
 			dir := filepath.Join(repoRoot, rel)
-			if err := replaceInYAMLTree(dir, imageRef, tag); err != nil {
+			if err := replaceInYAMLTree(dir, imageRef, tag, changelog); err != nil {
 				return err
 			}
 		}
@@ -125,10 +133,10 @@ func BumpJobImages(repoRoot string) error {
 	if err != nil {
 		return err
 	}
-	return BumpJobImagesWithTagMap(repoRoot, m)
+	return BumpJobImagesWithTagMap(repoRoot, m, nil)
 }
 
-func replaceInJobYAMLs(jobDir, imageRef, newTag string) error {
+func replaceInJobYAMLs(jobDir, imageRef, newTag string, changelog *Changelog) error {
 	return filepath.WalkDir(jobDir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
@@ -140,7 +148,11 @@ func replaceInJobYAMLs(jobDir, imageRef, newTag string) error {
 			return nil
 		}
 		return replaceInFile(path, func(body string) string {
-			return ReplaceKubevirtCIImage(body, imageRef, newTag)
+			next := ReplaceKubevirtCIImage(body, imageRef, newTag)
+			if next != body {
+				changelog.recordReplacement(path, body, imageRef, newTag)
+			}
+			return next
 		})
 	})
 }
@@ -151,10 +163,10 @@ func BumpProwDeploymentImages(repoRoot string) error {
 	if err != nil {
 		return err
 	}
-	return BumpProwDeploymentImagesWithTagMap(repoRoot, m)
+	return BumpProwDeploymentImagesWithTagMap(repoRoot, m, nil)
 }
 
-func replaceInYAMLTree(dir, imageRef, newTag string) error {
+func replaceInYAMLTree(dir, imageRef, newTag string, changelog *Changelog) error {
 	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
@@ -166,7 +178,11 @@ func replaceInYAMLTree(dir, imageRef, newTag string) error {
 			return nil
 		}
 		return replaceInFile(path, func(body string) string {
-			return ReplaceKubevirtCIImage(body, imageRef, newTag)
+			next := ReplaceKubevirtCIImage(body, imageRef, newTag)
+			if next != body {
+				changelog.recordReplacement(path, body, imageRef, newTag)
+			}
+			return next
 		})
 	})
 }
@@ -189,8 +205,12 @@ func replaceInFile(path string, transform func(string) string) error {
 	return os.WriteFile(path, []byte(next), mode)
 }
 
-// BumpContainerfileImages updates FROM lines for quay.io images using the quay API.
-func BumpContainerfileImages(repoRoot string) error {
+// BumpContainerfileImages updates FROM lines to the latest tag, which for
+// quay.io images is the tag of the image created last, and for images from
+// other registries only if the tag follows the kubevirtci date-hash pattern.
+// If pinDigests is set, or the image already was pinned, the digest of the
+// tag is appended. Changes are recorded in the changelog if it is not nil.
+func BumpContainerfileImages(repoRoot string, pinDigests bool, changelog *Changelog) error {
 	paths, err := gitLsFiles(repoRoot, func(name string) bool {
 		return strings.HasSuffix(name, "Containerfile") || strings.HasSuffix(name, "Dockerfile")
 	})
@@ -199,7 +219,7 @@ func BumpContainerfileImages(repoRoot string) error {
 	}
 	for _, rel := range paths {
 		path := filepath.Join(repoRoot, rel)
-		if err := bumpOneContainerfile(path); err != nil {
+		if err := bumpOneContainerfile(path, pinDigests, changelog); err != nil {
 			return fmt.Errorf("%s: %w", rel, err)
 		}
 	}
@@ -223,7 +243,7 @@ func gitLsFiles(repoRoot string, keep func(string) bool) ([]string, error) {
 	return paths, nil
 }
 
-func bumpOneContainerfile(path string) error {
+func bumpOneContainerfile(path string, pinDigests bool, changelog *Changelog) error {
 	lines, err := os.ReadFile(path)
 	if err != nil {
 		return err
@@ -237,23 +257,32 @@ func bumpOneContainerfile(path string) error {
 		if !ok {
 			continue
 		}
-		repo, oldTag, ok := SplitImageRef(img)
+		ref, _, pinned := strings.Cut(img, "@sha256:")
+		repo, oldTag, ok := SplitImageRef(ref)
 		if !ok {
 			continue
 		}
-		latest, err := LatestQuayTag(repo)
+		latest, err := latestContainerfileTag(repo, oldTag)
 		if err != nil {
-			// Match bash: skip when quay has no tag (non-quay images, etc.)
+			// Match bash: skip when the registry has no tag
+			logrus.WithError(err).WithField("image", img).Debug("skipping image")
 			continue
 		}
-		if oldTag == latest {
+		newFull := repo + ":" + latest
+		if pinDigests || pinned {
+			digest, err := registryClient.Digest(repo, latest)
+			if err != nil {
+				return err
+			}
+			newFull += "@" + digest
+		}
+		if img == newFull {
 			continue
 		}
-		oldFull := repo + ":" + oldTag
-		newFull := repo + ":" + latest
-		if strings.Contains(parts[i], oldFull) {
-			parts[i] = strings.Replace(parts[i], oldFull, newFull, 1)
+		if strings.Contains(parts[i], img) {
+			parts[i] = strings.Replace(parts[i], img, newFull, 1)
 			changed = true
+			changelog.record(path, repo, strings.TrimPrefix(img, repo+":"), strings.TrimPrefix(newFull, repo+":"), nil)
 		}
 	}
 	if !changed {
@@ -271,6 +300,16 @@ func bumpOneContainerfile(path string) error {
 	return os.WriteFile(path, []byte(out), mode)
 }
 
+func latestContainerfileTag(repo, currentTag string) (string, error) {
+	if strings.HasPrefix(repo, "quay.io/") {
+		return LatestCreatedTag(repo)
+	}
+	if kubevirtCITagPattern.MatchString(currentTag) {
+		return LatestTag(repo)
+	}
+	return currentTag, nil
+}
+
 func fromLineImage(line string) (string, bool) {
 	t := strings.TrimSpace(line)
 	if len(t) < 6 || !strings.EqualFold(t[:4], "from") {
@@ -0,0 +1,253 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package imagebump
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+// sourceRepoURL is the repository the kubevirtci images are built from, the
+// commit hash in their tags refers to it.
+const sourceRepoURL = "https://github.com/kubevirt/project-infra"
+
+var (
+	//go:embed pr-description.gomd
+	prDescriptionTemplateText string
+
+	prDescriptionTemplate = template.Must(template.New("prDescription").Parse(prDescriptionTemplateText))
+)
+
+// ImageChange describes the bump of one image from one tag to another.
+type ImageChange struct {
+	Image string
+	// OldTag and NewTag may carry a digest, i.e. <tag>@sha256:<digest>
+	OldTag     string
+	NewTag     string
+	OldCreated time.Time
+	NewCreated time.Time
+	Files      []string
+	Jobs       []string
+}
+
+// CommitRangeURL returns the URL comparing the commits the tags were built
+// from, or an empty string if the tags don't carry a commit hash.
+func (i ImageChange) CommitRangeURL() string {
+	oldCommit, newCommit := tagCommit(i.OldTag), tagCommit(i.NewTag)
+	if oldCommit == "" || newCommit == "" || oldCommit == newCommit {
+		return ""
+	}
+	return fmt.Sprintf("%s/compare/%s...%s", sourceRepoURL, oldCommit, newCommit)
+}
+
+func tagWithoutDigest(tag string) string {
+	tag, _, _ = strings.Cut(tag, "@")
+	return tag
+}
+
+func tagCommit(tag string) string {
+	tag = tagWithoutDigest(tag)
+	if !kubevirtCITagPattern.MatchString(tag) {
+		return ""
+	}
+	return tag[strings.LastIndex(tag, "-")+1:]
+}
+
+// Changelog records the image changes applied during a bump. A nil
+// Changelog records nothing.
+type Changelog struct {
+	repoRoot string
+
+	lock    sync.Mutex
+	changes map[string]*ImageChange
+}
+
+func NewChangelog(repoRoot string) *Changelog {
+	return &Changelog{repoRoot: repoRoot, changes: map[string]*ImageChange{}}
+}
+
+// record adds the change of the image in the file, together with the names of
+// the jobs that use the image.
+func (c *Changelog) record(path, image, oldTag, newTag string, jobs []string) {
+	if c == nil || oldTag == newTag {
+		return
+	}
+	relPath, err := filepath.Rel(c.repoRoot, path)
+	if err != nil {
+		relPath = path
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	key := image + ":" + oldTag + ">" + newTag
+	change, exists := c.changes[key]
+	if !exists {
+		change = &ImageChange{Image: image, OldTag: oldTag, NewTag: newTag}
+		c.changes[key] = change
+	}
+	change.Files = appendMissing(change.Files, relPath)
+	for _, job := range jobs {
+		change.Jobs = appendMissing(change.Jobs, job)
+	}
+}
+
+// recordReplacement records the changes for all references of imageRef in
+// content that are going to be replaced with newTag.
+func (c *Changelog) recordReplacement(path, content, imageRef, newTag string) {
+	if c == nil {
+		return
+	}
+	var jobs []string
+	if IsJobConfigPath(path) {
+		var err error
+		jobs, err = jobsUsingImage(path, imageRef)
+		if err != nil {
+			log.WithError(err).Warnf("could not determine jobs using %s in %s", imageRef, path)
+		}
+	}
+	for _, oldTag := range taggedReferences(content, imageRef) {
+		c.record(path, imageRef, oldTag, newTag, jobs)
+	}
+}
+
+// taggedReferences returns the distinct tags (including digests) of the
+// references to imageRef in content that ReplaceKubevirtCIImage replaces.
+func taggedReferences(content, imageRef string) []string {
+	reference := regexp.MustCompile(regexp.QuoteMeta(imageRef) + `:(v?[a-z0-9]+-[^\s"'@]+(@sha256:[0-9a-f]+)?)`)
+	var tags []string
+	for _, match := range reference.FindAllStringSubmatch(content, -1) {
+		tags = appendMissing(tags, match[1])
+	}
+	return tags
+}
+
+func jobsUsingImage(path, imageRef string) ([]string, error) {
+	jobConfig, err := config.ReadJobConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	var jobs []string
+	addJob := func(name string, spec *config.JobBase) {
+		if spec.Spec == nil {
+			return
+		}
+		for _, container := range spec.Spec.Containers {
+			if strings.HasPrefix(container.Image, imageRef+":") || strings.HasPrefix(container.Image, imageRef+"@") {
+				jobs = appendMissing(jobs, name)
+			}
+		}
+	}
+	for _, presubmits := range jobConfig.PresubmitsStatic {
+		for i := range presubmits {
+			addJob(presubmits[i].Name, &presubmits[i].JobBase)
+		}
+	}
+	for _, postsubmits := range jobConfig.PostsubmitsStatic {
+		for i := range postsubmits {
+			addJob(postsubmits[i].Name, &postsubmits[i].JobBase)
+		}
+	}
+	for i := range jobConfig.Periodics {
+		addJob(jobConfig.Periodics[i].Name, &jobConfig.Periodics[i].JobBase)
+	}
+	return jobs, nil
+}
+
+func appendMissing(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+// Changes returns the recorded changes sorted by image, with the creation
+// dates of the images resolved from the registry where possible.
+func (c *Changelog) Changes() []*ImageChange {
+	if c == nil {
+		return nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	var changes []*ImageChange
+	for _, change := range c.changes {
+		sort.Strings(change.Files)
+		sort.Strings(change.Jobs)
+		change.OldCreated = imageCreated(change.Image, change.OldTag)
+		change.NewCreated = imageCreated(change.Image, change.NewTag)
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Image != changes[j].Image {
+			return changes[i].Image < changes[j].Image
+		}
+		return changes[i].OldTag < changes[j].OldTag
+	})
+	return changes
+}
+
+func imageCreated(image, tag string) time.Time {
+	created, err := registryClient.Created(image, tagWithoutDigest(tag))
+	if err != nil {
+		log.WithError(err).Warnf("could not determine creation date of %s:%s", image, tag)
+		return time.Time{}
+	}
+	return created
+}
+
+type prDescriptionData struct {
+	Headline string
+	Changes  []*ImageChange
+}
+
+// WritePRDescriptionToFile writes a Markdown PR description listing all
+// recorded changes.
+func (c *Changelog) WritePRDescriptionToFile(outputFileName, headline string) error {
+	if outputFileName == "" {
+		return fmt.Errorf("output file name must not be empty")
+	}
+	outputFile, err := os.Create(outputFileName)
+	if err != nil {
+		return fmt.Errorf("could not create file: %w", err)
+	}
+	defer func() {
+		err2 := outputFile.Close()
+		if err2 != nil {
+			log.Errorf("failed to write output file: %v", err2)
+		}
+	}()
+	err = prDescriptionTemplate.Execute(outputFile, prDescriptionData{Headline: headline, Changes: c.Changes()})
+	if err != nil {
+		return fmt.Errorf("could not execute template: %w", err)
+	}
+	log.Infof("PR description written to %q", outputFileName)
+	return nil
+}
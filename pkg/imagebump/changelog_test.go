@@ -0,0 +1,170 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package imagebump
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const testJobConfig = `periodics:
+- name: periodic-golang
+  interval: 24h
+  spec:
+    containers:
+    - image: IMAGE:v20260101-aaaaaaa
+- name: periodic-other
+  interval: 24h
+  spec:
+    containers:
+    - image: quay.io/kubevirtci/other:v20260101-aaaaaaa
+presubmits:
+  kubevirt/project-infra:
+  - name: pull-golang
+    spec:
+      containers:
+      - image: IMAGE:v20260101-aaaaaaa
+`
+
+var _ = Describe("changelog", func() {
+
+	var (
+		registry               *testRegistry
+		originalRegistryClient RegistryClient
+		repository             string
+		repoRoot               string
+		jobDir                 string
+		oldCreated, newCreated time.Time
+	)
+
+	BeforeEach(func() {
+		registry = newTestRegistry()
+		repository = registry.host() + "/kubevirtci/golang"
+		originalRegistryClient = registryClient
+		registryClient = NewRegistryClient()
+
+		oldCreated = time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+		newCreated = time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+		registry.addImage("kubevirtci/golang", "v20260101-aaaaaaa", oldCreated)
+		registry.addImage("kubevirtci/golang", "v20260102-bbbbbbb", newCreated)
+
+		repoRoot = GinkgoT().TempDir()
+		jobDir = filepath.Join(repoRoot, "github", "ci", "prow-deploy", "files", "jobs", "kubevirt", "project-infra")
+		Expect(os.MkdirAll(jobDir, 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(jobDir, "project-infra-periodics.yaml"), []byte(strings.ReplaceAll(testJobConfig, "IMAGE", repository)), 0644)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		registryClient = originalRegistryClient
+		registry.server.Close()
+	})
+
+	It("records the changes of job images", func() {
+		changelog := NewChangelog(repoRoot)
+
+		Expect(replaceInJobYAMLs(jobDir, repository, "v20260102-bbbbbbb", changelog)).To(Succeed())
+
+		Expect(changelog.Changes()).To(Equal([]*ImageChange{
+			{
+				Image:      repository,
+				OldTag:     "v20260101-aaaaaaa",
+				NewTag:     "v20260102-bbbbbbb",
+				OldCreated: oldCreated,
+				NewCreated: newCreated,
+				Files:      []string{"github/ci/prow-deploy/files/jobs/kubevirt/project-infra/project-infra-periodics.yaml"},
+				Jobs:       []string{"periodic-golang", "pull-golang"},
+			},
+		}))
+	})
+
+	It("records nothing if the image is up to date", func() {
+		changelog := NewChangelog(repoRoot)
+
+		Expect(replaceInJobYAMLs(jobDir, repository, "v20260101-aaaaaaa", changelog)).To(Succeed())
+
+		Expect(changelog.Changes()).To(BeEmpty())
+	})
+
+	It("records Containerfile changes", func() {
+		changelog := NewChangelog(repoRoot)
+		containerfile := filepath.Join(repoRoot, "images", "golang", "Containerfile")
+		Expect(os.MkdirAll(filepath.Dir(containerfile), 0755)).To(Succeed())
+		Expect(os.WriteFile(containerfile, []byte("FROM "+repository+":v20260101-aaaaaaa\n"), 0644)).To(Succeed())
+
+		Expect(bumpOneContainerfile(containerfile, false, changelog)).To(Succeed())
+
+		changes := changelog.Changes()
+		Expect(changes).To(HaveLen(1))
+		Expect(changes[0].OldTag).To(Equal("v20260101-aaaaaaa"))
+		Expect(changes[0].NewTag).To(Equal("v20260102-bbbbbbb"))
+		Expect(changes[0].Files).To(Equal([]string{"images/golang/Containerfile"}))
+		Expect(changes[0].Jobs).To(BeEmpty())
+	})
+
+	It("is nil safe", func() {
+		var changelog *Changelog
+
+		Expect(replaceInJobYAMLs(jobDir, repository, "v20260102-bbbbbbb", changelog)).To(Succeed())
+
+		Expect(changelog.Changes()).To(BeNil())
+	})
+
+	DescribeTable("CommitRangeURL",
+		func(oldTag, newTag, expected string) {
+			Expect(ImageChange{OldTag: oldTag, NewTag: newTag}.CommitRangeURL()).To(Equal(expected))
+		},
+		Entry("date-hash tags", "v20260101-aaaaaaa", "v20260102-bbbbbbb", "https://github.com/kubevirt/project-infra/compare/aaaaaaa...bbbbbbb"),
+		Entry("pinned tags", "v20260101-aaaaaaa@sha256:0123", "v20260102-bbbbbbb@sha256:4567", "https://github.com/kubevirt/project-infra/compare/aaaaaaa...bbbbbbb"),
+		Entry("same commit", "v20260101-aaaaaaa", "v20260101-aaaaaaa@sha256:4567", ""),
+		Entry("other tags", "1.22", "1.23", ""),
+	)
+
+	It("writes the PR description", func() {
+		changelog := NewChangelog(repoRoot)
+		Expect(replaceInJobYAMLs(jobDir, repository, "v20260102-bbbbbbb", changelog)).To(Succeed())
+		outputFile := filepath.Join(GinkgoT().TempDir(), "pr-description.md")
+
+		Expect(changelog.WritePRDescriptionToFile(outputFile, "Bump prow-deploy images")).To(Succeed())
+
+		content, err := os.ReadFile(outputFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(strings.SplitN(string(content), "\n", 2)[0]).To(Equal("Bump prow-deploy images"))
+		Expect(string(content)).To(ContainSubstring("`v20260101-aaaaaaa` (created 2026-01-01 10:00 UTC) → `v20260102-bbbbbbb` (created 2026-01-02 10:00 UTC)"))
+		Expect(string(content)).To(ContainSubstring("Commits: https://github.com/kubevirt/project-infra/compare/aaaaaaa...bbbbbbb"))
+		Expect(string(content)).To(ContainSubstring("* `github/ci/prow-deploy/files/jobs/kubevirt/project-infra/project-infra-periodics.yaml`"))
+		Expect(string(content)).To(ContainSubstring("* `periodic-golang`"))
+		Expect(string(content)).ToNot(ContainSubstring("periodic-other"))
+	})
+
+	It("writes a PR description without changes", func() {
+		outputFile := filepath.Join(GinkgoT().TempDir(), "pr-description.md")
+
+		Expect(NewChangelog(repoRoot).WritePRDescriptionToFile(outputFile, "Bump prow-deploy images")).To(Succeed())
+
+		content, err := os.ReadFile(outputFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(ContainSubstring("No images updated."))
+	})
+})
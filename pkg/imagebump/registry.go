@@ -0,0 +1,308 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package imagebump
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	dockerHubHost         = "docker.io"
+	dockerHubRegistryHost = "registry-1.docker.io"
+
+	mediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+	mediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+)
+
+var (
+	manifestAcceptHeader = strings.Join([]string{mediaTypeOCIIndex, mediaTypeOCIManifest, mediaTypeDockerManifestList, mediaTypeDockerManifest}, ", ")
+
+	bearerChallengeParam = regexp.MustCompile(`([a-z]+)="([^"]*)"`)
+	linkNextURL          = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+)
+
+// RegistryClient provides the information about the images in a container
+// registry that is required for bumping. The repository is the image
+// reference without tag or digest, i.e. quay.io/kubevirtci/golang.
+type RegistryClient interface {
+	// ListTags returns all tags of the repository.
+	ListTags(repository string) ([]string, error)
+	// Digest returns the digest of the manifest the tag points to.
+	Digest(repository, tag string) (string, error)
+	// Created returns the creation date of the image the tag points to.
+	Created(repository, tag string) (time.Time, error)
+}
+
+// registryClient is used for all registry lookups.
+var registryClient RegistryClient = NewRegistryClient()
+
+// v2RegistryClient implements RegistryClient using the OCI distribution API,
+// which is served by quay.io, docker.io, ghcr.io and any other v2 registry.
+type v2RegistryClient struct {
+	httpClient *http.Client
+
+	tokensLock sync.Mutex
+	tokens     map[string]string
+}
+
+func NewRegistryClient() RegistryClient {
+	return &v2RegistryClient{
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		tokens:     map[string]string{},
+	}
+}
+
+// parseRepository splits the repository into the registry base URL and the
+// repository name, applying the docker.io defaults.
+func parseRepository(repository string) (baseURL, name string) {
+	host, name, found := strings.Cut(repository, "/")
+	if !found || (!strings.ContainsAny(host, ".:") && host != "localhost") {
+		host, name = dockerHubHost, repository
+	}
+	if host == dockerHubHost {
+		host = dockerHubRegistryHost
+		if !strings.Contains(name, "/") {
+			name = "library/" + name
+		}
+	}
+	scheme := "https"
+	if hostname := strings.Split(host, ":")[0]; hostname == "localhost" || hostname == "127.0.0.1" {
+		scheme = "http"
+	}
+	return scheme + "://" + host, name
+}
+
+func (c *v2RegistryClient) ListTags(repository string) ([]string, error) {
+	baseURL, name := parseRepository(repository)
+	nextURL := fmt.Sprintf("%s/v2/%s/tags/list?n=1000", baseURL, name)
+	var tags []string
+	for nextURL != "" {
+		resp, body, err := c.get(repository, nextURL, "")
+		if err != nil {
+			return nil, fmt.Errorf("list tags for %s: %w", repository, err)
+		}
+		var tagList struct {
+			Tags []string `json:"tags"`
+		}
+		if err := json.Unmarshal(body, &tagList); err != nil {
+			return nil, fmt.Errorf("parse tags for %s: %w", repository, err)
+		}
+		tags = append(tags, tagList.Tags...)
+
+		nextURL = ""
+		if matches := linkNextURL.FindStringSubmatch(resp.Header.Get("Link")); matches != nil {
+			next, err := resp.Request.URL.Parse(matches[1])
+			if err != nil {
+				return nil, fmt.Errorf("parse next link for %s: %w", repository, err)
+			}
+			nextURL = next.String()
+		}
+	}
+	return tags, nil
+}
+
+func (c *v2RegistryClient) Digest(repository, tag string) (string, error) {
+	baseURL, name := parseRepository(repository)
+	resp, body, err := c.get(repository, fmt.Sprintf("%s/v2/%s/manifests/%s", baseURL, name, tag), manifestAcceptHeader)
+	if err != nil {
+		return "", fmt.Errorf("get manifest for %s:%s: %w", repository, tag, err)
+	}
+	if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+	hash := sha256.Sum256(body)
+	return "sha256:" + hex.EncodeToString(hash[:]), nil
+}
+
+type manifest struct {
+	MediaType string `json:"mediaType"`
+	Config    struct {
+		Digest string `json:"digest"`
+	} `json:"config"`
+	Manifests []struct {
+		Digest   string `json:"digest"`
+		Platform struct {
+			Architecture string `json:"architecture"`
+			OS           string `json:"os"`
+		} `json:"platform"`
+	} `json:"manifests"`
+}
+
+func (c *v2RegistryClient) Created(repository, tag string) (time.Time, error) {
+	baseURL, name := parseRepository(repository)
+	imageManifest, err := c.manifest(repository, tag)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("get manifest for %s:%s: %w", repository, tag, err)
+	}
+	if len(imageManifest.Manifests) > 0 {
+		// for multi-arch images the amd64 image is taken as reference
+		reference := imageManifest.Manifests[0].Digest
+		for _, m := range imageManifest.Manifests {
+			if m.Platform.OS == "linux" && m.Platform.Architecture == "amd64" {
+				reference = m.Digest
+				break
+			}
+		}
+		imageManifest, err = c.manifest(repository, reference)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("get image manifest for %s:%s: %w", repository, tag, err)
+		}
+	}
+	if imageManifest.Config.Digest == "" {
+		return time.Time{}, fmt.Errorf("no config in manifest for %s:%s", repository, tag)
+	}
+	_, body, err := c.get(repository, fmt.Sprintf("%s/v2/%s/blobs/%s", baseURL, name, imageManifest.Config.Digest), "")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("get config for %s:%s: %w", repository, tag, err)
+	}
+	var config struct {
+		Created time.Time `json:"created"`
+	}
+	if err := json.Unmarshal(body, &config); err != nil {
+		return time.Time{}, fmt.Errorf("parse config for %s:%s: %w", repository, tag, err)
+	}
+	return config.Created, nil
+}
+
+func (c *v2RegistryClient) manifest(repository, reference string) (*manifest, error) {
+	baseURL, name := parseRepository(repository)
+	_, body, err := c.get(repository, fmt.Sprintf("%s/v2/%s/manifests/%s", baseURL, name, reference), manifestAcceptHeader)
+	if err != nil {
+		return nil, err
+	}
+	var result manifest
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+	return &result, nil
+}
+
+// get requests the URL, retrying once with a bearer token if the registry
+// asks for one. Tokens are scoped to the repository, thus they are stored per
+// repository.
+func (c *v2RegistryClient) get(repository, requestURL, accept string) (*http.Response, []byte, error) {
+	resp, body, err := c.do(requestURL, accept, c.token(repository))
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		token, err := c.fetchToken(resp.Header.Get("WWW-Authenticate"))
+		if err != nil {
+			return nil, nil, err
+		}
+		c.tokensLock.Lock()
+		c.tokens[repository] = token
+		c.tokensLock.Unlock()
+		resp, body, err = c.do(requestURL, accept, token)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("GET %s: %s: %s", requestURL, resp.Status, string(body))
+	}
+	return resp, body, nil
+}
+
+func (c *v2RegistryClient) token(repository string) string {
+	c.tokensLock.Lock()
+	defer c.tokensLock.Unlock()
+	return c.tokens[repository]
+}
+
+func (c *v2RegistryClient) do(requestURL, accept, token string) (*http.Response, []byte, error) {
+	req, err := http.NewRequest(http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	log.WithField("url", requestURL).Debug("requesting")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, body, nil
+}
+
+// fetchToken requests an anonymous token as described by the bearer challenge.
+func (c *v2RegistryClient) fetchToken(challenge string) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", fmt.Errorf("unsupported authentication challenge %q", challenge)
+	}
+	values := map[string]string{}
+	for _, match := range bearerChallengeParam.FindAllStringSubmatch(params, -1) {
+		values[match[1]] = match[2]
+	}
+	realm, err := url.Parse(values["realm"])
+	if err != nil || values["realm"] == "" {
+		return "", fmt.Errorf("invalid realm in authentication challenge %q", challenge)
+	}
+	query := realm.Query()
+	for _, key := range []string{"service", "scope"} {
+		if values[key] != "" {
+			query.Set(key, values[key])
+		}
+	}
+	realm.RawQuery = query.Encode()
+
+	resp, body, err := c.do(realm.String(), "", "")
+	if err != nil {
+		return "", fmt.Errorf("get token from %s: %w", realm, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("get token from %s: %s", realm, resp.Status)
+	}
+	var tokenResponse struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(body, &tokenResponse); err != nil {
+		return "", fmt.Errorf("parse token from %s: %w", realm, err)
+	}
+	if tokenResponse.Token != "" {
+		return tokenResponse.Token, nil
+	}
+	return tokenResponse.AccessToken, nil
+}
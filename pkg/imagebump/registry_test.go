@@ -0,0 +1,307 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package imagebump
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const testRegistryToken = "test-token"
+
+var testRegistryPath = regexp.MustCompile(`^/v2/(.+)/(tags/list|manifests/[^/]+|blobs/[^/]+)$`)
+
+// testRegistry is an in-process OCI distribution registry that keeps images
+// in memory and, like quay.io or docker.io, requires anonymous bearer tokens.
+type testRegistry struct {
+	server   *httptest.Server
+	pageSize int
+
+	// tags maps repository name to tag to manifest digest
+	tags map[string]map[string]string
+	// content maps digest to manifest or blob
+	content map[string][]byte
+}
+
+func newTestRegistry() *testRegistry {
+	r := &testRegistry{
+		pageSize: 2,
+		tags:     map[string]map[string]string{},
+		content:  map[string][]byte{},
+	}
+	r.server = httptest.NewServer(http.HandlerFunc(r.handle))
+	return r
+}
+
+func (r *testRegistry) host() string {
+	return strings.TrimPrefix(r.server.URL, "http://")
+}
+
+func (r *testRegistry) store(content any) string {
+	data, err := json.Marshal(content)
+	Expect(err).ToNot(HaveOccurred())
+	hash := sha256.Sum256(data)
+	digest := "sha256:" + hex.EncodeToString(hash[:])
+	r.content[digest] = data
+	return digest
+}
+
+func (r *testRegistry) addImageManifest(created time.Time) string {
+	configDigest := r.store(map[string]any{"created": created})
+	return r.store(map[string]any{
+		"schemaVersion": 2,
+		"mediaType":     mediaTypeOCIManifest,
+		"config":        map[string]any{"digest": configDigest},
+	})
+}
+
+func (r *testRegistry) tag(name, tag, digest string) {
+	if _, exists := r.tags[name]; !exists {
+		r.tags[name] = map[string]string{}
+	}
+	r.tags[name][tag] = digest
+}
+
+// addImage adds an image and returns its digest
+func (r *testRegistry) addImage(name, tag string, created time.Time) string {
+	digest := r.addImageManifest(created)
+	r.tag(name, tag, digest)
+	return digest
+}
+
+// addMultiArchImage adds an image index where the arm64 image has a
+// different creation date than the amd64 image.
+func (r *testRegistry) addMultiArchImage(name, tag string, amd64Created, arm64Created time.Time) string {
+	digest := r.store(map[string]any{
+		"schemaVersion": 2,
+		"mediaType":     mediaTypeOCIIndex,
+		"manifests": []map[string]any{
+			{"digest": r.addImageManifest(arm64Created), "platform": map[string]string{"os": "linux", "architecture": "arm64"}},
+			{"digest": r.addImageManifest(amd64Created), "platform": map[string]string{"os": "linux", "architecture": "amd64"}},
+		},
+	})
+	r.tag(name, tag, digest)
+	return digest
+}
+
+func (r *testRegistry) handle(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == "/token" {
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": testRegistryToken})
+		return
+	}
+	matches := testRegistryPath.FindStringSubmatch(req.URL.Path)
+	if matches == nil {
+		http.NotFound(w, req)
+		return
+	}
+	name, resource := matches[1], matches[2]
+	if req.Header.Get("Authorization") != "Bearer "+testRegistryToken {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test-registry",scope="repository:%s:pull"`, r.server.URL, name))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	tags, exists := r.tags[name]
+	if !exists {
+		http.NotFound(w, req)
+		return
+	}
+
+	switch {
+	case resource == "tags/list":
+		var names []string
+		for tag := range tags {
+			names = append(names, tag)
+		}
+		sort.Strings(names)
+		start := 0
+		if last := req.URL.Query().Get("last"); last != "" {
+			start = sort.SearchStrings(names, last) + 1
+		}
+		end := min(start+r.pageSize, len(names))
+		if end < len(names) {
+			w.Header().Set("Link", fmt.Sprintf(`</v2/%s/tags/list?n=%d&last=%s>; rel="next"`, name, r.pageSize, names[end-1]))
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"name": name, "tags": names[start:end]})
+	case strings.HasPrefix(resource, "manifests/"):
+		reference := strings.TrimPrefix(resource, "manifests/")
+		if digest, isTag := tags[reference]; isTag {
+			reference = digest
+		}
+		content, exists := r.content[reference]
+		if !exists {
+			http.NotFound(w, req)
+			return
+		}
+		w.Header().Set("Docker-Content-Digest", reference)
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		_, _ = w.Write(content)
+	default:
+		content, exists := r.content[strings.TrimPrefix(resource, "blobs/")]
+		if !exists {
+			http.NotFound(w, req)
+			return
+		}
+		_, _ = w.Write(content)
+	}
+}
+
+var _ = Describe("registry", func() {
+
+	var (
+		registry               *testRegistry
+		originalRegistryClient RegistryClient
+		repository             string
+	)
+
+	BeforeEach(func() {
+		registry = newTestRegistry()
+		repository = registry.host() + "/kubevirtci/golang"
+		originalRegistryClient = registryClient
+		registryClient = NewRegistryClient()
+	})
+
+	AfterEach(func() {
+		registryClient = originalRegistryClient
+		registry.server.Close()
+	})
+
+	DescribeTable("parseRepository",
+		func(repository, expectedBaseURL, expectedName string) {
+			baseURL, name := parseRepository(repository)
+			Expect(baseURL).To(Equal(expectedBaseURL))
+			Expect(name).To(Equal(expectedName))
+		},
+		Entry("quay", "quay.io/kubevirtci/golang", "https://quay.io", "kubevirtci/golang"),
+		Entry("ghcr", "ghcr.io/kubevirt/kubevirt/virt-launcher", "https://ghcr.io", "kubevirt/kubevirt/virt-launcher"),
+		Entry("docker.io official image", "docker.io/golang", "https://registry-1.docker.io", "library/golang"),
+		Entry("docker.io implicit", "golang", "https://registry-1.docker.io", "library/golang"),
+		Entry("docker.io implicit with namespace", "kubevirt/virt-launcher", "https://registry-1.docker.io", "kubevirt/virt-launcher"),
+		Entry("local registry", "localhost:5000/kubevirtci/golang", "http://localhost:5000", "kubevirtci/golang"),
+	)
+
+	It("lists all tags across pages", func() {
+		for _, tag := range []string{"v20260101-aaaaaaa", "v20260102-bbbbbbb", "v20260103-ccccccc", "latest", "v20260104-ddddddd"} {
+			registry.addImage("kubevirtci/golang", tag, time.Now())
+		}
+
+		tags, err := registryClient.ListTags(repository)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tags).To(Equal([]string{"latest", "v20260101-aaaaaaa", "v20260102-bbbbbbb", "v20260103-ccccccc", "v20260104-ddddddd"}))
+	})
+
+	It("returns the digest of a tag", func() {
+		digest := registry.addImage("kubevirtci/golang", "v20260101-aaaaaaa", time.Now())
+
+		Expect(registryClient.Digest(repository, "v20260101-aaaaaaa")).To(Equal(digest))
+	})
+
+	It("returns the creation date of the amd64 image", func() {
+		amd64Created := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+		registry.addMultiArchImage("kubevirtci/golang", "v20260101-aaaaaaa", amd64Created, amd64Created.Add(time.Hour))
+
+		Expect(registryClient.Created(repository, "v20260101-aaaaaaa")).To(Equal(amd64Created))
+	})
+
+	It("fails for unknown repositories", func() {
+		_, err := registryClient.ListTags(registry.host() + "/kubevirtci/unknown")
+		Expect(err).To(HaveOccurred())
+	})
+
+	When("pinning", func() {
+
+		It("appends the digests to the tags", func() {
+			digest := registry.addImage("kubevirtci/golang", "v20260101-aaaaaaa", time.Now())
+
+			Expect(PinDigests(map[string]string{repository: "v20260101-aaaaaaa"})).To(Equal(map[string]string{
+				repository: "v20260101-aaaaaaa@" + digest,
+			}))
+		})
+
+		It("pins job images", func() {
+			digest := registry.addImage("kubevirtci/golang", "v20260102-bbbbbbb", time.Now())
+			content := "image: " + repository + ":v20260101-aaaaaaa\n"
+
+			Expect(ReplaceKubevirtCIImage(content, repository, "v20260102-bbbbbbb@"+digest)).To(Equal(
+				"image: " + repository + ":v20260102-bbbbbbb@" + digest + "\n",
+			))
+		})
+	})
+
+	When("bumping Containerfiles", func() {
+
+		var containerfile string
+
+		BeforeEach(func() {
+			containerfile = filepath.Join(GinkgoT().TempDir(), "Containerfile")
+		})
+
+		bump := func(content string, pinDigests bool) string {
+			Expect(os.WriteFile(containerfile, []byte(content), 0644)).To(Succeed())
+			Expect(bumpOneContainerfile(containerfile, pinDigests, nil)).To(Succeed())
+			bumped, err := os.ReadFile(containerfile)
+			Expect(err).ToNot(HaveOccurred())
+			return string(bumped)
+		}
+
+		It("bumps date-hash tags of non-quay registries", func() {
+			registry.addImage("kubevirtci/golang", "v20260101-aaaaaaa", time.Now())
+			registry.addImage("kubevirtci/golang", "v20260102-bbbbbbb", time.Now())
+
+			Expect(bump("FROM "+repository+":v20260101-aaaaaaa AS builder\n", false)).To(Equal(
+				"FROM " + repository + ":v20260102-bbbbbbb AS builder\n",
+			))
+		})
+
+		It("keeps other tags of non-quay registries", func() {
+			registry.addImage("kubevirtci/golang", "1.22", time.Now())
+
+			Expect(bump("FROM "+repository+":1.22\n", false)).To(Equal("FROM " + repository + ":1.22\n"))
+		})
+
+		It("pins the digest", func() {
+			digest := registry.addImage("kubevirtci/golang", "1.22", time.Now())
+
+			Expect(bump("FROM "+repository+":1.22\n", true)).To(Equal("FROM " + repository + ":1.22@" + digest + "\n"))
+		})
+
+		It("updates an existing pin", func() {
+			registry.addImage("kubevirtci/golang", "v20260101-aaaaaaa", time.Now())
+			digest := registry.addImage("kubevirtci/golang", "v20260102-bbbbbbb", time.Now())
+
+			Expect(bump("FROM --platform=linux/amd64 "+repository+":v20260101-aaaaaaa@sha256:0123456789abcdef\n", false)).To(Equal(
+				"FROM --platform=linux/amd64 " + repository + ":v20260102-bbbbbbb@" + digest + "\n",
+			))
+		})
+	})
+})
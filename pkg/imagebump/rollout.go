@@ -0,0 +1,454 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package imagebump
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// JobTier groups prow jobs by the impact a broken image has on them.
+type JobTier string
+
+const (
+	// JobTierCanary are the jobs that don't block merging: periodics,
+	// postsubmits and optional presubmits.
+	JobTierCanary JobTier = "canary"
+	// JobTierRequired are the presubmits that are required for merging.
+	JobTierRequired JobTier = "required"
+)
+
+// RolloutJob is a prow job taking part in a staged rollout.
+type RolloutJob struct {
+	Name string
+	Type prowv1.ProwJobType
+	Tier JobTier
+}
+
+// RolloutPolicy configures the staged rollout of kubevirtci images. Images
+// are first bumped for the canary jobs only. Required jobs are bumped after
+// the canary jobs have been running with the new image for the soak period
+// and their success rate stayed above the threshold.
+type RolloutPolicy struct {
+	// SoakPeriod is the time the canary jobs need to run with the new image
+	// before the required jobs are bumped.
+	SoakPeriod metav1.Duration `json:"soakPeriod"`
+	// MinSuccessRate is the success rate in percent the finished canary runs
+	// need to reach.
+	MinSuccessRate float64 `json:"minSuccessRate"`
+	// MinRuns is the number of finished canary runs with the new image that
+	// are required for a decision.
+	MinRuns int `json:"minRuns"`
+	// MaxRunsPerJob is the number of most recent runs per job that are
+	// inspected.
+	MaxRunsPerJob int `json:"maxRunsPerJob"`
+	// Bucket is the GCS bucket prow stores the job results in.
+	Bucket string `json:"bucket"`
+}
+
+const (
+	defaultRolloutMaxRunsPerJob = 10
+	defaultRolloutBucket        = "kubevirt-prow"
+)
+
+// LoadRolloutPolicy reads the rollout policy from the YAML file.
+func LoadRolloutPolicy(path string) (*RolloutPolicy, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read rollout policy: %w", err)
+	}
+	policy := &RolloutPolicy{}
+	if err := sigsyaml.UnmarshalStrict(content, policy); err != nil {
+		return nil, fmt.Errorf("parse rollout policy %s: %w", path, err)
+	}
+	if policy.MinSuccessRate < 0 || policy.MinSuccessRate > 100 {
+		return nil, fmt.Errorf("rollout policy %s: minSuccessRate must be between 0 and 100", path)
+	}
+	if policy.MinRuns < 0 || policy.MaxRunsPerJob < 0 {
+		return nil, fmt.Errorf("rollout policy %s: minRuns and maxRunsPerJob must not be negative", path)
+	}
+	if policy.MaxRunsPerJob == 0 {
+		policy.MaxRunsPerJob = defaultRolloutMaxRunsPerJob
+	}
+	if policy.Bucket == "" {
+		policy.Bucket = defaultRolloutBucket
+	}
+	return policy, nil
+}
+
+// JobRun is the result of one run of a prow job.
+type JobRun struct {
+	Started  time.Time
+	Finished bool
+	Passed   bool
+	// Images are the container images the job ran with.
+	Images []string
+}
+
+// JobRunSource provides the runs of prow jobs.
+type JobRunSource interface {
+	// Runs returns the most recent runs of the job.
+	Runs(job RolloutJob, maxRuns int) ([]JobRun, error)
+	// FirstRun returns the first run of the job that used the image, i.e.
+	// the earliest of the most recent runs that all used it, or nil if the
+	// most recent run did not use the image.
+	FirstRun(job RolloutJob, image string) (*JobRun, error)
+}
+
+// RolloutDecision is the result of evaluating the canary runs against the
+// rollout policy.
+type RolloutDecision struct {
+	Promote bool
+	// Reject is set if the canary runs soaked long enough but failed the
+	// policy, so that the image should not be promoted at all.
+	Reject    bool
+	Reason    string
+	Runs      int
+	Passed    int
+	SoakedFor time.Duration
+}
+
+// SuccessRate returns the percentage of passed runs.
+func (d RolloutDecision) SuccessRate() float64 {
+	if d.Runs == 0 {
+		return 0
+	}
+	return float64(d.Passed) * 100 / float64(d.Runs)
+}
+
+// Evaluate decides whether the image can be rolled out to the required jobs,
+// given the recent runs of the canary jobs. Only runs that used the image are
+// taken into account. The soak period starts with firstStarted, the start of
+// the first canary run that used the image, since the recent runs might not
+// reach back that far.
+func (p *RolloutPolicy) Evaluate(image string, runs []JobRun, firstStarted, now time.Time) RolloutDecision {
+	decision := RolloutDecision{}
+	for _, run := range runs {
+		if !usesImage(run, image) || !run.Finished {
+			continue
+		}
+		decision.Runs++
+		if run.Passed {
+			decision.Passed++
+		}
+	}
+	if firstStarted.IsZero() {
+		decision.Reason = "no canary runs with the image yet"
+		return decision
+	}
+	decision.SoakedFor = now.Sub(firstStarted)
+	switch {
+	case decision.SoakedFor < p.SoakPeriod.Duration:
+		decision.Reason = fmt.Sprintf("soaking for %s of %s", decision.SoakedFor.Round(time.Minute), p.SoakPeriod.Duration)
+	case decision.Runs < p.MinRuns:
+		decision.Reason = fmt.Sprintf("%d of %d required canary runs finished", decision.Runs, p.MinRuns)
+	case decision.SuccessRate() < p.MinSuccessRate:
+		decision.Reject = true
+		decision.Reason = fmt.Sprintf("canary success rate %.1f%% is below %.1f%%", decision.SuccessRate(), p.MinSuccessRate)
+	default:
+		decision.Promote = true
+		decision.Reason = fmt.Sprintf("canary success rate %.1f%% over %d runs in %s", decision.SuccessRate(), decision.Runs, decision.SoakedFor.Round(time.Minute))
+	}
+	return decision
+}
+
+func usesImage(run JobRun, image string) bool {
+	for _, runImage := range run.Images {
+		if runImage == image {
+			return true
+		}
+	}
+	return false
+}
+
+// RolloutJobImages applies the tag map to the prow job configs in stages.
+// While the canary jobs use another tag than the required jobs, that tag is
+// soaking: it is promoted to the required jobs once the canary runs with it
+// satisfy the policy, and the canary jobs are left alone until then. Only
+// when no tag is soaking, or the soaking tag has been rejected, the canary
+// jobs are bumped to the new tag. Changes are recorded in the changelog if it
+// is not nil.
+func RolloutJobImages(repoRoot string, imageToTag map[string]string, policy *RolloutPolicy, source JobRunSource, changelog *Changelog) error {
+	jobDir := filepath.Join(repoRoot, "github/ci/prow-deploy/files/jobs")
+	now := time.Now()
+	for _, imageRef := range sortedImageRefs(imageToTag) {
+		if imageRepoName(imageRef) == "bootstrap-legacy" {
+			continue
+		}
+		tag := imageToTag[imageRef]
+		logger := log.WithField("image", imageRef)
+		canaryJobs, canaryTags, err := jobTierTags(jobDir, imageRef, JobTierCanary)
+		if err != nil {
+			return err
+		}
+		if len(canaryJobs) == 0 {
+			logger.Warn("no canary jobs use the image, not rolling out to required jobs")
+			continue
+		}
+		requiredJobs, requiredTags, err := jobTierTags(jobDir, imageRef, JobTierRequired)
+		if err != nil {
+			return err
+		}
+		soaking := len(requiredJobs) > 0 && len(canaryTags) == 1 &&
+			(len(requiredTags) != 1 || requiredTags[0] != canaryTags[0])
+		if soaking {
+			soakingTag := canaryTags[0]
+			decision, err := evaluateCanaryJobs(imageRef+":"+soakingTag, canaryJobs, policy, source, now)
+			if err != nil {
+				return err
+			}
+			logger := logger.WithField("tag", soakingTag).WithField("reason", decision.Reason)
+			switch {
+			case decision.Promote:
+				logger.Info("rolling out to required jobs")
+				if _, err := replaceInJobTier(jobDir, imageRef, soakingTag, JobTierRequired, changelog); err != nil {
+					return err
+				}
+			case decision.Reject:
+				logger.Warn("not rolling out to required jobs")
+			default:
+				logger.Info("holding rollout to required jobs")
+				continue
+			}
+		}
+		if len(canaryTags) == 1 && canaryTags[0] == tag {
+			continue
+		}
+		logger.WithField("tag", tag).Info("rolling out to canary jobs")
+		if _, err := replaceInJobTier(jobDir, imageRef, tag, JobTierCanary, changelog); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// evaluateCanaryJobs evaluates the runs of the canary jobs with the image
+// against the policy. The soak period starts with the first run of any of the
+// canary jobs that used the image.
+func evaluateCanaryJobs(image string, canaryJobs []RolloutJob, policy *RolloutPolicy, source JobRunSource, now time.Time) (RolloutDecision, error) {
+	var runs []JobRun
+	var firstStarted time.Time
+	for _, job := range canaryJobs {
+		jobRuns, err := source.Runs(job, policy.MaxRunsPerJob)
+		if err != nil {
+			return RolloutDecision{}, fmt.Errorf("runs of %s: %w", job.Name, err)
+		}
+		runs = append(runs, jobRuns...)
+		firstRun, err := source.FirstRun(job, image)
+		if err != nil {
+			return RolloutDecision{}, fmt.Errorf("first run of %s with %s: %w", job.Name, image, err)
+		}
+		if firstRun != nil && (firstStarted.IsZero() || firstRun.Started.Before(firstStarted)) {
+			firstStarted = firstRun.Started
+		}
+	}
+	return policy.Evaluate(image, runs, firstStarted, now), nil
+}
+
+// jobTierTags returns all jobs of the tier that use the image together with
+// the distinct tags they use.
+func jobTierTags(jobDir, imageRef string, tier JobTier) ([]RolloutJob, []string, error) {
+	var jobs []RolloutJob
+	var tags []string
+	err := walkJobConfigs(jobDir, imageRef, func(path string, lines []string, entries []jobConfigEntry) error {
+		for _, entry := range entries {
+			if entry.job.Tier != tier {
+				continue
+			}
+			jobs = append(jobs, entry.job)
+			for _, line := range entry.lines {
+				if line >= len(lines) {
+					continue
+				}
+				for _, tag := range taggedReferences(lines[line], imageRef) {
+					tags = appendMissing(tags, tag)
+				}
+			}
+		}
+		return nil
+	})
+	sort.Strings(tags)
+	return jobs, tags, err
+}
+
+// walkJobConfigs calls fn for each job config below jobDir that references
+// the image, with the lines of the file and the jobs referencing the image.
+func walkJobConfigs(jobDir, imageRef string, fn func(path string, lines []string, entries []jobConfigEntry) error) error {
+	return filepath.WalkDir(jobDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !IsJobConfigPath(path) {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(string(content), imageRef) {
+			return nil
+		}
+		entries, err := jobConfigEntries(content, imageRef)
+		if err != nil {
+			return fmt.Errorf("parse %s: %w", path, err)
+		}
+		return fn(path, strings.Split(string(content), "\n"), entries)
+	})
+}
+
+// replaceInJobTier replaces the image references in the jobs of the tier and
+// returns all jobs of the tier that use the image.
+func replaceInJobTier(jobDir, imageRef, newTag string, tier JobTier, changelog *Changelog) ([]RolloutJob, error) {
+	var jobs []RolloutJob
+	err := walkJobConfigs(jobDir, imageRef, func(path string, lines []string, entries []jobConfigEntry) error {
+		var changedJobs []string
+		var oldLines []string
+		for _, entry := range entries {
+			if entry.job.Tier != tier {
+				continue
+			}
+			jobs = append(jobs, entry.job)
+			changed := false
+			for _, line := range entry.lines {
+				if line >= len(lines) {
+					continue
+				}
+				next := ReplaceKubevirtCIImage(lines[line], imageRef, newTag)
+				if next != lines[line] {
+					oldLines = append(oldLines, lines[line])
+					lines[line] = next
+					changed = true
+				}
+			}
+			if changed {
+				changedJobs = append(changedJobs, entry.job.Name)
+			}
+		}
+		if len(changedJobs) == 0 {
+			return nil
+		}
+		for _, oldTag := range taggedReferences(strings.Join(oldLines, "\n"), imageRef) {
+			changelog.record(path, imageRef, oldTag, newTag, changedJobs)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(path, []byte(strings.Join(lines, "\n")), info.Mode()&os.ModePerm)
+	})
+	return jobs, err
+}
+
+type jobConfigEntry struct {
+	job RolloutJob
+	// lines are the zero based line numbers referencing the image
+	lines []int
+}
+
+// jobConfigEntries returns the jobs in the job config that reference the
+// image, together with the lines the references are on.
+func jobConfigEntries(content []byte, imageRef string) ([]jobConfigEntry, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+	if len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	var entries []jobConfigEntry
+	addJobs := func(jobType prowv1.ProwJobType, jobs *yaml.Node) {
+		if jobs.Kind != yaml.SequenceNode {
+			return
+		}
+		for _, job := range jobs.Content {
+			entry := jobConfigEntry{job: RolloutJob{Type: jobType, Tier: JobTierCanary}}
+			optional, skipReport := false, false
+			for i := 0; i+1 < len(job.Content); i += 2 {
+				switch job.Content[i].Value {
+				case "name":
+					entry.job.Name = job.Content[i+1].Value
+				case "optional":
+					optional = job.Content[i+1].Value == "true"
+				case "skip_report":
+					skipReport = job.Content[i+1].Value == "true"
+				}
+			}
+			if jobType == prowv1.PresubmitJob && !optional && !skipReport {
+				entry.job.Tier = JobTierRequired
+			}
+			entry.lines = imageLines(job, imageRef)
+			if len(entry.lines) > 0 {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	root := document.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i].Value, root.Content[i+1]
+		switch key {
+		case "periodics":
+			addJobs(prowv1.PeriodicJob, value)
+		case "postsubmits", "presubmits":
+			jobType := prowv1.PostsubmitJob
+			if key == "presubmits" {
+				jobType = prowv1.PresubmitJob
+			}
+			for j := 1; j < len(value.Content); j += 2 {
+				addJobs(jobType, value.Content[j])
+			}
+		}
+	}
+	return entries, nil
+}
+
+// imageLines returns the zero based line numbers of the scalars below node
+// that reference the image. Block scalars span multiple lines.
+func imageLines(node *yaml.Node, imageRef string) []int {
+	if node.Kind == yaml.ScalarNode {
+		if !strings.Contains(node.Value, imageRef) {
+			return nil
+		}
+		first, count := node.Line-1, strings.Count(node.Value, "\n")+1
+		if node.Style == yaml.LiteralStyle || node.Style == yaml.FoldedStyle {
+			first, count = node.Line, strings.Count(strings.TrimSuffix(node.Value, "\n"), "\n")+1
+		}
+		var lines []int
+		for line := first; line < first+count; line++ {
+			lines = append(lines, line)
+		}
+		return lines
+	}
+	var lines []int
+	for _, child := range node.Content {
+		lines = append(lines, imageLines(child, imageRef)...)
+	}
+	return lines
+}
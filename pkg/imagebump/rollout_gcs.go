@@ -0,0 +1,218 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package imagebump
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+)
+
+// gcsJobRunSource reads the job runs from the results prow uploads to GCS.
+// Periodic and postsubmit runs are stored below logs/<job>/<build>, the
+// presubmit runs are linked from pr-logs/directory/<job>/<build>.txt.
+type gcsJobRunSource struct {
+	ctx    context.Context
+	client *storage.Client
+	bucket string
+}
+
+func NewGCSJobRunSource(ctx context.Context, client *storage.Client, bucket string) JobRunSource {
+	return &gcsJobRunSource{ctx: ctx, client: client, bucket: bucket}
+}
+
+func (s *gcsJobRunSource) Runs(job RolloutJob, maxRuns int) ([]JobRun, error) {
+	builds, err := s.builds(job)
+	if err != nil {
+		return nil, err
+	}
+	if len(builds) > maxRuns {
+		builds = builds[:maxRuns]
+	}
+	var runs []JobRun
+	for _, build := range builds {
+		run, err := s.buildRun(job, build)
+		if err != nil {
+			return nil, err
+		}
+		if run != nil {
+			runs = append(runs, *run)
+		}
+	}
+	return runs, nil
+}
+
+// FirstRun walks back from the most recent build of the job until it finds a
+// run that did not use the image.
+func (s *gcsJobRunSource) FirstRun(job RolloutJob, image string) (*JobRun, error) {
+	builds, err := s.builds(job)
+	if err != nil {
+		return nil, err
+	}
+	var first *JobRun
+	for _, build := range builds {
+		run, err := s.buildRun(job, build)
+		if err != nil {
+			return nil, err
+		}
+		if run == nil {
+			continue
+		}
+		if !usesImage(*run, image) {
+			break
+		}
+		first = run
+	}
+	return first, nil
+}
+
+// builds returns the build directories, or for presubmits the links to them,
+// of the job with the most recent build first.
+func (s *gcsJobRunSource) builds(job RolloutJob) ([]string, error) {
+	if job.Type != prowv1.PresubmitJob {
+		prefixes, err := s.list(path.Join("logs", job.Name)+"/", true)
+		if err != nil {
+			return nil, err
+		}
+		return mostRecentBuilds(prefixes), nil
+	}
+	links, err := s.list(path.Join("pr-logs", "directory", job.Name)+"/", false)
+	if err != nil {
+		return nil, err
+	}
+	return mostRecentBuilds(links), nil
+}
+
+// buildRun returns the run of the build as returned by builds.
+func (s *gcsJobRunSource) buildRun(job RolloutJob, build string) (*JobRun, error) {
+	if job.Type != prowv1.PresubmitJob {
+		return s.run(build)
+	}
+	content, err := s.read(build)
+	if err != nil {
+		return nil, err
+	}
+	return s.run(strings.TrimPrefix(strings.TrimSpace(string(content)), "gs://"+s.bucket+"/"))
+}
+
+// mostRecentBuilds returns the names sorted by build number, highest first,
+// where the build number is the base name without extension.
+func mostRecentBuilds(names []string) []string {
+	buildNumbers := map[string]int{}
+	var builds []string
+	for _, name := range names {
+		base := path.Base(name)
+		buildNumber, err := strconv.Atoi(strings.TrimSuffix(base, path.Ext(base)))
+		if err != nil {
+			continue
+		}
+		buildNumbers[name] = buildNumber
+		builds = append(builds, name)
+	}
+	sort.Slice(builds, func(i, j int) bool {
+		return buildNumbers[builds[i]] > buildNumbers[builds[j]]
+	})
+	return builds
+}
+
+// run returns the run stored in the build directory, or nil if there is none.
+func (s *gcsJobRunSource) run(buildDir string) (*JobRun, error) {
+	content, err := s.read(path.Join(buildDir, "prowjob.json"))
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var prowJob prowv1.ProwJob
+	if err := json.Unmarshal(content, &prowJob); err != nil {
+		return nil, fmt.Errorf("parse prowjob.json in %s: %w", buildDir, err)
+	}
+	run := &JobRun{Started: prowJob.Status.StartTime.Time}
+	if prowJob.Spec.PodSpec != nil {
+		for _, container := range prowJob.Spec.PodSpec.Containers {
+			run.Images = append(run.Images, container.Image)
+		}
+	}
+
+	content, err = s.read(path.Join(buildDir, "finished.json"))
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		// build still running
+		return run, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var finished struct {
+		Passed *bool  `json:"passed"`
+		Result string `json:"result"`
+	}
+	if err := json.Unmarshal(content, &finished); err != nil {
+		return nil, fmt.Errorf("parse finished.json in %s: %w", buildDir, err)
+	}
+	run.Finished = true
+	if finished.Passed != nil {
+		run.Passed = *finished.Passed
+	} else {
+		run.Passed = finished.Result == "SUCCESS"
+	}
+	return run, nil
+}
+
+// list returns the directories (if dirs is set) or objects directly below
+// the prefix.
+func (s *gcsJobRunSource) list(prefix string, dirs bool) ([]string, error) {
+	objects := s.client.Bucket(s.bucket).Objects(s.ctx, &storage.Query{Prefix: prefix, Delimiter: "/"})
+	var names []string
+	for {
+		attrs, err := objects.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("list gs://%s/%s: %w", s.bucket, prefix, err)
+		}
+		if dirs && attrs.Prefix != "" {
+			names = append(names, strings.TrimSuffix(attrs.Prefix, "/"))
+		} else if !dirs && attrs.Name != "" {
+			names = append(names, attrs.Name)
+		}
+	}
+	return names, nil
+}
+
+func (s *gcsJobRunSource) read(object string) ([]byte, error) {
+	reader, err := s.client.Bucket(s.bucket).Object(object).NewReader(s.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("read gs://%s/%s: %w", s.bucket, object, err)
+	}
+	defer func() { _ = reader.Close() }()
+	return io.ReadAll(reader)
+}
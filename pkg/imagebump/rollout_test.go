@@ -0,0 +1,360 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package imagebump
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+)
+
+// recordedJobRuns serves recorded runs per job name.
+type recordedJobRuns map[string][]JobRun
+
+func (r recordedJobRuns) Runs(job RolloutJob, maxRuns int) ([]JobRun, error) {
+	runs := r[job.Name]
+	if len(runs) > maxRuns {
+		runs = runs[:maxRuns]
+	}
+	return runs, nil
+}
+
+func (r recordedJobRuns) FirstRun(job RolloutJob, image string) (*JobRun, error) {
+	var first *JobRun
+	for i, run := range r[job.Name] {
+		if !usesImage(run, image) {
+			break
+		}
+		first = &r[job.Name][i]
+	}
+	return first, nil
+}
+
+const rolloutJobConfig = `periodics:
+- name: periodic-bootstrap
+  interval: 24h
+  spec:
+    containers:
+    - image: quay.io/kubevirtci/bootstrap:v20260101-aaaaaaa
+presubmits:
+  kubevirt/kubevirt:
+  - name: pull-optional
+    optional: true
+    spec:
+      containers:
+      - image: quay.io/kubevirtci/bootstrap:v20260101-aaaaaaa
+  - name: pull-required
+    always_run: true
+    spec:
+      containers:
+      - image: quay.io/kubevirtci/bootstrap:v20260101-aaaaaaa
+        command:
+        - /bin/sh
+        - -c
+        - |
+          echo using quay.io/kubevirtci/bootstrap:v20260101-aaaaaaa
+          make test
+postsubmits:
+  kubevirt/kubevirt:
+  - name: post-bootstrap
+    spec:
+      containers:
+      - image: quay.io/kubevirtci/bootstrap:v20260101-aaaaaaa
+`
+
+var _ = Describe("rollout", func() {
+
+	const (
+		image     = "quay.io/kubevirtci/bootstrap"
+		oldTag    = "v20260101-aaaaaaa"
+		newTag    = "v20260102-bbbbbbb"
+		latestTag = "v20260103-ccccccc"
+	)
+
+	var (
+		now    time.Time
+		policy *RolloutPolicy
+	)
+
+	BeforeEach(func() {
+		now = time.Now()
+		policy = &RolloutPolicy{
+			SoakPeriod:     metav1.Duration{Duration: 48 * time.Hour},
+			MinSuccessRate: 80,
+			MinRuns:        4,
+			MaxRunsPerJob:  10,
+		}
+	})
+
+	runs := func(count, passed int, started time.Time, image string) []JobRun {
+		var result []JobRun
+		for i := 0; i < count; i++ {
+			result = append(result, JobRun{Started: started, Finished: true, Passed: i < passed, Images: []string{image}})
+		}
+		return result
+	}
+
+	Context("LoadRolloutPolicy", func() {
+
+		writePolicy := func(content string) string {
+			path := filepath.Join(GinkgoT().TempDir(), "rollout-policy.yaml")
+			Expect(os.WriteFile(path, []byte(content), 0644)).To(Succeed())
+			return path
+		}
+
+		It("applies defaults", func() {
+			policy, err := LoadRolloutPolicy(writePolicy("soakPeriod: 24h\nminSuccessRate: 90\nminRuns: 5\n"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(policy).To(Equal(&RolloutPolicy{
+				SoakPeriod:     metav1.Duration{Duration: 24 * time.Hour},
+				MinSuccessRate: 90,
+				MinRuns:        5,
+				MaxRunsPerJob:  defaultRolloutMaxRunsPerJob,
+				Bucket:         defaultRolloutBucket,
+			}))
+		})
+
+		It("rejects unknown fields", func() {
+			_, err := LoadRolloutPolicy(writePolicy("soakTime: 24h\n"))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("rejects invalid success rates", func() {
+			_, err := LoadRolloutPolicy(writePolicy("minSuccessRate: 120\n"))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("loads the policy of the image bump job", func() {
+			_, err := LoadRolloutPolicy("../../robots/image-bumper/rollout-policy.yaml")
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	DescribeTable("Evaluate",
+		func(jobRuns func() []JobRun, expectedPromote bool, expectedReason string) {
+			var firstStarted time.Time
+			if first, _ := (recordedJobRuns{"job": jobRuns()}).FirstRun(RolloutJob{Name: "job"}, image+":"+newTag); first != nil {
+				firstStarted = first.Started
+			}
+			decision := policy.Evaluate(image+":"+newTag, jobRuns(), firstStarted, now)
+			Expect(decision.Promote).To(Equal(expectedPromote))
+			Expect(decision.Reason).To(ContainSubstring(expectedReason))
+		},
+		Entry("without runs",
+			func() []JobRun { return nil },
+			false, "no canary runs"),
+		Entry("with runs of the old image only",
+			func() []JobRun { return runs(10, 10, now.Add(-72*time.Hour), image+":v20260101-aaaaaaa") },
+			false, "no canary runs"),
+		Entry("while soaking",
+			func() []JobRun { return runs(10, 10, now.Add(-24*time.Hour), image+":"+newTag) },
+			false, "soaking"),
+		Entry("with too few finished runs",
+			func() []JobRun {
+				return append([]JobRun{{Started: now, Images: []string{image + ":" + newTag}}}, runs(3, 3, now.Add(-72*time.Hour), image+":"+newTag)...)
+			},
+			false, "3 of 4 required canary runs finished"),
+		Entry("with a low success rate",
+			func() []JobRun { return runs(10, 7, now.Add(-72*time.Hour), image+":"+newTag) },
+			false, "canary success rate 70.0% is below 80.0%"),
+		Entry("with a sufficient success rate",
+			func() []JobRun { return runs(10, 8, now.Add(-72*time.Hour), image+":"+newTag) },
+			true, "canary success rate 80.0% over 10 runs"),
+		Entry("with the soak period starting before the recent runs",
+			func() []JobRun {
+				return append(runs(10, 10, now.Add(-time.Hour), image+":"+newTag), runs(5, 5, now.Add(-72*time.Hour), image+":"+newTag)...)
+			},
+			true, "canary success rate 100.0% over 15 runs"),
+	)
+
+	Context("RolloutJobImages", func() {
+
+		var (
+			repoRoot      string
+			jobConfigPath string
+		)
+
+		BeforeEach(func() {
+			repoRoot = GinkgoT().TempDir()
+			jobDir := filepath.Join(repoRoot, "github", "ci", "prow-deploy", "files", "jobs", "kubevirt", "kubevirt")
+			Expect(os.MkdirAll(jobDir, 0755)).To(Succeed())
+			jobConfigPath = filepath.Join(jobDir, "kubevirt-presubmits.yaml")
+			Expect(os.WriteFile(jobConfigPath, []byte(rolloutJobConfig), 0644)).To(Succeed())
+		})
+
+		// jobImages returns the tags of the image per job
+		jobImages := func() map[string][]string {
+			content, err := os.ReadFile(jobConfigPath)
+			Expect(err).ToNot(HaveOccurred())
+			entries, err := jobConfigEntries(content, image)
+			Expect(err).ToNot(HaveOccurred())
+			lines := strings.Split(string(content), "\n")
+			images := map[string][]string{}
+			for _, entry := range entries {
+				for _, line := range entry.lines {
+					images[entry.job.Name] = append(images[entry.job.Name], taggedReferences(lines[line], image)...)
+				}
+			}
+			return images
+		}
+
+		It("classifies the jobs", func() {
+			entries, err := jobConfigEntries([]byte(rolloutJobConfig), image)
+			Expect(err).ToNot(HaveOccurred())
+			var jobs []RolloutJob
+			for _, entry := range entries {
+				jobs = append(jobs, entry.job)
+			}
+			Expect(jobs).To(ConsistOf(
+				RolloutJob{Name: "periodic-bootstrap", Type: prowv1.PeriodicJob, Tier: JobTierCanary},
+				RolloutJob{Name: "pull-optional", Type: prowv1.PresubmitJob, Tier: JobTierCanary},
+				RolloutJob{Name: "pull-required", Type: prowv1.PresubmitJob, Tier: JobTierRequired},
+				RolloutJob{Name: "post-bootstrap", Type: prowv1.PostsubmitJob, Tier: JobTierCanary},
+			))
+		})
+
+		// startSoaking bumps the canary jobs to the tag
+		startSoaking := func(tag string) {
+			Expect(RolloutJobImages(repoRoot, map[string]string{image: tag}, policy, recordedJobRuns{}, nil)).To(Succeed())
+		}
+
+		It("bumps only the canary jobs if no image is soaking", func() {
+			changelog := NewChangelog(repoRoot)
+
+			Expect(RolloutJobImages(repoRoot, map[string]string{image: newTag}, policy, recordedJobRuns{}, changelog)).To(Succeed())
+
+			Expect(jobImages()).To(Equal(map[string][]string{
+				"periodic-bootstrap": {newTag},
+				"pull-optional":      {newTag},
+				"post-bootstrap":     {newTag},
+				"pull-required":      {oldTag, oldTag},
+			}))
+			Expect(changelog.Changes()).To(HaveLen(1))
+			Expect(changelog.Changes()[0].Jobs).To(Equal([]string{"periodic-bootstrap", "post-bootstrap", "pull-optional"}))
+		})
+
+		It("holds the required jobs while the image is soaking", func() {
+			startSoaking(newTag)
+			source := recordedJobRuns{
+				"periodic-bootstrap": runs(10, 10, now.Add(-time.Hour), image+":"+newTag),
+			}
+
+			Expect(RolloutJobImages(repoRoot, map[string]string{image: newTag}, policy, source, nil)).To(Succeed())
+
+			Expect(jobImages()["pull-required"]).To(Equal([]string{oldTag, oldTag}))
+		})
+
+		It("bumps the required jobs after the canary jobs soaked successfully", func() {
+			startSoaking(newTag)
+			source := recordedJobRuns{
+				"periodic-bootstrap": runs(2, 2, now.Add(-72*time.Hour), image+":"+newTag),
+				"pull-optional":      runs(3, 3, now.Add(-50*time.Hour), image+":"+newTag),
+			}
+
+			Expect(RolloutJobImages(repoRoot, map[string]string{image: newTag}, policy, source, nil)).To(Succeed())
+
+			Expect(jobImages()).To(Equal(map[string][]string{
+				"periodic-bootstrap": {newTag},
+				"pull-optional":      {newTag},
+				"post-bootstrap":     {newTag},
+				"pull-required":      {newTag, newTag},
+			}))
+		})
+
+		It("measures the soak period from the first canary run with the image", func() {
+			startSoaking(newTag)
+			source := recordedJobRuns{
+				"periodic-bootstrap": append(
+					runs(policy.MaxRunsPerJob, policy.MaxRunsPerJob, now.Add(-time.Hour), image+":"+newTag),
+					append(runs(2, 2, now.Add(-72*time.Hour), image+":"+newTag), runs(5, 5, now.Add(-96*time.Hour), image+":"+oldTag)...)...,
+				),
+			}
+
+			Expect(RolloutJobImages(repoRoot, map[string]string{image: newTag}, policy, source, nil)).To(Succeed())
+
+			Expect(jobImages()["pull-required"]).To(Equal([]string{newTag, newTag}))
+		})
+
+		It("does not bump the canary jobs to a newer tag while an image is soaking", func() {
+			startSoaking(newTag)
+			source := recordedJobRuns{
+				"periodic-bootstrap": runs(10, 10, now.Add(-time.Hour), image+":"+newTag),
+			}
+
+			Expect(RolloutJobImages(repoRoot, map[string]string{image: latestTag}, policy, source, nil)).To(Succeed())
+
+			Expect(jobImages()).To(Equal(map[string][]string{
+				"periodic-bootstrap": {newTag},
+				"pull-optional":      {newTag},
+				"post-bootstrap":     {newTag},
+				"pull-required":      {oldTag, oldTag},
+			}))
+		})
+
+		It("promotes the soaked image and starts soaking the newer tag", func() {
+			startSoaking(newTag)
+			source := recordedJobRuns{
+				"periodic-bootstrap": runs(10, 10, now.Add(-72*time.Hour), image+":"+newTag),
+			}
+
+			Expect(RolloutJobImages(repoRoot, map[string]string{image: latestTag}, policy, source, nil)).To(Succeed())
+
+			Expect(jobImages()).To(Equal(map[string][]string{
+				"periodic-bootstrap": {latestTag},
+				"pull-optional":      {latestTag},
+				"post-bootstrap":     {latestTag},
+				"pull-required":      {newTag, newTag},
+			}))
+		})
+
+		It("holds the required jobs if the canary jobs fail", func() {
+			startSoaking(newTag)
+			source := recordedJobRuns{
+				"periodic-bootstrap": runs(10, 2, now.Add(-72*time.Hour), image+":"+newTag),
+			}
+
+			Expect(RolloutJobImages(repoRoot, map[string]string{image: newTag}, policy, source, nil)).To(Succeed())
+
+			Expect(jobImages()["pull-required"]).To(Equal([]string{oldTag, oldTag}))
+		})
+
+		It("replaces a rejected image on the canary jobs with a newer tag", func() {
+			startSoaking(newTag)
+			source := recordedJobRuns{
+				"periodic-bootstrap": runs(10, 2, now.Add(-72*time.Hour), image+":"+newTag),
+			}
+
+			Expect(RolloutJobImages(repoRoot, map[string]string{image: latestTag}, policy, source, nil)).To(Succeed())
+
+			Expect(jobImages()).To(Equal(map[string][]string{
+				"periodic-bootstrap": {latestTag},
+				"pull-optional":      {latestTag},
+				"post-bootstrap":     {latestTag},
+				"pull-required":      {oldTag, oldTag},
+			}))
+		})
+	})
+})
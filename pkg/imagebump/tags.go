@@ -0,0 +1,148 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package imagebump
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// kubevirtCITagPattern matches tags selected by hack/update-jobs-with-latest-image.sh
+var kubevirtCITagPattern = regexp.MustCompile(`^v?[0-9]+-[a-z0-9]{7,9}$`)
+var ErrNoMatchingTag = errors.New("no tag matching kubevirtci date-hash pattern")
+
+// LatestTag returns the last tag in registry order that matches kubevirtCITagPattern
+// after sorting by the date part first - if that doesn't work, i.e. if the date part is the same for
+// two tags, then it resorts to comparing the creation dates of the images
+func LatestTag(imageRef string) (string, error) {
+	tags, err := registryClient.ListTags(imageRef)
+	if err != nil {
+		return "", err
+	}
+	var allMatching []string
+	for _, t := range tags {
+		if kubevirtCITagPattern.MatchString(t) {
+			allMatching = append(allMatching, t)
+		}
+	}
+	if len(allMatching) == 0 {
+		return "", fmt.Errorf("%w for %s (pattern %s)", ErrNoMatchingTag, imageRef, kubevirtCITagPattern.String())
+	}
+
+	// First pass, reverse sort, so newest elements should be at the start
+	slices.Reverse(allMatching)
+
+	// Now reduce to the subset of the latest candidates, i.e. remove all that don't have the date component of the last
+	// entry
+	dateComponent := strings.Split(allMatching[0], "-")[0]
+	var candidates []string
+	for _, c := range allMatching {
+		if !strings.HasPrefix(c, dateComponent) {
+			continue
+		}
+		candidates = append(candidates, c)
+	}
+
+	// Only one left, done
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+
+	// Now sort the candidates again, using the image config to look up the Created date
+	// This time the most recent tag will show up at the bottom
+	var sortErrs []error
+	sort.Slice(candidates, func(i, j int) bool {
+		iCreated, err := registryClient.Created(imageRef, candidates[i])
+		if err != nil {
+			sortErrs = append(sortErrs, err)
+			return false
+		}
+		jCreated, err := registryClient.Created(imageRef, candidates[j])
+		if err != nil {
+			sortErrs = append(sortErrs, err)
+			return false
+		}
+		return iCreated.Before(jCreated)
+	})
+	if len(sortErrs) > 0 {
+		return "", fmt.Errorf("errors when sorting tags: %w", errors.Join(sortErrs...))
+	}
+
+	return candidates[len(candidates)-1], nil
+}
+
+// maxCreatedTagCandidates is the number of tags LatestCreatedTag looks up the
+// creation date for
+const maxCreatedTagCandidates = 10
+
+// LatestCreatedTag returns the tag other than "latest" whose image was created
+// last, which is the tag quay.io lists first, matching
+// hack/_include_image_funcs.sh latest_image_tag.
+// Since looking up the creation date takes several requests per tag, only the
+// last tags in name order are considered, restricted to those matching
+// kubevirtCITagPattern if there are any. Tags whose creation date can't be
+// looked up, i.e. signatures or attestations, are skipped.
+func LatestCreatedTag(imageRef string) (string, error) {
+	tags, err := registryClient.ListTags(imageRef)
+	if err != nil {
+		return "", err
+	}
+	var candidates, matching []string
+	for _, tag := range tags {
+		if tag == "latest" {
+			continue
+		}
+		candidates = append(candidates, tag)
+		if kubevirtCITagPattern.MatchString(tag) {
+			matching = append(matching, tag)
+		}
+	}
+	if len(matching) > 0 {
+		candidates = matching
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(candidates)))
+	if len(candidates) > maxCreatedTagCandidates {
+		candidates = candidates[:maxCreatedTagCandidates]
+	}
+
+	var latestTag string
+	var latestCreated time.Time
+	for _, tag := range candidates {
+		created, err := registryClient.Created(imageRef, tag)
+		if err != nil {
+			log.WithError(err).Warnf("skipping tag %s of %s", tag, imageRef)
+			continue
+		}
+		if latestTag == "" || created.After(latestCreated) {
+			latestTag, latestCreated = tag, created
+		}
+	}
+	if latestTag == "" {
+		return "", fmt.Errorf("no tag other than latest with a creation date for %s", imageRef)
+	}
+	return latestTag, nil
+}
@@ -0,0 +1,142 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright The KubeVirt Authors.
+ *
+ */
+
+package imagebump
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("tags", func() {
+	Context("LatestTag", func() {
+		var registry *testRegistry
+		var originalRegistryClient RegistryClient
+		BeforeEach(func() {
+			registry = newTestRegistry()
+			originalRegistryClient = registryClient
+			registryClient = NewRegistryClient()
+		})
+		AfterEach(func() {
+			registryClient = originalRegistryClient
+			registry.server.Close()
+		})
+		DescribeTable("sorts",
+			func(repositoryName string, createdByTag map[string]time.Time, expectedTag string, expectsErr bool) {
+				for tag, created := range createdByTag {
+					registry.addImage(repositoryName, tag, created)
+				}
+				tag, err := LatestTag(registry.host() + "/" + repositoryName)
+				if expectsErr {
+					Expect(err).To(HaveOccurred())
+				} else {
+					Expect(tag, err).To(BeEquivalentTo(expectedTag))
+					Expect(err).ToNot(HaveOccurred())
+				}
+			},
+			Entry("none",
+				"kubevirtci/ginkgo-tests",
+				map[string]time.Time{
+					"latest": {},
+				},
+				"",
+				true,
+			),
+			Entry("different by day",
+				"kubevirtci/ginkgo-tests",
+				map[string]time.Time{
+					"v20260711-7a66da0": {},
+					"v20260716-a2b9efe": {},
+				},
+				"v20260716-a2b9efe",
+				false,
+			),
+			Entry("different by hour",
+				"kubevirtci/ginkgo-tests",
+				map[string]time.Time{
+					"v20260711-7a66da0": {},
+					"v20260716-a2b9efe": time.Date(2026, 07, 16, 10, 0, 0, 0, time.UTC),
+					"v20260716-ff8cd14": time.Date(2026, 07, 16, 1, 0, 0, 0, time.UTC),
+				},
+				"v20260716-a2b9efe",
+				false,
+			),
+			Entry("different by hour, latest sorted last",
+				"kubevirtci/ginkgo-tests",
+				map[string]time.Time{
+					"v20260716-a2b9efe": time.Date(2026, 07, 16, 1, 0, 0, 0, time.UTC),
+					"v20260716-ff8cd14": time.Date(2026, 07, 16, 10, 0, 0, 0, time.UTC),
+				},
+				"v20260716-ff8cd14",
+				false,
+			),
+		)
+	})
+
+	Context("LatestCreatedTag", func() {
+		var registry *testRegistry
+		var originalRegistryClient RegistryClient
+		BeforeEach(func() {
+			registry = newTestRegistry()
+			originalRegistryClient = registryClient
+			registryClient = NewRegistryClient()
+		})
+		AfterEach(func() {
+			registryClient = originalRegistryClient
+			registry.server.Close()
+		})
+		It("returns the tag created last", func() {
+			registry.addImage("kubevirtci/fedora", "latest", time.Date(2026, 07, 17, 0, 0, 0, 0, time.UTC))
+			registry.addImage("kubevirtci/fedora", "41", time.Date(2026, 07, 16, 0, 0, 0, 0, time.UTC))
+			registry.addImage("kubevirtci/fedora", "42", time.Date(2026, 07, 15, 0, 0, 0, 0, time.UTC))
+
+			Expect(LatestCreatedTag(registry.host() + "/kubevirtci/fedora")).To(Equal("41"))
+		})
+		It("only considers tags matching the kubevirtci pattern if there are any", func() {
+			registry.addImage("kubevirtci/golang", "debug", time.Date(2026, 07, 17, 0, 0, 0, 0, time.UTC))
+			registry.addImage("kubevirtci/golang", "v20260716-a2b9efe", time.Date(2026, 07, 16, 0, 0, 0, 0, time.UTC))
+			registry.addImage("kubevirtci/golang", "v20260715-ff8cd14", time.Date(2026, 07, 15, 0, 0, 0, 0, time.UTC))
+
+			Expect(LatestCreatedTag(registry.host() + "/kubevirtci/golang")).To(Equal("v20260716-a2b9efe"))
+		})
+		It("only looks up the creation date of the last tags in name order", func() {
+			for day := 1; day <= maxCreatedTagCandidates+2; day++ {
+				registry.addImage("kubevirtci/golang", fmt.Sprintf("v202607%02d-a2b9efe", day), time.Date(2026, 07, day, 0, 0, 0, 0, time.UTC))
+			}
+			registry.addImage("kubevirtci/golang", "v20260601-ff8cd14", time.Date(2026, 07, 31, 0, 0, 0, 0, time.UTC))
+
+			Expect(LatestCreatedTag(registry.host() + "/kubevirtci/golang")).To(Equal(fmt.Sprintf("v202607%02d-a2b9efe", maxCreatedTagCandidates+2)))
+		})
+		It("skips tags whose creation date can't be looked up", func() {
+			registry.addImage("kubevirtci/fedora", "41", time.Date(2026, 07, 16, 0, 0, 0, 0, time.UTC))
+			registry.tag("kubevirtci/fedora", "sha256-0123.sig", registry.store(map[string]any{"schemaVersion": 2, "mediaType": mediaTypeOCIManifest}))
+
+			Expect(LatestCreatedTag(registry.host() + "/kubevirtci/fedora")).To(Equal("41"))
+		})
+		It("fails if there is only the latest tag", func() {
+			registry.addImage("kubevirtci/fedora", "latest", time.Now())
+
+			_, err := LatestCreatedTag(registry.host() + "/kubevirtci/fedora")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,93 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package searchci
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// diskCache stores search results as files named after the hash of the
+// search URL. A nil diskCache caches nothing.
+type diskCache struct {
+	dir string
+	ttl time.Duration
+}
+
+func (d *diskCache) path(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return filepath.Join(d.dir, hex.EncodeToString(hash[:])+".json")
+}
+
+// get returns the cached content for key if it exists and has not expired.
+func (d *diskCache) get(key string) ([]byte, bool, error) {
+	if d == nil {
+		return nil, false, nil
+	}
+	path := d.path(key)
+	stat, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("could not stat cache file %q: %w", path, err)
+	}
+	if time.Since(stat.ModTime()) > d.ttl {
+		logger.Debugf("cache file %q expired", path)
+		return nil, false, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false, fmt.Errorf("could not read cache file %q: %w", path, err)
+	}
+	return content, true, nil
+}
+
+func (d *diskCache) put(key string, content []byte) error {
+	if d == nil {
+		return nil
+	}
+	if err := os.MkdirAll(d.dir, 0755); err != nil {
+		return fmt.Errorf("could not create cache dir %q: %w", d.dir, err)
+	}
+	path := d.path(key)
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("could not write cache file %q: %w", path, err)
+	}
+	return nil
+}
+
+// remove drops the cached content for key, e.g. if it turned out to be
+// unusable.
+func (d *diskCache) remove(key string) error {
+	if d == nil {
+		return nil
+	}
+	path := d.path(key)
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("could not remove cache file %q: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,344 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package searchci
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const searchPath = "/search"
+
+// SearchResponse is the response of the search.ci JSON search endpoint. It
+// maps the deck URL of each build that matched to the matches per search term.
+type SearchResponse map[string]map[string][]SearchMatch
+
+// SearchMatch is a file of a build whose content matched a search term.
+type SearchMatch struct {
+	Name         string    `json:"name,omitempty"`
+	LastModified time.Time `json:"lastModified,omitempty"`
+	FileType     string    `json:"filename,omitempty"`
+	Context      []string  `json:"context,omitempty"`
+	MoreLines    int       `json:"moreLines,omitempty"`
+}
+
+// build is a prow build as identified by its deck URL, which is either
+//
+//	<deck>/view/gs/<bucket>/pr-logs/pull/<org_repo>/<pr>/<job>/<build id>
+//
+// for presubmits or
+//
+//	<deck>/view/gs/<bucket>/logs/<job>/<build id>
+//
+// for periodics and postsubmits.
+type build struct {
+	url           string
+	job           string
+	jobHistoryURL string
+}
+
+func parseBuildURL(buildURL string) (build, error) {
+	u, err := url.Parse(buildURL)
+	if err != nil {
+		return build{}, fmt.Errorf("invalid build url %q: %w", buildURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return build{}, fmt.Errorf("invalid build url %q: unexpected scheme", buildURL)
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segments) < 6 || segments[0] != "view" || segments[1] != "gs" {
+		return build{}, fmt.Errorf("invalid build url %q: not a prow build", buildURL)
+	}
+	bucket := segments[2]
+	job, buildID := segments[len(segments)-2], segments[len(segments)-1]
+	if _, err := strconv.ParseUint(buildID, 10, 64); err != nil {
+		return build{}, fmt.Errorf("invalid build url %q: build id %q is not a number", buildURL, buildID)
+	}
+	var jobDir string
+	switch {
+	case segments[3] == "pr-logs" && len(segments) == 9 && segments[4] == "pull":
+		jobDir = "pr-logs/directory/" + job
+	case segments[3] == "logs" && len(segments) == 6:
+		jobDir = "logs/" + job
+	default:
+		return build{}, fmt.Errorf("invalid build url %q: not a prow build", buildURL)
+	}
+	return build{
+		url:           buildURL,
+		job:           job,
+		jobHistoryURL: fmt.Sprintf("%s://%s/job-history/gs/%s/%s", u.Scheme, u.Host, bucket, jobDir),
+	}, nil
+}
+
+// parseSearchResponse decodes the body of a search response, failing for
+// anything that is not an object keyed by prow build URLs.
+func parseSearchResponse(body []byte) (SearchResponse, error) {
+	var response SearchResponse
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if err := decoder.Decode(&response); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("unexpected content after search result")
+	}
+	if response == nil {
+		return nil, fmt.Errorf("search result is not an object")
+	}
+	for buildURL := range response {
+		if _, err := parseBuildURL(buildURL); err != nil {
+			return nil, err
+		}
+	}
+	return response, nil
+}
+
+// jobMatches are the builds of a job that matched a search.
+type jobMatches struct {
+	job           string
+	jobHistoryURL string
+	builds        []buildMatch
+}
+
+type buildMatch struct {
+	url          string
+	lastModified time.Time
+	context      []string
+}
+
+// byJob groups the matching builds of the search term by job, sorted by job
+// name and with the most recent builds first.
+func (r SearchResponse) byJob(search string) []jobMatches {
+	jobs := map[string]*jobMatches{}
+	for buildURL, termMatches := range r {
+		matches, exists := termMatches[search]
+		if !exists || len(matches) == 0 {
+			continue
+		}
+		// parseSearchResponse has validated all keys
+		b, _ := parseBuildURL(buildURL)
+		job, exists := jobs[b.job]
+		if !exists {
+			job = &jobMatches{job: b.job, jobHistoryURL: b.jobHistoryURL}
+			jobs[b.job] = job
+		}
+		match := buildMatch{url: buildURL}
+		for _, m := range matches {
+			if m.LastModified.After(match.lastModified) {
+				match.lastModified = m.LastModified
+			}
+			match.context = append(match.context, m.Context...)
+		}
+		job.builds = append(job.builds, match)
+	}
+	var result []jobMatches
+	for _, job := range jobs {
+		sort.Slice(job.builds, func(i, j int) bool {
+			if !job.builds[i].lastModified.Equal(job.builds[j].lastModified) {
+				return job.builds[i].lastModified.After(job.builds[j].lastModified)
+			}
+			return job.builds[i].url < job.builds[j].url
+		})
+		result = append(result, *job)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].job < result[j].job })
+	return result
+}
+
+// Match is a build that matched the search.
+type Match struct {
+	Job      string
+	BuildURL string
+	Age      time.Duration
+	Text     []string
+}
+
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	cache      *diskCache
+	now        func() time.Time
+}
+
+type ClientOpt func(*Client)
+
+func WithBaseURL(baseURL string) ClientOpt {
+	return func(c *Client) {
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+func WithHTTPClient(httpClient *http.Client) ClientOpt {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithCache stores the search results in cacheDir, where they are reused
+// until they are older than ttl.
+func WithCache(cacheDir string, ttl time.Duration) ClientOpt {
+	return func(c *Client) {
+		c.cache = &diskCache{dir: cacheDir, ttl: ttl}
+	}
+}
+
+func NewClient(opts ...ClientOpt) *Client {
+	c := &Client{
+		baseURL:    serviceURL,
+		httpClient: http.DefaultClient,
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Search returns the results of search.ci for the test name within the time range.
+func (c *Client) Search(testNameSubstring string, timeRange TimeRange) (SearchResponse, error) {
+	return c.search(newQuery(testNameSubstring, timeRange))
+}
+
+func (c *Client) search(query string) (SearchResponse, error) {
+	searchURL := fmt.Sprintf("%s%s?%s", c.baseURL, searchPath, query)
+
+	body, found, err := c.cache.get(searchURL)
+	if err != nil {
+		logger.WithError(err).Warnf("could not read cached search.ci result for %q", searchURL)
+	}
+	if found {
+		response, err := parseSearchResponse(body)
+		if err == nil {
+			return response, nil
+		}
+		logger.WithError(err).Warnf("discarding unparseable cached search.ci result for %q", searchURL)
+		if err := c.cache.remove(searchURL); err != nil {
+			logger.WithError(err).Warnf("could not remove cached search.ci result for %q", searchURL)
+		}
+	}
+
+	logger.Debugf("searching search.ci with URL %q", searchURL)
+	body, err = c.fetch(searchURL)
+	if err != nil {
+		return nil, err
+	}
+	response, err := parseSearchResponse(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse search.ci result from %s: %w", searchURL, err)
+	}
+	if err := c.cache.put(searchURL, body); err != nil {
+		logger.WithError(err).Warnf("could not cache search.ci result for %q", searchURL)
+	}
+	return response, nil
+}
+
+func (c *Client) fetch(searchURL string) ([]byte, error) {
+	resp, err := c.httpClient.Get(searchURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get search.ci results from %s: %w", searchURL, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get search.ci results from %s: status %d", searchURL, resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read search.ci result from %s: %w", searchURL, err)
+	}
+	return body, nil
+}
+
+// runs returns the number of builds of the job within the time range. Since
+// the search result only holds the builds that matched, the runs are counted
+// by searching the build logs of the job for any line.
+func (c *Client) runs(job string, timeRange TimeRange) (int, error) {
+	response, err := c.search(newRunsQuery(job, timeRange))
+	if err != nil {
+		return 0, err
+	}
+	runs := 0
+	for _, jobMatches := range response.byJob(anyLine) {
+		if jobMatches.job == job {
+			runs += len(jobMatches.builds)
+		}
+	}
+	return runs, nil
+}
+
+// Matches returns all builds that matched the search.
+func (c *Client) Matches(testNameSubstring string, timeRange TimeRange) ([]Match, error) {
+	response, err := c.Search(testNameSubstring, timeRange)
+	if err != nil {
+		return nil, err
+	}
+	var matches []Match
+	for _, job := range response.byJob(searchTerm(testNameSubstring)) {
+		for _, b := range job.builds {
+			matches = append(matches, Match{
+				Job:      job.job,
+				BuildURL: b.url,
+				Age:      c.now().Sub(b.lastModified),
+				Text:     b.context,
+			})
+		}
+	}
+	return matches, nil
+}
+
+// Impacts returns the impact of the test failures per job, which is the
+// percentage of the runs of the job that failed with a match.
+func (c *Client) Impacts(testNameSubstring string, timeRange TimeRange) ([]Impact, error) {
+	response, err := c.Search(testNameSubstring, timeRange)
+	if err != nil {
+		return nil, err
+	}
+	var impacts []Impact
+	for _, job := range response.byJob(searchTerm(testNameSubstring)) {
+		runs, err := c.runs(job.job, timeRange)
+		if err != nil {
+			return nil, err
+		}
+		// the build logs of the latest builds might not have been indexed yet
+		if runs < len(job.builds) {
+			runs = len(job.builds)
+		}
+		impact := Impact{
+			URL:          job.jobHistoryURL,
+			Percent:      math.Round(float64(len(job.builds)) * 100 / float64(runs)),
+			URLToDisplay: job.job,
+		}
+		for _, b := range job.builds {
+			impact.BuildURLs = append(impact.BuildURLs, JobBuildURL{
+				URL:      b.url,
+				Interval: c.now().Sub(b.lastModified),
+			})
+		}
+		impacts = append(impacts, impact)
+	}
+	return impacts, nil
+}
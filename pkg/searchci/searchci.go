@@ -21,11 +21,8 @@ package searchci
 
 import (
 	"fmt"
-	"io"
-	"net/http"
 	"net/url"
 	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
@@ -37,6 +34,9 @@ const (
 	FourteenDays = TimeRange("336h")
 
 	excludePeriodic = "periodic-.*"
+
+	// anyLine matches every build log, see Client.runs
+	anyLine = "."
 )
 
 type TimeRange string
@@ -54,88 +54,44 @@ type JobBuildURL struct {
 }
 
 var (
-	impactScrapeRegex = regexp.MustCompile(`(<tr><td.*<a target="_blank" href="(https://[a-z\.]+/job-history/kubevirt-prow/pr-logs/directory/[^"]+)">.*[0-9]+% of (failures|runs) match( = ([0-9\.]+)% impact)?</em></td></tr>|<tr class="row-match"><td[^\r\n]+href="(https://[a-z\.]+/view/[^"]+)">#[0-9]+</a>.*>([0-9]+) (minutes|hours|days) ago<.*</td></tr>)`)
-	logger            = log.WithField("module", "searchci")
-	serviceURL        = "https://search.ci.kubevirt.io"
+	logger     = log.WithField("module", "searchci")
+	serviceURL = "https://search.ci.kubevirt.io"
+
+	// DefaultClient is the client used by FetchImpacts.
+	DefaultClient = NewClient()
 )
 
-// ScrapeImpacts scrapes results that are relevant for quarantining from search.ci.kubevirt.io
-func ScrapeImpacts(testNameSubstring string, timeRange TimeRange) ([]Impact, error) {
-	scrapeResultURL := NewScrapeURL(testNameSubstring, timeRange)
-	logger.Debugf("scraping search.ci for test %q with URL %q", testNameSubstring, scrapeResultURL)
-	resp, err := http.Get(scrapeResultURL)
+// FetchImpacts fetches results that are relevant for quarantining from search.ci.kubevirt.io
+func FetchImpacts(testNameSubstring string, timeRange TimeRange) ([]Impact, error) {
+	impacts, err := DefaultClient.Impacts(testNameSubstring, timeRange)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get search.ci results from %s: %w", scrapeResultURL, err)
+		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read search.ci result from %s: %w", scrapeResultURL, err)
-	}
-	return FilterImpacts(ScrapeImpact(string(body)), timeRange), nil
+	return FilterImpacts(impacts, timeRange), nil
 }
 
+// NewScrapeURL returns the URL of the search.ci page that shows the results
+// for the test, i.e. for humans to follow.
 func NewScrapeURL(testNameSubstring string, timeRange TimeRange) string {
-	scrapeResultURL := fmt.Sprintf("%s/?search=%s&maxAge=%s&context=1&type=junit&name=&excludeName=%s&maxMatches=1&maxBytes=20971520&groupBy=job", serviceURL, escapeForQuery(testNameSubstring), timeRange, excludePeriodic)
-	return scrapeResultURL
+	return fmt.Sprintf("%s/?%s", serviceURL, newQuery(testNameSubstring, timeRange))
 }
 
-func escapeForQuery(testNameSubstring string) string {
-	result := strings.ReplaceAll(testNameSubstring, "[", `\[`)
-	result = url.QueryEscape(result)
-	return result
+func newQuery(testNameSubstring string, timeRange TimeRange) string {
+	return fmt.Sprintf("search=%s&maxAge=%s&context=1&type=junit&name=&excludeName=%s&maxMatches=1&maxBytes=20971520&groupBy=job", escapeForQuery(testNameSubstring), timeRange, excludePeriodic)
 }
 
-func ScrapeImpact(body string) []Impact {
-	var result []Impact
-	impactSubmatches := impactScrapeRegex.FindAllStringSubmatch(body, -1)
-	for _, submatch := range impactSubmatches {
-		jobHistoryURL := submatch[2]
-		viewJobBuildURL := submatch[6]
-		var err error
-		switch {
-		case strings.Contains(jobHistoryURL, "job-history"):
-			impactPercent := 0.0
-			action := submatch[3]
-			if action == "failures" {
-				impactPercentStr := submatch[5]
-				impactPercent, err = strconv.ParseFloat(impactPercentStr, 64)
-				if err != nil {
-					log.WithError(err).Fatalf("unparseable impact %q", impactPercentStr)
-				}
-			}
-			result = append(result, Impact{
-				URL:          jobHistoryURL,
-				Percent:      impactPercent,
-				URLToDisplay: jobHistoryURL[strings.LastIndex(jobHistoryURL, "/")+1:],
-			})
-		case strings.Contains(viewJobBuildURL, "view"):
-			if len(result) == 0 {
-				continue
-			}
-			timeAmountStr := submatch[7]
-			timeAmount, err := strconv.Atoi(timeAmountStr)
-			if err != nil {
-				log.Fatalf("unparseable amount %q", timeAmountStr)
-			}
-			unitWord := submatch[8]
-			var duration time.Duration
-			switch {
-			case unitWord == "minutes":
-				duration = time.Minute * time.Duration(timeAmount)
-			case unitWord == "hours":
-				duration = time.Hour * time.Duration(timeAmount)
-			case unitWord == "days":
-				duration = time.Hour * 24 * time.Duration(timeAmount)
-			}
-			jobBuild := JobBuildURL{
-				URL:      viewJobBuildURL,
-				Interval: duration,
-			}
-			result[len(result)-1].BuildURLs = append(result[len(result)-1].BuildURLs, jobBuild)
-		}
-	}
-	return result
+func newRunsQuery(job string, timeRange TimeRange) string {
+	return fmt.Sprintf("search=%s&maxAge=%s&context=0&type=build-log&name=%s&maxMatches=1&maxBytes=20971520", url.QueryEscape(anyLine), timeRange, url.QueryEscape("^"+regexp.QuoteMeta(job)+"$"))
+}
+
+// searchTerm returns the search term for the test name, which is also the
+// key of the matches in the search result.
+func searchTerm(testNameSubstring string) string {
+	return strings.ReplaceAll(testNameSubstring, "[", `\[`)
+}
+
+func escapeForQuery(testNameSubstring string) string {
+	return url.QueryEscape(searchTerm(testNameSubstring))
 }
 
 type FilterOpt func(i Impact) bool
@@ -20,195 +20,20 @@
 package searchci
 
 import (
-	"net/http"
-	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+
+	"kubevirt.io/project-infra/pkg/searchci/searchcitest"
 )
 
 var _ = Describe("searchci", func() {
 
 	const testName = `[test_id:3007] Should force restart a VM with terminationGracePeriodSeconds\u003e0`
 
-	DescribeTable("ScrapeImpact extracts",
-
-		func(text string, expected []Impact) {
-			Expect(ScrapeImpact(text)).To(BeEquivalentTo(expected))
-		},
-
-		Entry("scrape impact with empty",
-			"",
-			nil,
-		),
-		Entry("basic scrape impact, no build urls",
-			`<tr><td colspan="4"><a target="_blank" href="https://prow.ci.kubevirt.io/job-history/kubevirt-prow/pr-logs/directory/pull-kubevirt-e2e-k8s-1.30-sig-compute">pull-kubevirt-e2e-k8s-1.30-sig-compute</a> <a href="/?context=1&amp;excludeName=&amp;groupByJob=job&amp;maxAge=672h0m0s&amp;maxBytes=20971520&amp;maxMatches=5&amp;mode=text&amp;name=%5Epull-kubevirt-e2e-k8s-1%5C.30-sig-compute%24&amp;search=%5C%5Btest_id%3A3007%5D+Should+force+restart+a+VM+with+terminationGracePeriodSeconds%5Cu003e0&amp;searchType=junit&amp;wrapLines=false">(all)</a> - <em title="107 runs, 7 failures, 5 matching runs">107 runs, 7% failed, 71% of failures match = 5% impact</em></td></tr>
-`,
-			[]Impact{
-				{
-					URL:          "https://prow.ci.kubevirt.io/job-history/kubevirt-prow/pr-logs/directory/pull-kubevirt-e2e-k8s-1.30-sig-compute",
-					Percent:      5.0,
-					URLToDisplay: "pull-kubevirt-e2e-k8s-1.30-sig-compute",
-				},
-			},
-		),
-		Entry("scrape impact with build urls",
-			`<tr><td colspan="4"><a target="_blank" href="https://prow.ci.kubevirt.io/job-history/kubevirt-prow/pr-logs/directory/pull-kubevirt-e2e-k8s-1.30-sig-compute">pull-kubevirt-e2e-k8s-1.30-sig-compute</a> <a href="/?context=1&amp;excludeName=&amp;groupByJob=job&amp;maxAge=672h0m0s&amp;maxBytes=20971520&amp;maxMatches=5&amp;mode=text&amp;name=%5Epull-kubevirt-e2e-k8s-1%5C.30-sig-compute%24&amp;search=%5C%5Btest_id%3A3007%5D+Should+force+restart+a+VM+with+terminationGracePeriodSeconds%5Cu003e0&amp;searchType=junit&amp;wrapLines=false">(all)</a> - <em title="107 runs, 7 failures, 5 matching runs">107 runs, 7% failed, 71% of failures match = 5% impact</em></td></tr>
-<tr class="row-match"><td><a target="_blank" href="https://prow.ci.kubevirt.io/view/gs/kubevirt-prow/pr-logs/pull/kubevirt_kubevirt/14632/pull-kubevirt-e2e-k8s-1.30-sig-compute/1932345605980426240">#1932345605980426240</a></td><td>junit</td><td class="text-nowrap">36 hours ago</td><td class="col-12"></td></tr>
-<tr class="row-match"><td class="" colspan="4"><pre class="small"># [sig-compute] [rfe_id:1177][crit:medium] VirtualMachine [test_id:3007] Should force restart a VM with terminationGracePeriodSeconds&gt;0
-tests/compute/vm_lifecycle.go:51
-</pre></td></tr>
-<tr class="row-match"><td><a target="_blank" href="https://prow.ci.kubevirt.io/view/gs/kubevirt-prow/pr-logs/pull/kubevirt_kubevirt/14884/pull-kubevirt-e2e-k8s-1.30-sig-compute/1932327352512024576">#1932327352512024576</a></td><td>junit</td><td class="text-nowrap">37 hours ago</td><td class="col-12"></td></tr>
-<tr class="row-match"><td class="" colspan="4"><pre class="small"># [sig-compute] [rfe_id:1177][crit:medium] VirtualMachine [test_id:3007] Should force restart a VM with terminationGracePeriodSeconds&gt;0
-tests/compute/vm_lifecycle.go:51
-</pre></td></tr>
-<tr class="row-match"><td><a target="_blank" href="https://prow.ci.kubevirt.io/view/gs/kubevirt-prow/pr-logs/pull/kubevirt_kubevirt/14802/pull-kubevirt-e2e-k8s-1.30-sig-compute/1930397210671845376">#1930397210671845376</a></td><td>junit</td><td class="text-nowrap">6 days ago</td><td class="col-12"></td></tr>
-`,
-			[]Impact{
-				{
-					URL:          "https://prow.ci.kubevirt.io/job-history/kubevirt-prow/pr-logs/directory/pull-kubevirt-e2e-k8s-1.30-sig-compute",
-					Percent:      5.0,
-					URLToDisplay: "pull-kubevirt-e2e-k8s-1.30-sig-compute",
-					BuildURLs: []JobBuildURL{
-						{
-							URL:      "https://prow.ci.kubevirt.io/view/gs/kubevirt-prow/pr-logs/pull/kubevirt_kubevirt/14632/pull-kubevirt-e2e-k8s-1.30-sig-compute/1932345605980426240",
-							Interval: time.Hour * 36,
-						},
-						{
-							URL:      "https://prow.ci.kubevirt.io/view/gs/kubevirt-prow/pr-logs/pull/kubevirt_kubevirt/14884/pull-kubevirt-e2e-k8s-1.30-sig-compute/1932327352512024576",
-							Interval: time.Hour * 37,
-						},
-						{
-							URL:      "https://prow.ci.kubevirt.io/view/gs/kubevirt-prow/pr-logs/pull/kubevirt_kubevirt/14802/pull-kubevirt-e2e-k8s-1.30-sig-compute/1930397210671845376",
-							Interval: time.Hour * 24 * 6,
-						},
-					},
-				},
-			},
-		),
-		Entry("scrape with no failures",
-			`<tr><td colspan="4"><a target="_blank" href="https://prow.ci.kubevirt.io/job-history/kubevirt-prow/pr-logs/directory/pull-kubernetes-nmstate-e2e-operator-k8s">pull-kubernetes-nmstate-e2e-operator-k8s</a> <a href="/?context=1&amp;excludeName=&amp;groupByJob=job&amp;maxAge=672h0m0s&amp;maxBytes=20971520&amp;maxMatches=1&amp;mode=text&amp;name=%5Epull-kubernetes-nmstate-e2e-operator-k8s%24&amp;search=AfterSuite&amp;searchType=junit&amp;wrapLines=false">(all)</a> - <em title="3 runs, 0 failures, 3 matching runs">3 runs, 0% failed, 100% of runs match</em></td></tr>
-<tr class="row-match"><td><a target="_blank" href="https://prow.ci.kubevirt.io/view/gs/kubevirt-prow/pr-logs/pull/nmstate_kubernetes-nmstate/1354/pull-kubernetes-nmstate-e2e-operator-k8s/1947282574778830848">#1947282574778830848</a></td><td>junit</td><td class="text-nowrap">18 hours ago</td><td class="col-12"></td></tr>
-<tr class="row-match"><td class="" colspan="4"><pre class="small"># Operator E2E Test Suite.[AfterSuite]
-&gt; Enter [AfterSuite] TOP-LEVEL - /tmp/knmstate/kubernetes-nmstate/test/e2e/operator/main_test.go:76 @ 07/21/25 13:42:10.335
-
-... 1 lines not shown
-
-</pre></td></tr>
-<tr class="row-match"><td><a target="_blank" href="https://prow.ci.kubevirt.io/view/gs/kubevirt-prow/pr-logs/pull/nmstate_kubernetes-nmstate/1354/pull-kubernetes-nmstate-e2e-operator-k8s/1947235763452121088">#1947235763452121088</a></td><td>junit</td><td class="text-nowrap">21 hours ago</td><td class="col-12"></td></tr>`,
-			[]Impact{
-				{
-					URL:          "https://prow.ci.kubevirt.io/job-history/kubevirt-prow/pr-logs/directory/pull-kubernetes-nmstate-e2e-operator-k8s",
-					Percent:      0,
-					URLToDisplay: "pull-kubernetes-nmstate-e2e-operator-k8s",
-					BuildURLs: []JobBuildURL{
-						{
-							URL:      "https://prow.ci.kubevirt.io/view/gs/kubevirt-prow/pr-logs/pull/nmstate_kubernetes-nmstate/1354/pull-kubernetes-nmstate-e2e-operator-k8s/1947282574778830848",
-							Interval: time.Hour * 18,
-						},
-						{
-							URL:      "https://prow.ci.kubevirt.io/view/gs/kubevirt-prow/pr-logs/pull/nmstate_kubernetes-nmstate/1354/pull-kubernetes-nmstate-e2e-operator-k8s/1947235763452121088",
-							Interval: time.Hour * 21,
-						},
-					},
-				},
-			},
-		),
-		Entry("scrape with minutes ago",
-			`<tr><td colspan="4"><a target="_blank" href="https://prow.ci.kubevirt.io/job-history/kubevirt-prow/pr-logs/directory/pull-kubevirt-e2e-k8s-1.32-sig-compute-migrations-1.6">pull-kubevirt-e2e-k8s-1.32-sig-compute-migrations-1.6</a> <a href="/?context=1&amp;excludeName=&amp;groupByJob=job&amp;maxAge=672h0m0s&amp;maxBytes=20971520&amp;maxMatches=1&amp;mode=text&amp;name=%5Epull-kubevirt-e2e-k8s-1%5C.32-sig-compute-migrations-1%5C.6%24&amp;search=%5C%5Brfe_id%3A393%5D%5C%5Bcrit%3Ahigh%5D%5C%5Bvendor%3Acnv-qe%40redhat.com%5D%5C%5Blevel%3Asystem%5D%5C%5Bsig-compute%5D+Live+Migration+across+namespaces+container+disk+should+live+migrate+a+container+disk+vm%2C+with+an+additional+PVC+mounted%2C+should+stay+mounted+after+migration&amp;searchType=junit&amp;wrapLines=false">(all)</a> - <em title="14 runs, 7 failures, 4 matching runs">14 runs, 50% failed, 57% of failures match = 29% impact</em></td></tr>
-<tr class="row-match"><td><a target="_blank" href="https://prow.ci.kubevirt.io/view/gs/kubevirt-prow/pr-logs/pull/kubevirt_kubevirt/15256/pull-kubevirt-e2e-k8s-1.32-sig-compute-migrations-1.6/1947961013684932608">#1947961013684932608</a></td><td>junit</td><td class="text-nowrap">34 minutes ago</td><td class="col-12"></td></tr>
-<tr class="row-match"><td class="" colspan="4"><pre class="small"># [rfe_id:393][crit:high][vendor:cnv-qe@redhat.com][level:system][sig-compute] Live Migration across namespaces container disk should live migrate a container disk vm, with an additional PVC mounted, should stay mounted after migration
-tests/migration/namespace.go:236
-</pre></td></tr>
-<tr class="row-match"><td><a target="_blank" href="https://prow.ci.kubevirt.io/view/gs/kubevirt-prow/pr-logs/pull/kubevirt_kubevirt/15246/pull-kubevirt-e2e-k8s-1.32-sig-compute-migrations-1.6/1947958310749605888">#1947958310749605888</a></td><td>junit</td><td class="text-nowrap">44 minutes ago</td><td class="col-12"></td></tr>
-<tr class="row-match"><td class="" colspan="4"><pre class="small"># [rfe_id:393][crit:high][vendor:cnv-qe@redhat.com][level:system][sig-compute] Live Migration across namespaces container disk should live migrate a container disk vm, with an additional PVC mounted, should stay mounted after migration
-tests/migration/namespace.go:236
-</pre></td></tr>
-<tr class="row-match"><td><a target="_blank" href="https://prow.ci.kubevirt.io/view/gs/kubevirt-prow/pr-logs/pull/kubevirt_kubevirt/15256/pull-kubevirt-e2e-k8s-1.32-sig-compute-migrations-1.6/1947915060424740864">#1947915060424740864</a></td><td>junit</td><td class="text-nowrap">4 hours ago</td><td class="col-12"></td></tr>
-<tr class="row-match"><td class="" colspan="4"><pre class="small"># [rfe_id:393][crit:high][vendor:cnv-qe@redhat.com][level:system][sig-compute] Live Migration across namespaces container disk should live migrate a container disk vm, with an additional PVC mounted, should stay mounted after migration
-tests/migration/namespace.go:236
-</pre></td></tr>
-<tr class="row-match"><td><a target="_blank" href="https://prow.ci.kubevirt.io/view/gs/kubevirt-prow/pr-logs/pull/kubevirt_kubevirt/15256/pull-kubevirt-e2e-k8s-1.32-sig-compute-migrations-1.6/1947746664915668992">#1947746664915668992</a></td><td>junit</td><td class="text-nowrap">15 hours ago</td><td class="col-12"></td></tr>
-<tr class="row-match"><td class="" colspan="4"><pre class="small"># [rfe_id:393][crit:high][vendor:cnv-qe@redhat.com][level:system][sig-compute] Live Migration across namespaces container disk should live migrate a container disk vm, with an additional PVC mounted, should stay mounted after migration
-tests/migration/namespace.go:236
-</pre></td></tr>`,
-			[]Impact{
-				{
-					URL:          "https://prow.ci.kubevirt.io/job-history/kubevirt-prow/pr-logs/directory/pull-kubevirt-e2e-k8s-1.32-sig-compute-migrations-1.6",
-					Percent:      29,
-					URLToDisplay: "pull-kubevirt-e2e-k8s-1.32-sig-compute-migrations-1.6",
-					BuildURLs: []JobBuildURL{
-						{
-							URL:      "https://prow.ci.kubevirt.io/view/gs/kubevirt-prow/pr-logs/pull/kubevirt_kubevirt/15256/pull-kubevirt-e2e-k8s-1.32-sig-compute-migrations-1.6/1947961013684932608",
-							Interval: time.Minute * 34,
-						},
-						{
-							URL:      "https://prow.ci.kubevirt.io/view/gs/kubevirt-prow/pr-logs/pull/kubevirt_kubevirt/15246/pull-kubevirt-e2e-k8s-1.32-sig-compute-migrations-1.6/1947958310749605888",
-							Interval: time.Minute * 44,
-						},
-						{
-							URL:      "https://prow.ci.kubevirt.io/view/gs/kubevirt-prow/pr-logs/pull/kubevirt_kubevirt/15256/pull-kubevirt-e2e-k8s-1.32-sig-compute-migrations-1.6/1947915060424740864",
-							Interval: time.Hour * 4,
-						},
-						{
-							URL:      "https://prow.ci.kubevirt.io/view/gs/kubevirt-prow/pr-logs/pull/kubevirt_kubevirt/15256/pull-kubevirt-e2e-k8s-1.32-sig-compute-migrations-1.6/1947746664915668992",
-							Interval: time.Hour * 15,
-						},
-					},
-				},
-			},
-		),
-		Entry("range index error",
-			`
-<!DOCTYPE html>
-<html>
-<head>
-<meta charset="UTF-8"><title>Search OpenShift CI</title>
-<link rel="stylesheet" href="/static/bootstrap-4.4.1.min.css" integrity="sha384-Vkoo8x4CGsO3+Hhxv8T/Q5PaXtkKtu6ug5TOeNV6gBiFeWPGFN9MuhOf23Q9Ifjh" crossorigin="anonymous">
-<link rel="stylesheet" href="/static/uPlot.min.css">
-<meta name="viewport" content="width=device-width, initial-scale=1, shrink-to-fit=no">
-<style>
-#results.nowrap PRE { white-space: pre; }
-#results PRE { width: calc(95vw - 2.5em); white-space: pre-wrap; padding-bottom: 1em; }
-.row-match TD { border-top: 0; }
-.table TD { padding-bottom: 0.25rem; }
-#results .table-job-compact TD > PRE { margin-bottom: 0; padding-bottom: 0.25rem; }
-</style>
-</head>
-<body>
-<div id="results" class="container-fluid nowrap">
-
-<form class="form mt-4 mb-4" method="GET">
-	<div class="input-group input-group-lg mb-2">
-		<input title="A regular expression over the contents of test logs and junit output - uses ripgrep regular expressions" autocomplete="off" autofocus name="search" class="form-control col-auto" value="\[rfe_id:393]\[crit:high]\[vendor:cnv-qe@redhat.com]\[level:system]\[sig-compute] VM Live Migration triggered by evacuation during evacuation when evacuating fails after a successful migration backoff should be cleared\u0026maxAge=336h\u0026context=1\u0026type=junit\u0026name=\u0026excludeName=periodic-.*\u0026maxMatches=1\u0026maxBytes=20971520\u0026groupBy=job" placeholder="Search OpenShift CI failures by entering a regex search ...">
-		<select title="How far back to search for jobs" name="maxAge" class="form-control custom-select col-1" onchange="this.form.submit();"><option value="6h" >6h</option><option value="12h" >12h</option><option value="24h" >1d</option><option value="48h" selected>2d</option><option value="168h" >7d</option><option value="336h" >14d</option></select>
-		<select title="Number of lines before and after the match to show" name="context" class="form-control custom-select col-1" onchange="this.form.submit();"><option value="-1" selected>Links</option><option value="0" >No context</option><option value="1" selected>1 lines</option><option value="2" >2 lines</option><option value="3" >3 lines</option><option value="5" >5 lines</option><option value="7" >7 lines</option><option value="10" >10 lines</option><option value="15" >15 lines</option></select>
-		<select title="Type of results to return" name="type" class="form-control custom-select col-1" onchange="this.form.submit();"><option value="bug+issue+junit" selected>bug+issue+junit</option><option value="bug+junit" >bug+junit</option><option value="bug+issue" >bug+issue</option><option value="issue" >issue</option><option value="bug" >bug</option><option value="junit" >junit</option><option value="build-log" >build-log</option><option value="all" >all</option></select>
-		<div class="input-group-append"><input class="btn btn-outline-primary" type="submit" value="Search"></div>
-	</div>
-	<div class="input-group input-group-sm mb-3">
-		<div class="input-group-prepend"><span class="input-group-text" for="name">Job:</span></div>
-		<input title="A regular expression that matches the name of a job or the title of a bug" class="form-control col-auto" name="name" value="" placeholder="Focus job or bug names by regex ...">
-		<input title="A regular expression that matches the name of a job or the title of a bug" class="form-control col-auto" name="excludeName" value="" placeholder="Skip job or bug names by regex ...">
-		<input title="The number of matches per job / file to show" autocomplete="off" class="form-control col-1" name="maxMatches" value="5" placeholder="Max matches per job or bug">
-		<input title="The maximum number of bytes for the response" autocomplete="off" class="form-control col-1" name="maxBytes" value="20971520" placeholder="Max bytes to return">
-		<select title="Group results by job (with stats) or no grouping" name="groupBy" class="form-control custom-select col-1" onchange="this.form.submit();">[<option value="job" selected>job</option> <option value="none" >none</option>]</select>
-		<div class="input-group-append"><span class="input-group-text">
-			<input id="wrap" type="checkbox" name="wrap"  onchange="document.getElementById('results').classList.toggle('nowrap')">
-			<label for="wrap" style="margin-bottom: 0; margin-left: 0.4em;">Wrap lines</label>
-		</span></div>
-	</div>
-</form>
-<div style="margin-top: 3rem; position: relative" class="pl-3"><p style="position:absolute; top: -2rem;" class="small"><em title="5924 runs, 659 failing runs, 0 matched runs, 517 jobs, 0 matched jobs">Found in 0.00% of runs (0.00% of failures) across 5924 total runs and 517 jobs (11.12% failed) in 41ms</em> - <a href="/">clear search</a> | <a href="/chart?search=%5C%5Brfe_id%3A393%5D%5C%5Bcrit%3Ahigh%5D%5C%5Bvendor%3Acnv-qe%40redhat.com%5D%5C%5Blevel%3Asystem%5D%5C%5Bsig-compute%5D+VM+Live+Migration+triggered+by+evacuation+during+evacuation+when+evacuating+fails+after+a+successful+migration+backoff+should+be+cleared\u0026maxAge=336h\u0026context=1\u0026type=junit\u0026name=\u0026excludeName=periodic-.*\u0026maxMatches=1\u0026maxBytes=20971520\u0026groupBy=job">chart view</a> - source code located <a target="_blank" href="https://github.com/openshift/ci-search">on github</a></p><p style="padding-top: 1em;"><em>No results found.</em></p><p><em>Search uses <a target="_blank" href="https://docs.rs/regex/0.2.5/regex/#syntax">ripgrep regular-expression patterns</a> to find results. Try simplifying your search or using case-insensitive options.</em></p></div>
-</div>
-</body>
-</html>
-`,
-			nil,
-		),
-	)
 	Context("NewScrapeURL", func() {
 
 		It("escapes the test name correctly", func() {
@@ -337,26 +162,181 @@ tests/migration/namespace.go:236
 		),
 	)
 
-	Context("ScrapeImpacts", func() {
-		var body []byte
-		var server *httptest.Server
+	Context("Client", func() {
+		const (
+			recordedTestName = "[test_id:3007] Should force restart a VM with terminationGracePeriodSeconds>0"
+			jobName          = "pull-kubevirt-e2e-k8s-1.30-sig-compute"
+			otherJobName     = "pull-kubevirt-e2e-k8s-1.31-sig-compute"
+		)
+		var (
+			fakeServer *searchcitest.FakeServer
+			now        = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+		)
+		recorded := func(name string) []byte {
+			content, err := os.ReadFile(filepath.Join("testdata", name))
+			Expect(err).ToNot(HaveOccurred())
+			return content
+		}
 		BeforeEach(func() {
-			var err error
-			body, err = os.ReadFile("testdata/searchci.html")
+			fakeServer = searchcitest.NewFakeServer()
+			fakeServer.AddTestResponse(recordedTestName, recorded("search-junit.json"))
+			fakeServer.AddRunsResponse(jobName, recorded("search-build-log-"+jobName+".json"))
+			fakeServer.AddRunsResponse(otherJobName, recorded("search-build-log-"+otherJobName+".json"))
+		})
+		AfterEach(func() {
+			fakeServer.Close()
+		})
+
+		newClient := func(opts ...ClientOpt) *Client {
+			client := NewClient(append([]ClientOpt{WithBaseURL(fakeServer.URL)}, opts...)...)
+			client.now = func() time.Time { return now }
+			return client
+		}
+
+		It("converts the matching builds into impacts per job", func() {
+			impacts, err := newClient().Impacts(recordedTestName, FourteenDays)
 			Expect(err).ToNot(HaveOccurred())
-			server = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
-				_, _ = writer.Write(body)
-				writer.WriteHeader(http.StatusOK)
+			Expect(impacts).To(BeEquivalentTo([]Impact{
+				{
+					URL:          "https://prow.ci.kubevirt.io/job-history/gs/kubevirt-prow/pr-logs/directory/" + jobName,
+					Percent:      10.0,
+					URLToDisplay: jobName,
+					BuildURLs: []JobBuildURL{
+						{
+							URL:      "https://prow.ci.kubevirt.io/view/gs/kubevirt-prow/pr-logs/pull/kubevirt_kubevirt/14632/" + jobName + "/1979345605980426240",
+							Interval: 36 * time.Hour,
+						},
+						{
+							URL:      "https://prow.ci.kubevirt.io/view/gs/kubevirt-prow/pr-logs/pull/kubevirt_kubevirt/14802/" + jobName + "/1977397210671845376",
+							Interval: 6 * 24 * time.Hour,
+						},
+					},
+				},
+				{
+					URL:          "https://prow.ci.kubevirt.io/job-history/gs/kubevirt-prow/pr-logs/directory/" + otherJobName,
+					Percent:      4.0,
+					URLToDisplay: otherJobName,
+					BuildURLs: []JobBuildURL{
+						{
+							URL:      "https://prow.ci.kubevirt.io/view/gs/kubevirt-prow/pr-logs/pull/kubevirt_kubevirt/14799/" + otherJobName + "/1978412283713640448",
+							Interval: 2 * 24 * time.Hour,
+						},
+					},
+				},
 			}))
-			serviceURL = server.URL
 		})
-		AfterEach(func() {
-			server.Close()
+
+		It("returns the matches", func() {
+			matches, err := newClient().Matches(recordedTestName, FourteenDays)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(matches).To(HaveLen(3))
+			Expect(matches[0].Job).To(Equal(jobName))
+			Expect(matches[0].Age).To(Equal(36 * time.Hour))
+			Expect(matches[0].Text).To(ContainElement("tests/compute/vm_lifecycle.go:51"))
 		})
-		It("scrapes", func() {
-			impacts, err := ScrapeImpacts(testName, FourteenDays)
+
+		It("returns no impacts for unknown tests", func() {
+			impacts, err := newClient().Impacts("unknown test", FourteenDays)
 			Expect(err).ToNot(HaveOccurred())
-			Expect(impacts).ToNot(BeNil())
+			Expect(impacts).To(BeNil())
+		})
+
+		It("fails on an unexpected status", func() {
+			_, err := NewClient(WithBaseURL(fakeServer.URL+"/unknown")).Search(recordedTestName, FourteenDays)
+			Expect(err).To(HaveOccurred())
+		})
+
+		DescribeTable("fails on an unexpected result",
+			func(body string) {
+				fakeServer.AddTestResponse(recordedTestName, []byte(body))
+				_, err := newClient().Search(recordedTestName, FourteenDays)
+				Expect(err).To(HaveOccurred())
+			},
+			Entry("not an object", `[]`),
+			Entry("null", `null`),
+			Entry("jobs instead of builds", `{"jobs": [{"name": "`+jobName+`"}]}`),
+			Entry("matches not grouped by search term", `{"https://prow.ci.kubevirt.io/view/gs/kubevirt-prow/logs/`+jobName+`/1": [{"context": []}]}`),
+			Entry("build url without build id", `{"https://prow.ci.kubevirt.io/view/gs/kubevirt-prow/logs/`+jobName+`": {}}`),
+			Entry("build url that is not a prow build", `{"https://example.com/`+jobName+`/1": {}}`),
+			Entry("trailing content", `{} {}`),
+		)
+
+		When("caching", func() {
+			var cacheDir string
+			BeforeEach(func() {
+				cacheDir = GinkgoT().TempDir()
+			})
+
+			It("serves results from the cache", func() {
+				client := newClient(WithCache(cacheDir, time.Hour))
+				impacts, err := client.Impacts(recordedTestName, FourteenDays)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(impacts).To(HaveLen(2))
+				Expect(fakeServer.Requests()).To(Equal(3))
+
+				impacts, err = client.Impacts(recordedTestName, FourteenDays)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(impacts).To(HaveLen(2))
+				Expect(fakeServer.Requests()).To(Equal(3))
+			})
+
+			It("fetches results again after the ttl expired", func() {
+				client := newClient(WithCache(cacheDir, time.Hour))
+				_, err := client.Search(recordedTestName, FourteenDays)
+				Expect(err).ToNot(HaveOccurred())
+				entries, err := os.ReadDir(cacheDir)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(entries).To(HaveLen(1))
+				expired := time.Now().Add(-2 * time.Hour)
+				Expect(os.Chtimes(filepath.Join(cacheDir, entries[0].Name()), expired, expired)).To(Succeed())
+
+				_, err = client.Search(recordedTestName, FourteenDays)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fakeServer.Requests()).To(Equal(2))
+			})
+
+			It("fetches results again if the cached result is unparseable", func() {
+				client := newClient(WithCache(cacheDir, time.Hour))
+				_, err := client.Search(recordedTestName, FourteenDays)
+				Expect(err).ToNot(HaveOccurred())
+				entries, err := os.ReadDir(cacheDir)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(entries).To(HaveLen(1))
+				cacheFile := filepath.Join(cacheDir, entries[0].Name())
+				Expect(os.WriteFile(cacheFile, []byte(`{"truncated`), 0644)).To(Succeed())
+
+				response, err := client.Search(recordedTestName, FourteenDays)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(response).ToNot(BeEmpty())
+				Expect(fakeServer.Requests()).To(Equal(2))
+				Expect(os.ReadFile(cacheFile)).To(Equal(recorded("search-junit.json")))
+			})
+
+			It("does not cache unexpected results", func() {
+				fakeServer.AddTestResponse(recordedTestName, []byte(`[]`))
+				_, err := newClient(WithCache(cacheDir, time.Hour)).Search(recordedTestName, FourteenDays)
+				Expect(err).To(HaveOccurred())
+				entries, err := os.ReadDir(cacheDir)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(entries).To(BeEmpty())
+			})
+		})
+
+		Context("FetchImpacts", func() {
+			var originalDefaultClient *Client
+			BeforeEach(func() {
+				originalDefaultClient = DefaultClient
+				DefaultClient = newClient()
+			})
+			AfterEach(func() {
+				DefaultClient = originalDefaultClient
+			})
+			It("keeps only relevant impacts", func() {
+				impacts, err := FetchImpacts(recordedTestName, FourteenDays)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(impacts).To(HaveLen(1))
+				Expect(impacts[0].URLToDisplay).To(Equal(jobName))
+			})
 		})
 	})
 })
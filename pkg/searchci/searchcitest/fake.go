@@ -0,0 +1,98 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+// Package searchcitest provides a fake search.ci server for tests.
+package searchcitest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// searchPath is the path of the search.ci JSON search endpoint.
+const searchPath = "/search"
+
+// FakeServer is a search.ci server for tests that serves recorded search
+// results, i.e. the bodies of the search.ci JSON search endpoint, per search
+// type, term and job name. Any other search yields an empty result.
+type FakeServer struct {
+	*httptest.Server
+
+	lock      sync.Mutex
+	responses map[fakeSearch][]byte
+	requests  int
+}
+
+type fakeSearch struct {
+	searchType string
+	search     string
+	name       string
+}
+
+func NewFakeServer() *FakeServer {
+	f := &FakeServer{responses: map[fakeSearch][]byte{}}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.handle))
+	return f
+}
+
+// AddTestResponse registers the recorded response to be returned when
+// searching the junit results for the test name, which the client escapes
+// for search.ci's regular expression search.
+func (f *FakeServer) AddTestResponse(testNameSubstring string, recordedResponse []byte) {
+	f.addResponse(fakeSearch{searchType: "junit", search: strings.ReplaceAll(testNameSubstring, "[", `\[`)}, recordedResponse)
+}
+
+// AddRunsResponse registers the recorded response to be returned when
+// searching the build logs of the job for the number of its runs.
+func (f *FakeServer) AddRunsResponse(job string, recordedResponse []byte) {
+	f.addResponse(fakeSearch{searchType: "build-log", search: ".", name: "^" + regexp.QuoteMeta(job) + "$"}, recordedResponse)
+}
+
+func (f *FakeServer) addResponse(search fakeSearch, recordedResponse []byte) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.responses[search] = recordedResponse
+}
+
+// Requests returns the number of search requests served.
+func (f *FakeServer) Requests() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.requests
+}
+
+func (f *FakeServer) handle(writer http.ResponseWriter, request *http.Request) {
+	if request.URL.Path != searchPath {
+		http.NotFound(writer, request)
+		return
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.requests++
+	query := request.URL.Query()
+	response, exists := f.responses[fakeSearch{searchType: query.Get("type"), search: query.Get("search"), name: query.Get("name")}]
+	if !exists {
+		response = []byte("{}")
+	}
+	writer.Header().Set("Content-Type", "application/json")
+	_, _ = writer.Write(response)
+}
@@ -0,0 +1,229 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package test_report
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/onsi/ginkgo/v2/types"
+	"kubevirt.io/project-infra/pkg/ginkgo"
+)
+
+const (
+	// NeverRunReasonSelected is the grouping key for specs that the label filter of at least one lane selects, but
+	// that still have not been run, i.e. because they are skipped at runtime
+	NeverRunReasonSelected = "selected by a lane label filter but not run"
+
+	// NeverRunReasonNoLabelFilter is the grouping key for specs where none of the lanes has a label filter configured
+	NeverRunReasonNoLabelFilter = "no label filter configured for any lane"
+
+	unknownSIG = "unknown"
+)
+
+var (
+	sigLabelRegex = regexp.MustCompile(`^sig-[a-z0-9-]+$`)
+	sigTextRegex  = regexp.MustCompile(`\[(sig-[a-z0-9-]+)\]`)
+)
+
+// JobNamePatternToLabelFilter describes the Ginkgo label filter that the lanes matching the pattern run the tests with
+type JobNamePatternToLabelFilter struct {
+
+	// JobNamePattern describes what jobs run the tests with the label filter
+	JobNamePattern string `yaml:"jobNamePattern"`
+
+	// LabelFilter is the Ginkgo label filter expression, i.e. `sig-compute && !Quarantine`
+	LabelFilter string `yaml:"labelFilter"`
+}
+
+// NeverRunSpec is a spec of the inventory that has not been run on any lane during the report period
+type NeverRunSpec struct {
+
+	// Name is the full text of the spec
+	Name string `json:"name"`
+
+	// SIG is the SIG the spec belongs to, taken from the labels or the text of the spec
+	SIG string `json:"sig"`
+
+	// Labels are the labels of the spec and its containers
+	Labels []string `json:"labels"`
+
+	// LanesSelecting are the lanes whose label filter selects the spec
+	LanesSelecting []string `json:"lanesSelecting,omitempty"`
+
+	// LabelFiltersExcluding are the label filters that exclude the spec, pointing to the lanes using them
+	LabelFiltersExcluding map[string][]string `json:"labelFiltersExcluding,omitempty"`
+
+	// BlockingLabels are the labels of the spec that on their own cause a label filter to exclude the spec
+	BlockingLabels []string `json:"blockingLabels,omitempty"`
+}
+
+// labelFilterMatcher is a parsed JobNamePatternToLabelFilter
+type labelFilterMatcher struct {
+	jobNamePattern *regexp.Regexp
+	expression     string
+	filter         types.LabelFilter
+}
+
+// FindNeverRunSpecs determines the specs of the dry-run reports that have not been run on any of the lanes. A spec
+// is considered run if any test name that has been run on a lane contains all node texts of the spec.
+//
+// For each spec that has not been run, the label filter of each lane is evaluated to find out whether the lane
+// would have selected the spec. The first entry of labelFilters matching a lane name is taken as its filter, lanes
+// without filter are not evaluated.
+func FindNeverRunSpecs(reports []types.Report, testNamesToJobNamesToExecutionStatus map[string]map[string]int, lanes []string, labelFilters []*JobNamePatternToLabelFilter) ([]NeverRunSpec, error) {
+	var matchers []labelFilterMatcher
+	for _, labelFilter := range labelFilters {
+		filter, err := types.ParseLabelFilter(labelFilter.LabelFilter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse label filter %q: %v", labelFilter.LabelFilter, err)
+		}
+		jobNamePattern, err := regexp.Compile(labelFilter.JobNamePattern)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse job name pattern %q: %v", labelFilter.JobNamePattern, err)
+		}
+		matchers = append(matchers, labelFilterMatcher{jobNamePattern: jobNamePattern, expression: labelFilter.LabelFilter, filter: filter})
+	}
+	lanesToMatchers := map[string]*labelFilterMatcher{}
+	for _, lane := range lanes {
+		for i := range matchers {
+			if matchers[i].jobNamePattern.MatchString(lane) {
+				lanesToMatchers[lane] = &matchers[i]
+				break
+			}
+		}
+	}
+
+	var runTestNameMatchers []func(types.SpecReport) bool
+	for testName, jobNamesToExecutionStatus := range testNamesToJobNamesToExecutionStatus {
+		for _, executionStatus := range jobNamesToExecutionStatus {
+			if executionStatus == TestExecution_Run {
+				runTestNameMatchers = append(runTestNameMatchers, ginkgo.ByName(testName))
+				break
+			}
+		}
+	}
+
+	var neverRunSpecs []NeverRunSpec
+	for _, report := range reports {
+		for _, specReport := range report.SpecReports {
+			if specReport.LeafNodeType != types.NodeTypeIt || hasBeenRun(specReport, runTestNameMatchers) {
+				continue
+			}
+			neverRunSpec := NeverRunSpec{
+				Name:   specReport.FullText(),
+				SIG:    sigOf(specReport),
+				Labels: specReport.Labels(),
+			}
+			blockingLabels := map[string]struct{}{}
+			for _, lane := range lanes {
+				matcher, exists := lanesToMatchers[lane]
+				if !exists {
+					continue
+				}
+				if matcher.filter(neverRunSpec.Labels) {
+					neverRunSpec.LanesSelecting = append(neverRunSpec.LanesSelecting, lane)
+					continue
+				}
+				if neverRunSpec.LabelFiltersExcluding == nil {
+					neverRunSpec.LabelFiltersExcluding = map[string][]string{}
+				}
+				neverRunSpec.LabelFiltersExcluding[matcher.expression] = append(neverRunSpec.LabelFiltersExcluding[matcher.expression], lane)
+				for _, label := range blockingLabelsFor(matcher.filter, neverRunSpec.Labels) {
+					blockingLabels[label] = struct{}{}
+				}
+			}
+			for label := range blockingLabels {
+				neverRunSpec.BlockingLabels = append(neverRunSpec.BlockingLabels, label)
+			}
+			sort.Strings(neverRunSpec.BlockingLabels)
+			neverRunSpecs = append(neverRunSpecs, neverRunSpec)
+		}
+	}
+	sort.Slice(neverRunSpecs, func(i, j int) bool {
+		if neverRunSpecs[i].SIG != neverRunSpecs[j].SIG {
+			return neverRunSpecs[i].SIG < neverRunSpecs[j].SIG
+		}
+		return neverRunSpecs[i].Name < neverRunSpecs[j].Name
+	})
+	return neverRunSpecs, nil
+}
+
+// LabelFilterGroups returns the keys under which the spec is grouped by label filter: each label filter that excludes
+// it, or one of NeverRunReasonSelected and NeverRunReasonNoLabelFilter.
+func (s NeverRunSpec) LabelFilterGroups() []string {
+	if len(s.LanesSelecting) > 0 {
+		return []string{NeverRunReasonSelected}
+	}
+	if len(s.LabelFiltersExcluding) == 0 {
+		return []string{NeverRunReasonNoLabelFilter}
+	}
+	var groups []string
+	for labelFilter := range s.LabelFiltersExcluding {
+		groups = append(groups, labelFilter)
+	}
+	sort.Strings(groups)
+	return groups
+}
+
+func hasBeenRun(specReport types.SpecReport, runTestNameMatchers []func(types.SpecReport) bool) bool {
+	for _, matches := range runTestNameMatchers {
+		if matches(specReport) {
+			return true
+		}
+	}
+	return false
+}
+
+// blockingLabelsFor returns the labels without which the filter would select the spec.
+func blockingLabelsFor(filter types.LabelFilter, labels []string) []string {
+	var blocking []string
+	for i, label := range labels {
+		withoutLabel := append(append([]string{}, labels[:i]...), labels[i+1:]...)
+		if filter(withoutLabel) {
+			blocking = append(blocking, label)
+		}
+	}
+	return blocking
+}
+
+func sigOf(specReport types.SpecReport) string {
+	for _, label := range specReport.Labels() {
+		if sigLabelRegex.MatchString(label) {
+			return label
+		}
+	}
+	if matches := sigTextRegex.FindStringSubmatch(specReport.FullText()); matches != nil {
+		return matches[1]
+	}
+	return unknownSIG
+}
+
+// groupNeverRunSpecs returns the names of the specs grouped by the keys the function returns for each spec.
+func groupNeverRunSpecs(neverRunSpecs []NeverRunSpec, keys func(NeverRunSpec) []string) map[string][]string {
+	groups := map[string][]string{}
+	for _, spec := range neverRunSpecs {
+		for _, key := range keys(spec) {
+			groups[key] = append(groups[key], spec.Name)
+		}
+	}
+	return groups
+}
@@ -0,0 +1,143 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package test_report
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/onsi/ginkgo/v2/types"
+)
+
+func spec(containerTexts []string, containerLabels [][]string, text string, labels ...string) types.SpecReport {
+	return types.SpecReport{
+		ContainerHierarchyTexts:  containerTexts,
+		ContainerHierarchyLabels: containerLabels,
+		LeafNodeType:             types.NodeTypeIt,
+		LeafNodeText:             text,
+		LeafNodeLabels:           labels,
+	}
+}
+
+func TestFindNeverRunSpecs(t *testing.T) {
+	reports := []types.Report{
+		{
+			SpecReports: []types.SpecReport{
+				spec([]string{"[sig-compute] VMI"}, [][]string{{"sig-compute"}}, "should start"),
+				spec([]string{"[sig-compute] VMI"}, [][]string{{"sig-compute"}}, "should start with a GPU", "GPU"),
+				spec([]string{"[sig-compute] VMI"}, [][]string{{"sig-compute"}}, "should flake", "Quarantine"),
+				spec([]string{"[sig-network] bridge"}, [][]string{{"sig-network"}}, "should connect"),
+				spec([]string{"[sig-storage] DataVolume"}, nil, "should import"),
+				{LeafNodeType: types.NodeTypeBeforeSuite},
+			},
+		},
+	}
+	testNamesToJobNamesToExecutionStatus := map[string]map[string]int{
+		"[It] [sig-compute] VMI should start [sig-compute]": {
+			"pull-kubevirt-e2e-k8s-1.30-sig-compute": TestExecution_Run,
+		},
+		"[It] [sig-network] bridge should connect [sig-network]": {
+			"pull-kubevirt-e2e-k8s-1.30-sig-compute": TestExecution_NoData,
+			"pull-kubevirt-e2e-k8s-1.30-sig-network": TestExecution_Skipped,
+		},
+	}
+	lanes := []string{"pull-kubevirt-e2e-k8s-1.30-sig-compute", "pull-kubevirt-e2e-k8s-1.30-sig-network"}
+	labelFilters := []*JobNamePatternToLabelFilter{
+		{JobNamePattern: "-sig-compute$", LabelFilter: "sig-compute && !(GPU,VGPU) && !Quarantine"},
+		{JobNamePattern: "-sig-network$", LabelFilter: "sig-network && !Quarantine"},
+	}
+
+	got, err := FindNeverRunSpecs(reports, testNamesToJobNamesToExecutionStatus, lanes, labelFilters)
+	if err != nil {
+		t.Fatalf("FindNeverRunSpecs() unexpected error: %v", err)
+	}
+
+	computeLane := []string{"pull-kubevirt-e2e-k8s-1.30-sig-compute"}
+	networkLane := []string{"pull-kubevirt-e2e-k8s-1.30-sig-network"}
+	want := []NeverRunSpec{
+		{
+			Name:   "[sig-compute] VMI should flake",
+			SIG:    "sig-compute",
+			Labels: []string{"sig-compute", "Quarantine"},
+			LabelFiltersExcluding: map[string][]string{
+				"sig-compute && !(GPU,VGPU) && !Quarantine": computeLane,
+				"sig-network && !Quarantine":                networkLane,
+			},
+			BlockingLabels: []string{"Quarantine"},
+		},
+		{
+			Name:   "[sig-compute] VMI should start with a GPU",
+			SIG:    "sig-compute",
+			Labels: []string{"sig-compute", "GPU"},
+			LabelFiltersExcluding: map[string][]string{
+				"sig-compute && !(GPU,VGPU) && !Quarantine": computeLane,
+				"sig-network && !Quarantine":                networkLane,
+			},
+			BlockingLabels: []string{"GPU"},
+		},
+		{
+			Name:           "[sig-network] bridge should connect",
+			SIG:            "sig-network",
+			Labels:         []string{"sig-network"},
+			LanesSelecting: networkLane,
+			LabelFiltersExcluding: map[string][]string{
+				"sig-compute && !(GPU,VGPU) && !Quarantine": computeLane,
+			},
+		},
+		{
+			Name:   "[sig-storage] DataVolume should import",
+			SIG:    "sig-storage",
+			Labels: []string{},
+			LabelFiltersExcluding: map[string][]string{
+				"sig-compute && !(GPU,VGPU) && !Quarantine": computeLane,
+				"sig-network && !Quarantine":                networkLane,
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindNeverRunSpecs() = %+v, want %+v", got, want)
+	}
+
+	var data Data
+	data.SetNeverRunSpecs(6, got)
+	wantBySIG := map[string][]string{
+		"sig-compute": {"[sig-compute] VMI should flake", "[sig-compute] VMI should start with a GPU"},
+		"sig-network": {"[sig-network] bridge should connect"},
+		"sig-storage": {"[sig-storage] DataVolume should import"},
+	}
+	if !reflect.DeepEqual(data.NeverRunSpecsBySIG, wantBySIG) {
+		t.Errorf("NeverRunSpecsBySIG = %v, want %v", data.NeverRunSpecsBySIG, wantBySIG)
+	}
+	wantByLabelFilter := map[string][]string{
+		"sig-compute && !(GPU,VGPU) && !Quarantine": {"[sig-compute] VMI should flake", "[sig-compute] VMI should start with a GPU", "[sig-storage] DataVolume should import"},
+		"sig-network && !Quarantine":                {"[sig-compute] VMI should flake", "[sig-compute] VMI should start with a GPU", "[sig-storage] DataVolume should import"},
+		NeverRunReasonSelected:                      {"[sig-network] bridge should connect"},
+	}
+	if !reflect.DeepEqual(data.NeverRunSpecsByLabelFilter, wantByLabelFilter) {
+		t.Errorf("NeverRunSpecsByLabelFilter = %v, want %v", data.NeverRunSpecsByLabelFilter, wantByLabelFilter)
+	}
+}
+
+func TestFindNeverRunSpecsInvalidLabelFilter(t *testing.T) {
+	_, err := FindNeverRunSpecs(nil, nil, nil, []*JobNamePatternToLabelFilter{{JobNamePattern: ".*", LabelFilter: "sig-compute &&"}})
+	if err == nil {
+		t.Errorf("FindNeverRunSpecs() expected error for invalid label filter")
+	}
+}
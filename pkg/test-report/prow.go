@@ -0,0 +1,217 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package test_report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/joshdk/go-junit"
+	"google.golang.org/api/iterator"
+	"kubevirt.io/project-infra/pkg/flakefinder"
+)
+
+const (
+	DefaultProwBucket = "kubevirt-prow"
+
+	prowLogsDir = "logs"
+)
+
+// junitFileNameRegex matches the junit files in the artifacts directory of a job run
+var junitFileNameRegex = regexp.MustCompile(`^junit.*\.xml$`)
+
+// ProwConfig is the configuration for taking the test executions from the job runs that Prow uploaded to GCS
+type ProwConfig struct {
+
+	// Bucket is the GCS bucket containing the job runs, if empty DefaultProwBucket is used
+	Bucket string `yaml:"bucket,omitempty"`
+
+	// JobNamePattern is a regexp.Regexp that describes which jobs below `logs/` are considered for the report
+	JobNamePattern string `yaml:"jobNamePattern"`
+}
+
+func (c *ProwConfig) GetBucket() string {
+	if c.Bucket == "" {
+		return DefaultProwBucket
+	}
+	return c.Bucket
+}
+
+// ProwJobHistoryURL returns the URL to the history of the job runs on Deck
+func ProwJobHistoryURL(bucket, jobName string) string {
+	return fmt.Sprintf("https://prow.ci.kubevirt.io/job-history/gs/%s", path.Join(bucket, prowLogsDir, jobName))
+}
+
+// FilterMatchingProwJobNames returns the names of the jobs that have runs stored in the bucket and match the pattern
+func FilterMatchingProwJobNames(ctx context.Context, client *storage.Client, bucket string, jobNamePattern *regexp.Regexp) ([]string, error) {
+	jobNames, err := flakefinder.ListGcsObjects(ctx, client, bucket, prowLogsDir+"/", "/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs in bucket %s: %v", bucket, err)
+	}
+	logger.Printf("Filtering for prow jobs matching %s", jobNamePattern)
+	var filteredJobNames []string
+	for _, jobName := range jobNames {
+		if jobNamePattern.MatchString(jobName) {
+			filteredJobNames = append(filteredJobNames, jobName)
+		}
+	}
+	sort.Strings(filteredJobNames)
+	logger.Printf("%d prow jobs left after filtering", len(filteredJobNames))
+	return filteredJobNames, nil
+}
+
+// GetTestNamesToJobNamesToTestExecutionsFromProw reads the junit files of the runs of the jobs finished since
+// startOfReport from `logs/<job>/<build>/artifacts/junit*.xml`. A test is considered run on a lane if it has been run
+// in any of the job runs.
+func GetTestNamesToJobNamesToTestExecutionsFromProw(ctx context.Context, client *storage.Client, bucket string, jobNames []string, startOfReport time.Time, maxConcurrentJobs int) (map[string]map[string]int, error) {
+	if maxConcurrentJobs <= 0 {
+		maxConcurrentJobs = 1
+	}
+	var (
+		wg       sync.WaitGroup
+		mutex    sync.Mutex
+		errs     []error
+		results  []map[string]map[string]int
+		inFlight = make(chan struct{}, maxConcurrentJobs)
+	)
+	for _, jobName := range jobNames {
+		wg.Add(1)
+		go func(jobName string) {
+			defer wg.Done()
+			inFlight <- struct{}{}
+			defer func() { <-inFlight }()
+			result, err := getTestExecutionsForProwJob(ctx, client, bucket, jobName, startOfReport)
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("job %s: %w", jobName, err))
+				return
+			}
+			results = append(results, result)
+		}(jobName)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return MergeTestNamesToJobNamesToTestExecutions(results, nil, nil), nil
+}
+
+func getTestExecutionsForProwJob(ctx context.Context, client *storage.Client, bucket string, jobName string, startOfReport time.Time) (map[string]map[string]int, error) {
+	jLog := logger.WithField("job", jobName)
+	jobDir := path.Join(prowLogsDir, jobName)
+	buildDirs, err := flakefinder.ListGcsObjects(ctx, client, bucket, jobDir+"/", "/")
+	if err != nil {
+		return nil, err
+	}
+	var builds []int
+	for _, buildDir := range buildDirs {
+		build, err := strconv.Atoi(buildDir)
+		if err != nil {
+			continue
+		}
+		builds = append(builds, build)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(builds)))
+
+	testNamesToJobNamesToExecutionStatus := map[string]map[string]int{}
+	for _, build := range builds {
+		buildDir := path.Join(jobDir, strconv.Itoa(build))
+		attrs, err := client.Bucket(bucket).Object(path.Join(buildDir, "finished.json")).Attrs(ctx)
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			// build still running
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read finished.json of %s: %v", buildDir, err)
+		}
+		if attrs.Created.Before(startOfReport) {
+			break
+		}
+		suites, err := readJUnitFiles(ctx, client, bucket, path.Join(buildDir, "artifacts"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read junit files of %s: %v", buildDir, err)
+		}
+		jLog.Debugf("build %d: %d suites", build, len(suites))
+		AddTestExecutions(testNamesToJobNamesToExecutionStatus, jobName, suites)
+	}
+	return testNamesToJobNamesToExecutionStatus, nil
+}
+
+func readJUnitFiles(ctx context.Context, client *storage.Client, bucket, artifactsDir string) ([]junit.Suite, error) {
+	var suites []junit.Suite
+	it := client.Bucket(bucket).Objects(ctx, &storage.Query{Prefix: artifactsDir + "/", Delimiter: "/"})
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			return suites, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if attrs.Name == "" || !junitFileNameRegex.MatchString(path.Base(attrs.Name)) {
+			continue
+		}
+		reader, err := client.Bucket(bucket).Object(attrs.Name).NewReader(ctx)
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(reader)
+		_ = reader.Close()
+		if err != nil {
+			return nil, err
+		}
+		fileSuites, err := junit.Ingest(data)
+		if err != nil {
+			logger.Warnf("skipping %s: %v", attrs.Name, err)
+			continue
+		}
+		suites = append(suites, fileSuites...)
+	}
+}
+
+// AddTestExecutions records the tests of the suites for the job. Once a test has been run on the job, it stays
+// recorded as run.
+func AddTestExecutions(testNamesToJobNamesToExecutionStatus map[string]map[string]int, jobName string, suites []junit.Suite) {
+	for _, suite := range suites {
+		for _, test := range suite.Tests {
+			if _, exists := testNamesToJobNamesToExecutionStatus[test.Name]; !exists {
+				testNamesToJobNamesToExecutionStatus[test.Name] = map[string]int{}
+			}
+			if testNamesToJobNamesToExecutionStatus[test.Name][jobName] == TestExecution_Run {
+				continue
+			}
+			if test.Status == junit.StatusSkipped {
+				testNamesToJobNamesToExecutionStatus[test.Name][jobName] = TestExecution_Skipped
+			} else {
+				testNamesToJobNamesToExecutionStatus[test.Name][jobName] = TestExecution_Run
+			}
+		}
+	}
+}
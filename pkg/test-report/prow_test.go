@@ -0,0 +1,140 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package test_report
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/joshdk/go-junit"
+)
+
+func TestAddTestExecutions(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing map[string]map[string]int
+		suites   []junit.Suite
+		want     map[string]map[string]int
+	}{
+		{
+			name:     "tests are recorded as run or skipped",
+			existing: map[string]map[string]int{},
+			suites: []junit.Suite{
+				{
+					Tests: []junit.Test{
+						{Name: "passed", Status: junit.StatusPassed},
+						{Name: "failed", Status: junit.StatusFailed},
+						{Name: "skipped", Status: junit.StatusSkipped},
+					},
+				},
+			},
+			want: map[string]map[string]int{
+				"passed":  {"job": TestExecution_Run},
+				"failed":  {"job": TestExecution_Run},
+				"skipped": {"job": TestExecution_Skipped},
+			},
+		},
+		{
+			name: "a test run in another job run stays run",
+			existing: map[string]map[string]int{
+				"test":  {"job": TestExecution_Run},
+				"other": {"job": TestExecution_Skipped},
+			},
+			suites: []junit.Suite{
+				{
+					Tests: []junit.Test{
+						{Name: "test", Status: junit.StatusSkipped},
+						{Name: "other", Status: junit.StatusPassed},
+					},
+				},
+			},
+			want: map[string]map[string]int{
+				"test":  {"job": TestExecution_Run},
+				"other": {"job": TestExecution_Run},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			AddTestExecutions(tt.existing, "job", tt.suites)
+			if !reflect.DeepEqual(tt.existing, tt.want) {
+				t.Errorf("AddTestExecutions() = %v, want %v", tt.existing, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeTestNamesToJobNamesToTestExecutions(t *testing.T) {
+	jenkinsResult := map[string]map[string]int{
+		"[sig-compute] test":  {"test-kubevirt-cnv-4.13-compute-ocs": TestExecution_Run},
+		"[sig-network] test":  {"test-kubevirt-cnv-4.13-network-ovn-ocs": TestExecution_Skipped},
+		"[sig-compute] other": {"test-kubevirt-cnv-4.13-compute-ocs": TestExecution_Skipped},
+	}
+	prowResult := map[string]map[string]int{
+		"[sig-compute] test": {"periodic-kubevirt-e2e-k8s-1.30-sig-compute": TestExecution_Skipped},
+		"[sig-compute] new":  {"periodic-kubevirt-e2e-k8s-1.30-sig-compute": TestExecution_Run},
+	}
+	tests := []struct {
+		name                       string
+		testNamePattern            *regexp.Regexp
+		jobNamePatternForTestNames *regexp.Regexp
+		want                       map[string]map[string]int
+	}{
+		{
+			name: "sources are combined",
+			want: map[string]map[string]int{
+				"[sig-compute] test": {
+					"test-kubevirt-cnv-4.13-compute-ocs":         TestExecution_Run,
+					"periodic-kubevirt-e2e-k8s-1.30-sig-compute": TestExecution_Skipped,
+				},
+				"[sig-network] test":  {"test-kubevirt-cnv-4.13-network-ovn-ocs": TestExecution_Skipped},
+				"[sig-compute] other": {"test-kubevirt-cnv-4.13-compute-ocs": TestExecution_Skipped},
+				"[sig-compute] new":   {"periodic-kubevirt-e2e-k8s-1.30-sig-compute": TestExecution_Run},
+			},
+		},
+		{
+			name:            "test names are filtered",
+			testNamePattern: regexp.MustCompile(`\[sig-network\]`),
+			want: map[string]map[string]int{
+				"[sig-network] test": {"test-kubevirt-cnv-4.13-network-ovn-ocs": TestExecution_Skipped},
+			},
+		},
+		{
+			name:                       "only tests seen on the jobs for test names are kept",
+			jobNamePatternForTestNames: regexp.MustCompile(`^periodic-`),
+			want: map[string]map[string]int{
+				"[sig-compute] test": {
+					"test-kubevirt-cnv-4.13-compute-ocs":         TestExecution_Run,
+					"periodic-kubevirt-e2e-k8s-1.30-sig-compute": TestExecution_Skipped,
+				},
+				"[sig-compute] new": {"periodic-kubevirt-e2e-k8s-1.30-sig-compute": TestExecution_Run},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MergeTestNamesToJobNamesToTestExecutions([]map[string]map[string]int{jenkinsResult, prowResult}, tt.testNamePattern, tt.jobNamePatternForTestNames)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MergeTestNamesToJobNamesToTestExecutions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
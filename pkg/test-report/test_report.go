@@ -69,6 +69,9 @@ type Data struct {
 	// LookedAtJobs contains the names of all test lanes that have been looked at
 	LookedAtJobs []string `json:"lookedAtJobs"`
 
+	// JobURLs contains the URLs to link for the test lanes that are not Jenkins jobs, i.e. the Prow job history
+	JobURLs map[string]string `json:"jobURLs,omitempty"`
+
 	// TestNamesToJobNamesToSkipped contains a map of test names per test pointing to the jobs where that test has been seen, which
 	// points to the state that was seen on that lane.
 	// See TestExecution_NoData, TestExecution_Skipped, TestExecution_Run, TestExecution_Unsupported
@@ -88,6 +91,19 @@ type Data struct {
 
 	// ReportConfigName holds the name of the report configuration being used for this report
 	ReportConfigName string
+
+	// SpecInventorySize is the number of specs found by the dry-run over the test sources, zero if no dry-run was done
+	SpecInventorySize int `json:"specInventorySize,omitempty"`
+
+	// NeverRunSpecs contains the specs from the dry-run that have not been run on any lane
+	NeverRunSpecs []NeverRunSpec `json:"neverRunSpecs,omitempty"`
+
+	// NeverRunSpecsBySIG contains the names of the NeverRunSpecs per SIG
+	NeverRunSpecsBySIG map[string][]string `json:"neverRunSpecsBySIG,omitempty"`
+
+	// NeverRunSpecsByLabelFilter contains the names of the NeverRunSpecs per label filter that excludes them
+	// (see NeverRunSpec.LabelFilterGroups)
+	NeverRunSpecsByLabelFilter map[string][]string `json:"neverRunSpecsByLabelFilter,omitempty"`
 }
 
 func (d Data) String() string {
@@ -114,6 +130,17 @@ func (d *Data) SetReportConfigName(name string) {
 	d.ReportConfigName = name
 }
 
+func (d *Data) SetJobURLs(jobURLs map[string]string) {
+	d.JobURLs = jobURLs
+}
+
+func (d *Data) SetNeverRunSpecs(specInventorySize int, neverRunSpecs []NeverRunSpec) {
+	d.SpecInventorySize = specInventorySize
+	d.NeverRunSpecs = neverRunSpecs
+	d.NeverRunSpecsBySIG = groupNeverRunSpecs(neverRunSpecs, func(s NeverRunSpec) []string { return []string{s.SIG} })
+	d.NeverRunSpecsByLabelFilter = groupNeverRunSpecs(neverRunSpecs, NeverRunSpec.LabelFilterGroups)
+}
+
 func NewData(testNames []string, filteredTestNames map[string]interface{}, skippedTests map[string]interface{}, lookedAtJobs []string, testNamesToJobNamesToSkipped map[string]map[string]int) Data {
 	return Data{
 		TestNames:                    testNames,
@@ -150,6 +177,14 @@ type Config struct {
 
 	// MaxConnsPerHost sets a boundary to the maximum number of parallel connections to the Jenkins
 	MaxConnsPerHost int `yaml:"maxConnsPerHost"`
+
+	// JobNamePatternsToLabelFilters describes the Ginkgo label filter per lane, which is evaluated for the specs that
+	// have not been run on any lane
+	JobNamePatternsToLabelFilters []*JobNamePatternToLabelFilter `yaml:"jobNamePatternsToLabelFilters,omitempty"`
+
+	// Prow configures taking test executions from the Prow job runs stored in GCS in addition to the Jenkins jobs
+	// matching JobNamePattern. If JobNamePattern is empty, only Prow job runs are considered.
+	Prow *ProwConfig `yaml:"prow,omitempty"`
 }
 
 type JobNamePatternToDontRunFileURL struct {
@@ -230,19 +265,29 @@ func GetTestNamesToJobNamesToTestExecutions(jobs []*gojenkins.Job, startOfReport
 	resultsChan := make(chan map[string]map[string]int)
 	go getTestNamesToJobNamesToTestExecutionForAllJobs(resultsChan, jobs, startOfReport, ctx, logger)
 
+	var results []map[string]map[string]int
+	for result := range resultsChan {
+		results = append(results, result)
+	}
+	return MergeTestNamesToJobNamesToTestExecutions(results, testNamePattern, jobNamePatternForTestNames)
+}
+
+// MergeTestNamesToJobNamesToTestExecutions merges the test executions from several jobs or sources into one matrix.
+// Only tests matching testNamePattern are kept, if it is given. If jobNamePatternForTestNames is given, only tests
+// that have been seen on any job matching it are kept.
+func MergeTestNamesToJobNamesToTestExecutions(results []map[string]map[string]int, testNamePattern *regexp.Regexp, jobNamePatternForTestNames *regexp.Regexp) map[string]map[string]int {
 	testNamesToJobNamesToExecutionStatus := map[string]map[string]int{}
 
-	for result := range resultsChan {
+	for _, result := range results {
 		for testName, jobNamesToExecutionStatus := range result {
-			if !testNamePattern.MatchString(testName) {
+			if testNamePattern != nil && !testNamePattern.MatchString(testName) {
 				continue
 			}
-			if _, exists := testNamesToJobNamesToExecutionStatus[testName]; exists {
-				for jobName, executionStatus := range jobNamesToExecutionStatus {
-					testNamesToJobNamesToExecutionStatus[testName][jobName] = executionStatus
-				}
-			} else {
-				testNamesToJobNamesToExecutionStatus[testName] = jobNamesToExecutionStatus
+			if _, exists := testNamesToJobNamesToExecutionStatus[testName]; !exists {
+				testNamesToJobNamesToExecutionStatus[testName] = map[string]int{}
+			}
+			for jobName, executionStatus := range jobNamesToExecutionStatus {
+				testNamesToJobNamesToExecutionStatus[testName][jobName] = executionStatus
 			}
 		}
 	}
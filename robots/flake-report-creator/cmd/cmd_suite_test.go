@@ -22,6 +22,9 @@ package cmd
 import (
 	"strings"
 	"testing"
+	"time"
+
+	"kubevirt.io/project-infra/pkg/flakefinder"
 )
 
 type expectation struct {
@@ -37,3 +40,11 @@ func (e *expectation) contains(content string, t *testing.T) {
 func expectContains(expectedToBeContained string) expectation {
 	return expectation{expectedToBeContained: expectedToBeContained}
 }
+
+func reportParamsFor(startOfReport, endOfReport time.Time, source Source, reports []*flakefinder.JobResult) ReportParams {
+	jobsToSources := map[string]Source{}
+	for _, report := range reports {
+		jobsToSources[report.Job] = source
+	}
+	return createReportParams(startOfReport, endOfReport, []Source{source}, reports, jobsToSources)
+}
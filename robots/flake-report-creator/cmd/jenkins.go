@@ -22,11 +22,8 @@ package cmd
 import (
 	"context"
 	"crypto/tls"
-	_ "embed"
-	"encoding/json"
 	"fmt"
 	"net/http"
-	"os"
 	"regexp"
 	"strings"
 	"sync"
@@ -40,7 +37,6 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/bndr/gojenkins"
-	"github.com/joshdk/go-junit"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -51,12 +47,7 @@ const (
 	jenkinsShortUsage            = "flake-report-creator jenkins creates reports from junit xml artifact files generated during jenkins builds"
 )
 
-//go:embed jenkins-report-template.gohtml
-var jenkinsReportTemplate string
-
 var (
-	fileNameRegex  = regexp.MustCompile(defaultArtifactFileNameRegex)
-	jobNameRegexes []*regexp.Regexp
 	opts           jenkinsOptions
 	jenkinsCommand = &cobra.Command{
 		Use:   "jenkins",
@@ -101,98 +92,135 @@ type jenkinsOptions struct {
 	startFromForRatings   time.Duration
 }
 
-type JenkinsReportParams struct {
-	flakefinder.Params
-	JenkinsBaseURL    string
-	JobNamesToRatings map[string]build.Rating
-}
-
 type JSONParams struct {
 	Data map[string]map[string]*flakefinder.Details `json:"data"`
 }
 
-func JenkinsCommand() *cobra.Command {
-	return jenkinsCommand
+// jenkinsSource yields the results of the junit artifacts of the builds of the jenkins jobs matching the job name
+// patterns. Builds are skipped if their number of failures deviates too much from the other builds of the job,
+// see build.Rating.
+type jenkinsSource struct {
+	jenkins             *gojenkins.Jenkins
+	endpoint            string
+	jobNameRegexes      []*regexp.Regexp
+	fileNameRegex       *regexp.Regexp
+	startFromForRatings time.Duration
+
+	ratings []build.Rating
 }
 
-func runJenkinsReport(cmd *cobra.Command, args []string) error {
-	err := cmd.InheritedFlags().Parse(args)
+func newJenkinsSource(ctx context.Context, o jenkinsOptions) (*jenkinsSource, error) {
+	fileNameRegex, err := regexp.Compile(o.artifactFileNameRegex)
 	if err != nil {
-		return err
-	}
-
-	if err = globalOpts.Validate(); err != nil {
-		_, err2 := fmt.Fprint(cmd.OutOrStderr(), cmd.UsageString(), err)
-		if err2 != nil {
-			return err2
-		}
-		return fmt.Errorf("invalid arguments provided: %v", err)
-	}
-
-	fileNameRegex, err = regexp.Compile(opts.artifactFileNameRegex)
-	if err != nil {
-		_, err2 := fmt.Fprint(cmd.OutOrStderr(), cmd.UsageString(), err)
-		if err2 != nil {
-			return err2
-		}
-		return fmt.Errorf("invalid arguments provided: %v", err)
+		return nil, fmt.Errorf("invalid artifact file name pattern: %v", err)
 	}
 
-	for _, jobNamePattern := range opts.jobNamePatterns {
+	var jobNameRegexes []*regexp.Regexp
+	for _, jobNamePattern := range o.jobNamePatterns {
 		jobNameRegex, err := regexp.Compile(jobNamePattern)
 		if err != nil {
-			_, err2 := fmt.Fprint(cmd.OutOrStderr(), cmd.UsageString(), err)
-			if err2 != nil {
-				return err2
-			}
-			jLog.Fatalf("failed to fetch jobs: %v", err)
+			return nil, fmt.Errorf("invalid job name pattern: %v", err)
 		}
 		jobNameRegexes = append(jobNameRegexes, jobNameRegex)
 	}
 
-	ctx := context.Background()
-
 	// limit http client connections to avoid 504 errors, looks like we are getting rate limited
 	client := &http.Client{
 		Transport: &http.Transport{
-			MaxConnsPerHost: opts.maxConnsPerHost,
+			MaxConnsPerHost: o.maxConnsPerHost,
 			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: opts.insecureSkipVerify,
+				InsecureSkipVerify: o.insecureSkipVerify,
 			},
 		},
 	}
 
-	jLog.Printf("Creating client for %s", opts.endpoint)
-	jenkins := gojenkins.CreateJenkins(client, opts.endpoint)
+	jLog.Printf("Creating client for %s", o.endpoint)
+	jenkins := gojenkins.CreateJenkins(client, o.endpoint)
 	_, err = jenkins.Init(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to contact jenkins %s: %v", opts.endpoint, err)
+		return nil, fmt.Errorf("failed to contact jenkins %s: %v", o.endpoint, err)
 	}
 
+	return &jenkinsSource{
+		jenkins:             jenkins,
+		endpoint:            o.endpoint,
+		jobNameRegexes:      jobNameRegexes,
+		fileNameRegex:       fileNameRegex,
+		startFromForRatings: o.startFromForRatings,
+	}, nil
+}
+
+func (s *jenkinsSource) Description() string {
+	var patterns []string
+	for _, jobNameRegex := range s.jobNameRegexes {
+		patterns = append(patterns, jobNameRegex.String())
+	}
+	return fmt.Sprintf("jenkins %s jobs matching %s", s.endpoint, strings.Join(patterns, ", "))
+}
+
+func (s *jenkinsSource) JobResults(ctx context.Context, startOfReport time.Time) ([]*flakefinder.JobResult, error) {
 	jLog.Printf("Fetching jobs")
-	jobNames, err := jenkins.GetAllJobNames(ctx)
+	jobNames, err := s.jenkins.GetAllJobNames(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to fetch jobs: %v", err)
+		return nil, fmt.Errorf("failed to fetch jobs: %v", err)
 	}
 	jLog.Printf("Fetched %d jobs", len(jobNames))
 
-	startOfReport := time.Now().Add(-1 * opts.startFrom)
-	startOfReportForRatings := time.Now().Add(-1 * opts.startFromForRatings)
+	startOfReportForRatings := time.Now().Add(-1 * s.startFromForRatings)
+
+	reports, ratings := s.fetchJunitReportsFromMatchingJobs(startOfReport, startOfReportForRatings, jobNames, ctx)
+	s.ratings = ratings
+	return reports, nil
+}
+
+func (s *jenkinsSource) Ratings() []build.Rating {
+	return s.ratings
+}
+
+func (s *jenkinsSource) JobURL(job string) string {
+	return fmt.Sprintf("%s/job/%s/", strings.TrimSuffix(s.endpoint, "/"), job)
+}
+
+func (s *jenkinsSource) BuildURL(job string, buildNumber int) string {
+	return fmt.Sprintf("%s/job/%s/%d/", strings.TrimSuffix(s.endpoint, "/"), job, buildNumber)
+}
+
+func JenkinsCommand() *cobra.Command {
+	return jenkinsCommand
+}
 
-	junitReportsFromMatchingJobs, ratings := fetchJunitReportsFromMatchingJobs(startOfReport, startOfReportForRatings, jobNames, jenkins, ctx)
-	writeReportToFile(startOfReport, time.Now(), junitReportsFromMatchingJobs, globalOpts.outputFile, ratings)
+func runJenkinsReport(cmd *cobra.Command, args []string) error {
+	err := cmd.InheritedFlags().Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if err = globalOpts.Validate(); err != nil {
+		_, err2 := fmt.Fprint(cmd.OutOrStderr(), cmd.UsageString(), err)
+		if err2 != nil {
+			return err2
+		}
+		return fmt.Errorf("invalid arguments provided: %v", err)
+	}
+
+	ctx := context.Background()
+	source, err := newJenkinsSource(ctx, opts)
+	if err != nil {
+		return err
+	}
 
-	return nil
+	startOfReport := time.Now().Add(-1 * opts.startFrom)
+	return runReport(ctx, []Source{source}, startOfReport, globalOpts.outputFile)
 }
 
-func fetchJunitReportsFromMatchingJobs(startOfReport time.Time, startOfReportsForRatings time.Time, innerJobs []gojenkins.InnerJob, jenkins *gojenkins.Jenkins, ctx context.Context) ([]*flakefinder.JobResult, []build.Rating) {
-	filteredJobs := filterMatchingJobs(innerJobs)
-	return fetchJobReports(startOfReport, startOfReportsForRatings, filteredJobs, jenkins, ctx)
+func (s *jenkinsSource) fetchJunitReportsFromMatchingJobs(startOfReport time.Time, startOfReportsForRatings time.Time, innerJobs []gojenkins.InnerJob, ctx context.Context) ([]*flakefinder.JobResult, []build.Rating) {
+	filteredJobs := s.filterMatchingJobs(innerJobs)
+	return s.fetchJobReports(startOfReport, startOfReportsForRatings, filteredJobs, ctx)
 }
 
-func filterMatchingJobs(innerJobs []gojenkins.InnerJob) []gojenkins.InnerJob {
+func (s *jenkinsSource) filterMatchingJobs(innerJobs []gojenkins.InnerJob) []gojenkins.InnerJob {
 	filteredJobs := []gojenkins.InnerJob{}
-	for _, jobNameRegex := range jobNameRegexes {
+	for _, jobNameRegex := range s.jobNameRegexes {
 		jLog.Printf("Filtering for jobs matching %s", jobNameRegex)
 		for _, innerJob := range innerJobs {
 			if !jobNameRegex.MatchString(innerJob.Name) {
@@ -205,10 +233,10 @@ func filterMatchingJobs(innerJobs []gojenkins.InnerJob) []gojenkins.InnerJob {
 	return filteredJobs
 }
 
-func fetchJobReports(startOfReport time.Time, startOfReportForRatings time.Time, filteredJobs []gojenkins.InnerJob, jenkins *gojenkins.Jenkins, ctx context.Context) ([]*flakefinder.JobResult, []build.Rating) {
+func (s *jenkinsSource) fetchJobReports(startOfReport time.Time, startOfReportForRatings time.Time, filteredJobs []gojenkins.InnerJob, ctx context.Context) ([]*flakefinder.JobResult, []build.Rating) {
 	resultChan := make(chan result)
 
-	go runReportDataFetches(filteredJobs, jenkins, ctx, startOfReport, startOfReportForRatings, resultChan)
+	go s.runReportDataFetches(filteredJobs, ctx, startOfReport, startOfReportForRatings, resultChan)
 
 	buildRatings := []build.Rating{}
 	reports := []*flakefinder.JobResult{}
@@ -219,13 +247,13 @@ func fetchJobReports(startOfReport time.Time, startOfReportForRatings time.Time,
 	return reports, buildRatings
 }
 
-func runReportDataFetches(filteredJobs []gojenkins.InnerJob, jenkins *gojenkins.Jenkins, ctx context.Context, startOfReport time.Time, startOfReportForRatings time.Time, resultChan chan result) {
+func (s *jenkinsSource) runReportDataFetches(filteredJobs []gojenkins.InnerJob, ctx context.Context, startOfReport time.Time, startOfReportForRatings time.Time, resultChan chan result) {
 	var wg sync.WaitGroup
 	wg.Add(len(filteredJobs))
 
 	defer close(resultChan)
 	for _, filteredJob := range filteredJobs {
-		go fetchReportDataForJob(filteredJob, jenkins, ctx, startOfReport, startOfReportForRatings, &wg, resultChan)
+		go s.fetchReportDataForJob(filteredJob, ctx, startOfReport, startOfReportForRatings, &wg, resultChan)
 	}
 
 	wg.Wait()
@@ -236,25 +264,25 @@ type result struct {
 	buildRating build.Rating
 }
 
-func fetchReportDataForJob(filteredJob gojenkins.InnerJob, jenkins *gojenkins.Jenkins, ctx context.Context, startOfReport time.Time, startOfReportForRatings time.Time, wg *sync.WaitGroup, resultChan chan result) {
+func (s *jenkinsSource) fetchReportDataForJob(filteredJob gojenkins.InnerJob, ctx context.Context, startOfReport time.Time, startOfReportForRatings time.Time, wg *sync.WaitGroup, resultChan chan result) {
 	defer wg.Done()
 
 	fLog := jLog.WithField("job", filteredJob.Name)
 
 	fLog.Printf("Fetching job")
-	job, err := jenkins.GetJob(ctx, filteredJob.Name)
+	job, err := s.jenkins.GetJob(ctx, filteredJob.Name)
 	if err != nil {
 		fLog.Fatalf("failed to fetch job: %v", err)
 	}
 
 	buildNumbersToFailures := flakejenkins.GetBuildNumbersToFailuresForJob(startOfReportForRatings, job, ctx, jLog)
-	ratingForBuilds := build.NewRating(filteredJob.Name, opts.endpoint, opts.startFrom, buildNumbersToFailures)
+	ratingForBuilds := build.NewRating(filteredJob.Name, s.endpoint, s.startFromForRatings, buildNumbersToFailures)
 
 	completedBuilds := flakejenkins.FetchCompletedBuildsForJob(startOfReport, job.Raw.LastBuild.Number, job, ctx, fLog, 4)
 
 	filteredBuilds := filterBuildsByRating(completedBuilds, ratingForBuilds, fLog)
 
-	junitFilesFromArtifacts := fetchJunitFilesFromArtifacts(filteredBuilds, fLog)
+	junitFilesFromArtifacts := s.fetchJunitFilesFromArtifacts(filteredBuilds, fLog)
 	reportsPerJob := convertJunitFileDataToReport(junitFilesFromArtifacts, ctx, job, fLog)
 
 	resultChan <- result{
@@ -276,12 +304,12 @@ func filterBuildsByRating(completedBuilds []*gojenkins.Build, ratingForBuilds bu
 	return filteredBuilds
 }
 
-func fetchJunitFilesFromArtifacts(completedBuilds []*gojenkins.Build, fLog *log.Entry) []gojenkins.Artifact {
+func (s *jenkinsSource) fetchJunitFilesFromArtifacts(completedBuilds []*gojenkins.Build, fLog *log.Entry) []gojenkins.Artifact {
 	fLog.Printf("Fetch junit files from artifacts for %d completed builds", len(completedBuilds))
 	artifacts := []gojenkins.Artifact{}
 	for _, completedBuild := range completedBuilds {
 		for _, artifact := range completedBuild.GetArtifacts() {
-			if !fileNameRegex.MatchString(artifact.FileName) {
+			if !s.fileNameRegex.MatchString(artifact.FileName) {
 				continue
 			}
 			artifacts = append(artifacts, artifact)
@@ -298,76 +326,28 @@ func convertJunitFileDataToReport(junitFilesFromArtifacts []gojenkins.Artifact,
 
 	// step 1: download the report junit data and store them in a slice per build
 	fLog.Printf("Download %d artifacts and convert to reports", len(junitFilesFromArtifacts))
-	artifactsPerBuild := map[int64][][]junit.Suite{}
+	artifactsPerBuild := map[int64][]junitMerge.Source{}
 	for _, artifact := range junitFilesFromArtifacts {
 		data, err := artifact.GetData(ctx)
 		if err != nil {
 			fLog.Fatalf("failed to fetch artifact data: %v", err)
 		}
-		report, err := junit.Ingest(data)
+		report, err := junitMerge.Ingest(data)
 		if err != nil {
 			fLog.Fatalf("failed to fetch artifact data: %v", err)
 		}
 		buildNumber := artifact.Build.Info().Number
-		artifactsPerBuild[buildNumber] = append(artifactsPerBuild[buildNumber], report)
+		artifactsPerBuild[buildNumber] = append(artifactsPerBuild[buildNumber], junitMerge.Source{Name: artifact.FileName, Suites: report})
 	}
 
-	// step 2: merge all the suites for a build into one suite per build
+	// step 2: merge all the suites for a build into one suite per build, keeping
+	// 			the information whether a test only passed on retry
 	fLog.Printf("Merge reports for %d builds", len(artifactsPerBuild))
 	reportsPerJob := []*flakefinder.JobResult{}
 	for buildNumber, artifacts := range artifactsPerBuild {
-		// TODO: evaluate conflicts somehow
-		mergedResult, _ := junitMerge.Merge(artifacts)
+		mergedResult := junitMerge.ToSuites(junitMerge.MergeAttempts(artifacts))
 		reportsPerJob = append(reportsPerJob, &flakefinder.JobResult{Job: job.GetName(), JUnit: mergedResult, BuildNumber: int(buildNumber)})
 	}
 
 	return reportsPerJob
 }
-
-func writeReportToFile(startOfReport time.Time, endOfReport time.Time, reports []*flakefinder.JobResult, outputFile string, ratings []build.Rating) {
-	parameters := flakefinder.CreateFlakeReportData(reports, []int{}, endOfReport, "kubevirt", "kubevirt", startOfReport)
-	jLog.Printf("writing output to %s", outputFile)
-
-	jobNamesToRatings := map[string]build.Rating{}
-	for _, rating := range ratings {
-		jobNamesToRatings[rating.Name] = rating
-	}
-
-	writeHTMLReportToOutputFile(outputFile, jenkinsReportTemplate, JenkinsReportParams{Params: parameters, JenkinsBaseURL: opts.endpoint, JobNamesToRatings: jobNamesToRatings})
-
-	writeJSONToOutputFile(strings.TrimSuffix(outputFile, ".html")+".json", parameters)
-}
-
-func writeHTMLReportToOutputFile(outputFile string, reportTemplate string, params interface{}) {
-	reportOutputWriter := createReportOutputWriter(outputFile)
-	defer func() { _ = reportOutputWriter.Close() }()
-
-	err := flakefinder.WriteTemplateToOutput(reportTemplate, params, reportOutputWriter)
-	if err != nil {
-		jLog.Fatalf("failed to write report: %v", err)
-	}
-}
-
-func writeJSONToOutputFile(jsonOutputFile string, parameters flakefinder.Params) {
-	reportOutputWriter := createReportOutputWriter(jsonOutputFile)
-	defer func() { _ = reportOutputWriter.Close() }()
-
-	encoder := json.NewEncoder(reportOutputWriter)
-	err := encoder.Encode(
-		map[string]interface{}{
-			"data":            parameters.Data,
-			"failuresForJobs": parameters.FailuresForJobs,
-		},
-	)
-	if err != nil {
-		jLog.Fatalf("failed to write report: %v", err)
-	}
-}
-
-func createReportOutputWriter(outputFile string) *os.File {
-	reportOutputWriter, err := os.OpenFile(outputFile, os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil && err != os.ErrNotExist {
-		jLog.Fatalf("failed to write report: %v", err)
-	}
-	return reportOutputWriter
-}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"testing"
 	"time"
 
@@ -82,7 +83,8 @@ func Test_fetchJunitFilesFromArtifacts(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := fetchJunitFilesFromArtifacts(tt.args.completedBuilds, tt.args.fLog)
+			source := &jenkinsSource{fileNameRegex: regexp.MustCompile(defaultArtifactFileNameRegex)}
+			got := source.fetchJunitFilesFromArtifacts(tt.args.completedBuilds, tt.args.fLog)
 			actualFileNames := []string{}
 			for _, artifact := range got {
 				actualFileNames = append(actualFileNames, artifact.FileName)
@@ -94,7 +96,7 @@ func Test_fetchJunitFilesFromArtifacts(t *testing.T) {
 	}
 }
 
-func Test_writeReportToFileProducesValidOutput(t *testing.T) {
+func Test_writeJenkinsReportProducesValidOutput(t *testing.T) {
 	type args struct {
 		startOfReport time.Time
 		endOfReport   time.Time
@@ -312,7 +314,12 @@ func Test_writeReportToFileProducesValidOutput(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tempFile := filepath.Join(tempDir, "report.html")
-			writeReportToFile(tt.args.startOfReport, tt.args.endOfReport, tt.args.reports, tempFile, tt.args.ratings)
+			source := &jenkinsSource{endpoint: defaultJenkinsBaseUrl, ratings: tt.args.ratings}
+			err := writeReport(reportParamsFor(tt.args.startOfReport, tt.args.endOfReport, source, tt.args.reports), tempFile)
+			if err != nil {
+				t.Errorf("failed to write report to file: %v", err)
+				return
+			}
 
 			for _, currentValidator := range tt.args.validators {
 				targetFileName := currentValidator.GetTargetFileName(tempFile)
@@ -331,7 +338,7 @@ func Test_writeReportToFileProducesValidOutput(t *testing.T) {
 	}
 }
 
-func Test_writeReportToFileCreatesTags(t *testing.T) {
+func Test_writeJenkinsReportCreatesTags(t *testing.T) {
 	type args struct {
 		startOfReport time.Time
 		endOfReport   time.Time
@@ -399,7 +406,12 @@ func Test_writeReportToFileCreatesTags(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tempFile := filepath.Join(tempDir, "report.html")
-			writeReportToFile(tt.args.startOfReport, tt.args.endOfReport, tt.args.reports, tempFile, tt.args.ratings)
+			source := &jenkinsSource{endpoint: defaultJenkinsBaseUrl, ratings: tt.args.ratings}
+			err := writeReport(reportParamsFor(tt.args.startOfReport, tt.args.endOfReport, source, tt.args.reports), tempFile)
+			if err != nil {
+				t.Errorf("failed to write report to file: %v", err)
+				return
+			}
 
 			content, err := os.ReadFile(tempFile)
 			if err != nil {
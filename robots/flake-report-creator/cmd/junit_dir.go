@@ -0,0 +1,151 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"kubevirt.io/project-infra/pkg/flakefinder"
+	junitMerge "kubevirt.io/project-infra/pkg/flakefinder/junit-merge"
+)
+
+// junitDirSource yields the results of the junit files in a local directory, which contains a directory per job,
+// which in turn contains a directory per build named after the build number:
+//
+//	<dir>/<job>/<build number>/**/<junit file>
+//
+// All junit files of a build are merged into one result, and the build is considered finished when the latest
+// junit file has been modified.
+type junitDirSource struct {
+	dir           string
+	fileNameRegex *regexp.Regexp
+}
+
+func newJunitDirSource(dir string, fileNameRegex *regexp.Regexp) (*junitDirSource, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve junit dir %s: %v", dir, err)
+	}
+	stat, err := os.Stat(absDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read junit dir %s: %v", dir, err)
+	}
+	if !stat.IsDir() {
+		return nil, fmt.Errorf("junit dir %s is not a directory", dir)
+	}
+	return &junitDirSource{dir: absDir, fileNameRegex: fileNameRegex}, nil
+}
+
+func (s *junitDirSource) Description() string {
+	return "junit dir " + s.dir
+}
+
+func (s *junitDirSource) JobResults(_ context.Context, startOfReport time.Time) ([]*flakefinder.JobResult, error) {
+	jobDirs, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	reports := []*flakefinder.JobResult{}
+	for _, jobDir := range jobDirs {
+		if !jobDir.IsDir() {
+			continue
+		}
+		buildDirs, err := os.ReadDir(filepath.Join(s.dir, jobDir.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, buildDir := range buildDirs {
+			buildNumber, err := strconv.Atoi(buildDir.Name())
+			if !buildDir.IsDir() || err != nil {
+				continue
+			}
+			report, err := s.readBuild(jobDir.Name(), buildNumber, startOfReport)
+			if err != nil {
+				return nil, err
+			}
+			if report != nil {
+				reports = append(reports, report)
+			}
+		}
+	}
+	return reports, nil
+}
+
+// readBuild returns the merged results of the junit files of the build, or nil if there are none or the build
+// finished before startOfReport.
+func (s *junitDirSource) readBuild(job string, buildNumber int, startOfReport time.Time) (*flakefinder.JobResult, error) {
+	buildDir := filepath.Join(s.dir, job, strconv.Itoa(buildNumber))
+	var junitFiles []string
+	var finished time.Time
+	err := filepath.WalkDir(buildDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !s.fileNameRegex.MatchString(d.Name()) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(finished) {
+			finished = info.ModTime()
+		}
+		junitFiles = append(junitFiles, path)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list junit files of %s: %v", buildDir, err)
+	}
+	if len(junitFiles) == 0 || finished.Before(startOfReport) {
+		return nil, nil
+	}
+	sort.Strings(junitFiles)
+
+	var sources []junitMerge.Source
+	for _, junitFile := range junitFiles {
+		suites, err := junitMerge.IngestFile(junitFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read junit file %s: %v", junitFile, err)
+		}
+		sources = append(sources, junitMerge.Source{Name: filepath.Base(junitFile), Suites: suites})
+	}
+	return &flakefinder.JobResult{
+		Job:         job,
+		JUnit:       junitMerge.ToSuites(junitMerge.MergeAttempts(sources)),
+		BuildNumber: buildNumber,
+	}, nil
+}
+
+func (s *junitDirSource) JobURL(job string) string {
+	return "file://" + filepath.Join(s.dir, job)
+}
+
+func (s *junitDirSource) BuildURL(job string, buildNumber int) string {
+	return "file://" + filepath.Join(s.dir, job, strconv.Itoa(buildNumber))
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/joshdk/go-junit"
+)
+
+const (
+	junitFailedTest = `<testsuite name="Tests Suite" tests="2" failures="1">
+    <testcase name="test a" classname="Tests Suite"><failure message="failed">failed</failure></testcase>
+    <testcase name="test b" classname="Tests Suite"></testcase>
+</testsuite>`
+	junitPassedTests = `<testsuite name="Tests Suite" tests="2" failures="0">
+    <testcase name="test a" classname="Tests Suite"></testcase>
+    <testcase name="test b" classname="Tests Suite"></testcase>
+</testsuite>`
+)
+
+func writeJunitFile(t *testing.T, path, content string, modTime time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write junit file: %v", err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("failed to set mod time: %v", err)
+	}
+}
+
+func Test_junitDirSourceJobResults(t *testing.T) {
+	now := time.Now()
+	dir := t.TempDir()
+	writeJunitFile(t, filepath.Join(dir, "job1", "1", "junit.functest.xml"), junitFailedTest, now)
+	writeJunitFile(t, filepath.Join(dir, "job1", "2", "artifacts", "partial.junit.functest.1.xml"), junitFailedTest, now)
+	writeJunitFile(t, filepath.Join(dir, "job1", "2", "artifacts", "partial.junit.functest.2.xml"), junitPassedTests, now)
+	writeJunitFile(t, filepath.Join(dir, "job1", "3", "junit.functest.xml"), junitFailedTest, now.Add(-30*24*time.Hour))
+	writeJunitFile(t, filepath.Join(dir, "job1", "4", "build-log.xml"), junitFailedTest, now)
+	writeJunitFile(t, filepath.Join(dir, "job1", "latest", "junit.functest.xml"), junitFailedTest, now)
+	writeJunitFile(t, filepath.Join(dir, "job2", "17", "xunit_results.xml"), junitPassedTests, now)
+	writeJunitFile(t, filepath.Join(dir, "junit.functest.xml"), junitPassedTests, now)
+
+	source, err := newJunitDirSource(dir, regexp.MustCompile(defaultArtifactFileNameRegex))
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+	results, err := source.JobResults(context.Background(), now.Add(-14*24*time.Hour))
+	if err != nil {
+		t.Fatalf("failed to fetch job results: %v", err)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].BuildNumber < results[j].BuildNumber })
+
+	type build struct {
+		job         string
+		buildNumber int
+		statuses    map[string]junit.Status
+	}
+	expected := []build{
+		{job: "job1", buildNumber: 1, statuses: map[string]junit.Status{"test a": junit.StatusFailed, "test b": junit.StatusPassed}},
+		{job: "job1", buildNumber: 2, statuses: map[string]junit.Status{"test a": junit.StatusPassed, "test b": junit.StatusPassed}},
+		{job: "job2", buildNumber: 17, statuses: map[string]junit.Status{"test a": junit.StatusPassed, "test b": junit.StatusPassed}},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("expected %d results, got %d: %v", len(expected), len(results), results)
+	}
+	for i, e := range expected {
+		result := results[i]
+		if result.Job != e.job || result.BuildNumber != e.buildNumber {
+			t.Errorf("expected %s/%d, got %s/%d", e.job, e.buildNumber, result.Job, result.BuildNumber)
+			continue
+		}
+		statuses := map[string]junit.Status{}
+		for _, suite := range result.JUnit {
+			for _, test := range suite.Tests {
+				statuses[test.Name] = test.Status
+			}
+		}
+		for name, status := range e.statuses {
+			if statuses[name] != status {
+				t.Errorf("%s/%d: expected %q to be %s, got %s", e.job, e.buildNumber, name, status, statuses[name])
+			}
+		}
+	}
+
+	if url := source.BuildURL("job1", 2); url != "file://"+filepath.Join(dir, "job1", "2") {
+		t.Errorf("unexpected build url %s", url)
+	}
+}
+
+func Test_newJunitDirSourceRequiresDirectory(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "junit.functest.xml")
+	writeJunitFile(t, file, junitPassedTests, time.Now())
+
+	for _, path := range []string{file, filepath.Join(dir, "missing")} {
+		if _, err := newJunitDirSource(path, regexp.MustCompile(defaultArtifactFileNameRegex)); err == nil {
+			t.Errorf("expected error for %s", path)
+		}
+	}
+}
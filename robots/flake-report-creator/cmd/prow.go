@@ -21,9 +21,7 @@ package cmd
 
 import (
 	"context"
-	_ "embed"
 	"fmt"
-	"os"
 	"path"
 	"regexp"
 	"strconv"
@@ -50,9 +48,6 @@ const (
 	shortUsage  = "flake-report-creator prow creates an ad-hoc report of any GCS directories that contain kubevirt testing junit files"
 )
 
-//go:embed prow-report-template.gohtml
-var ProwReportTemplate string
-
 func init() {
 	prowOpts = prowOptions{}
 	prowCommand.PersistentFlags().StringVar(&prowOpts.bucketName, "bucketName", "", "The name of the GCS bucket")
@@ -66,8 +61,6 @@ func init() {
 }
 
 type prowOptions struct {
-	org                  string
-	repo                 string
 	bucketName           string
 	jobDataPathes        []string
 	matchingSubDirRegExp string
@@ -203,10 +196,131 @@ $ flake-report-creator prow --ci-system=kubevirt --periodics 1.21,1.22
 	prowOpts prowOptions
 )
 
-type SimpleReportParams struct {
-	flakefinder.Params
-	BucketName    string
-	JobDataPathes []string
+// prowDeckURLs are the urls of the prow deployments that store their job results in the bucket
+var prowDeckURLs = map[string]string{
+	"kubevirt-prow":  "https://prow.ci.kubevirt.io",
+	"origin-ci-test": "https://prow.ci.openshift.org",
+}
+
+// prowSource yields the results of the prow jobs that have their job directories below the data pathes in a GCS
+// bucket.
+type prowSource struct {
+	storageClient *storage.Client
+	bucketName    string
+	jobDataPathes []string
+	// periodics determines whether the data pathes are prefixes of the job directories
+	periodics bool
+	// useSubDirs determines whether the job directories are the sub directories of the data pathes
+	useSubDirs   bool
+	subDirRegExp *regexp.Regexp
+
+	jobDirs      map[string]string
+	buildJobDirs map[string]string
+}
+
+func newProwSource(storageClient *storage.Client, bucketName string, jobDataPathes []string, periodics, useSubDirs bool, subDirRegExp *regexp.Regexp) *prowSource {
+	return &prowSource{
+		storageClient: storageClient,
+		bucketName:    bucketName,
+		jobDataPathes: jobDataPathes,
+		periodics:     periodics,
+		useSubDirs:    useSubDirs,
+		subDirRegExp:  subDirRegExp,
+		jobDirs:       map[string]string{},
+		buildJobDirs:  map[string]string{},
+	}
+}
+
+func (s *prowSource) Description() string {
+	var pathes []string
+	for _, dataPath := range s.jobDataPathes {
+		pathes = append(pathes, fmt.Sprintf("gs://%s/%s", s.bucketName, dataPath))
+	}
+	return "prow " + strings.Join(pathes, ", ")
+}
+
+func (s *prowSource) JobResults(ctx context.Context, startOfReport time.Time) ([]*flakefinder.JobResult, error) {
+	reports := []*flakefinder.JobResult{}
+	for _, jobDirectorySegments := range s.jobDirectories(ctx) {
+		results, err := flakefinder.FindUnitTestFilesForPeriodicJob(ctx, s.storageClient, s.bucketName, jobDirectorySegments, startOfReport, maxTime)
+		if err != nil {
+			log.Printf("failed to load JUnit files for job %v: %v", path.Join(jobDirectorySegments...), err)
+		}
+		jobDir := path.Join(jobDirectorySegments...)
+		for _, result := range results {
+			if _, exists := s.jobDirs[result.Job]; !exists {
+				s.jobDirs[result.Job] = jobDir
+			}
+			s.buildJobDirs[fmt.Sprintf("%s-%d", result.Job, result.BuildNumber)] = jobDir
+		}
+		reports = append(reports, results...)
+	}
+	return reports, nil
+}
+
+// jobDirectories returns the path segments of the job directories below the data pathes.
+func (s *prowSource) jobDirectories(ctx context.Context) [][]string {
+	var jobDirectories [][]string
+	if s.periodics {
+		basePath := path.Dir(s.jobDataPathes[0])
+		dirs, err := flakefinder.ListGcsObjects(ctx, s.storageClient, s.bucketName, basePath+"/", "/")
+		if err != nil {
+			log.Printf("failed to list objects for dataPath %v: %v", path.Base(s.jobDataPathes[0])+"/", err)
+		}
+		for _, dataPath := range s.jobDataPathes {
+			for _, dir := range dirs {
+				if !strings.HasPrefix(path.Join(basePath, dir), dataPath) {
+					continue
+				}
+				jobDirectories = append(jobDirectories, []string{basePath, dir})
+			}
+		}
+		return jobDirectories
+	}
+	for _, dataPath := range s.jobDataPathes {
+		if !s.useSubDirs {
+			jobDirectories = append(jobDirectories, []string{dataPath})
+			continue
+		}
+		subDirs, err := flakefinder.ListGcsObjects(ctx, s.storageClient, s.bucketName, dataPath+"/", "/")
+		if err != nil {
+			log.Printf("failed to list objects for dataPath %v: %v", dataPath, err)
+		}
+		for _, subDir := range subDirs {
+			if s.subDirRegExp != nil && !s.subDirRegExp.MatchString(subDir) {
+				continue
+			}
+			jobDirectories = append(jobDirectories, []string{dataPath, subDir})
+		}
+	}
+	return jobDirectories
+}
+
+func (s *prowSource) JobURL(job string) string {
+	deckURL, exists := prowDeckURLs[s.bucketName]
+	if !exists {
+		return ""
+	}
+	jobDir, exists := s.jobDirs[job]
+	if !exists {
+		return ""
+	}
+	if strings.HasPrefix(jobDir, "pr-logs/") {
+		jobDir = path.Join("pr-logs/directory", job)
+	}
+	return fmt.Sprintf("%s/job-history/gs/%s/%s", deckURL, s.bucketName, jobDir)
+}
+
+func (s *prowSource) BuildURL(job string, buildNumber int) string {
+	deckURL, exists := prowDeckURLs[s.bucketName]
+	if !exists {
+		return ""
+	}
+	jobDir, exists := s.buildJobDirs[fmt.Sprintf("%s-%d", job, buildNumber)]
+	if !exists {
+		return ""
+	}
+	return fmt.Sprintf("%s/view/gs/%s/%s/%d", deckURL, s.bucketName, jobDir, buildNumber)
 }
 
 func ProwCommand() *cobra.Command {
@@ -242,77 +356,12 @@ func runProwReport(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("Failed to create new storage client: %v.\n", err)
 	}
 
-	reportOutputWriter, err := os.OpenFile(globalOpts.outputFile, os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil && err != os.ErrNotExist {
-		return fmt.Errorf("failed to write report: %v", err)
-	}
-
-	startOfReport := time.Now().Add(-1 * prowOpts.startFrom)
-
-	reports := []*flakefinder.JobResult{}
 	var subDirRegExp *regexp.Regexp
 	if prowOpts.matchingSubDirRegExp != "" {
 		subDirRegExp = regexp.MustCompile(prowOpts.matchingSubDirRegExp)
 	}
-	if prowOpts.periodics != "" {
-		basePath := path.Dir(prowOpts.jobDataPathes[0])
-		dirs, err := flakefinder.ListGcsObjects(ctx, storageClient, prowOpts.bucketName, basePath+"/", "/")
-		if err != nil {
-			log.Printf("failed to list objects for dataPath %v: %v", path.Base(prowOpts.jobDataPathes[0])+"/", err)
-		}
-		var realJobDataPathes []string
-		for _, dataPath := range prowOpts.jobDataPathes {
-			for _, dir := range dirs {
-				if !strings.HasPrefix(path.Join(basePath, dir), dataPath) {
-					continue
-				}
-				results, err := flakefinder.FindUnitTestFilesForPeriodicJob(ctx, storageClient, prowOpts.bucketName, []string{basePath, dir}, startOfReport, maxTime)
-				if err != nil {
-					log.Printf("failed to load JUnit files for job %v: %v", dataPath, err)
-				}
-				realJobDataPathes = append(realJobDataPathes, path.Join(basePath, dir))
-				reports = append(reports, results...)
-			}
-		}
-		prowOpts.jobDataPathes = realJobDataPathes
-	} else {
-		for _, dataPath := range prowOpts.jobDataPathes {
-			if prowOpts.useSubDirs {
-				subDirs, err := flakefinder.ListGcsObjects(ctx, storageClient, prowOpts.bucketName, dataPath+"/", "/")
-				if err != nil {
-					log.Printf("failed to list objects for dataPath %v: %v", dataPath, err)
-				}
-				for _, subDir := range subDirs {
-					if subDirRegExp != nil && !subDirRegExp.MatchString(subDir) {
-						continue
-					}
-					results, err := flakefinder.FindUnitTestFilesForPeriodicJob(ctx, storageClient, prowOpts.bucketName, []string{dataPath, subDir}, startOfReport, maxTime)
-					if err != nil {
-						log.Printf("failed to load JUnit files for job %v: %v", path.Join(dataPath, subDir), err)
-					}
-					reports = append(reports, results...)
-				}
-			} else {
-				results, err := flakefinder.FindUnitTestFilesForPeriodicJob(ctx, storageClient, prowOpts.bucketName, []string{dataPath}, startOfReport, maxTime)
-				if err != nil {
-					log.Printf("failed to load JUnit files for job %v: %v", dataPath, err)
-				}
-				reports = append(reports, results...)
-			}
-		}
-	}
+	source := newProwSource(storageClient, prowOpts.bucketName, prowOpts.jobDataPathes, prowOpts.periodics != "", prowOpts.useSubDirs, subDirRegExp)
 
-	err = writeProwReportToFile(startOfReport, reports, reportOutputWriter)
-	if err != nil {
-		log.Fatal(fmt.Errorf("failed to write report: %v", err))
-	}
-	return nil
-}
-
-func writeProwReportToFile(startOfReport time.Time, reports []*flakefinder.JobResult, reportOutputWriter *os.File) error {
-	parameters := flakefinder.CreateFlakeReportData(reports, []int{}, maxTime, prowOpts.org, prowOpts.repo, startOfReport)
-
-	log.Printf("writing output file to %s", globalOpts.outputFile)
-
-	return flakefinder.WriteTemplateToOutput(ProwReportTemplate, SimpleReportParams{parameters, prowOpts.bucketName, prowOpts.jobDataPathes}, reportOutputWriter)
+	startOfReport := time.Now().Add(-1 * prowOpts.startFrom)
+	return runReport(ctx, []Source{source}, startOfReport, globalOpts.outputFile)
 }
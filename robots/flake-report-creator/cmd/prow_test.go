@@ -11,7 +11,7 @@ import (
 	"kubevirt.io/project-infra/pkg/validation"
 )
 
-func Test_writeProwReportToFileProducesValidOutput(t *testing.T) {
+func Test_writeProwReportProducesValidOutput(t *testing.T) {
 	type args struct {
 		startOfReport time.Time
 		endOfReport   time.Time
@@ -176,12 +176,8 @@ func Test_writeProwReportToFileProducesValidOutput(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			baseReportFile := filepath.Join(tempDir, "report.html")
-			tempFile, err := os.OpenFile(baseReportFile, os.O_CREATE|os.O_WRONLY, 0644)
-			if err != nil {
-				t.Errorf("failed to create temp report file: %v", err)
-				return
-			}
-			err = writeProwReportToFile(tt.args.startOfReport, tt.args.reports, tempFile)
+			source := newProwSource(nil, "kubevirt-prow", []string{"logs/periodic-job"}, false, false, nil)
+			err := writeReport(reportParamsFor(tt.args.startOfReport, tt.args.endOfReport, source, tt.args.reports), baseReportFile)
 			if err != nil {
 				t.Errorf("failed to write report to file: %v", err)
 				return
@@ -204,7 +200,7 @@ func Test_writeProwReportToFileProducesValidOutput(t *testing.T) {
 	}
 }
 
-func Test_writeProwReportToFileCreatesTags(t *testing.T) {
+func Test_writeProwReportCreatesTags(t *testing.T) {
 	type args struct {
 		startOfReport time.Time
 		endOfReport   time.Time
@@ -271,12 +267,8 @@ func Test_writeProwReportToFileCreatesTags(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			baseReportFile := filepath.Join(tempDir, "report.html")
-			tempFile, err := os.OpenFile(baseReportFile, os.O_CREATE|os.O_WRONLY, 0644)
-			if err != nil {
-				t.Errorf("failed to create temp report file: %v", err)
-				return
-			}
-			_ = writeProwReportToFile(tt.args.startOfReport, tt.args.reports, tempFile)
+			source := newProwSource(nil, "kubevirt-prow", []string{"logs/periodic-job"}, false, false, nil)
+			_ = writeReport(reportParamsFor(tt.args.startOfReport, tt.args.endOfReport, source, tt.args.reports), baseReportFile)
 
 			content, err := os.ReadFile(baseReportFile)
 			if err != nil {
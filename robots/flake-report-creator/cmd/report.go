@@ -0,0 +1,165 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/spf13/cobra"
+)
+
+const reportShortUsage = "flake-report-creator report creates one report from the junit files of prow jobs, jenkins jobs and local directories"
+
+var (
+	reportOpts    reportOptions
+	reportCommand = &cobra.Command{
+		Use:   "report",
+		Short: reportShortUsage,
+		Long: reportShortUsage + `
+
+Each of the sources yields the job results for a set of lanes, which are rendered into the same report, i.e.
+upstream prow lanes and downstream jenkins lanes running the same tests can be compared side by side.
+
+Local junit directories need to contain a directory per job, which in turn contains a directory per build named
+after the build number, where all junit files matching the artifact file name pattern are merged:
+
+    <junitDir>/<job>/<build number>/**/<junit file>
+
+Examples:
+
+# create a report over two prow periodics and the matching jenkins jobs for the last 14 days
+$ flake-report-creator report \
+    --prowJobDataPath=logs/periodic-kubevirt-e2e-k8s-1.30-sig-compute \
+    --prowJobDataPath=logs/periodic-kubevirt-e2e-k8s-1.30-sig-storage \
+    --jenkinsJobNamePattern='^test-kubevirt-cnv-4\.16-(compute|storage)-ocs$'
+
+# create a report over a prow periodic and locally stored junit files for the last 3 days
+$ flake-report-creator report --startFrom=72h \
+    --prowJobDataPath=logs/periodic-kubevirt-e2e-k8s-1.30-sig-compute \
+    --junitDir=/tmp/junit-results
+`,
+		RunE: runReportCommand,
+	}
+)
+
+func init() {
+	reportOpts = reportOptions{}
+	reportCommand.PersistentFlags().DurationVar(&reportOpts.startFrom, "startFrom", 14*24*time.Hour, "The duration when the report data should be fetched")
+	reportCommand.PersistentFlags().StringVar(&reportOpts.prowBucketName, "prowBucketName", "kubevirt-prow", "The name of the GCS bucket for the prow jobs")
+	reportCommand.PersistentFlags().StringArrayVar(&reportOpts.prowJobDataPathes, "prowJobDataPath", nil, "Path of a prow job directory below the bucket to retrieve the data from. May occur more than once")
+	reportCommand.PersistentFlags().StringVar(&reportOpts.jenkins.endpoint, "jenkinsEndpoint", defaultJenkinsBaseUrl, "jenkins base url")
+	reportCommand.PersistentFlags().StringArrayVar(&reportOpts.jenkins.jobNamePatterns, "jenkinsJobNamePattern", nil, "jenkins job name go regex pattern to filter jobs for the report. May occur more than once")
+	reportCommand.PersistentFlags().DurationVar(&reportOpts.jenkins.startFromForRatings, "startFromForRatings", 14*24*time.Hour, "The duration when the rating data for the jenkins jobs should be fetched")
+	reportCommand.PersistentFlags().IntVar(&reportOpts.jenkins.maxConnsPerHost, "maxConnsPerHost", 5, "The maximum number of connections to the jenkins endpoint (to avoid getting rate limited)")
+	reportCommand.PersistentFlags().BoolVar(&reportOpts.jenkins.insecureSkipVerify, "insecureSkipVerify", false, "Whether the tls verification for the jenkins endpoint should be skipped (this is insecure!)")
+	reportCommand.PersistentFlags().StringArrayVar(&reportOpts.junitDirs, "junitDir", nil, "Path of a local directory containing junit files per job and build. May occur more than once")
+	reportCommand.PersistentFlags().StringVar(&reportOpts.artifactFileNameRegex, "artifactFileNamePattern", defaultArtifactFileNameRegex, "file name go regex pattern of the junit files in jenkins artifacts and local directories")
+}
+
+type reportOptions struct {
+	startFrom             time.Duration
+	prowBucketName        string
+	prowJobDataPathes     []string
+	jenkins               jenkinsOptions
+	junitDirs             []string
+	artifactFileNameRegex string
+}
+
+func (o *reportOptions) validate() error {
+	if len(o.prowJobDataPathes) == 0 && len(o.jenkins.jobNamePatterns) == 0 && len(o.junitDirs) == 0 {
+		return fmt.Errorf("no sources given, check for at least one --prowJobDataPath, --jenkinsJobNamePattern or --junitDir")
+	}
+	if o.prowBucketName == "" && len(o.prowJobDataPathes) > 0 {
+		return fmt.Errorf("--prowBucketName must be set for --prowJobDataPath")
+	}
+	if _, err := regexp.Compile(o.artifactFileNameRegex); err != nil {
+		return fmt.Errorf("invalid --artifactFileNamePattern: %v", err)
+	}
+	o.jenkins.artifactFileNameRegex = o.artifactFileNameRegex
+	return nil
+}
+
+func ReportCommand() *cobra.Command {
+	return reportCommand
+}
+
+func runReportCommand(cmd *cobra.Command, args []string) error {
+	err := cmd.InheritedFlags().Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if err = globalOpts.Validate(); err != nil {
+		_, err2 := fmt.Fprint(cmd.OutOrStderr(), cmd.UsageString(), err)
+		if err2 != nil {
+			return err2
+		}
+		return fmt.Errorf("invalid arguments provided: %v", err)
+	}
+
+	if err = reportOpts.validate(); err != nil {
+		_, err2 := fmt.Fprint(cmd.OutOrStderr(), cmd.UsageString())
+		if err2 != nil {
+			return err2
+		}
+		return fmt.Errorf("invalid arguments provided: %v", err)
+	}
+
+	ctx := context.Background()
+	sources, err := newReportSources(ctx, reportOpts)
+	if err != nil {
+		return err
+	}
+
+	startOfReport := time.Now().Add(-1 * reportOpts.startFrom)
+	return runReport(ctx, sources, startOfReport, globalOpts.outputFile)
+}
+
+// newReportSources returns the sources for the options, in the order prow, jenkins and local directories.
+func newReportSources(ctx context.Context, o reportOptions) ([]Source, error) {
+	var sources []Source
+	if len(o.prowJobDataPathes) > 0 {
+		storageClient, err := storage.NewClient(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create new storage client: %v", err)
+		}
+		sources = append(sources, newProwSource(storageClient, o.prowBucketName, o.prowJobDataPathes, false, false, nil))
+	}
+	if len(o.jenkins.jobNamePatterns) > 0 {
+		source, err := newJenkinsSource(ctx, o.jenkins)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	fileNameRegex := regexp.MustCompile(o.artifactFileNameRegex)
+	for _, junitDir := range o.junitDirs {
+		source, err := newJunitDirSource(junitDir, fileNameRegex)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
@@ -66,6 +66,7 @@ func init() {
 
 	rootCmd.AddCommand(JenkinsCommand())
 	rootCmd.AddCommand(ProwCommand())
+	rootCmd.AddCommand(ReportCommand())
 }
 
 func Execute() error {
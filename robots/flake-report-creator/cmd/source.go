@@ -0,0 +1,208 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package cmd
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"strings"
+	"time"
+
+	"kubevirt.io/project-infra/pkg/flakefinder"
+	"kubevirt.io/project-infra/pkg/flakefinder/build"
+
+	log "github.com/sirupsen/logrus"
+)
+
+//go:embed report-template.gohtml
+var reportTemplate string
+
+// Source yields the results of the junit files of the builds of a set of jobs, i.e. the lanes of the report.
+type Source interface {
+	// Description describes where the results are taken from
+	Description() string
+
+	// JobResults returns the results of all builds that have finished after startOfReport
+	JobResults(ctx context.Context, startOfReport time.Time) ([]*flakefinder.JobResult, error)
+
+	// JobURL returns the link to the overview of the job, or an empty string if there is none
+	JobURL(job string) string
+
+	// BuildURL returns the link to the build of the job, or an empty string if there is none
+	BuildURL(job string, buildNumber int) string
+}
+
+// ratingSource is implemented by sources that rate the builds of their jobs by the number of failures, see
+// build.Rating.
+type ratingSource interface {
+	Ratings() []build.Rating
+}
+
+// ReportParams are the parameters of the report template. The links to jobs and builds are resolved
+// through the source that yielded the results of the job. Since they are built from the configured endpoints,
+// they are trusted by the template, which would otherwise reject e.g. file urls of local sources.
+type ReportParams struct {
+	flakefinder.Params
+	Sources           []string
+	JobNamesToRatings map[string]build.Rating
+
+	jobsToSources map[string]Source
+}
+
+func (p ReportParams) JobURL(job string) template.URL {
+	if source, exists := p.jobsToSources[job]; exists {
+		return template.URL(source.JobURL(job))
+	}
+	return ""
+}
+
+func (p ReportParams) BuildURL(job string, buildNumber int) template.URL {
+	if source, exists := p.jobsToSources[job]; exists {
+		return template.URL(source.BuildURL(job, buildNumber))
+	}
+	return ""
+}
+
+// RatedBuildURL returns the link to the build of the job for the build numbers of a build.Rating.
+func (p ReportParams) RatedBuildURL(job string, buildNumber int64) template.URL {
+	return p.BuildURL(job, int(buildNumber))
+}
+
+// Sigma returns the rating of the build of the job, if the job has been rated.
+func (p ReportParams) Sigma(job string, buildNumber int) string {
+	rating, exists := p.JobNamesToRatings[job]
+	if !exists {
+		return ""
+	}
+	buildData, exists := rating.BuildNumbersToData[int64(buildNumber)]
+	if !exists {
+		return ""
+	}
+	return fmt.Sprintf("%.2f", buildData.Sigma)
+}
+
+// fetchJobResults returns the results of all sources together with the source per job. If a job is yielded by
+// several sources, the first source is used for the links.
+func fetchJobResults(ctx context.Context, sources []Source, startOfReport time.Time) ([]*flakefinder.JobResult, map[string]Source, error) {
+	reports := []*flakefinder.JobResult{}
+	jobsToSources := map[string]Source{}
+	for _, source := range sources {
+		log.Printf("Fetching job results from %s", source.Description())
+		results, err := source.JobResults(ctx, startOfReport)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to fetch job results from %s: %v", source.Description(), err)
+		}
+		for _, result := range results {
+			if _, exists := jobsToSources[result.Job]; !exists {
+				jobsToSources[result.Job] = source
+			}
+		}
+		reports = append(reports, results...)
+	}
+	return reports, jobsToSources, nil
+}
+
+// createReportParams aggregates the results of the jobs into the parameters of the report template.
+func createReportParams(startOfReport, endOfReport time.Time, sources []Source, reports []*flakefinder.JobResult, jobsToSources map[string]Source) ReportParams {
+	jobNamesToRatings := map[string]build.Rating{}
+	var descriptions []string
+	for _, source := range sources {
+		descriptions = append(descriptions, source.Description())
+		rs, ok := source.(ratingSource)
+		if !ok {
+			continue
+		}
+		for _, rating := range rs.Ratings() {
+			jobNamesToRatings[rating.Name] = rating
+		}
+	}
+	return ReportParams{
+		Params:            flakefinder.CreateFlakeReportData(reports, []int{}, endOfReport, "kubevirt", "kubevirt", startOfReport),
+		Sources:           descriptions,
+		JobNamesToRatings: jobNamesToRatings,
+		jobsToSources:     jobsToSources,
+	}
+}
+
+// runReport fetches the results of the sources and writes the report to outputFile, together with the json
+// data next to it.
+func runReport(ctx context.Context, sources []Source, startOfReport time.Time, outputFile string) error {
+	endOfReport := time.Now()
+	reports, jobsToSources, err := fetchJobResults(ctx, sources, startOfReport)
+	if err != nil {
+		return err
+	}
+	return writeReport(createReportParams(startOfReport, endOfReport, sources, reports, jobsToSources), outputFile)
+}
+
+func writeReport(params ReportParams, outputFile string) error {
+	log.Printf("writing output to %s", outputFile)
+	err := writeHTMLReportToOutputFile(outputFile, reportTemplate, params)
+	if err != nil {
+		return err
+	}
+	return writeJSONToOutputFile(strings.TrimSuffix(outputFile, ".html")+".json", params.Params)
+}
+
+func writeHTMLReportToOutputFile(outputFile string, reportTemplate string, params interface{}) error {
+	reportOutputWriter, err := createReportOutputWriter(outputFile)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = reportOutputWriter.Close() }()
+
+	err = flakefinder.WriteTemplateToOutput(reportTemplate, params, reportOutputWriter)
+	if err != nil {
+		return fmt.Errorf("failed to write report: %v", err)
+	}
+	return nil
+}
+
+func writeJSONToOutputFile(jsonOutputFile string, parameters flakefinder.Params) error {
+	reportOutputWriter, err := createReportOutputWriter(jsonOutputFile)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = reportOutputWriter.Close() }()
+
+	encoder := json.NewEncoder(reportOutputWriter)
+	err = encoder.Encode(
+		map[string]interface{}{
+			"data":            parameters.Data,
+			"failuresForJobs": parameters.FailuresForJobs,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to write report: %v", err)
+	}
+	return nil
+}
+
+func createReportOutputWriter(outputFile string) (*os.File, error) {
+	reportOutputWriter, err := os.OpenFile(outputFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write report: %v", err)
+	}
+	return reportOutputWriter, nil
+}
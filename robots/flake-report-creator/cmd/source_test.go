@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"kubevirt.io/project-infra/pkg/flakefinder"
+	"kubevirt.io/project-infra/pkg/validation"
+
+	"github.com/joshdk/go-junit"
+)
+
+func Test_writeReportMixesSources(t *testing.T) {
+	now := time.Now()
+	startOfReport := now.Add(-14 * 24 * time.Hour)
+
+	dir := t.TempDir()
+	writeJunitFile(t, filepath.Join(dir, "local-lane", "7", "junit.functest.xml"), junitFailedTest, now)
+	junitDir, err := newJunitDirSource(dir, regexp.MustCompile(defaultArtifactFileNameRegex))
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+
+	prow := newProwSource(nil, "kubevirt-prow", []string{"logs/periodic-kubevirt-e2e-k8s-1.30-sig-compute"}, false, false, nil)
+	prow.jobDirs["periodic-kubevirt-e2e-k8s-1.30-sig-compute"] = "logs/periodic-kubevirt-e2e-k8s-1.30-sig-compute"
+	prow.buildJobDirs["periodic-kubevirt-e2e-k8s-1.30-sig-compute-1234"] = "logs/periodic-kubevirt-e2e-k8s-1.30-sig-compute"
+	jenkins := &jenkinsSource{endpoint: "https://jenkins.example.com/"}
+
+	failedTest := []junit.Suite{
+		{
+			Name: "Tests Suite",
+			Tests: []junit.Test{
+				{Name: "test a", Classname: "Tests Suite", Status: junit.StatusFailed},
+			},
+		},
+	}
+	reports := []*flakefinder.JobResult{
+		{Job: "periodic-kubevirt-e2e-k8s-1.30-sig-compute", JUnit: failedTest, BuildNumber: 1234},
+		{Job: "test-kubevirt-cnv-4.16-compute-ocs", JUnit: failedTest, BuildNumber: 42},
+	}
+	jobsToSources := map[string]Source{
+		"periodic-kubevirt-e2e-k8s-1.30-sig-compute": prow,
+		"test-kubevirt-cnv-4.16-compute-ocs":         jenkins,
+	}
+
+	localReports, localJobsToSources, err := fetchJobResults(context.Background(), []Source{junitDir}, startOfReport)
+	if err != nil {
+		t.Fatalf("failed to fetch job results: %v", err)
+	}
+	reports = append(reports, localReports...)
+	for job, source := range localJobsToSources {
+		jobsToSources[job] = source
+	}
+
+	params := createReportParams(startOfReport, now, []Source{prow, jenkins, junitDir}, reports, jobsToSources)
+	if len(params.Headers) != 3 {
+		t.Errorf("expected a column per lane, got %v", params.Headers)
+	}
+
+	reportFile := filepath.Join(t.TempDir(), "report.html")
+	if err := writeReport(params, reportFile); err != nil {
+		t.Fatalf("failed to write report: %v", err)
+	}
+	for _, validator := range []validation.ContentValidator{validation.HTMLValidator{}, validation.JSONValidator{}} {
+		content, err := os.ReadFile(validator.GetTargetFileName(reportFile))
+		if err != nil {
+			t.Fatalf("failed to read report: %v", err)
+		}
+		if err := validator.IsValid(content); err != nil {
+			t.Errorf("failed to validate report: %v", err)
+		}
+	}
+
+	content, err := os.ReadFile(reportFile)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	for _, e := range []expectation{
+		expectContains("https://prow.ci.kubevirt.io/view/gs/kubevirt-prow/logs/periodic-kubevirt-e2e-k8s-1.30-sig-compute/1234"),
+		expectContains("https://prow.ci.kubevirt.io/job-history/gs/kubevirt-prow/logs/periodic-kubevirt-e2e-k8s-1.30-sig-compute"),
+		expectContains("https://jenkins.example.com/job/test-kubevirt-cnv-4.16-compute-ocs/42/"),
+		expectContains("file://" + filepath.Join(dir, "local-lane", "7")),
+		expectContains("jenkins https://jenkins.example.com/ jobs matching"),
+	} {
+		e.contains(string(content), t)
+	}
+}
+
+func Test_prowSourceURLs(t *testing.T) {
+	source := newProwSource(nil, "kubevirt-prow", []string{"pr-logs/pull/kubevirt_kubevirt/6812"}, false, true, nil)
+	source.jobDirs["pull-kubevirt-e2e-k8s-1.30-sig-compute"] = "pr-logs/pull/kubevirt_kubevirt/6812/pull-kubevirt-e2e-k8s-1.30-sig-compute"
+	source.buildJobDirs["pull-kubevirt-e2e-k8s-1.30-sig-compute-17"] = "pr-logs/pull/kubevirt_kubevirt/6812/pull-kubevirt-e2e-k8s-1.30-sig-compute"
+
+	tests := []struct {
+		name     string
+		actual   string
+		expected string
+	}{
+		{
+			name:     "job history of presubmit",
+			actual:   source.JobURL("pull-kubevirt-e2e-k8s-1.30-sig-compute"),
+			expected: "https://prow.ci.kubevirt.io/job-history/gs/kubevirt-prow/pr-logs/directory/pull-kubevirt-e2e-k8s-1.30-sig-compute",
+		},
+		{
+			name:     "build of presubmit",
+			actual:   source.BuildURL("pull-kubevirt-e2e-k8s-1.30-sig-compute", 17),
+			expected: "https://prow.ci.kubevirt.io/view/gs/kubevirt-prow/pr-logs/pull/kubevirt_kubevirt/6812/pull-kubevirt-e2e-k8s-1.30-sig-compute/17",
+		},
+		{
+			name:     "unknown build",
+			actual:   source.BuildURL("pull-kubevirt-e2e-k8s-1.30-sig-compute", 18),
+			expected: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.actual != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, tt.actual)
+			}
+		})
+	}
+}
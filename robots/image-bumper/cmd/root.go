@@ -20,58 +20,91 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 
 	"kubevirt.io/project-infra/pkg/imagebump"
 
+	"cloud.google.com/go/storage"
 	"github.com/spf13/cobra"
 )
 
-var repoRoot string
+var (
+	repoRoot   string
+	pinDigests bool
+
+	rolloutPolicyPath string
+
+	prDescriptionOutputFile string
+	prDescriptionHeadline   string
+
+	changelog *imagebump.Changelog
+)
 
 func Execute() error {
 	rootCmd := &cobra.Command{
 		Use:   "bump",
 		Short: "Bump kubevirtci and related container image references in project-infra",
+		PersistentPreRun: func(_ *cobra.Command, _ []string) {
+			changelog = imagebump.NewChangelog(repoRoot)
+		},
+		PersistentPostRunE: func(_ *cobra.Command, _ []string) error {
+			if prDescriptionOutputFile == "" {
+				return nil
+			}
+			return changelog.WritePRDescriptionToFile(prDescriptionOutputFile, prDescriptionHeadline)
+		},
 	}
 	rootCmd.PersistentFlags().StringVar(&repoRoot, "repo-root", ".", "path to the project-infra git checkout")
+	rootCmd.PersistentFlags().BoolVar(&pinDigests, "pin-digests", false, "pin images to the digest of the tag, i.e. image:tag@sha256:digest")
+	rootCmd.PersistentFlags().StringVar(&rolloutPolicyPath, "rollout-policy", "", "if set, roll out job images in stages as configured in this policy file: canary jobs first, required presubmits after the canary jobs soaked successfully")
+	rootCmd.PersistentFlags().StringVar(&prDescriptionOutputFile, "pr-description-output-file", "", "if set, write a Markdown PR description listing the bumped images to this file")
+	rootCmd.PersistentFlags().StringVar(&prDescriptionHeadline, "pr-description-headline", "Bump prow-deploy images", "first line of the PR description, used as PR title")
 
 	jobImagesCmd := &cobra.Command{
 		Use:   "job-images",
 		Short: "Update kubevirtci images in prow job YAML under github/ci/prow-deploy/files/jobs",
 		RunE: func(_ *cobra.Command, _ []string) error {
-			return imagebump.BumpJobImages(repoRoot)
+			tags, err := resolveTags()
+			if err != nil {
+				return err
+			}
+			return bumpJobImages(tags)
 		},
 	}
 	deploymentImagesCmd := &cobra.Command{
 		Use:   "prow-deployment-images",
 		Short: "Update kubevirtci images in prow kustom deployment YAML",
 		RunE: func(_ *cobra.Command, _ []string) error {
-			return imagebump.BumpProwDeploymentImages(repoRoot)
+			tags, err := resolveTags()
+			if err != nil {
+				return err
+			}
+			return imagebump.BumpProwDeploymentImagesWithTagMap(repoRoot, tags, changelog)
 		},
 	}
 	containerfileCmd := &cobra.Command{
 		Use:   "containerfile-images",
-		Short: "Update FROM lines using quay.io tags (tracked Containerfiles/Dockerfiles)",
+		Short: "Update FROM lines using the latest registry tags (tracked Containerfiles/Dockerfiles)",
 		RunE: func(_ *cobra.Command, _ []string) error {
-			return imagebump.BumpContainerfileImages(repoRoot)
+			return imagebump.BumpContainerfileImages(repoRoot, pinDigests, changelog)
 		},
 	}
 	allCmd := &cobra.Command{
 		Use:   "all",
 		Short: "Run job-images, prow-deployment-images, and containerfile-images bumps",
 		RunE: func(_ *cobra.Command, _ []string) error {
-			tags, err := imagebump.ResolveKubevirtCITagMap(repoRoot)
+			tags, err := resolveTags()
 			if err != nil {
-				return fmt.Errorf("resolve kubevirtci tags: %w", err)
+				return err
 			}
-			if err := imagebump.BumpJobImagesWithTagMap(repoRoot, tags); err != nil {
+			if err := bumpJobImages(tags); err != nil {
 				return fmt.Errorf("job-images: %w", err)
 			}
-			if err := imagebump.BumpProwDeploymentImagesWithTagMap(repoRoot, tags); err != nil {
+			if err := imagebump.BumpProwDeploymentImagesWithTagMap(repoRoot, tags, changelog); err != nil {
 				return fmt.Errorf("prow-deployment-images: %w", err)
 			}
-			if err := imagebump.BumpContainerfileImages(repoRoot); err != nil {
+			if err := imagebump.BumpContainerfileImages(repoRoot, pinDigests, changelog); err != nil {
 				return fmt.Errorf("containerfile-images: %w", err)
 			}
 			return nil
@@ -82,3 +115,38 @@ func Execute() error {
 
 	return rootCmd.Execute()
 }
+
+// resolveTags returns the latest kubevirtci tags, pinned to their digests if requested.
+func resolveTags() (map[string]string, error) {
+	tags, err := imagebump.ResolveKubevirtCITagMap(repoRoot)
+	if err != nil {
+		return nil, fmt.Errorf("resolve kubevirtci tags: %w", err)
+	}
+	if !pinDigests {
+		return tags, nil
+	}
+	tags, err = imagebump.PinDigests(tags)
+	if err != nil {
+		return nil, fmt.Errorf("pin kubevirtci digests: %w", err)
+	}
+	return tags, nil
+}
+
+// bumpJobImages bumps the job images either all at once or, if a rollout
+// policy is given, in stages.
+func bumpJobImages(tags map[string]string) error {
+	if rolloutPolicyPath == "" {
+		return imagebump.BumpJobImagesWithTagMap(repoRoot, tags, changelog)
+	}
+	policy, err := imagebump.LoadRolloutPolicy(rolloutPolicyPath)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return fmt.Errorf("create gcs client: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+	return imagebump.RolloutJobImages(repoRoot, tags, policy, imagebump.NewGCSJobRunSource(ctx, client, policy.Bucket), changelog)
+}
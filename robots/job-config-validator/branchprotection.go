@@ -0,0 +1,209 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+const (
+	checkOrphanedContext    = "orphaned-required-context"
+	checkUnreachableContext = "unreachable-required-context"
+
+	sourceProwConfig = "prow config"
+	sourceGitHub     = "github"
+)
+
+// branchProtectionClient provides the branch protection that is in effect on
+// GitHub.
+type branchProtectionClient interface {
+	GetBranches(org, repo string, onlyProtected bool) ([]github.Branch, error)
+	GetBranchProtection(org, repo, branch string) (*github.BranchProtection, error)
+}
+
+// checkBranchProtection compares the contexts required per org/repo/branch,
+// as computed from prow's branch-protection config and, if a client is given,
+// as set on GitHub, with the presubmits reporting them.
+//
+// A required context is orphaned if no presubmit reports it, regardless of
+// whether it is required by the branch-protection config or on GitHub, since
+// either is left behind when a job is removed. Only the contexts in
+// externalContexts are expected to be reported by systems other than prow.
+// A required context is unreachable if the presubmits reporting it don't do
+// so on every PR, i.e. they are optional, skip reporting, don't run on the
+// branch or only run conditionally. Jobs that need to be triggered manually
+// count as reporting if the branch requires manually triggered jobs.
+func checkBranchProtection(c *config.Config, client branchProtectionClient, externalContexts []string) ([]Finding, error) {
+	var findings []Finding
+	for _, org := range sortedKeys(c.BranchProtection.Orgs) {
+		orgConfig := c.BranchProtection.GetOrg(org)
+		repos := sortedKeys(c.BranchProtection.Orgs[org].Repos)
+		for _, repo := range repos {
+			orgRepo := org + "/" + repo
+			repoConfig := orgConfig.GetRepo(repo)
+			branches, err := protectedBranches(client, org, repo, repoConfig)
+			if err != nil {
+				return nil, err
+			}
+			presubmits := c.GetPresubmitsStatic(orgRepo)
+			for _, branch := range branches {
+				branchConfig, err := repoConfig.GetBranch(branch)
+				if err != nil {
+					return nil, fmt.Errorf("%s=%s: %w", orgRepo, branch, err)
+				}
+				if branchConfig.Unmanaged != nil && *branchConfig.Unmanaged {
+					continue
+				}
+				required, requireManuallyTriggeredJobs, err := requiredContexts(c, client, org, repo, branch, branchConfig, presubmits)
+				if err != nil {
+					return nil, err
+				}
+				for _, context := range sortedKeys(required) {
+					jobs := presubmitsWithContext(presubmits, context)
+					sources := strings.Join(required[context], ", ")
+					if len(jobs) == 0 {
+						if contains(externalContexts, context) {
+							continue
+						}
+						findings = append(findings, Finding{
+							Check:   checkOrphanedContext,
+							Message: fmt.Sprintf("context %q is required on %s=%s (%s) but no presubmit reports it", context, orgRepo, branch, sources),
+						})
+						continue
+					}
+					if reason := unreachableReason(jobs, branch, requireManuallyTriggeredJobs); reason != "" {
+						findings = append(findings, Finding{
+							Check:   checkUnreachableContext,
+							Job:     jobs[0].Name,
+							Message: fmt.Sprintf("context %q is required on %s=%s (%s) but is not reported on every PR: %s", context, orgRepo, branch, sources, reason),
+						})
+					}
+				}
+			}
+		}
+	}
+	return findings, nil
+}
+
+// protectedBranches returns the branches configured for the repository
+// together with the branches protected on GitHub.
+func protectedBranches(client branchProtectionClient, org, repo string, repoConfig *config.Repo) ([]string, error) {
+	branches := sortedKeys(repoConfig.Branches)
+	if client == nil {
+		return branches, nil
+	}
+	githubBranches, err := client.GetBranches(org, repo, true)
+	if err != nil {
+		return nil, fmt.Errorf("could not get protected branches of %s/%s: %w", org, repo, err)
+	}
+	for _, branch := range githubBranches {
+		if !contains(branches, branch.Name) {
+			branches = append(branches, branch.Name)
+		}
+	}
+	sort.Strings(branches)
+	return branches, nil
+}
+
+// requiredContexts returns the required contexts with the sources requiring
+// them, and whether manually triggered jobs are required.
+func requiredContexts(c *config.Config, client branchProtectionClient, org, repo, branch string, branchConfig *config.Branch, presubmits []config.Presubmit) (map[string][]string, bool, error) {
+	required := map[string][]string{}
+	policy, err := c.GetPolicy(org, repo, branch, *branchConfig, presubmits, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s/%s=%s: %w", org, repo, branch, err)
+	}
+	requireManuallyTriggeredJobs := false
+	if policy != nil {
+		requireManuallyTriggeredJobs = policy.RequireManuallyTriggeredJobs != nil && *policy.RequireManuallyTriggeredJobs
+		if policy.RequiredStatusChecks != nil {
+			for _, context := range policy.RequiredStatusChecks.Contexts {
+				required[context] = append(required[context], sourceProwConfig)
+			}
+		}
+	}
+	if client == nil {
+		return required, requireManuallyTriggeredJobs, nil
+	}
+	protection, err := client.GetBranchProtection(org, repo, branch)
+	if err != nil {
+		return nil, false, fmt.Errorf("could not get branch protection of %s/%s=%s: %w", org, repo, branch, err)
+	}
+	if protection != nil && protection.RequiredStatusChecks != nil {
+		for _, context := range protection.RequiredStatusChecks.Contexts {
+			required[context] = append(required[context], sourceGitHub)
+		}
+	}
+	return required, requireManuallyTriggeredJobs, nil
+}
+
+func presubmitsWithContext(presubmits []config.Presubmit, context string) []config.Presubmit {
+	var result []config.Presubmit
+	for _, presubmit := range presubmits {
+		if presubmit.Context == context {
+			result = append(result, presubmit)
+		}
+	}
+	return result
+}
+
+// unreachableReason returns why none of the jobs reports its context on every
+// PR against the branch, or an empty string if any of them does.
+func unreachableReason(jobs []config.Presubmit, branch string, requireManuallyTriggeredJobs bool) string {
+	var reasons []string
+	for _, job := range jobs {
+		var reason string
+		switch {
+		case job.SkipReport:
+			reason = "skip_report is set"
+		case !job.CouldRun(branch):
+			reason = "it does not run on the branch"
+		case job.Optional:
+			reason = "it is optional"
+		case job.NeedsExplicitTrigger():
+			if requireManuallyTriggeredJobs {
+				return ""
+			}
+			reason = "it only runs if triggered manually"
+		case job.TriggersConditionally():
+			reason = "it only runs if matching files are changed"
+		default:
+			return ""
+		}
+		if !contains(reasons, reason) {
+			reasons = append(reasons, reason)
+		}
+	}
+	return strings.Join(reasons, ", ")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+var fakeGitHubBranchesPath = regexp.MustCompile(`^/repos/([^/]+)/([^/]+)/branches(?:/([^/]+)/protection)?$`)
+
+// fakeGitHubBranchProtection serves the branch protection endpoints of the
+// GitHub API from the required contexts per org/repo and branch.
+type fakeGitHubBranchProtection struct {
+	*httptest.Server
+	requiredContexts map[string]map[string][]string
+}
+
+func newFakeGitHubBranchProtection(requiredContexts map[string]map[string][]string) *fakeGitHubBranchProtection {
+	f := &fakeGitHubBranchProtection{requiredContexts: requiredContexts}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.handle))
+	return f
+}
+
+func (f *fakeGitHubBranchProtection) handle(w http.ResponseWriter, r *http.Request) {
+	matches := fakeGitHubBranchesPath.FindStringSubmatch(r.URL.Path)
+	if matches == nil {
+		http.NotFound(w, r)
+		return
+	}
+	branches := f.requiredContexts[matches[1]+"/"+matches[2]]
+	w.Header().Set("Content-Type", "application/json")
+	if matches[3] == "" {
+		var protectedBranches []github.Branch
+		for _, branch := range sortedKeys(branches) {
+			protectedBranches = append(protectedBranches, github.Branch{Name: branch, Protected: true})
+		}
+		_ = json.NewEncoder(w).Encode(protectedBranches)
+		return
+	}
+	contexts, protected := branches[matches[3]]
+	if !protected {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(map[string]string{"message": "Branch not protected"})
+		return
+	}
+	_ = json.NewEncoder(w).Encode(github.BranchProtection{
+		RequiredStatusChecks: &github.RequiredStatusChecks{Contexts: contexts},
+	})
+}
+
+func (f *fakeGitHubBranchProtection) client() github.Client {
+	client, err := github.NewClient(func() []byte { return []byte("token") }, func(content []byte) []byte { return content }, f.URL+"/graphql", f.URL)
+	Expect(err).ToNot(HaveOccurred())
+	return client
+}
+
+var _ = Describe("branch protection", func() {
+
+	var prowConfig *config.Config
+
+	BeforeEach(func() {
+		var err error
+		prowConfig, err = config.Load("testdata/branchprotection/config.yaml", "testdata/branchprotection/jobs", nil, "")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("checks the contexts required by the prow config", func() {
+		Expect(checkBranchProtection(prowConfig, nil, []string{"external-ci"})).To(BeEmpty())
+	})
+
+	It("reports contexts required by the prow config that no presubmit reports", func() {
+		findings, err := checkBranchProtection(prowConfig, nil, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(findings).To(Equal([]Finding{
+			{
+				Check:   checkOrphanedContext,
+				Message: `context "external-ci" is required on kubevirt/test=main (prow config) but no presubmit reports it`,
+			},
+		}))
+	})
+
+	It("reports orphaned and unreachable contexts required on GitHub", func() {
+		fakeGitHub := newFakeGitHubBranchProtection(map[string]map[string][]string{
+			"kubevirt/test": {
+				"main":        {"pull-required", "pull-optional", "pull-conditional", "pull-removed", "external-ci", "dco"},
+				"release-0.1": {"pull-removed"},
+				"release-0.2": {"pull-required", "pull-manual", "pull-main-only"},
+			},
+		})
+		defer fakeGitHub.Close()
+
+		findings, err := checkBranchProtection(prowConfig, fakeGitHub.client(), []string{"external-ci", "dco"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(findings).To(Equal([]Finding{
+			{
+				Check:   checkUnreachableContext,
+				Job:     "pull-conditional",
+				Message: `context "pull-conditional" is required on kubevirt/test=main (github) but is not reported on every PR: it only runs if matching files are changed`,
+			},
+			{
+				Check:   checkUnreachableContext,
+				Job:     "pull-optional",
+				Message: `context "pull-optional" is required on kubevirt/test=main (github) but is not reported on every PR: it is optional`,
+			},
+			{
+				Check:   checkOrphanedContext,
+				Message: `context "pull-removed" is required on kubevirt/test=main (github) but no presubmit reports it`,
+			},
+			{
+				Check:   checkUnreachableContext,
+				Job:     "pull-main-only",
+				Message: `context "pull-main-only" is required on kubevirt/test=release-0.2 (github) but is not reported on every PR: it does not run on the branch`,
+			},
+			{
+				Check:   checkUnreachableContext,
+				Job:     "pull-manual",
+				Message: `context "pull-manual" is required on kubevirt/test=release-0.2 (github) but is not reported on every PR: it only runs if triggered manually`,
+			},
+		}))
+	})
+
+	It("accepts manually triggered jobs if the branch requires them", func() {
+		prowConfig.BranchProtection.Orgs["kubevirt"].Repos["test"].Branches["main"] = config.Branch{Policy: config.Policy{
+			Protect:                      &[]bool{true}[0],
+			RequireManuallyTriggeredJobs: &[]bool{true}[0],
+		}}
+		fakeGitHub := newFakeGitHubBranchProtection(map[string]map[string][]string{
+			"kubevirt/test": {"main": {"pull-manual"}},
+		})
+		defer fakeGitHub.Close()
+
+		Expect(checkBranchProtection(prowConfig, fakeGitHub.client(), []string{"external-ci"})).To(BeEmpty())
+	})
+})
@@ -21,7 +21,9 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
+	"fmt"
 	"io/fs"
 	"os"
 	"os/user"
@@ -34,6 +36,9 @@ import (
 	"k8s.io/client-go/kubernetes"
 	restclient "k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/prow/pkg/config/secret"
+	"sigs.k8s.io/prow/pkg/github"
 )
 
 func flagOptions() options {
@@ -41,10 +46,96 @@ func flagOptions() options {
 	flag.StringVar(&o.configMapName, "config-map-name", "job-config", "The name of the config map that contains the job config files")
 	flag.StringVar(&o.configMapNameSpace, "config-map-namespace", "kubevirt-prow", "The namespace of the config map that contains the job config files")
 	flag.StringVar(&o.jobConfigPath, "job-config-path", "github/ci/prow-deploy/files/jobs/kubevirt/kubevirt", "The path to the job configuration files that need to be present")
+	flag.BoolVar(&o.offline, "offline", false, "Validate the job configuration semantically without accessing the cluster, use with --job-config-path=github/ci/prow-deploy/files/jobs")
+	flag.StringVar(&o.prowConfigPath, "prow-config-path", "github/ci/prow-deploy/files/config.yaml", "The path to the prow configuration (offline mode)")
+	flag.Var(&o.knownClusters, "known-cluster", "A cluster jobs may be scheduled on, may be given multiple times (offline mode)")
+	flag.Var(&o.allowedRegistries, "allowed-registry", "A registry (or registry/organization) job images may be pulled from, may be given multiple times (offline mode)")
+	flag.Var(&o.unpinnedImages, "unpinned-image", "An image, without tag, that jobs may use without pinning it to a tag or digest, may be given multiple times (offline mode)")
+	flag.Var(&o.repoCheckouts, "repo-checkout", "A checkout of a repository in the form org/repo=path, used to check that run_if_changed matches any file, may be given multiple times (offline mode)")
+	flag.Var(&o.externalContexts, "external-context", "A required context that is reported by a system other than prow, may be given multiple times (offline and branch protection mode)")
+	flag.StringVar(&o.outputFile, "output-file", "", "The file to write the findings to as JSON, stdout if empty (offline and branch protection mode)")
+	flag.BoolVar(&o.branchProtection, "branch-protection", false, "Compare the contexts required by branch protection on GitHub and in the prow config with the presubmits reporting them, use with --job-config-path=github/ci/prow-deploy/files/jobs")
+	flag.StringVar(&o.githubTokenPath, "github-token-path", "/etc/github/oauth", "Path to the file containing the GitHub OAuth secret (branch protection mode)")
+	flag.StringVar(&o.githubEndpoint, "github-endpoint", "https://api.github.com/", "GitHub's API endpoint (branch protection mode)")
 	flag.Parse()
+	if len(o.knownClusters) == 0 {
+		o.knownClusters = defaultKnownClusters
+	}
+	if len(o.allowedRegistries) == 0 {
+		o.allowedRegistries = defaultAllowedRegistries
+	}
+	if len(o.unpinnedImages) == 0 {
+		o.unpinnedImages = defaultUnpinnedImages
+	}
+	if len(o.externalContexts) == 0 {
+		o.externalContexts = defaultExternalContexts
+	}
 	return o
 }
 
+var (
+	defaultKnownClusters = stringList{
+		"kubevirt-prow-control-plane",
+		"prow-workloads",
+		"prow-arm64-workloads",
+		"prow-s390x-workloads",
+		"prow-hyperv-workloads",
+		"amd-workloads",
+	}
+	defaultAllowedRegistries = stringList{
+		"quay.io/kubevirtci",
+		"quay.io/kubevirt",
+		"gcr.io/k8s-testimages",
+		"gcr.io/k8s-staging-test-infra",
+		"us-docker.pkg.dev/k8s-infra-prow",
+		"registry.ci.openshift.org/ci",
+	}
+	// images that are only published as latest
+	defaultUnpinnedImages = stringList{
+		"registry.ci.openshift.org/ci/ipi-deprovision",
+	}
+	// contexts reported by the DCO app, by OpenShift CI and by Travis CI
+	defaultExternalContexts = stringList{
+		"dco",
+		"ci/prow/images",
+		"ci/prow/openshift-e2e",
+		"continuous-integration/travis-ci/pr",
+	}
+)
+
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+type repoCheckouts map[string]string
+
+func (r *repoCheckouts) String() string {
+	var values []string
+	for repo, checkout := range *r {
+		values = append(values, repo+"="+checkout)
+	}
+	return strings.Join(values, ",")
+}
+
+func (r *repoCheckouts) Set(value string) error {
+	repo, checkout, found := strings.Cut(value, "=")
+	if !found || !strings.Contains(repo, "/") || checkout == "" {
+		return fmt.Errorf("expected org/repo=path, got %q", value)
+	}
+	if *r == nil {
+		*r = repoCheckouts{}
+	}
+	(*r)[repo] = checkout
+	return nil
+}
+
 func (o *options) validate() error {
 	_, err := os.Stat(o.jobConfigPath)
 	if err == os.ErrNotExist {
@@ -58,6 +149,19 @@ type options struct {
 	configMapName      string
 	jobConfigPath      string
 	configMapNameSpace string
+
+	offline           bool
+	prowConfigPath    string
+	knownClusters     stringList
+	allowedRegistries stringList
+	unpinnedImages    stringList
+	repoCheckouts     repoCheckouts
+	externalContexts  stringList
+	outputFile        string
+
+	branchProtection bool
+	githubTokenPath  string
+	githubEndpoint   string
 }
 
 func main() {
@@ -71,6 +175,53 @@ func main() {
 		log.Fatalf("Arguments invalid: %v", err)
 	}
 
+	if opts.offline {
+		findings, err := validateOffline(offlineOptions{
+			prowConfigPath:    opts.prowConfigPath,
+			jobConfigPath:     opts.jobConfigPath,
+			knownClusters:     opts.knownClusters,
+			allowedRegistries: opts.allowedRegistries,
+			unpinnedImages:    opts.unpinnedImages,
+			repoCheckouts:     opts.repoCheckouts,
+			externalContexts:  opts.externalContexts,
+		})
+		if err != nil {
+			log.Fatalf("Failed to validate job config: %v", err)
+		}
+		if err := writeFindings(opts.outputFile, findings); err != nil {
+			log.Fatalf("Failed to write findings: %v", err)
+		}
+		if len(findings) > 0 {
+			log.Fatalf("Job config has %d findings", len(findings))
+		}
+		return
+	}
+
+	if opts.branchProtection {
+		prowConfig, err := config.Load(opts.prowConfigPath, opts.jobConfigPath, nil, "")
+		if err != nil {
+			log.Fatalf("Failed to load prow config: %v", err)
+		}
+		if err := secret.Add(opts.githubTokenPath); err != nil {
+			log.Fatalf("Failed to start secrets agent: %v", err)
+		}
+		githubClient, err := github.NewClient(secret.GetTokenGenerator(opts.githubTokenPath), secret.Censor, github.DefaultGraphQLEndpoint, opts.githubEndpoint)
+		if err != nil {
+			log.Fatalf("Failed to create GitHub client: %v", err)
+		}
+		findings, err := checkBranchProtection(prowConfig, githubClient, opts.externalContexts)
+		if err != nil {
+			log.Fatalf("Failed to check branch protection: %v", err)
+		}
+		if err := writeFindings(opts.outputFile, findings); err != nil {
+			log.Fatalf("Failed to write findings: %v", err)
+		}
+		if len(findings) > 0 {
+			log.Fatalf("Branch protection has %d findings", len(findings))
+		}
+		return
+	}
+
 	clientset, err := NewClientset()
 	if err != nil {
 		log.Fatalf("Failed to create clientset: %v", err)
@@ -112,6 +263,21 @@ func main() {
 	}
 }
 
+func writeFindings(outputFile string, findings []Finding) error {
+	if findings == nil {
+		findings = []Finding{}
+	}
+	output, err := json.MarshalIndent(findings, "", "  ")
+	if err != nil {
+		return err
+	}
+	if outputFile == "" {
+		_, err = fmt.Println(string(output))
+		return err
+	}
+	return os.WriteFile(outputFile, output, 0644)
+}
+
 func NewClientset() (*kubernetes.Clientset, error) {
 	config, err := GetConfig()
 	if err != nil {
@@ -0,0 +1,368 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+const (
+	checkLoad              = "load"
+	checkDuplicateJobName  = "duplicate-job-name"
+	checkUnknownCluster    = "unknown-cluster"
+	checkUndefinedPreset   = "undefined-preset"
+	checkImageRegistry     = "image-registry"
+	checkImageNotPinned    = "image-not-pinned"
+	checkMissingTimeout    = "missing-timeout"
+	checkRunIfChangedMatch = "run-if-changed"
+
+	// defaultCluster is the cluster prow schedules jobs on that don't set one
+	defaultCluster = "default"
+)
+
+// Finding is a problem detected in the job configuration.
+type Finding struct {
+	Check   string `json:"check"`
+	File    string `json:"file,omitempty"`
+	Job     string `json:"job,omitempty"`
+	Message string `json:"message"`
+}
+
+// jobEntry is a job as it is written in a job config file, i.e. without the
+// defaults prow applies when loading the configuration.
+type jobEntry struct {
+	file    string
+	repo    string
+	jobType prowv1.ProwJobType
+	job     config.JobBase
+
+	branches     []string
+	runIfChanged string
+}
+
+type offlineOptions struct {
+	prowConfigPath    string
+	jobConfigPath     string
+	knownClusters     []string
+	allowedRegistries []string
+	unpinnedImages    []string
+	externalContexts  []string
+	// repoCheckouts maps org/repo to the path of a checkout of the repository
+	repoCheckouts map[string]string
+}
+
+// validateOffline checks the job configuration without accessing the cluster.
+func validateOffline(o offlineOptions) ([]Finding, error) {
+	prowConfig, err := config.Load(o.prowConfigPath, "", nil, "")
+	if err != nil {
+		return nil, fmt.Errorf("could not load prow config %q: %w", o.prowConfigPath, err)
+	}
+	entries, presets, err := readJobEntries(o.jobConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	presets = append(presets, prowConfig.Presets...)
+
+	repoFiles := map[string][]string{}
+	for repo, checkout := range o.repoCheckouts {
+		files, err := gitLsFiles(checkout)
+		if err != nil {
+			return nil, fmt.Errorf("could not list files of %s in %q: %w", repo, checkout, err)
+		}
+		repoFiles[repo] = files
+	}
+
+	var findings []Finding
+	findings = append(findings, checkDuplicateJobNames(entries)...)
+	for _, entry := range entries {
+		findings = append(findings, checkCluster(entry, o.knownClusters)...)
+		findings = append(findings, checkPresets(entry, presets)...)
+		findings = append(findings, checkImages(entry, o.allowedRegistries, o.unpinnedImages)...)
+		findings = append(findings, checkTimeout(entry, prowConfig)...)
+		findings = append(findings, checkRunIfChanged(entry, repoFiles)...)
+	}
+
+	// the remaining checks require the configuration as prow sees it
+	fullConfig, err := config.Load(o.prowConfigPath, o.jobConfigPath, nil, "")
+	if err != nil {
+		findings = append(findings, Finding{Check: checkLoad, Message: err.Error()})
+	} else {
+		branchProtectionFindings, err := checkBranchProtection(fullConfig, nil, o.externalContexts)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, branchProtectionFindings...)
+	}
+	return findings, nil
+}
+
+// readJobEntries reads all job config files below the path, together with
+// the presets defined in them.
+func readJobEntries(jobConfigPath string) ([]jobEntry, []config.Preset, error) {
+	var entries []jobEntry
+	var presets []config.Preset
+	err := filepath.WalkDir(jobConfigPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".yaml") {
+			return nil
+		}
+		jobConfig, err := config.ReadJobConfig(path)
+		if err != nil {
+			return fmt.Errorf("could not read job config %q: %w", path, err)
+		}
+		relPath, err := filepath.Rel(jobConfigPath, path)
+		if err != nil {
+			return err
+		}
+		presets = append(presets, jobConfig.Presets...)
+		for repo, presubmits := range jobConfig.PresubmitsStatic {
+			for _, presubmit := range presubmits {
+				entries = append(entries, jobEntry{
+					file: relPath, repo: repo, jobType: prowv1.PresubmitJob, job: presubmit.JobBase,
+					branches: presubmit.Branches, runIfChanged: presubmit.RunIfChanged,
+				})
+			}
+		}
+		for repo, postsubmits := range jobConfig.PostsubmitsStatic {
+			for _, postsubmit := range postsubmits {
+				entries = append(entries, jobEntry{
+					file: relPath, repo: repo, jobType: prowv1.PostsubmitJob, job: postsubmit.JobBase,
+					branches: postsubmit.Branches, runIfChanged: postsubmit.RunIfChanged,
+				})
+			}
+		}
+		for _, periodic := range jobConfig.Periodics {
+			entries = append(entries, jobEntry{file: relPath, jobType: prowv1.PeriodicJob, job: periodic.JobBase})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].file != entries[j].file {
+			return entries[i].file < entries[j].file
+		}
+		return entries[i].job.Name < entries[j].job.Name
+	})
+	return entries, presets, nil
+}
+
+// checkDuplicateJobNames reports jobs of the same type and repository that
+// share a name and run on the same branches, which prow would reject.
+func checkDuplicateJobNames(entries []jobEntry) []Finding {
+	files := map[string][]string{}
+	var keys []string
+	for _, entry := range entries {
+		branches := append([]string{}, entry.branches...)
+		sort.Strings(branches)
+		key := strings.Join([]string{string(entry.jobType), entry.repo, entry.job.Name, strings.Join(branches, ",")}, "|")
+		if _, exists := files[key]; !exists {
+			keys = append(keys, key)
+		}
+		files[key] = append(files[key], entry.file)
+	}
+	var findings []Finding
+	for _, key := range keys {
+		if len(files[key]) < 2 {
+			continue
+		}
+		parts := strings.Split(key, "|")
+		findings = append(findings, Finding{
+			Check:   checkDuplicateJobName,
+			File:    files[key][0],
+			Job:     parts[2],
+			Message: fmt.Sprintf("%s job is defined %d times, in %s", parts[0], len(files[key]), strings.Join(files[key], ", ")),
+		})
+	}
+	return findings
+}
+
+func checkCluster(entry jobEntry, knownClusters []string) []Finding {
+	cluster := entry.job.Cluster
+	if cluster == "" {
+		cluster = defaultCluster
+	}
+	for _, known := range knownClusters {
+		if cluster == known {
+			return nil
+		}
+	}
+	return []Finding{entry.finding(checkUnknownCluster, "cluster %q is unknown", cluster)}
+}
+
+// checkPresets reports preset labels no preset is defined for. Labels set to
+// "false" opt out of a preset and thus are not checked.
+func checkPresets(entry jobEntry, presets []config.Preset) []Finding {
+	var findings []Finding
+	for _, key := range sortedKeys(entry.job.Labels) {
+		if !strings.HasPrefix(key, "preset-") || entry.job.Labels[key] == "false" {
+			continue
+		}
+		if !isPresetDefined(key, entry.job.Labels[key], presets) {
+			findings = append(findings, entry.finding(checkUndefinedPreset, "preset %s: %q is not defined", key, entry.job.Labels[key]))
+		}
+	}
+	return findings
+}
+
+func isPresetDefined(key, value string, presets []config.Preset) bool {
+	for _, preset := range presets {
+		if presetValue, exists := preset.Labels[key]; exists && presetValue == value {
+			return true
+		}
+	}
+	return false
+}
+
+// checkImages reports images from registries that aren't allowed, or that
+// aren't pinned to a tag other than latest or a digest, unless the image may
+// be used unpinned.
+func checkImages(entry jobEntry, allowedRegistries, unpinnedImages []string) []Finding {
+	if entry.job.Spec == nil {
+		return nil
+	}
+	var containers []v1.Container
+	containers = append(containers, entry.job.Spec.InitContainers...)
+	containers = append(containers, entry.job.Spec.Containers...)
+	var findings []Finding
+	for _, container := range containers {
+		if !isFromAllowedRegistry(container.Image, allowedRegistries) {
+			findings = append(findings, entry.finding(checkImageRegistry, "image %q is not from an allowed registry", container.Image))
+		}
+		if !isPinned(container.Image) && !slices.Contains(unpinnedImages, imageName(container.Image)) {
+			findings = append(findings, entry.finding(checkImageNotPinned, "image %q is not pinned to a tag or digest", container.Image))
+		}
+	}
+	return findings
+}
+
+func isFromAllowedRegistry(image string, allowedRegistries []string) bool {
+	for _, registry := range allowedRegistries {
+		if strings.HasPrefix(image, strings.TrimSuffix(registry, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func isPinned(image string) bool {
+	if strings.Contains(image, "@sha256:") {
+		return true
+	}
+	name := image[strings.LastIndex(image, "/")+1:]
+	_, tag, found := strings.Cut(name, ":")
+	return found && tag != "" && tag != "latest"
+}
+
+// imageName returns the image without tag or digest.
+func imageName(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i]
+	}
+	return image
+}
+
+// checkTimeout reports decorated jobs that have no timeout, neither set
+// explicitly nor by the default decoration configs of plank that match the
+// repository and cluster of the job.
+func checkTimeout(entry jobEntry, prowConfig *config.Config) []Finding {
+	decorated := prowConfig.DecorateAllJobs
+	if entry.job.Decorate != nil {
+		decorated = *entry.job.Decorate
+	}
+	if !decorated {
+		return nil
+	}
+	repo := entry.repo
+	if entry.jobType == prowv1.PeriodicJob && len(entry.job.ExtraRefs) > 0 {
+		repo = entry.job.ExtraRefs[0].Org + "/" + entry.job.ExtraRefs[0].Repo
+	}
+	cluster := entry.job.Cluster
+	if cluster == "" {
+		cluster = defaultCluster
+	}
+	decorationConfig := prowConfig.Plank.GuessDefaultDecorationConfigWithJobDC(repo, cluster, entry.job.DecorationConfig)
+	if decorationConfig.Timeout != nil {
+		return nil
+	}
+	return []Finding{entry.finding(checkMissingTimeout, "decoration_config.timeout is not set")}
+}
+
+// checkRunIfChanged reports run_if_changed expressions that are invalid, or
+// that don't match any file in the checkout of the repository.
+func checkRunIfChanged(entry jobEntry, repoFiles map[string][]string) []Finding {
+	if entry.runIfChanged == "" {
+		return nil
+	}
+	runIfChanged, err := regexp.Compile(entry.runIfChanged)
+	if err != nil {
+		return []Finding{entry.finding(checkRunIfChangedMatch, "run_if_changed %q is invalid: %v", entry.runIfChanged, err)}
+	}
+	files, exists := repoFiles[entry.repo]
+	if !exists || matchesAny(runIfChanged, files) {
+		return nil
+	}
+	return []Finding{entry.finding(checkRunIfChangedMatch, "run_if_changed %q matches no file in %s", entry.runIfChanged, entry.repo)}
+}
+
+func matchesAny(expression *regexp.Regexp, files []string) bool {
+	for _, file := range files {
+		if expression.MatchString(file) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e jobEntry) finding(check, format string, args ...any) Finding {
+	return Finding{Check: check, File: e.file, Job: e.job.Name, Message: fmt.Sprintf(format, args...)}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func gitLsFiles(dir string) ([]string, error) {
+	output, err := exec.Command("git", "-C", dir, "ls-files").Output()
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimSpace(string(output)), "\n"), nil
+}
@@ -0,0 +1,117 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("offline validation", func() {
+
+	options := func(testdata string) offlineOptions {
+		return offlineOptions{
+			prowConfigPath:    filepath.Join("testdata", testdata, "config.yaml"),
+			jobConfigPath:     filepath.Join("testdata", testdata, "jobs"),
+			knownClusters:     defaultKnownClusters,
+			allowedRegistries: defaultAllowedRegistries,
+			externalContexts:  []string{"external-ci"},
+		}
+	}
+
+	const jobConfigFile = "kubevirt/test/test-presubmits.yaml"
+
+	It("reports the findings", func() {
+		findings, err := validateOffline(options("offline"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(findings).To(ConsistOf(
+			Finding{Check: checkUnknownCluster, File: jobConfigFile, Job: "pull-test-invalid", Message: `cluster "unknown-workloads" is unknown`},
+			Finding{Check: checkUndefinedPreset, File: jobConfigFile, Job: "pull-test-invalid", Message: `preset preset-undefined: "true" is not defined`},
+			Finding{Check: checkImageRegistry, File: jobConfigFile, Job: "pull-test-invalid", Message: `image "docker.io/library/golang:latest" is not from an allowed registry`},
+			Finding{Check: checkImageNotPinned, File: jobConfigFile, Job: "pull-test-invalid", Message: `image "docker.io/library/golang:latest" is not pinned to a tag or digest`},
+			Finding{Check: checkMissingTimeout, File: jobConfigFile, Job: "pull-test-invalid", Message: "decoration_config.timeout is not set"},
+			Finding{Check: checkUnreachableContext, Job: "pull-test-skip-report", Message: `context "pull-test-skip-report" is required on kubevirt/test=main (prow config) but is not reported on every PR: skip_report is set`},
+		))
+	})
+
+	It("reports run_if_changed that matches no file in the checkout", func() {
+		checkout := GinkgoT().TempDir()
+		Expect(os.MkdirAll(filepath.Join(checkout, "pkg"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(checkout, "pkg", "main.go"), []byte("package main\n"), 0644)).To(Succeed())
+		for _, args := range [][]string{{"init", "-q"}, {"add", "."}} {
+			Expect(exec.Command("git", append([]string{"-C", checkout}, args...)...).Run()).To(Succeed())
+		}
+		opts := options("offline")
+		opts.repoCheckouts = map[string]string{"kubevirt/test": checkout}
+
+		findings, err := validateOffline(opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(findings).To(ContainElement(
+			Finding{Check: checkRunIfChangedMatch, File: jobConfigFile, Job: "pull-test-invalid", Message: `run_if_changed "^docs/.*" matches no file in kubevirt/test`},
+		))
+	})
+
+	It("doesn't report images that may be used unpinned", func() {
+		opts := options("offline")
+		opts.unpinnedImages = []string{"docker.io/library/golang"}
+
+		findings, err := validateOffline(opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(findings).ToNot(ContainElement(HaveField("Check", checkImageNotPinned)))
+	})
+
+	It("reports duplicate job names", func() {
+		findings, err := validateOffline(options("duplicates"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(findings).To(HaveLen(2))
+		Expect(findings[0]).To(Equal(Finding{
+			Check:   checkDuplicateJobName,
+			File:    "kubevirt/test/test-periodics-copy.yaml",
+			Job:     "periodic-test",
+			Message: "periodic job is defined 2 times, in kubevirt/test/test-periodics-copy.yaml, kubevirt/test/test-periodics.yaml",
+		}))
+		Expect(findings[1].Check).To(Equal(checkLoad))
+	})
+
+	DescribeTable("isPinned",
+		func(image string, expected bool) {
+			Expect(isPinned(image)).To(Equal(expected))
+		},
+		Entry("tag", "quay.io/kubevirtci/golang:v20260101-0000000", true),
+		Entry("digest", "quay.io/kubevirtci/golang@sha256:0123", true),
+		Entry("latest", "quay.io/kubevirtci/golang:latest", false),
+		Entry("no tag", "quay.io/kubevirtci/golang", false),
+		Entry("registry with port", "localhost:5000/golang", false),
+	)
+
+	DescribeTable("imageName",
+		func(image, expected string) {
+			Expect(imageName(image)).To(Equal(expected))
+		},
+		Entry("tag", "quay.io/kubevirtci/golang:v20260101-0000000", "quay.io/kubevirtci/golang"),
+		Entry("digest", "quay.io/kubevirtci/golang@sha256:0123", "quay.io/kubevirtci/golang"),
+		Entry("no tag", "quay.io/kubevirtci/golang", "quay.io/kubevirtci/golang"),
+		Entry("registry with port", "localhost:5000/golang:latest", "localhost:5000/golang"),
+	)
+})
@@ -0,0 +1,134 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	junitMerge "kubevirt.io/project-infra/pkg/flakefinder/junit-merge"
+)
+
+const (
+	formatJUnit = "junit"
+	formatJSON  = "json"
+)
+
+type options struct {
+	outputFile string
+	format     string
+	sources    []string
+}
+
+func flagOptions() options {
+	o := options{}
+	flag.StringVar(&o.outputFile, "output", "", "file to write the merged result to, stdout if empty")
+	flag.StringVar(&o.format, "format", formatJUnit, fmt.Sprintf("output format, one of %q or %q", formatJUnit, formatJSON))
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [source=]junit-file...\n\n", os.Args[0])
+		fmt.Fprintln(flag.CommandLine.Output(), "Merges junit files keeping every attempt of each test. The source name of the attempts is the prefix before '=', i.e. the lane, or the file path if no prefix is given.")
+		fmt.Fprintln(flag.CommandLine.Output())
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	o.sources = flag.Args()
+	return o
+}
+
+func (o *options) validate() error {
+	if o.format != formatJUnit && o.format != formatJSON {
+		return fmt.Errorf("format %q is not supported", o.format)
+	}
+	if len(o.sources) == 0 {
+		return fmt.Errorf("no junit files given")
+	}
+	return nil
+}
+
+func main() {
+	opts := flagOptions()
+
+	log.StandardLogger().SetFormatter(&log.JSONFormatter{})
+	mLog := log.StandardLogger().WithField("robot", "junit-merge")
+
+	if err := opts.validate(); err != nil {
+		mLog.Fatalf("validating command line options failed: %v", err)
+	}
+
+	sources, err := loadSources(opts.sources)
+	if err != nil {
+		mLog.Fatalf("failed to load junit files: %v", err)
+	}
+	results := junitMerge.MergeAttempts(sources)
+
+	var output io.Writer = os.Stdout
+	if opts.outputFile != "" {
+		outputFile, err := os.Create(opts.outputFile)
+		if err != nil {
+			mLog.Fatalf("failed to create output file %q: %v", opts.outputFile, err)
+		}
+		defer outputFile.Close()
+		output = outputFile
+	}
+
+	if err := write(output, opts.format, sources, results); err != nil {
+		mLog.Fatalf("failed to write merged result: %v", err)
+	}
+	if opts.outputFile != "" {
+		mLog.Infof("merged %d junit files into %q", len(sources), opts.outputFile)
+	}
+}
+
+// loadSources ingests the junit files given as [source=]path, where source is the name used to record the
+// provenance of the test attempts, e.g. the lane. If no source is given, the path is used.
+func loadSources(args []string) ([]junitMerge.Source, error) {
+	var sources []junitMerge.Source
+	for _, arg := range args {
+		name, path, found := strings.Cut(arg, "=")
+		if !found {
+			name, path = arg, arg
+		}
+		suites, err := junitMerge.IngestFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to ingest %q: %w", path, err)
+		}
+		sources = append(sources, junitMerge.Source{Name: name, Suites: suites})
+	}
+	return sources, nil
+}
+
+func write(w io.Writer, format string, sources []junitMerge.Source, results []junitMerge.TestResult) error {
+	switch format {
+	case formatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(junitMerge.NewSummary(sources, results))
+	case formatJUnit:
+		return junitMerge.WriteJUnit(w, sources, results)
+	default:
+		return fmt.Errorf("format %q is not supported", format)
+	}
+}
@@ -20,6 +20,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
@@ -40,6 +41,9 @@ type options struct {
 	author              string
 	branchName          string
 	ensureLabelsMissing string
+	prNumber            int
+	policyPath          string
+	outputFile          string
 }
 
 func (o *options) validate() error {
@@ -49,6 +53,9 @@ func (o *options) validate() error {
 	if o.repo == "" {
 		return fmt.Errorf("repo is required")
 	}
+	if o.prNumber > 0 {
+		return nil
+	}
 	if o.author == "" {
 		return fmt.Errorf("author is required")
 	}
@@ -62,6 +69,22 @@ func (o *options) getEnsureLabelsMissing() []string {
 	return strings.Split(o.ensureLabelsMissing, ",")
 }
 
+// getPolicy returns the policy from the policy file, or, if none is given,
+// the policy requiring the labels to be missing.
+func (o *options) getPolicy() (*Policy, error) {
+	if o.policyPath != "" {
+		return LoadPolicy(o.policyPath)
+	}
+	return &Policy{
+		Rules: []Rule{
+			{
+				Name:    "ensure-labels-missing",
+				Require: Requirement{LabelsMissing: o.getEnsureLabelsMissing()},
+			},
+		},
+	}, nil
+}
+
 var o = options{}
 
 func init() {
@@ -71,7 +94,10 @@ func init() {
 	flag.StringVar(&o.repo, "repo", "", "The repo for the PR.")
 	flag.StringVar(&o.author, "author", "", "The author for the PR.")
 	flag.StringVar(&o.branchName, "branch-name", "", "The branch name for the PR.")
-	flag.StringVar(&o.ensureLabelsMissing, "ensure-labels-missing", "lgtm", "What labels have to be missing on the PR (list of comma separated labels).")
+	flag.StringVar(&o.ensureLabelsMissing, "ensure-labels-missing", "lgtm", "What labels have to be missing on the PR (list of comma separated labels), ignored if a policy is given.")
+	flag.IntVar(&o.prNumber, "pr-number", 0, "The number of the PR, if not given the open PR for author and branch name is checked.")
+	flag.StringVar(&o.policyPath, "policy", "", "Path to the file containing the rules the PR has to comply with, see pr-policy.yaml.")
+	flag.StringVar(&o.outputFile, "output-file", "", "The file to write the result per rule to as JSON, stdout if empty.")
 }
 
 func main() {
@@ -106,24 +132,68 @@ func main() {
 		}
 	}
 
-	prs, _, err := client.PullRequests.List(ctx, o.org, o.repo, &github.PullRequestListOptions{
-		State:       "open",
-		Head:        fmt.Sprintf("%s:%s", o.author, o.branchName),
-		ListOptions: github.ListOptions{},
-	})
+	policy, err := o.getPolicy()
 	if err != nil {
-		log().WithError(err).Fatal("failed to find PR")
-	} else if len(prs) == 0 {
-		log().Info("No PR found")
-		os.Exit(0)
-	} else if len(prs) > 1 {
-		log().Fatalf("More than one PR found: %+v", prs)
+		log().WithError(err).Fatal("failed to load policy")
+	}
+
+	var pr *github.PullRequest
+	if o.prNumber > 0 {
+		pr, _, err = client.PullRequests.Get(ctx, o.org, o.repo, o.prNumber)
+		if err != nil {
+			log().WithError(err).Fatal("failed to get PR")
+		}
+	} else {
+		prs, _, err := client.PullRequests.List(ctx, o.org, o.repo, &github.PullRequestListOptions{
+			State:       "open",
+			Head:        fmt.Sprintf("%s:%s", o.author, o.branchName),
+			ListOptions: github.ListOptions{},
+		})
+		if err != nil {
+			log().WithError(err).Fatal("failed to find PR")
+		} else if len(prs) == 0 {
+			log().Info("No PR found")
+			os.Exit(0)
+		} else if len(prs) > 1 {
+			log().Fatalf("More than one PR found: %+v", prs)
+		}
+		pr = prs[0]
 	}
 
-	if checkAnyLabelExists(prs[0], o.getEnsureLabelsMissing()) {
-		log().WithField("PR", prs[0].GetNumber()).Fatalf("ensureLabelsMissing: some labels were present that shouldn't be")
+	prToCheck := &PullRequest{PullRequest: pr}
+	if o.policyPath != "" {
+		prToCheck, err = fetchPullRequest(ctx, client, o.org, o.repo, pr)
+		if err != nil {
+			log().WithError(err).Fatal("failed to fetch PR")
+		}
 	}
 
+	results := policy.Evaluate(prToCheck)
+	failed := 0
+	for _, result := range results {
+		log().WithField("PR", pr.GetNumber()).WithField("rule", result.Rule).Infof("%s: %s", result.Result, result.Reason)
+		if result.Result == ResultFail {
+			failed++
+		}
+	}
+	if err := writeResults(o.outputFile, results); err != nil {
+		log().WithError(err).Fatal("failed to write results")
+	}
+	if failed > 0 {
+		log().WithField("PR", pr.GetNumber()).Fatalf("%d of %d rules failed", failed, len(results))
+	}
+}
+
+func writeResults(outputFile string, results []RuleResult) error {
+	output, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	if outputFile == "" {
+		_, err = fmt.Println(string(output))
+		return err
+	}
+	return os.WriteFile(outputFile, output, 0644)
 }
 
 func checkAnyLabelExists(prToCheck *github.PullRequest, labelsToCheck []string) bool {
@@ -0,0 +1,285 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	ResultPass = "pass"
+	ResultFail = "fail"
+	ResultSkip = "skip"
+
+	defaultStatusState = "success"
+)
+
+// Policy is a set of rules a PR has to comply with.
+type Policy struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Rule requires a PR that matches the condition to fulfill the requirement.
+type Rule struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	If          Condition   `json:"if,omitempty"`
+	Require     Requirement `json:"require"`
+}
+
+// Condition selects the PRs a rule applies to. All fields that are set have
+// to match, an empty condition matches every PR.
+type Condition struct {
+	// Authors are the logins of the PR authors
+	Authors []string `json:"authors,omitempty"`
+	// BaseBranches are regular expressions of which one has to match the
+	// branch the PR targets
+	BaseBranches []string `json:"baseBranches,omitempty"`
+	// Labels all have to be present on the PR
+	Labels []string `json:"labels,omitempty"`
+	// ChangedFiles are regular expressions of which one has to match any
+	// file the PR changes
+	ChangedFiles []string `json:"changedFiles,omitempty"`
+	// TitleMatches is a regular expression that has to match the title
+	TitleMatches string `json:"titleMatches,omitempty"`
+}
+
+// Requirement is what a PR has to fulfill. All fields that are set have to
+// be fulfilled.
+type Requirement struct {
+	// Labels all have to be present on the PR
+	Labels []string `json:"labels,omitempty"`
+	// LabelsMissing all have to be absent from the PR
+	LabelsMissing []string `json:"labelsMissing,omitempty"`
+	// Statuses all have to be reported on the head commit of the PR
+	Statuses []StatusRequirement `json:"statuses,omitempty"`
+	// TitleMatches is a regular expression that has to match the title
+	TitleMatches string `json:"titleMatches,omitempty"`
+	// BodyMatches is a regular expression that has to match the description
+	BodyMatches string `json:"bodyMatches,omitempty"`
+	// AnyOf requires at least one of the requirements to be fulfilled
+	AnyOf []Requirement `json:"anyOf,omitempty"`
+}
+
+// StatusRequirement requires a status with a context matching the regular
+// expression to be in the state, success if empty.
+type StatusRequirement struct {
+	Context string `json:"context"`
+	State   string `json:"state,omitempty"`
+}
+
+// RuleResult is the outcome of evaluating a rule against a PR.
+type RuleResult struct {
+	Rule   string `json:"rule"`
+	Result string `json:"result"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// PullRequest is a PR together with the data the rules are evaluated on.
+type PullRequest struct {
+	*github.PullRequest
+	ChangedFiles []string
+	Statuses     []github.RepoStatus
+}
+
+func LoadPolicy(path string) (*Policy, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var policy Policy
+	if err := yaml.UnmarshalStrict(content, &policy); err != nil {
+		return nil, fmt.Errorf("could not parse policy %q: %w", path, err)
+	}
+	if err := policy.validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy %q: %w", path, err)
+	}
+	return &policy, nil
+}
+
+func (p *Policy) validate() error {
+	names := map[string]struct{}{}
+	for _, rule := range p.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("rule without name")
+		}
+		if _, exists := names[rule.Name]; exists {
+			return fmt.Errorf("rule %q is defined more than once", rule.Name)
+		}
+		names[rule.Name] = struct{}{}
+		expressions := append(append([]string{rule.If.TitleMatches}, rule.If.BaseBranches...), rule.If.ChangedFiles...)
+		expressions = append(expressions, rule.Require.expressions()...)
+		for _, expression := range expressions {
+			if _, err := regexp.Compile(expression); err != nil {
+				return fmt.Errorf("rule %q: %w", rule.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (r Requirement) expressions() []string {
+	expressions := []string{r.TitleMatches, r.BodyMatches}
+	for _, status := range r.Statuses {
+		expressions = append(expressions, status.Context)
+	}
+	for _, requirement := range r.AnyOf {
+		expressions = append(expressions, requirement.expressions()...)
+	}
+	return expressions
+}
+
+// Evaluate evaluates all rules against the PR.
+func (p *Policy) Evaluate(pr *PullRequest) []RuleResult {
+	var results []RuleResult
+	for _, rule := range p.Rules {
+		results = append(results, rule.Evaluate(pr))
+	}
+	return results
+}
+
+func (r Rule) Evaluate(pr *PullRequest) RuleResult {
+	if reason := r.If.mismatch(pr); reason != "" {
+		return RuleResult{Rule: r.Name, Result: ResultSkip, Reason: reason}
+	}
+	if reason := r.Require.violation(pr); reason != "" {
+		return RuleResult{Rule: r.Name, Result: ResultFail, Reason: reason}
+	}
+	return RuleResult{Rule: r.Name, Result: ResultPass}
+}
+
+// mismatch returns why the PR doesn't match the condition, or an empty
+// string if it matches.
+func (c Condition) mismatch(pr *PullRequest) string {
+	if len(c.Authors) > 0 && !containsFold(c.Authors, pr.GetUser().GetLogin()) {
+		return fmt.Sprintf("author %s is not one of %s", pr.GetUser().GetLogin(), strings.Join(c.Authors, ", "))
+	}
+	if len(c.BaseBranches) > 0 && !matchesAny(c.BaseBranches, pr.GetBase().GetRef()) {
+		return fmt.Sprintf("base branch %s does not match", pr.GetBase().GetRef())
+	}
+	if missing := missingLabels(pr.PullRequest, c.Labels); len(missing) > 0 {
+		return fmt.Sprintf("labels %s are missing", strings.Join(missing, ", "))
+	}
+	if len(c.ChangedFiles) > 0 && !anyFileMatches(c.ChangedFiles, pr.ChangedFiles) {
+		return "no changed file matches"
+	}
+	if c.TitleMatches != "" && !regexp.MustCompile(c.TitleMatches).MatchString(pr.GetTitle()) {
+		return "title does not match"
+	}
+	return ""
+}
+
+// violation returns why the PR doesn't fulfill the requirement, or an empty
+// string if it does.
+func (r Requirement) violation(pr *PullRequest) string {
+	if missing := missingLabels(pr.PullRequest, r.Labels); len(missing) > 0 {
+		return fmt.Sprintf("labels %s are missing", strings.Join(missing, ", "))
+	}
+	if checkAnyLabelExists(pr.PullRequest, r.LabelsMissing) {
+		return fmt.Sprintf("one of the labels %s is present", strings.Join(r.LabelsMissing, ", "))
+	}
+	for _, status := range r.Statuses {
+		if !hasStatus(pr.Statuses, status) {
+			return fmt.Sprintf("no status matching %q is in state %s", status.Context, status.state())
+		}
+	}
+	if r.TitleMatches != "" && !regexp.MustCompile(r.TitleMatches).MatchString(pr.GetTitle()) {
+		return fmt.Sprintf("title does not match %q", r.TitleMatches)
+	}
+	if r.BodyMatches != "" && !regexp.MustCompile(r.BodyMatches).MatchString(pr.GetBody()) {
+		return fmt.Sprintf("description does not match %q", r.BodyMatches)
+	}
+	if len(r.AnyOf) > 0 {
+		var violations []string
+		for _, requirement := range r.AnyOf {
+			violation := requirement.violation(pr)
+			if violation == "" {
+				return ""
+			}
+			violations = append(violations, violation)
+		}
+		return "none of the alternatives is fulfilled: " + strings.Join(violations, "; ")
+	}
+	return ""
+}
+
+func (s StatusRequirement) state() string {
+	if s.State == "" {
+		return defaultStatusState
+	}
+	return s.State
+}
+
+func hasStatus(statuses []github.RepoStatus, required StatusRequirement) bool {
+	context := regexp.MustCompile(required.Context)
+	for _, status := range statuses {
+		if context.MatchString(status.GetContext()) && status.GetState() == required.state() {
+			return true
+		}
+	}
+	return false
+}
+
+func missingLabels(pr *github.PullRequest, labels []string) []string {
+	present := map[string]struct{}{}
+	for _, label := range pr.Labels {
+		present[label.GetName()] = struct{}{}
+	}
+	var missing []string
+	for _, label := range labels {
+		if _, exists := present[label]; !exists {
+			missing = append(missing, label)
+		}
+	}
+	return missing
+}
+
+func anyFileMatches(expressions []string, files []string) bool {
+	for _, file := range files {
+		if matchesAny(expressions, file) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAny(expressions []string, value string) bool {
+	for _, expression := range expressions {
+		if regexp.MustCompile(expression).MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
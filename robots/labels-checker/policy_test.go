@@ -0,0 +1,167 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/github"
+)
+
+func TestPolicy_Evaluate(t *testing.T) {
+	policy, err := LoadPolicy("pr-policy.yaml")
+	if err != nil {
+		t.Fatalf("LoadPolicy() error = %v", err)
+	}
+	tests := []struct {
+		name string
+		pr   *PullRequest
+		want []RuleResult
+	}{
+		{
+			name: "bot PR without labels",
+			pr:   pullRequest("kubevirt-bot", "main", "Bump kubevirtci", "", []string{"release-note-none"}, []string{"cluster-up-sha.txt"}),
+			want: []RuleResult{
+				{Rule: "bot-prs-skip-review", Result: ResultFail, Reason: "labels skip-review are missing"},
+				{Rule: "job-config-rehearsal", Result: ResultSkip, Reason: "no changed file matches"},
+				{Rule: "cherry-pick-references-original", Result: ResultSkip, Reason: "base branch main does not match"},
+			},
+		},
+		{
+			name: "job config change with ok-to-rehearse by bot",
+			pr: pullRequest("kubevirt-bot", "main", "Bump images", "", []string{"skip-review", "release-note-none", "ok-to-rehearse"},
+				[]string{"github/ci/prow-deploy/files/jobs/kubevirt/kubevirt/kubevirt-presubmits.yaml"}),
+			want: []RuleResult{
+				{Rule: "bot-prs-skip-review", Result: ResultPass},
+				{Rule: "job-config-rehearsal", Result: ResultPass},
+				{Rule: "cherry-pick-references-original", Result: ResultSkip, Reason: "base branch main does not match"},
+			},
+		},
+		{
+			name: "job config change with rehearsal status",
+			pr: withStatuses(pullRequest("someone", "main", "Add lane", "", nil, []string{"github/ci/prow-deploy/files/jobs/kubevirt/kubevirt/kubevirt-presubmits.yaml"}),
+				status("rehearsal-pull-kubevirt-e2e", "success")),
+			want: []RuleResult{
+				{Rule: "bot-prs-skip-review", Result: ResultSkip, Reason: "author someone is not one of kubevirt-bot"},
+				{Rule: "job-config-rehearsal", Result: ResultPass},
+				{Rule: "cherry-pick-references-original", Result: ResultSkip, Reason: "base branch main does not match"},
+			},
+		},
+		{
+			name: "job config change with failed rehearsal",
+			pr: withStatuses(pullRequest("someone", "main", "Add lane", "", nil, []string{"github/ci/prow-deploy/files/jobs/kubevirt/kubevirt/kubevirt-presubmits.yaml"}),
+				status("rehearsal-pull-kubevirt-e2e", "failure")),
+			want: []RuleResult{
+				{Rule: "bot-prs-skip-review", Result: ResultSkip, Reason: "author someone is not one of kubevirt-bot"},
+				{Rule: "job-config-rehearsal", Result: ResultFail, Reason: `none of the alternatives is fulfilled: labels ok-to-rehearse are missing; no status matching "^rehearsal-" is in state success`},
+				{Rule: "cherry-pick-references-original", Result: ResultSkip, Reason: "base branch main does not match"},
+			},
+		},
+		{
+			name: "automated cherry-pick",
+			pr:   pullRequest("kubevirt-bot", "release-1.5", "[release-1.5] Fix migration", "This is an automated cherry-pick of #12345\n\n/assign someone", []string{"skip-review", "release-note-none"}, nil),
+			want: []RuleResult{
+				{Rule: "bot-prs-skip-review", Result: ResultPass},
+				{Rule: "job-config-rehearsal", Result: ResultSkip, Reason: "no changed file matches"},
+				{Rule: "cherry-pick-references-original", Result: ResultPass},
+			},
+		},
+		{
+			name: "manual cherry-pick without reference",
+			pr:   pullRequest("someone", "release-1.5", "[release-1.5] Fix migration", "Fixes the migration", nil, nil),
+			want: []RuleResult{
+				{Rule: "bot-prs-skip-review", Result: ResultSkip, Reason: "author someone is not one of kubevirt-bot"},
+				{Rule: "job-config-rehearsal", Result: ResultSkip, Reason: "no changed file matches"},
+				{Rule: "cherry-pick-references-original", Result: ResultFail, Reason: `description does not match "(?i)(cherry-pick|backport)[^\\n]*(#[0-9]+|github\\.com/[^/]+/[^/]+/pull/[0-9]+)"`},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.Evaluate(tt.pr); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  string
+		wantErr bool
+	}{
+		{
+			name:   "valid rule",
+			policy: "rules:\n- name: lgtm\n  require:\n    labels: [lgtm]\n",
+		},
+		{
+			name:    "unknown field",
+			policy:  "rules:\n- name: lgtm\n  require:\n    label: [lgtm]\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid expression",
+			policy:  "rules:\n- name: title\n  require:\n    titleMatches: '('\n",
+			wantErr: true,
+		},
+		{
+			name:    "duplicate rule",
+			policy:  "rules:\n- name: lgtm\n  require:\n    labels: [lgtm]\n- name: lgtm\n  require:\n    labels: [approved]\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "policy.yaml")
+			if err := os.WriteFile(path, []byte(tt.policy), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadPolicy(path); (err != nil) != tt.wantErr {
+				t.Errorf("LoadPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func pullRequest(author, baseBranch, title, body string, labels []string, changedFiles []string) *PullRequest {
+	pr := &github.PullRequest{
+		User:  &github.User{Login: &author},
+		Base:  &github.PullRequestBranch{Ref: &baseBranch},
+		Title: &title,
+		Body:  &body,
+	}
+	for _, labelText := range labels {
+		pr.Labels = append(pr.Labels, label(labelText))
+	}
+	return &PullRequest{PullRequest: pr, ChangedFiles: changedFiles}
+}
+
+func withStatuses(pr *PullRequest, statuses ...github.RepoStatus) *PullRequest {
+	pr.Statuses = statuses
+	return pr
+}
+
+func status(context, state string) github.RepoStatus {
+	return github.RepoStatus{Context: &context, State: &state}
+}
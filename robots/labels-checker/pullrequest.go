@@ -0,0 +1,60 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/github"
+)
+
+// fetchPullRequest fetches the PR together with the files it changes and the
+// statuses of its head commit.
+func fetchPullRequest(ctx context.Context, client *github.Client, org, repo string, pr *github.PullRequest) (*PullRequest, error) {
+	result := &PullRequest{PullRequest: pr}
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		files, r, err := client.PullRequests.ListFiles(ctx, org, repo, pr.GetNumber(), opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list files of PR %s/%s#%d: %w", org, repo, pr.GetNumber(), err)
+		}
+		for _, file := range files {
+			result.ChangedFiles = append(result.ChangedFiles, file.GetFilename())
+		}
+		if r.NextPage == 0 {
+			break
+		}
+		opts.Page = r.NextPage
+	}
+	opts = &github.ListOptions{PerPage: 100}
+	for {
+		status, r, err := client.Repositories.GetCombinedStatus(ctx, org, repo, pr.GetHead().GetSHA(), opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get statuses of PR %s/%s#%d: %w", org, repo, pr.GetNumber(), err)
+		}
+		result.Statuses = append(result.Statuses, status.Statuses...)
+		if r.NextPage == 0 {
+			break
+		}
+		opts.Page = r.NextPage
+	}
+	return result, nil
+}
@@ -0,0 +1,138 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"kubevirt.io/project-infra/pkg/flakefinder"
+	ghapi "kubevirt.io/project-infra/pkg/flakefinder/github"
+)
+
+// JobRun is a run of a presubmit job for a PR.
+type JobRun struct {
+	JobName     string
+	BuildNumber int
+	// BuildDir is the path of the build directory within the bucket
+	BuildDir string
+	// Finished is false if the run is still ongoing
+	Finished bool
+	Passed   bool
+}
+
+// jobRunSource provides the runs of the presubmit jobs for a commit of a PR.
+type jobRunSource interface {
+	Runs(number int, sha string) ([]JobRun, error)
+}
+
+// gcsJobRunSource reads the job runs from the pr-logs prow uploads to GCS,
+// i.e. pr-logs/pull/<org>_<repo>/<pr>/<job>/<build>.
+type gcsJobRunSource struct {
+	ctx    context.Context
+	client *storage.Client
+	bucket string
+	org    string
+	repo   string
+}
+
+func newGCSJobRunSource(ctx context.Context, client *storage.Client, bucket, org, repo string) jobRunSource {
+	return &gcsJobRunSource{ctx: ctx, client: client, bucket: bucket, org: org, repo: repo}
+}
+
+func (s *gcsJobRunSource) Runs(number int, sha string) ([]JobRun, error) {
+	prDir := path.Join("pr-logs", "pull", s.org+"_"+s.repo, strconv.Itoa(number))
+	jobs, err := flakefinder.ListGcsObjects(s.ctx, s.client, s.bucket, prDir+"/", "/")
+	if err != nil {
+		return nil, err
+	}
+	change := &ghapi.PullRequest{Number: number, SHA: sha}
+	var runs []JobRun
+	for _, job := range jobs {
+		builds, err := flakefinder.ListGcsObjects(s.ctx, s.client, s.bucket, path.Join(prDir, job)+"/", "/")
+		if err != nil {
+			return nil, err
+		}
+		for _, build := range builds {
+			buildNumber, err := strconv.Atoi(build)
+			if err != nil {
+				continue
+			}
+			buildDir := path.Join(prDir, job, build)
+			started, err := s.read(path.Join(buildDir, "started.json"))
+			if errors.Is(err, storage.ErrObjectNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if !flakefinder.IsLatestCommit(started, change) {
+				continue
+			}
+			run := JobRun{JobName: job, BuildNumber: buildNumber, BuildDir: buildDir}
+			finished, err := s.read(path.Join(buildDir, "finished.json"))
+			if errors.Is(err, storage.ErrObjectNotExist) {
+				runs = append(runs, run)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			run.Finished = true
+			run.Passed, err = passed(finished)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse finished.json in %s: %w", buildDir, err)
+			}
+			runs = append(runs, run)
+		}
+	}
+	return runs, nil
+}
+
+func (s *gcsJobRunSource) read(object string) ([]byte, error) {
+	reader, err := s.client.Bucket(s.bucket).Object(object).NewReader(s.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gs://%s/%s: %w", s.bucket, object, err)
+	}
+	defer func() { _ = reader.Close() }()
+	return io.ReadAll(reader)
+}
+
+// passed returns whether the finished.json records a passed run.
+func passed(finishedJSON []byte) (bool, error) {
+	var finished struct {
+		Passed *bool  `json:"passed"`
+		Result string `json:"result"`
+	}
+	if err := json.Unmarshal(finishedJSON, &finished); err != nil {
+		return false, err
+	}
+	if finished.Passed != nil {
+		return *finished.Passed, nil
+	}
+	return strings.EqualFold(finished.Result, "SUCCESS"), nil
+}
@@ -0,0 +1,126 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+	log "github.com/sirupsen/logrus"
+)
+
+const lgtmLabel = "lgtm"
+
+// retestCommentRegex matches the comments that trigger jobs to run again
+var retestCommentRegex = regexp.MustCompile(`(?m)^/(retest|retest-required|test\s+\S+)\s*$`)
+
+// pullRequestSource provides the merged PRs and what happened on them.
+type pullRequestSource interface {
+	MergedPullRequests(since, until time.Time) ([]*github.PullRequest, error)
+	// Retests returns the number of comments triggering jobs again
+	Retests(number int) (int, error)
+	// LGTMAt returns when the lgtm label was last added, nil if never
+	LGTMAt(number int) (*time.Time, error)
+}
+
+type githubPullRequestSource struct {
+	ctx    context.Context
+	client *github.Client
+	org    string
+	repo   string
+}
+
+func newGitHubPullRequestSource(ctx context.Context, client *github.Client, org, repo string) pullRequestSource {
+	return &githubPullRequestSource{ctx: ctx, client: client, org: org, repo: repo}
+}
+
+// MergedPullRequests returns the PRs merged in the interval, going through
+// the closed PRs by recent update until reaching those not updated since.
+func (s *githubPullRequestSource) MergedPullRequests(since, until time.Time) ([]*github.PullRequest, error) {
+	var merged []*github.PullRequest
+	for nextPage := 1; nextPage > 0; {
+		pullRequests, response, err := s.client.PullRequests.List(s.ctx, s.org, s.repo, &github.PullRequestListOptions{
+			State:       "closed",
+			Sort:        "updated",
+			Direction:   "desc",
+			ListOptions: github.ListOptions{Page: nextPage, PerPage: 100},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list PRs of %s/%s (page %d): %w", s.org, s.repo, nextPage, err)
+		}
+		nextPage = response.NextPage
+		for _, pr := range pullRequests {
+			if pr.GetUpdatedAt().Before(since) {
+				nextPage = 0
+				break
+			}
+			if pr.MergedAt == nil || pr.GetMergedAt().Before(since) || pr.GetMergedAt().After(until) {
+				continue
+			}
+			log.Debugf("PR %d merged at %v", pr.GetNumber(), pr.GetMergedAt())
+			merged = append(merged, pr)
+		}
+	}
+	return merged, nil
+}
+
+func (s *githubPullRequestSource) Retests(number int) (int, error) {
+	retests := 0
+	opts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		comments, response, err := s.client.Issues.ListComments(s.ctx, s.org, s.repo, number, opts)
+		if err != nil {
+			return 0, fmt.Errorf("failed to list comments of %s/%s#%d: %w", s.org, s.repo, number, err)
+		}
+		for _, comment := range comments {
+			if retestCommentRegex.MatchString(comment.GetBody()) {
+				retests++
+			}
+		}
+		if response.NextPage == 0 {
+			return retests, nil
+		}
+		opts.Page = response.NextPage
+	}
+}
+
+func (s *githubPullRequestSource) LGTMAt(number int) (*time.Time, error) {
+	var lgtmAt *time.Time
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		events, response, err := s.client.Issues.ListIssueEvents(s.ctx, s.org, s.repo, number, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list events of %s/%s#%d: %w", s.org, s.repo, number, err)
+		}
+		for _, event := range events {
+			if event.GetEvent() == "labeled" && event.GetLabel().GetName() == lgtmLabel {
+				createdAt := event.GetCreatedAt()
+				lgtmAt = &createdAt
+			}
+		}
+		if response.NextPage == 0 {
+			return lgtmAt, nil
+		}
+		opts.Page = response.NextPage
+	}
+}
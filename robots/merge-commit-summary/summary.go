@@ -0,0 +1,143 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// PullRequestSummary is what it took to get a PR merged.
+type PullRequestSummary struct {
+	Number   int        `json:"number"`
+	Title    string     `json:"title"`
+	Author   string     `json:"author"`
+	URL      string     `json:"url"`
+	MergedAt time.Time  `json:"mergedAt"`
+	LGTMAt   *time.Time `json:"lgtmAt,omitempty"`
+	// LGTMToMerge is the time between the last lgtm and the merge
+	LGTMToMerge time.Duration `json:"lgtmToMerge,omitempty"`
+	// Retests is the number of comments triggering jobs again
+	Retests int `json:"retests"`
+	// FailedLanes are the failed runs of the lanes on the merged commit
+	FailedLanes []Job `json:"failedLanes"`
+}
+
+// LGTMToMergeString returns the time from lgtm to merge rounded to minutes.
+func (s PullRequestSummary) LGTMToMergeString() string {
+	if s.LGTMAt == nil {
+		return "n/a"
+	}
+	return s.LGTMToMerge.Round(time.Minute).String()
+}
+
+type Job struct {
+	JobName      string `json:"jobName"`
+	BuildNumber  string `json:"buildNumber"`
+	BuildURL     string `json:"buildURL"`
+	Failure      bool   `json:"failure"`
+	ArtifactsURL string `json:"artifactsURL"`
+}
+
+// Summary is the summary over the PRs merged in the time range.
+type Summary struct {
+	Org           string    `json:"org"`
+	Repo          string    `json:"repo"`
+	DaysInThePast int       `json:"daysInThePast"`
+	Date          time.Time `json:"date"`
+	// PullRequests are sorted by number of failed lanes, descending
+	PullRequests []PullRequestSummary `json:"pullRequests"`
+}
+
+type summarizer struct {
+	pullRequests pullRequestSource
+	jobRuns      jobRunSource
+	bucket       string
+	laneRegex    *regexp.Regexp
+}
+
+// summarize collects the summaries of the PRs merged in the time range.
+func (s *summarizer) summarize(since, until time.Time) ([]PullRequestSummary, error) {
+	pullRequests, err := s.pullRequests.MergedPullRequests(since, until)
+	if err != nil {
+		return nil, err
+	}
+	var summaries []PullRequestSummary
+	for _, pr := range pullRequests {
+		summary := PullRequestSummary{
+			Number:   pr.GetNumber(),
+			Title:    pr.GetTitle(),
+			Author:   pr.GetUser().GetLogin(),
+			URL:      pr.GetHTMLURL(),
+			MergedAt: pr.GetMergedAt(),
+		}
+		summary.Retests, err = s.pullRequests.Retests(pr.GetNumber())
+		if err != nil {
+			return nil, err
+		}
+		summary.LGTMAt, err = s.pullRequests.LGTMAt(pr.GetNumber())
+		if err != nil {
+			return nil, err
+		}
+		if summary.LGTMAt != nil {
+			summary.LGTMToMerge = summary.MergedAt.Sub(*summary.LGTMAt)
+		}
+		runs, err := s.jobRuns.Runs(pr.GetNumber(), pr.GetHead().GetSHA())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get job runs for PR %d: %w", pr.GetNumber(), err)
+		}
+		summary.FailedLanes = s.failedLanes(runs)
+		summaries = append(summaries, summary)
+	}
+	sort.SliceStable(summaries, func(i, j int) bool {
+		if len(summaries[i].FailedLanes) != len(summaries[j].FailedLanes) {
+			return len(summaries[i].FailedLanes) > len(summaries[j].FailedLanes)
+		}
+		return summaries[i].Number > summaries[j].Number
+	})
+	return summaries, nil
+}
+
+func (s *summarizer) failedLanes(runs []JobRun) []Job {
+	sort.Slice(runs, func(i, j int) bool {
+		if runs[i].JobName != runs[j].JobName {
+			return runs[i].JobName < runs[j].JobName
+		}
+		return runs[i].BuildNumber < runs[j].BuildNumber
+	})
+	var jobs []Job
+	for _, run := range runs {
+		if !run.Finished || run.Passed || !s.laneRegex.MatchString(run.JobName) {
+			continue
+		}
+		jobs = append(jobs, Job{
+			JobName:      run.JobName,
+			BuildNumber:  strconv.Itoa(run.BuildNumber),
+			BuildURL:     fmt.Sprintf("https://prow.ci.kubevirt.io/view/gs/%s", path.Join(s.bucket, run.BuildDir)),
+			Failure:      true,
+			ArtifactsURL: fmt.Sprintf("https://gcsweb.ci.kubevirt.io/gcs/%s/artifacts", path.Join(s.bucket, run.BuildDir)),
+		})
+	}
+	return jobs
+}
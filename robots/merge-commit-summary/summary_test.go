@@ -0,0 +1,139 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type fakePullRequestSource struct {
+	pullRequests []*github.PullRequest
+	retests      map[int]int
+	lgtmAt       map[int]time.Time
+}
+
+func (f *fakePullRequestSource) MergedPullRequests(_, _ time.Time) ([]*github.PullRequest, error) {
+	return f.pullRequests, nil
+}
+
+func (f *fakePullRequestSource) Retests(number int) (int, error) {
+	return f.retests[number], nil
+}
+
+func (f *fakePullRequestSource) LGTMAt(number int) (*time.Time, error) {
+	lgtmAt, exists := f.lgtmAt[number]
+	if !exists {
+		return nil, nil
+	}
+	return &lgtmAt, nil
+}
+
+type fakeJobRunSource map[string][]JobRun
+
+func (f fakeJobRunSource) Runs(_ int, sha string) ([]JobRun, error) {
+	return f[sha], nil
+}
+
+func newPullRequest(number int, sha string, mergedAt time.Time) *github.PullRequest {
+	return &github.PullRequest{
+		Number:   github.Int(number),
+		Title:    github.String("a title"),
+		User:     &github.User{Login: github.String("author")},
+		Head:     &github.PullRequestBranch{SHA: github.String(sha)},
+		MergedAt: &mergedAt,
+	}
+}
+
+var _ = Describe("summarize", func() {
+
+	mergedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	It("reports retests, lgtm to merge and failed lanes, most failures first", func() {
+		s := &summarizer{
+			pullRequests: &fakePullRequestSource{
+				pullRequests: []*github.PullRequest{
+					newPullRequest(1, "sha1", mergedAt),
+					newPullRequest(2, "sha2", mergedAt),
+				},
+				retests: map[int]int{2: 3},
+				lgtmAt:  map[int]time.Time{2: mergedAt.Add(-90 * time.Minute)},
+			},
+			jobRuns: fakeJobRunSource{
+				"sha2": {
+					{JobName: "pull-kubevirt-e2e-k8s-1.30-sig-compute", BuildNumber: 11, BuildDir: "pr-logs/pull/kubevirt_kubevirt/2/pull-kubevirt-e2e-k8s-1.30-sig-compute/11", Finished: true},
+					{JobName: "pull-kubevirt-e2e-k8s-1.30-sig-compute", BuildNumber: 12, BuildDir: "pr-logs/pull/kubevirt_kubevirt/2/pull-kubevirt-e2e-k8s-1.30-sig-compute/12", Finished: true, Passed: true},
+					{JobName: "pull-kubevirt-unit-test", BuildNumber: 13, Finished: true},
+					{JobName: "pull-kubevirt-e2e-k8s-1.30-sig-network", BuildNumber: 14},
+				},
+			},
+			bucket:    "kubevirt-prow",
+			laneRegex: regexp.MustCompile("e2e"),
+		}
+
+		summaries, err := s.summarize(mergedAt.AddDate(0, 0, -7), mergedAt)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summaries).To(HaveLen(2))
+		Expect(summaries[0].Number).To(Equal(2))
+		Expect(summaries[0].Retests).To(Equal(3))
+		Expect(summaries[0].LGTMToMerge).To(Equal(90 * time.Minute))
+		Expect(summaries[0].FailedLanes).To(Equal([]Job{
+			{
+				JobName:      "pull-kubevirt-e2e-k8s-1.30-sig-compute",
+				BuildNumber:  "11",
+				BuildURL:     "https://prow.ci.kubevirt.io/view/gs/kubevirt-prow/pr-logs/pull/kubevirt_kubevirt/2/pull-kubevirt-e2e-k8s-1.30-sig-compute/11",
+				Failure:      true,
+				ArtifactsURL: "https://gcsweb.ci.kubevirt.io/gcs/kubevirt-prow/pr-logs/pull/kubevirt_kubevirt/2/pull-kubevirt-e2e-k8s-1.30-sig-compute/11/artifacts",
+			},
+		}))
+		Expect(summaries[1].Number).To(Equal(1))
+		Expect(summaries[1].LGTMAt).To(BeNil())
+		Expect(summaries[1].LGTMToMergeString()).To(Equal("n/a"))
+		Expect(summaries[1].FailedLanes).To(BeEmpty())
+	})
+
+})
+
+var _ = DescribeTable("passed",
+	func(finishedJSON string, expected bool) {
+		Expect(passed([]byte(finishedJSON))).To(Equal(expected))
+	},
+	Entry("passed", `{"timestamp":1714564800,"passed":true,"result":"SUCCESS"}`, true),
+	Entry("failed", `{"timestamp":1714564800,"passed":false,"result":"FAILURE"}`, false),
+	Entry("only result", `{"timestamp":1714564800,"result":"SUCCESS"}`, true),
+	Entry("aborted", `{"timestamp":1714564800,"result":"ABORTED"}`, false),
+)
+
+var _ = DescribeTable("retestCommentRegex",
+	func(comment string, expected bool) {
+		Expect(retestCommentRegex.MatchString(comment)).To(Equal(expected))
+	},
+	Entry("retest", "/retest", true),
+	Entry("retest-required", "/retest-required", true),
+	Entry("retest with explanation", "flaky test\n/retest\n", true),
+	Entry("single lane", "/test pull-kubevirt-e2e-k8s-1.30-sig-compute", true),
+	Entry("lgtm", "/lgtm", false),
+	Entry("quoted", "> /retest", false),
+)
@@ -0,0 +1,182 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+	log "github.com/sirupsen/logrus"
+
+	"kubevirt.io/project-infra/pkg/github/ratelimit"
+)
+
+const (
+	perPage = 100
+
+	// maxSearchResults is the number of results the search API returns at
+	// most for a query
+	maxSearchResults = 1000
+)
+
+// activitySource provides the activity on the PRs of an org.
+type activitySource interface {
+	// MergedPullRequests returns the PRs of the org merged in [from, to)
+	MergedPullRequests(org string, from, to time.Time) ([]github.Issue, error)
+	Reviews(org, repo string, number int) ([]*github.PullRequestReview, error)
+	Comments(org, repo string, number int) ([]*github.IssueComment, error)
+	// MergedBefore returns whether the author got a PR merged into the repo
+	// before the given time
+	MergedBefore(org, repo, author string, before time.Time) (bool, error)
+}
+
+type githubActivitySource struct {
+	ctx    context.Context
+	client *github.Client
+}
+
+func newGitHubActivitySource(ctx context.Context, client *github.Client) *githubActivitySource {
+	return &githubActivitySource{ctx: ctx, client: client}
+}
+
+// MergedPullRequests splits the time range until the search for each part
+// returns less results than the search API limit.
+func (s *githubActivitySource) MergedPullRequests(org string, from, to time.Time) ([]github.Issue, error) {
+	query := fmt.Sprintf("org:%s is:pr is:merged merged:%s..%s", org, from.UTC().Format(time.RFC3339), to.Add(-time.Second).UTC().Format(time.RFC3339))
+	total, err := s.count(query)
+	if err != nil {
+		return nil, err
+	}
+	if total > maxSearchResults && to.Sub(from) > time.Hour {
+		middle := from.Add(to.Sub(from) / 2).Truncate(time.Second)
+		log.Debugf("%d results for %q, splitting at %v", total, query, middle)
+		first, err := s.MergedPullRequests(org, from, middle)
+		if err != nil {
+			return nil, err
+		}
+		second, err := s.MergedPullRequests(org, middle, to)
+		if err != nil {
+			return nil, err
+		}
+		return append(first, second...), nil
+	}
+	return s.search(query)
+}
+
+func (s *githubActivitySource) MergedBefore(org, repo, author string, before time.Time) (bool, error) {
+	total, err := s.count(fmt.Sprintf("repo:%s/%s is:pr is:merged author:%s merged:<%s", org, repo, author, before.UTC().Format(time.RFC3339)))
+	if err != nil {
+		return false, err
+	}
+	return total > 0, nil
+}
+
+func (s *githubActivitySource) Reviews(org, repo string, number int) ([]*github.PullRequestReview, error) {
+	var reviews []*github.PullRequestReview
+	opts := &github.ListOptions{PerPage: perPage}
+	for {
+		var page []*github.PullRequestReview
+		var response *github.Response
+		err := ratelimit.Retry(s.ctx, func() error {
+			var err error
+			page, response, err = s.client.PullRequests.ListReviews(s.ctx, org, repo, number, opts)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list reviews of %s/%s#%d: %w", org, repo, number, err)
+		}
+		reviews = append(reviews, page...)
+		if response.NextPage == 0 {
+			return reviews, nil
+		}
+		opts.Page = response.NextPage
+	}
+}
+
+func (s *githubActivitySource) Comments(org, repo string, number int) ([]*github.IssueComment, error) {
+	var comments []*github.IssueComment
+	opts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: perPage}}
+	for {
+		var page []*github.IssueComment
+		var response *github.Response
+		err := ratelimit.Retry(s.ctx, func() error {
+			var err error
+			page, response, err = s.client.Issues.ListComments(s.ctx, org, repo, number, opts)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list comments of %s/%s#%d: %w", org, repo, number, err)
+		}
+		comments = append(comments, page...)
+		if response.NextPage == 0 {
+			return comments, nil
+		}
+		opts.Page = response.NextPage
+	}
+}
+
+// count returns the total number of results for the query.
+func (s *githubActivitySource) count(query string) (int, error) {
+	var result *github.IssuesSearchResult
+	err := ratelimit.Retry(s.ctx, func() error {
+		var err error
+		result, _, err = s.client.Search.Issues(s.ctx, query, &github.SearchOptions{ListOptions: github.ListOptions{PerPage: 1}})
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to search %q: %w", query, err)
+	}
+	return result.GetTotal(), nil
+}
+
+// search returns all results for the query, up to the search API limit.
+func (s *githubActivitySource) search(query string) ([]github.Issue, error) {
+	log.Debugf("GitHub query: %q", query)
+	var issues []github.Issue
+	opts := &github.SearchOptions{Sort: "created", Order: "asc", ListOptions: github.ListOptions{PerPage: perPage}}
+	for {
+		var result *github.IssuesSearchResult
+		var response *github.Response
+		err := ratelimit.Retry(s.ctx, func() error {
+			var err error
+			result, response, err = s.client.Search.Issues(s.ctx, query, opts)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to search %q: %w", query, err)
+		}
+		issues = append(issues, result.Issues...)
+		if response.NextPage == 0 {
+			if result.GetTotal() > len(issues) {
+				log.Warnf("search %q returned %d of %d results", query, len(issues), result.GetTotal())
+			}
+			return issues, nil
+		}
+		opts.Page = response.NextPage
+	}
+}
+
+// repoName returns the name of the repository the search result belongs to.
+func repoName(issue github.Issue) string {
+	return issue.GetRepositoryURL()[strings.LastIndex(issue.GetRepositoryURL(), "/")+1:]
+}
@@ -0,0 +1,90 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("githubActivitySource", func() {
+
+	var (
+		server  *httptest.Server
+		queries []string
+		source  *githubActivitySource
+	)
+
+	BeforeEach(func() {
+		queries = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.Query().Get("q")
+			queries = append(queries, query)
+			result := github.IssuesSearchResult{Total: github.Int(1)}
+			// the whole quarter exceeds the limit, its halves don't
+			if strings.Contains(query, "2024-01-01T00:00:00Z..2024-03-31T23:59:59Z") {
+				result.Total = github.Int(maxSearchResults + 1)
+			}
+			if r.URL.Query().Get("per_page") != "1" {
+				result.Issues = []github.Issue{{Number: github.Int(len(queries))}}
+			}
+			Expect(json.NewEncoder(w).Encode(result)).To(Succeed())
+		}))
+		client := github.NewClient(nil)
+		baseURL, err := url.Parse(server.URL + "/")
+		Expect(err).ToNot(HaveOccurred())
+		client.BaseURL = baseURL
+		source = newGitHubActivitySource(context.Background(), client)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("splits the time range if the search exceeds the result limit", func() {
+		pullRequests, err := source.MergedPullRequests("kubevirt", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pullRequests).To(HaveLen(2))
+		Expect(queries).To(Equal([]string{
+			"org:kubevirt is:pr is:merged merged:2024-01-01T00:00:00Z..2024-03-31T23:59:59Z",
+			"org:kubevirt is:pr is:merged merged:2024-01-01T00:00:00Z..2024-02-15T11:59:59Z",
+			"org:kubevirt is:pr is:merged merged:2024-01-01T00:00:00Z..2024-02-15T11:59:59Z",
+			"org:kubevirt is:pr is:merged merged:2024-02-15T12:00:00Z..2024-03-31T23:59:59Z",
+			"org:kubevirt is:pr is:merged merged:2024-02-15T12:00:00Z..2024-03-31T23:59:59Z",
+		}))
+	})
+
+	It("checks whether the author got a PR merged before", func() {
+		mergedBefore, err := source.MergedBefore("kubevirt", "kubevirt", "alice", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(mergedBefore).To(BeTrue())
+		Expect(queries).To(Equal([]string{"repo:kubevirt/kubevirt is:pr is:merged author:alice merged:<2024-01-01T00:00:00Z"}))
+	})
+
+})
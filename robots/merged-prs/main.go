@@ -20,100 +20,163 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"flag"
 	"fmt"
-	"net/http"
-	"net/url"
+	"os"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/google/go-github/v28/github"
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+	"sigs.k8s.io/prow/pkg/config/secret"
 )
 
-const (
-	githubAPIURL = "https://api.github.com/search/issues"
-)
+const dateLayout = "2006-01-02"
 
-type User struct {
-	Login string `json:"login"`
-}
+var quarterRegex = regexp.MustCompile(`^(\d{4})-?Q([1-4])$`)
 
-type Item struct {
-	Number        int    `json:"number"`
-	HTMLURL       string `json:"html_url"`
-	Title         string `json:"title"`
-	User          User   `json:"user"`
-	RepositoryURL string `json:"repository_url"`
-}
+type options struct {
+	org               string
+	startDate         string
+	endDate           string
+	quarter           string
+	authors           string
+	tokenPath         string
+	contributorReport bool
+	outputDir         string
+	outputFormats     outputFormats
+	ignoredUsers      string
 
-type SearchResult struct {
-	TotalCount int    `json:"total_count"`
-	Items      []Item `json:"items"`
+	from, to time.Time
 }
 
-func main() {
-	startDate := flag.String("start-date", time.Now().AddDate(0, 0, -7).Format("2006-01-02"), "Start date for PR search, format YYYY-MM-DD")
-	authors := flag.String("authors", "dhiller,dollierp", "Comma-separated list of GitHub author handles")
+func (o *options) parseFlags() {
+	flag.StringVar(&o.org, "org", "kubevirt", "GitHub org to search the PRs in")
+	flag.StringVar(&o.startDate, "start-date", time.Now().AddDate(0, 0, -7).Format(dateLayout), "Start date for PR search, format YYYY-MM-DD")
+	flag.StringVar(&o.endDate, "end-date", "", "End date (exclusive) for the contributor report, format YYYY-MM-DD, default is today")
+	flag.StringVar(&o.quarter, "quarter", "", "Quarter to create the contributor report for, format YYYY-Qn, i.e. 2024-Q1, overrides start and end date")
+	flag.StringVar(&o.authors, "authors", "dhiller,dollierp", "Comma-separated list of GitHub author handles")
+	flag.StringVar(&o.tokenPath, "github-token-path", "", "Path to the file containing the GitHub OAuth secret, required for the contributor report")
+	flag.BoolVar(&o.contributorReport, "contributor-report", false, "Whether to create the contributor activity report for the whole org instead of listing the PRs of the authors")
+	flag.StringVar(&o.outputDir, "output-dir", "", "Directory to write the contributor report to, default is a temp directory")
+	flag.Var(&o.outputFormats, "output-format", fmt.Sprintf("Output format of the contributor report, one of %v, can be given multiple times (default %s)", supportedOutputFormats, outputFormatHTML))
+	flag.StringVar(&o.ignoredUsers, "ignored-users", "kubevirt-bot,kubevirt-commenter-bot", "Comma-separated list of users that are not counted as contributors, bots are always ignored")
 	flag.Parse()
+}
 
-	query, searchResults, err := queryMergedPRsForAuthors(authors, startDate)
-	if err != nil {
-		log.WithError(err).Fatal()
+func (o *options) validate() error {
+	var err error
+	if o.quarter != "" {
+		matches := quarterRegex.FindStringSubmatch(o.quarter)
+		if matches == nil {
+			return fmt.Errorf("invalid quarter %q, expected format YYYY-Qn", o.quarter)
+		}
+		year, _ := strconv.Atoi(matches[1])
+		quarter, _ := strconv.Atoi(matches[2])
+		o.from = time.Date(year, time.Month(3*(quarter-1)+1), 1, 0, 0, 0, 0, time.UTC)
+		o.to = o.from.AddDate(0, 3, 0)
+		o.startDate = o.from.Format(dateLayout)
+	} else {
+		if o.from, err = time.Parse(dateLayout, o.startDate); err != nil {
+			return fmt.Errorf("invalid start date %q: %w", o.startDate, err)
+		}
+		o.to = time.Now().UTC().Truncate(24 * time.Hour)
+		if o.endDate != "" {
+			if o.to, err = time.Parse(dateLayout, o.endDate); err != nil {
+				return fmt.Errorf("invalid end date %q: %w", o.endDate, err)
+			}
+		}
 	}
-
-	generateMarkdownForMergedPRs(query, searchResults)
+	if !o.contributorReport {
+		return nil
+	}
+	if !o.from.Before(o.to) {
+		return fmt.Errorf("start date %s is not before end date %s", o.from.Format(dateLayout), o.to.Format(dateLayout))
+	}
+	if o.tokenPath == "" {
+		return fmt.Errorf("github-token-path is required for the contributor report")
+	}
+	if len(o.outputFormats) == 0 {
+		o.outputFormats = outputFormats{outputFormatHTML}
+	}
+	if o.outputDir == "" {
+		if o.outputDir, err = os.MkdirTemp("", "merged-prs-*"); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+	return nil
 }
 
-func queryMergedPRsForAuthors(authors *string, startDate *string) (query string, searchResults []Item, err error) {
-	var authorQueries []string
-	for _, author := range strings.Split(*authors, ",") {
-		authorQueries = append(authorQueries, "author:"+strings.TrimSpace(author))
+func main() {
+	var o options
+	o.parseFlags()
+	if err := o.validate(); err != nil {
+		log.WithError(err).Fatal("invalid options")
 	}
 
-	query = fmt.Sprintf("is:pr is:merged merged:>=%s %s org:kubevirt", *startDate, strings.Join(authorQueries, " "))
-	perPage := 20
-	totalCount := perPage + 1
-	page := 0
-
-	for totalCount > page*perPage {
-		page++
+	ctx := context.Background()
+	source := newGitHubActivitySource(ctx, newGitHubClient(ctx, o.tokenPath))
 
-		var result SearchResult
-		reqURL := fmt.Sprintf("%s?page=%d&per_page=%d&q=%s", githubAPIURL, page, perPage, url.QueryEscape(query))
-		result, err = queryPageOfMergedPRsForAuthors(reqURL)
+	if !o.contributorReport {
+		query := fmt.Sprintf("is:pr is:merged merged:>=%s %s org:%s", o.startDate, authorQuery(o.authors), o.org)
+		searchResults, err := source.search(query)
 		if err != nil {
-			return reqURL, nil, err
+			log.WithError(err).Fatal()
 		}
-		totalCount = result.TotalCount
-		searchResults = append(searchResults, result.Items...)
+		generateMarkdownForMergedPRs(query, searchResults)
+		return
 	}
-	return
-}
 
-func queryPageOfMergedPRsForAuthors(reqURL string) (SearchResult, error) {
-	log.Debugf("GitHub query: %q", reqURL)
-	resp, err := http.Get(reqURL)
+	report, err := collectContributorActivity(source, o.org, o.from, o.to, splitList(o.ignoredUsers))
 	if err != nil {
-		return SearchResult{}, err
+		log.WithError(err).Fatal("failed to collect contributor activity")
+	}
+	for _, format := range o.outputFormats {
+		if err := writeReport(report, format, o.outputDir); err != nil {
+			log.WithError(err).Fatalf("failed to write %s report", format)
+		}
 	}
-	defer func() { _ = resp.Body.Close() }()
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return SearchResult{}, fmt.Errorf("GitHub API returned status code %d", resp.StatusCode)
+// newGitHubClient returns an unauthenticated client if no token is given.
+func newGitHubClient(ctx context.Context, tokenPath string) *github.Client {
+	if tokenPath == "" {
+		return github.NewClient(nil)
 	}
+	if err := secret.Add(tokenPath); err != nil {
+		log.WithError(err).Fatalf("failed to load token from path %s", tokenPath)
+	}
+	return github.NewClient(oauth2.NewClient(ctx, oauth2.StaticTokenSource(
+		&oauth2.Token{AccessToken: string(secret.GetSecret(tokenPath))},
+	)))
+}
 
-	var result SearchResult
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return SearchResult{}, fmt.Errorf("error decoding JSON response: %v", err)
+func authorQuery(authors string) string {
+	var authorQueries []string
+	for _, author := range splitList(authors) {
+		authorQueries = append(authorQueries, "author:"+author)
+	}
+	return strings.Join(authorQueries, " ")
+}
+
+func splitList(list string) []string {
+	var result []string
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			result = append(result, value)
+		}
 	}
-	return result, nil
+	return result
 }
 
-func generateMarkdownForMergedPRs(query string, searchResults []Item) {
+func generateMarkdownForMergedPRs(query string, searchResults []github.Issue) {
 	fmt.Printf("* %d recently merged PRs authored by SIG CI (query: %s”)\n\n", len(searchResults), query)
 	for _, item := range searchResults {
-		repoPath := strings.TrimPrefix(item.RepositoryURL, "https://api.github.com/repos/")
-		fmt.Printf("  * [%s#%d](%s): %s (by @%s)\n", repoPath, item.Number, item.HTMLURL, item.Title, item.User.Login)
+		repoPath := strings.TrimPrefix(item.GetRepositoryURL(), "https://api.github.com/repos/")
+		fmt.Printf("  * [%s#%d](%s): %s (by @%s)\n", repoPath, item.GetNumber(), item.GetHTMLURL(), item.GetTitle(), item.GetUser().GetLogin())
 	}
 }
@@ -0,0 +1,166 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	outputFormatCSV  = "csv"
+	outputFormatJSON = "json"
+	outputFormatHTML = "html"
+)
+
+var (
+	supportedOutputFormats = []string{outputFormatCSV, outputFormatJSON, outputFormatHTML}
+
+	//go:embed report.gohtml
+	reportTemplateText string
+
+	reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+		"join": strings.Join,
+	}).Parse(reportTemplateText))
+)
+
+type outputFormats []string
+
+func (f *outputFormats) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *outputFormats) Set(value string) error {
+	for _, format := range supportedOutputFormats {
+		if value == format {
+			*f = append(*f, value)
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported output format %q, expected one of %v", value, supportedOutputFormats)
+}
+
+// fileBaseName is the name the report files share, i.e.
+// kubevirt-contributors-2024-01-01-2024-04-01.
+func (r *ContributorReport) fileBaseName() string {
+	return fmt.Sprintf("%s-contributors-%s-%s", r.Org, r.From.Format("2006-01-02"), r.To.Format("2006-01-02"))
+}
+
+// writeReport writes the report in the format to the output directory.
+func writeReport(report *ContributorReport, format, outputDir string) error {
+	switch format {
+	case outputFormatCSV:
+		if err := writeReportFile(filepath.Join(outputDir, report.fileBaseName()+".csv"), report.writeDetailsCSV); err != nil {
+			return err
+		}
+		return writeReportFile(filepath.Join(outputDir, report.fileBaseName()+"-summary.csv"), report.writeSummaryCSV)
+	case outputFormatJSON:
+		return writeReportFile(filepath.Join(outputDir, report.fileBaseName()+".json"), report.writeJSON)
+	case outputFormatHTML:
+		return writeReportFile(filepath.Join(outputDir, report.fileBaseName()+".html"), report.writeHTML)
+	default:
+		return fmt.Errorf("unsupported output format %q", format)
+	}
+}
+
+func writeReportFile(path string, write func(w io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+	if err := write(file); err != nil {
+		return err
+	}
+	log.Infof("File written to: %s", path)
+	return nil
+}
+
+// writeDetailsCSV writes one line per repository, month and user.
+func (r *ContributorReport) writeDetailsCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"repo", "month", "user", "merged", "reviews", "lgtms", "approves", "first time contributor"}); err != nil {
+		return err
+	}
+	for _, detail := range r.Details {
+		err := writer.Write([]string{
+			detail.Repo,
+			detail.Month,
+			detail.User,
+			strconv.Itoa(detail.Merged),
+			strconv.Itoa(detail.Reviews),
+			strconv.Itoa(detail.LGTMs),
+			strconv.Itoa(detail.Approves),
+			strconv.FormatBool(detail.FirstTimeContributor),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeSummaryCSV writes one line per repository and one per month, with the
+// number of distinct contributors, reviewers and first time contributors.
+func (r *ContributorReport) writeSummaryCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"repo", "month", "merged", "reviews", "lgtms", "approves", "contributors", "reviewers", "first time contributors"}); err != nil {
+		return err
+	}
+	for _, activity := range append(append(append([]Activity{}, r.PerRepo...), r.PerMonth...), r.Total) {
+		err := writer.Write([]string{
+			activity.Repo,
+			activity.Month,
+			strconv.Itoa(activity.Merged),
+			strconv.Itoa(activity.Reviews),
+			strconv.Itoa(activity.LGTMs),
+			strconv.Itoa(activity.Approves),
+			strconv.Itoa(len(activity.Contributors)),
+			strconv.Itoa(len(activity.Reviewers)),
+			strconv.Itoa(len(activity.FirstTimeContributors)),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func (r *ContributorReport) writeJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+func (r *ContributorReport) writeHTML(w io.Writer) error {
+	return reportTemplate.Execute(w, r)
+}
@@ -0,0 +1,257 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+	log "github.com/sirupsen/logrus"
+)
+
+const monthLayout = "2006-01"
+
+var (
+	lgtmCommentRegex    = regexp.MustCompile(`(?m)^/lgtm\s*$`)
+	approveCommentRegex = regexp.MustCompile(`(?m)^/approve\s*$`)
+)
+
+// ContributorActivity is the activity of a user on a repository in a month.
+type ContributorActivity struct {
+	Repo   string `json:"repo"`
+	Month  string `json:"month"`
+	User   string `json:"user"`
+	Merged int    `json:"merged"`
+	// Reviews is the number of merged PRs the user reviewed
+	Reviews  int `json:"reviews"`
+	LGTMs    int `json:"lgtms"`
+	Approves int `json:"approves"`
+	// FirstTimeContributor is set if the first PR of the user in the repo
+	// got merged in this month
+	FirstTimeContributor bool `json:"firstTimeContributor,omitempty"`
+}
+
+// Activity is the activity aggregated over the contributors, per repository
+// and month or over the whole report.
+type Activity struct {
+	Repo                  string   `json:"repo,omitempty"`
+	Month                 string   `json:"month,omitempty"`
+	Merged                int      `json:"merged"`
+	Reviews               int      `json:"reviews"`
+	LGTMs                 int      `json:"lgtms"`
+	Approves              int      `json:"approves"`
+	Contributors          []string `json:"contributors"`
+	Reviewers             []string `json:"reviewers"`
+	FirstTimeContributors []string `json:"firstTimeContributors"`
+}
+
+// ContributorReport is the activity on the PRs merged into the repositories
+// of an org in a time range.
+type ContributorReport struct {
+	Org     string    `json:"org"`
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	Created time.Time `json:"created"`
+
+	Total    Activity              `json:"total"`
+	PerRepo  []Activity            `json:"perRepo"`
+	PerMonth []Activity            `json:"perMonth"`
+	Details  []ContributorActivity `json:"details"`
+}
+
+type activityKey struct {
+	repo, month, user string
+}
+
+// collectContributorActivity collects the activity on the PRs merged in
+// [from, to). The authors of the PRs are counted in the month of the merge,
+// reviews and comments in the month they were made, skipping those outside of
+// the time range. Users that are ignored, i.e. bots, are skipped.
+func collectContributorActivity(source activitySource, org string, from, to time.Time, ignoredUsers []string) (*ContributorReport, error) {
+	pullRequests, err := source.MergedPullRequests(org, from, to)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("%d PRs merged into %s between %v and %v", len(pullRequests), org, from, to)
+
+	activities := map[activityKey]*ContributorActivity{}
+	activity := func(repo string, at time.Time, user string) *ContributorActivity {
+		key := activityKey{repo: repo, month: at.UTC().Format(monthLayout), user: user}
+		if _, exists := activities[key]; !exists {
+			activities[key] = &ContributorActivity{Repo: key.repo, Month: key.month, User: key.user}
+		}
+		return activities[key]
+	}
+	inRange := func(at time.Time) bool {
+		return !at.Before(from) && at.Before(to)
+	}
+	ignored := func(user *github.User) bool {
+		return user.GetType() == "Bot" || containsFold(ignoredUsers, user.GetLogin())
+	}
+
+	// firstMerges is the earliest merge per repo and author in the range
+	firstMerges := map[activityKey]time.Time{}
+	for _, pr := range pullRequests {
+		repo, author := repoName(pr), pr.GetUser().GetLogin()
+		if !ignored(pr.GetUser()) {
+			activity(repo, pr.GetClosedAt(), author).Merged++
+			key := activityKey{repo: repo, user: author}
+			if first, exists := firstMerges[key]; !exists || pr.GetClosedAt().Before(first) {
+				firstMerges[key] = pr.GetClosedAt()
+			}
+		}
+
+		reviews, err := source.Reviews(org, repo, pr.GetNumber())
+		if err != nil {
+			return nil, err
+		}
+		reviewed := map[string]struct{}{}
+		for _, review := range reviews {
+			reviewer := review.GetUser().GetLogin()
+			if _, counted := reviewed[reviewer]; counted || reviewer == author || ignored(review.GetUser()) || !inRange(review.GetSubmittedAt()) {
+				continue
+			}
+			reviewed[reviewer] = struct{}{}
+			activity(repo, review.GetSubmittedAt(), reviewer).Reviews++
+		}
+
+		comments, err := source.Comments(org, repo, pr.GetNumber())
+		if err != nil {
+			return nil, err
+		}
+		for _, comment := range comments {
+			if ignored(comment.GetUser()) || !inRange(comment.GetCreatedAt()) {
+				continue
+			}
+			if lgtmCommentRegex.MatchString(comment.GetBody()) {
+				activity(repo, comment.GetCreatedAt(), comment.GetUser().GetLogin()).LGTMs++
+			}
+			if approveCommentRegex.MatchString(comment.GetBody()) {
+				activity(repo, comment.GetCreatedAt(), comment.GetUser().GetLogin()).Approves++
+			}
+		}
+	}
+
+	for key, firstMerge := range firstMerges {
+		mergedBefore, err := source.MergedBefore(org, key.repo, key.user, from)
+		if err != nil {
+			return nil, err
+		}
+		if !mergedBefore {
+			activity(key.repo, firstMerge, key.user).FirstTimeContributor = true
+		}
+	}
+
+	report := &ContributorReport{Org: org, From: from, To: to, Created: time.Now()}
+	for _, a := range activities {
+		report.Details = append(report.Details, *a)
+	}
+	sort.Slice(report.Details, func(i, j int) bool {
+		a, b := report.Details[i], report.Details[j]
+		if a.Repo != b.Repo {
+			return a.Repo < b.Repo
+		}
+		if a.Month != b.Month {
+			return a.Month < b.Month
+		}
+		return strings.ToLower(a.User) < strings.ToLower(b.User)
+	})
+	if total := aggregate(report.Details, func(ContributorActivity) activityKey { return activityKey{} }); len(total) > 0 {
+		report.Total = total[0]
+	}
+	report.PerRepo = aggregate(report.Details, func(a ContributorActivity) activityKey { return activityKey{repo: a.Repo} })
+	report.PerMonth = aggregate(report.Details, func(a ContributorActivity) activityKey { return activityKey{month: a.Month} })
+	return report, nil
+}
+
+// aggregate sums up the activities grouped by the key, ordered by the key. It
+// returns an empty slice if there is no activity.
+func aggregate(details []ContributorActivity, key func(ContributorActivity) activityKey) []Activity {
+	type group struct {
+		activity                                       Activity
+		contributors, reviewers, firstTimeContributors map[string]struct{}
+	}
+	groups := map[activityKey]*group{}
+	var keys []activityKey
+	for _, detail := range details {
+		k := key(detail)
+		if _, exists := groups[k]; !exists {
+			groups[k] = &group{
+				activity:              Activity{Repo: k.repo, Month: k.month},
+				contributors:          map[string]struct{}{},
+				reviewers:             map[string]struct{}{},
+				firstTimeContributors: map[string]struct{}{},
+			}
+			keys = append(keys, k)
+		}
+		g := groups[k]
+		g.activity.Merged += detail.Merged
+		g.activity.Reviews += detail.Reviews
+		g.activity.LGTMs += detail.LGTMs
+		g.activity.Approves += detail.Approves
+		if detail.Merged > 0 {
+			g.contributors[detail.User] = struct{}{}
+		}
+		if detail.Reviews > 0 || detail.LGTMs > 0 || detail.Approves > 0 {
+			g.reviewers[detail.User] = struct{}{}
+		}
+		if detail.FirstTimeContributor {
+			g.firstTimeContributors[detail.User] = struct{}{}
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].repo != keys[j].repo {
+			return keys[i].repo < keys[j].repo
+		}
+		return keys[i].month < keys[j].month
+	})
+	result := make([]Activity, 0, len(keys))
+	for _, k := range keys {
+		g := groups[k]
+		g.activity.Contributors = sortedKeys(g.contributors)
+		g.activity.Reviewers = sortedKeys(g.reviewers)
+		g.activity.FirstTimeContributors = sortedKeys(g.firstTimeContributors)
+		result = append(result, g.activity)
+	}
+	return result
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return strings.ToLower(keys[i]) < strings.ToLower(keys[j])
+	})
+	return keys
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
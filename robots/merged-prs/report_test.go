@@ -0,0 +1,211 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type fakeActivitySource struct {
+	pullRequests []github.Issue
+	reviews      map[int][]*github.PullRequestReview
+	comments     map[int][]*github.IssueComment
+	mergedBefore map[string]bool
+}
+
+func (f *fakeActivitySource) MergedPullRequests(_ string, _, _ time.Time) ([]github.Issue, error) {
+	return f.pullRequests, nil
+}
+
+func (f *fakeActivitySource) Reviews(_, _ string, number int) ([]*github.PullRequestReview, error) {
+	return f.reviews[number], nil
+}
+
+func (f *fakeActivitySource) Comments(_, _ string, number int) ([]*github.IssueComment, error) {
+	return f.comments[number], nil
+}
+
+func (f *fakeActivitySource) MergedBefore(_, repo, author string, _ time.Time) (bool, error) {
+	return f.mergedBefore[repo+"/"+author], nil
+}
+
+func user(login string) *github.User {
+	return &github.User{Login: github.String(login), Type: github.String("User")}
+}
+
+func mergedPR(repo string, number int, author string, mergedAt time.Time) github.Issue {
+	return github.Issue{
+		Number:        github.Int(number),
+		User:          user(author),
+		RepositoryURL: github.String(fmt.Sprintf("https://api.github.com/repos/kubevirt/%s", repo)),
+		ClosedAt:      &mergedAt,
+	}
+}
+
+func review(reviewer string, at time.Time) *github.PullRequestReview {
+	return &github.PullRequestReview{User: user(reviewer), SubmittedAt: &at}
+}
+
+func comment(commenter, body string, at time.Time) *github.IssueComment {
+	return &github.IssueComment{User: user(commenter), Body: github.String(body), CreatedAt: &at}
+}
+
+var _ = Describe("collectContributorActivity", func() {
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	january := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	february := time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC)
+
+	var report *ContributorReport
+
+	BeforeEach(func() {
+		source := &fakeActivitySource{
+			pullRequests: []github.Issue{
+				mergedPR("kubevirt", 1, "alice", january),
+				mergedPR("kubevirt", 2, "bob", february),
+				mergedPR("kubevirt", 3, "alice", february),
+				mergedPR("project-infra", 4, "alice", february),
+				mergedPR("project-infra", 5, "kubevirt-bot", february),
+			},
+			reviews: map[int][]*github.PullRequestReview{
+				1: {
+					review("bob", january),
+					review("bob", january.Add(time.Hour)),
+					review("alice", january),
+					review("carol", from.Add(-time.Hour)),
+				},
+				2: {review("carol", february)},
+			},
+			comments: map[int][]*github.IssueComment{
+				1: {
+					comment("bob", "/lgtm", january),
+					comment("carol", "looks good\n/approve", january),
+					comment("carol", "/lgtm cancel", january),
+				},
+				5: {comment("carol", "/lgtm\n/approve", february)},
+			},
+			mergedBefore: map[string]bool{"kubevirt/alice": true},
+		}
+		var err error
+		report, err = collectContributorActivity(source, "kubevirt", from, to, []string{"kubevirt-bot"})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("counts the activity per repo, month and user", func() {
+		Expect(report.Details).To(Equal([]ContributorActivity{
+			{Repo: "kubevirt", Month: "2024-01", User: "alice", Merged: 1},
+			{Repo: "kubevirt", Month: "2024-01", User: "bob", Reviews: 1, LGTMs: 1},
+			{Repo: "kubevirt", Month: "2024-01", User: "carol", Approves: 1},
+			{Repo: "kubevirt", Month: "2024-02", User: "alice", Merged: 1},
+			{Repo: "kubevirt", Month: "2024-02", User: "bob", Merged: 1, FirstTimeContributor: true},
+			{Repo: "kubevirt", Month: "2024-02", User: "carol", Reviews: 1},
+			{Repo: "project-infra", Month: "2024-02", User: "alice", Merged: 1, FirstTimeContributor: true},
+			{Repo: "project-infra", Month: "2024-02", User: "carol", LGTMs: 1, Approves: 1},
+		}))
+	})
+
+	It("aggregates the activity", func() {
+		Expect(report.Total).To(Equal(Activity{
+			Merged: 4, Reviews: 2, LGTMs: 2, Approves: 2,
+			Contributors:          []string{"alice", "bob"},
+			Reviewers:             []string{"bob", "carol"},
+			FirstTimeContributors: []string{"alice", "bob"},
+		}))
+		Expect(report.PerRepo).To(HaveLen(2))
+		Expect(report.PerRepo[0].Repo).To(Equal("kubevirt"))
+		Expect(report.PerRepo[0].FirstTimeContributors).To(Equal([]string{"bob"}))
+		Expect(report.PerMonth).To(HaveLen(2))
+		Expect(report.PerMonth[1].Month).To(Equal("2024-02"))
+		Expect(report.PerMonth[1].Merged).To(Equal(3))
+	})
+
+	It("writes the summary as csv", func() {
+		var buffer bytes.Buffer
+		Expect(report.writeSummaryCSV(&buffer)).To(Succeed())
+		Expect(buffer.String()).To(Equal(`repo,month,merged,reviews,lgtms,approves,contributors,reviewers,first time contributors
+kubevirt,,3,2,1,1,2,2,1
+project-infra,,1,0,1,1,1,1,1
+,2024-01,1,1,1,1,1,2,0
+,2024-02,3,1,1,1,2,1,2
+,,4,2,2,2,2,2,2
+`))
+	})
+
+	It("writes html", func() {
+		var buffer bytes.Buffer
+		Expect(report.writeHTML(&buffer)).To(Succeed())
+		Expect(buffer.String()).To(ContainSubstring("Welcome to our first time contributors: alice, bob"))
+	})
+
+	When("there is no activity", func() {
+
+		BeforeEach(func() {
+			var err error
+			report, err = collectContributorActivity(&fakeActivitySource{}, "kubevirt", from, to, nil)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("reports an empty total", func() {
+			Expect(report.Total).To(Equal(Activity{}))
+			Expect(report.PerRepo).To(BeEmpty())
+			Expect(report.PerMonth).To(BeEmpty())
+		})
+
+		It("writes the summary as csv", func() {
+			var buffer bytes.Buffer
+			Expect(report.writeSummaryCSV(&buffer)).To(Succeed())
+			Expect(buffer.String()).To(Equal(`repo,month,merged,reviews,lgtms,approves,contributors,reviewers,first time contributors
+,,0,0,0,0,0,0,0
+`))
+		})
+
+		It("writes html", func() {
+			var buffer bytes.Buffer
+			Expect(report.writeHTML(&buffer)).To(Succeed())
+		})
+	})
+
+})
+
+var _ = DescribeTable("options.validate",
+	func(o options, expectedFrom, expectedTo time.Time, expectError bool) {
+		err := o.validate()
+		if expectError {
+			Expect(err).To(HaveOccurred())
+			return
+		}
+		Expect(err).ToNot(HaveOccurred())
+		Expect(o.from).To(Equal(expectedFrom))
+		Expect(o.to).To(Equal(expectedTo))
+	},
+	Entry("quarter", options{quarter: "2024-Q4"}, time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), false),
+	Entry("quarter without dash", options{quarter: "2024Q2"}, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), false),
+	Entry("invalid quarter", options{quarter: "2024-Q5"}, time.Time{}, time.Time{}, true),
+	Entry("dates", options{startDate: "2024-01-01", endDate: "2024-02-01"}, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), false),
+	Entry("report without token", options{contributorReport: true, startDate: "2024-01-01", endDate: "2024-02-01"}, time.Time{}, time.Time{}, true),
+	Entry("report with end before start", options{contributorReport: true, tokenPath: "/etc/github/token", startDate: "2024-02-01", endDate: "2024-01-01"}, time.Time{}, time.Time{}, true),
+)
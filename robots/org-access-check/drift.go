@@ -0,0 +1,153 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	githubql "github.com/shurcooL/githubv4"
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+const (
+	checkUndeclaredCollaborator = "undeclared-collaborator"
+	checkAdminOutsideAdminTeams = "admin-outside-admin-teams"
+	checkInactiveUser           = "inactive-user"
+)
+
+// Finding is a difference between the access declared in the org config and
+// the access on GitHub.
+type Finding struct {
+	Check      string                     `json:"check"`
+	Repo       string                     `json:"repo,omitempty"`
+	User       string                     `json:"user"`
+	Permission github.RepoPermissionLevel `json:"permission,omitempty"`
+	Message    string                     `json:"message"`
+}
+
+// driftClient provides the access and activity of users on GitHub.
+type driftClient interface {
+	ListDirectCollaboratorsWithPermissions(org, repo string) (map[string]github.RepoPermissionLevel, error)
+	QueryWithGitHubAppsSupport(ctx context.Context, q interface{}, vars map[string]interface{}, org string) error
+}
+
+// activitySearch counts the issues and PRs matching the search query without
+// fetching them.
+type activitySearch struct {
+	Search struct {
+		IssueCount githubql.Int
+	} `graphql:"search(type: ISSUE, first: 1, query: $query)"`
+}
+
+// checkDrift compares the access to the repositories declared in the org
+// config with the direct collaborators on GitHub. Direct collaborators are
+// reported if their permission exceeds the one the config declares for them,
+// and if they have admin permission without being org admin or member of a
+// team that is declared with admin permission on the repository.
+//
+// If inactiveSince is set, the declared admins and members that haven't been
+// involved in any issue or PR of the org since then are reported as well.
+func checkDrift(client driftClient, config *orgsConfig, org string, inactiveSince time.Time) ([]Finding, error) {
+	orgConfig, exists := config.Orgs[org]
+	if !exists {
+		return nil, fmt.Errorf("org %q is not configured", org)
+	}
+	collaboratorAccess, teamAccess := orgConfig.collaboratorAccess(), orgConfig.teamAccess()
+
+	var findings []Finding
+	for _, repo := range sortedKeys(orgConfig.Repos) {
+		if archived := orgConfig.Repos[repo].Archived; archived != nil && *archived {
+			continue
+		}
+		collaborators, err := client.ListDirectCollaboratorsWithPermissions(org, repo)
+		if err != nil {
+			return nil, fmt.Errorf("could not list collaborators of %s/%s: %w", org, repo, err)
+		}
+		for _, user := range sortedKeys(collaborators) {
+			if contains(orgConfig.Admins, user) {
+				continue
+			}
+			permission := collaborators[user]
+			if permission == github.Admin && teamAccess.permission(user, repo) != github.Admin {
+				findings = append(findings, Finding{
+					Check: checkAdminOutsideAdminTeams, Repo: repo, User: user, Permission: permission,
+					Message: fmt.Sprintf("%s has admin permission on %s/%s but is not member of a team declared with admin permission", user, org, repo),
+				})
+				continue
+			}
+			declared := collaboratorAccess.permission(user, repo)
+			if permissionRank(teamAccess.permission(user, repo)) > permissionRank(declared) {
+				declared = teamAccess.permission(user, repo)
+			}
+			if permissionRank(permission) <= permissionRank(declared) {
+				continue
+			}
+			message := fmt.Sprintf("%s is direct collaborator with %s permission on %s/%s but is not declared", user, permission, org, repo)
+			if declared != "" {
+				message = fmt.Sprintf("%s is direct collaborator with %s permission on %s/%s but is declared with %s permission", user, permission, org, repo, declared)
+			}
+			findings = append(findings, Finding{
+				Check: checkUndeclaredCollaborator, Repo: repo, User: user, Permission: permission,
+				Message: message,
+			})
+		}
+	}
+
+	if inactiveSince.IsZero() {
+		return findings, nil
+	}
+	for _, user := range append(append([]string{}, orgConfig.Admins...), orgConfig.Members...) {
+		active, err := isActive(client, org, user, inactiveSince)
+		if err != nil {
+			return nil, err
+		}
+		if !active {
+			findings = append(findings, Finding{
+				Check: checkInactiveUser, User: user,
+				Message: fmt.Sprintf("%s has not been involved in any issue or PR of %s since %s", user, org, inactiveSince.Format(time.DateOnly)),
+			})
+		}
+	}
+	return findings, nil
+}
+
+// isActive returns whether the user has been involved in any issue or PR of
+// the org that has been updated since the given time.
+func isActive(client driftClient, org, user string, since time.Time) (bool, error) {
+	query := fmt.Sprintf("org:%s involves:%s updated:>=%s", org, user, since.Format(time.DateOnly))
+	var search activitySearch
+	vars := map[string]interface{}{"query": githubql.String(query)}
+	if err := client.QueryWithGitHubAppsSupport(context.Background(), &search, vars, org); err != nil {
+		return false, fmt.Errorf("could not search activity of %s in %s: %w", user, org, err)
+	}
+	return search.Search.IssueCount > 0, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,134 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	githubql "github.com/shurcooL/githubv4"
+	"sigs.k8s.io/prow/pkg/github"
+
+	"kubevirt.io/project-infra/pkg/testutils"
+)
+
+// fakeDriftClient answers the activity searches with the number of issues
+// the users are involved in, since the fake GitHub client has no search.
+type fakeDriftClient struct {
+	*testutils.FakeClient
+	involved map[string]int
+	queries  []string
+}
+
+func (f *fakeDriftClient) QueryWithGitHubAppsSupport(ctx context.Context, q interface{}, vars map[string]interface{}, org string) error {
+	query := string(vars["query"].(githubql.String))
+	f.queries = append(f.queries, query)
+	for _, term := range strings.Fields(query) {
+		if user, found := strings.CutPrefix(term, "involves:"); found {
+			q.(*activitySearch).Search.IssueCount = githubql.Int(f.involved[user])
+		}
+	}
+	return nil
+}
+
+var _ = Describe("checkDrift", func() {
+
+	var (
+		config *orgsConfig
+		client *fakeDriftClient
+	)
+
+	BeforeEach(func() {
+		var err error
+		config, err = loadOrgsConfig("testdata/orgs.yaml")
+		Expect(err).ToNot(HaveOccurred())
+		client = &fakeDriftClient{
+			FakeClient: &testutils.FakeClient{
+				CollaboratorPermissions: map[string]map[string]github.RepoPermissionLevel{
+					"kubevirt/kubevirt": {
+						"org-admin":    github.Admin,
+						"team-admin":   github.Admin,
+						"collaborator": github.Admin,
+						"outsider":     github.Write,
+					},
+					"kubevirt/project-infra": {
+						"collaborator":      github.Write,
+						"active-maintainer": github.Maintain,
+					},
+					"kubevirt/old-repo": {
+						"outsider": github.Admin,
+					},
+				},
+			},
+			involved: map[string]int{
+				"org-admin":         1,
+				"active-maintainer": 2,
+				"team-admin":        1,
+				"collaborator":      3,
+			},
+		}
+	})
+
+	It("reports direct collaborators exceeding their declared access", func() {
+		findings, err := checkDrift(client, config, "kubevirt", time.Time{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(findings).To(Equal([]Finding{
+			{
+				Check: checkAdminOutsideAdminTeams, Repo: "kubevirt", User: "collaborator", Permission: github.Admin,
+				Message: "collaborator has admin permission on kubevirt/kubevirt but is not member of a team declared with admin permission",
+			},
+			{
+				Check: checkUndeclaredCollaborator, Repo: "kubevirt", User: "outsider", Permission: github.Write,
+				Message: "outsider is direct collaborator with write permission on kubevirt/kubevirt but is not declared",
+			},
+			{
+				Check: checkUndeclaredCollaborator, Repo: "project-infra", User: "active-maintainer", Permission: github.Maintain,
+				Message: "active-maintainer is direct collaborator with maintain permission on kubevirt/project-infra but is declared with read permission",
+			},
+		}))
+		Expect(client.queries).To(BeEmpty())
+	})
+
+	It("reports declared admins and members without activity", func() {
+		since := time.Date(2026, 4, 19, 0, 0, 0, 0, time.UTC)
+		findings, err := checkDrift(client, config, "kubevirt", since)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(findings).To(ContainElements(
+			Finding{
+				Check: checkInactiveUser, User: "inactive-admin",
+				Message: "inactive-admin has not been involved in any issue or PR of kubevirt since 2026-04-19",
+			},
+			Finding{
+				Check: checkInactiveUser, User: "Inactive-Member",
+				Message: "Inactive-Member has not been involved in any issue or PR of kubevirt since 2026-04-19",
+			},
+		))
+		Expect(findings).To(HaveLen(5))
+		Expect(client.queries).To(ContainElement("org:kubevirt involves:team-admin updated:>=2026-04-19"))
+	})
+
+	It("fails for an org that is not configured", func() {
+		_, err := checkDrift(client, config, "nmstate", time.Time{})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -23,13 +23,15 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/google/go-github/github"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/oauth2"
+	"sigs.k8s.io/prow/pkg/config/secret"
+	prowgithub "sigs.k8s.io/prow/pkg/github"
 	"sigs.k8s.io/yaml"
 )
 
@@ -42,6 +44,17 @@ type options struct {
 	endpoint  string
 
 	org string
+
+	includePrivate bool
+	outputFormats  outputFormats
+	outputDir      string
+
+	drift                   bool
+	orgsConfigPath          string
+	inactiveMonths          int
+	outputFile              string
+	remediate               bool
+	prDescriptionOutputFile string
 }
 type Collaborators []string
 type Repositories []string
@@ -64,6 +77,15 @@ func (o *options) Validate() error {
 	fs.StringVar(&o.endpoint, "github-endpoint", "https://api.github.com/", "GitHub's API endpoint (may differ for enterprise).")
 	fs.StringVar(&o.org, "org", "kubevirt", "The GitHub org")
 	fs.BoolVar(&o.debugLogging, "v", false, "verbose aka debug logging")
+	fs.BoolVar(&o.includePrivate, "include-private", false, "Whether to include private repositories in the report")
+	fs.Var(&o.outputFormats, "output-format", fmt.Sprintf("The format to write the report in, one of %v, may be given multiple times (default yaml)", supportedOutputFormats))
+	fs.StringVar(&o.outputDir, "output-dir", "", "The directory to write the report files to, the default temp directory if empty")
+	fs.BoolVar(&o.drift, "drift", false, "Compare the direct collaborators and the activity of the org members on GitHub with the org config instead of writing the access reports")
+	fs.StringVar(&o.orgsConfigPath, "orgs-config-path", "github/ci/prow-deploy/files/orgs.yaml", "The path to the peribolos org config (drift mode)")
+	fs.IntVar(&o.inactiveMonths, "inactive-months", 6, "The number of months after which org members without any activity are reported as inactive, 0 disables the check (drift mode)")
+	fs.StringVar(&o.outputFile, "output-file", "", "The file to write the findings to as YAML, stdout if empty (drift mode)")
+	fs.BoolVar(&o.remediate, "remediate", false, "Remove the inactive org members from the org config and write a PR description (drift mode)")
+	fs.StringVar(&o.prDescriptionOutputFile, "pr-description-output-file", "", "The file to write the PR description for the remediation to, stdout if empty (drift mode)")
 	err := fs.Parse(os.Args[1:])
 	if err != nil {
 		return err
@@ -74,6 +96,15 @@ func (o *options) Validate() error {
 	if o.org == "" {
 		return fmt.Errorf("org is required")
 	}
+	if len(o.outputFormats) == 0 {
+		o.outputFormats = outputFormats{outputFormatYAML}
+	}
+	if o.remediate && !o.drift {
+		return fmt.Errorf("remediate requires drift")
+	}
+	if o.inactiveMonths < 0 {
+		return fmt.Errorf("inactive-months must not be negative")
+	}
 	return nil
 }
 
@@ -95,6 +126,11 @@ func main() {
 		logrus.SetLevel(logrus.DebugLevel)
 	}
 
+	if o.drift {
+		runDrift()
+		return
+	}
+
 	rawToken, err := os.ReadFile(o.tokenPath)
 	if err != nil {
 		log().WithError(err).Fatalf("failed to read token %q", o.tokenPath)
@@ -109,135 +145,68 @@ func main() {
 		log().WithError(err).Fatal("failed to create github client")
 	}
 
-	admins, r, err := githubClient.Organizations.ListMembers(ctx, o.org, &github.ListMembersOptions{
-		Role: "admin",
-	})
-	githubOrgAdmins := make(map[string]struct{})
-	switch r.StatusCode {
-	case http.StatusOK:
-		var adminHandles []string
-		for _, admin := range admins {
-			adminHandles = append(adminHandles, admin.GetLogin())
-			githubOrgAdmins[admin.GetLogin()] = struct{}{}
-		}
-		log().Infof("org admins are %v", adminHandles)
-	default:
-		log().WithError(err).Fatalf("failed to get github repositories for org %q", o.org)
-	}
-
-	accessPermissionsToRepositoriesToCollaborators := AccessPermissionsToRepositoriesToCollaborators{
-		"admin": RepositoriesToCollaborators{},
-	}
-	repositoriesToAccessPermissionsToCollaborators := RepositoriesToAccessPermissionsToCollaborators{}
-	collaboratorsToAccessPermissionsToRepositories := CollaboratorsToAccessPermissionsToRepositories{}
-
-	defaultListOptions := github.ListOptions{
-		PerPage: 99999,
+	report, err := collectAccess(ctx, githubClient, o.org, o.includePrivate)
+	if err != nil {
+		log().WithError(err).Fatal("failed to collect access")
 	}
-	repos, r, err := githubClient.Repositories.ListByOrg(ctx, o.org, &github.RepositoryListByOrgOptions{
-		Type:        "public",
-		ListOptions: defaultListOptions,
-	})
-	switch r.StatusCode {
-	case http.StatusOK:
-		log().Infof("%d repositories found", len(repos))
-		for _, repo := range repos {
-			if repo.GetArchived() || repo.GetPrivate() {
-				logForRepo(repo).Info("skipping repo since archived or private")
-				continue
-			}
-			accessPermissionsToRepositoriesToCollaborators["admin"][repo.GetName()] = Collaborators{}
-			repositoriesToAccessPermissionsToCollaborators[repo.GetName()] = AccessPermissionsToCollaborators{}
-			logForRepo(repo).Info("checking permissions")
-			collaborators, r, err := githubClient.Repositories.ListCollaborators(ctx, o.org, repo.GetName(), &github.ListCollaboratorsOptions{
-				Affiliation: "all",
-				ListOptions: defaultListOptions,
-			})
-			if err != nil {
-				logForRepo(repo).WithError(err).Fatal("failed to get github collaborators")
-			}
-			switch r.StatusCode {
-			case http.StatusOK:
-				for _, collaborator := range collaborators {
-					if _, isAdmin := githubOrgAdmins[collaborator.GetLogin()]; isAdmin {
-						logForRepo(repo).WithField("collaborator", collaborator.GetLogin()).Debug("skipping permission check for org admin")
-						continue
-					}
-					for _, perm := range checkPermissions {
-						permissions := fetchImportantPermissions(collaborator)
-						if permissions[perm] {
-							if _, ok := accessPermissionsToRepositoriesToCollaborators[perm]; !ok {
-								accessPermissionsToRepositoriesToCollaborators[perm] = RepositoriesToCollaborators{}
-							}
-							if _, ok := repositoriesToAccessPermissionsToCollaborators[repo.GetName()][perm]; !ok {
-								repositoriesToAccessPermissionsToCollaborators[repo.GetName()][perm] = Collaborators{}
-							}
-							if _, ok := collaboratorsToAccessPermissionsToRepositories[collaborator.GetLogin()]; !ok {
-								collaboratorsToAccessPermissionsToRepositories[collaborator.GetLogin()] = AccessPermissionsToRepositories{}
-							}
-							if _, ok := collaboratorsToAccessPermissionsToRepositories[collaborator.GetLogin()][perm]; !ok {
-								collaboratorsToAccessPermissionsToRepositories[collaborator.GetLogin()][perm] = Repositories{}
-							}
-							logForRepo(repo).WithField("collaborator", collaborator.GetLogin()).Debugf("permission %q seen", perm)
-							accessPermissionsToRepositoriesToCollaborators[perm][repo.GetName()] = append(accessPermissionsToRepositoriesToCollaborators[perm][repo.GetName()], collaborator.GetLogin())
-							repositoriesToAccessPermissionsToCollaborators[repo.GetName()][perm] = append(repositoriesToAccessPermissionsToCollaborators[repo.GetName()][perm], collaborator.GetLogin())
-							collaboratorsToAccessPermissionsToRepositories[collaborator.GetLogin()][perm] = append(collaboratorsToAccessPermissionsToRepositories[collaborator.GetLogin()][perm], repo.GetName())
-						}
-					}
-				}
-			default:
-				logForRepo(repo).WithError(err).Fatal("failed to get github collaborators")
-			}
+	log().Infof("%d repositories checked", len(report.Repositories))
+	for _, format := range o.outputFormats {
+		if err := writeReport(report, format, o.outputDir); err != nil {
+			log().WithError(err).Fatalf("failed to write %s report", format)
 		}
-	default:
-		log().WithError(err).Fatal("failed to get github repositories")
 	}
+}
 
-	marshal1, err := yaml.Marshal(accessPermissionsToRepositoriesToCollaborators)
+func runDrift() {
+	config, err := loadOrgsConfig(o.orgsConfigPath)
 	if err != nil {
-		log().WithError(err).Fatal("failed to marshall file")
+		log().WithError(err).Fatal("failed to load org config")
 	}
-	err = writeYAMLFile(marshal1, "org-accesspermissions-repos-collaborators*.yaml")
-	if err != nil {
-		log().WithError(err).Fatal("failed to marshall file")
+	if err := secret.Add(o.tokenPath); err != nil {
+		log().WithError(err).Fatal("failed to start secrets agent")
 	}
-	marshal2, err := yaml.Marshal(repositoriesToAccessPermissionsToCollaborators)
+	githubClient, err := prowgithub.NewClient(secret.GetTokenGenerator(o.tokenPath), secret.Censor, prowgithub.DefaultGraphQLEndpoint, o.endpoint)
 	if err != nil {
-		log().WithError(err).Fatal("failed to marshall file")
+		log().WithError(err).Fatal("failed to create github client")
 	}
-	err = writeYAMLFile(marshal2, "org-repos-accesspermissions-collaborators*.yaml")
-	if err != nil {
-		log().WithError(err).Fatal("failed to marshall file")
+	var inactiveSince time.Time
+	if o.inactiveMonths > 0 {
+		inactiveSince = time.Now().AddDate(0, -o.inactiveMonths, 0)
 	}
-	marshal3, err := yaml.Marshal(collaboratorsToAccessPermissionsToRepositories)
+	findings, err := checkDrift(githubClient, config, o.org, inactiveSince)
 	if err != nil {
-		log().WithError(err).Fatal("failed to marshall file")
+		log().WithError(err).Fatal("failed to check drift")
 	}
-	err = writeYAMLFile(marshal3, "org-collaborators-accesspermissions-repos*.yaml")
-	if err != nil {
-		log().WithError(err).Fatal("failed to marshall file")
+	log().Infof("%d findings", len(findings))
+	if findings == nil {
+		findings = []Finding{}
 	}
-}
-
-func writeYAMLFile(inputYaml []byte, fileName string) error {
-	temp, err := os.CreateTemp("", fileName)
+	output, err := yaml.Marshal(findings)
 	if err != nil {
-		return err
+		log().WithError(err).Fatal("failed to marshall findings")
 	}
-	defer func() { _ = temp.Close() }()
-	_, err = temp.Write(inputYaml)
+	if err := writeOutput(o.outputFile, output); err != nil {
+		log().WithError(err).Fatal("failed to write findings")
+	}
+	if !o.remediate {
+		return
+	}
+	removed, err := removeInactiveMembers(o.orgsConfigPath, o.org, findings)
 	if err != nil {
-		return err
+		log().WithError(err).Fatal("failed to remediate org config")
+	}
+	log().Infof("removed %d inactive users from %s", len(removed), o.orgsConfigPath)
+	if err := writeOutput(o.prDescriptionOutputFile, []byte(prDescription(o.org, findings, removed))); err != nil {
+		log().WithError(err).Fatal("failed to write PR description")
 	}
-	log().Infof("File written to: %s", temp.Name())
-	return nil
 }
 
-func fetchImportantPermissions(collaborator *github.User) map[string]bool {
-	permissions := collaborator.GetPermissions()
-	delete(permissions, "pull")
-	delete(permissions, "triage")
-	return permissions
+func writeOutput(outputFile string, output []byte) error {
+	if outputFile == "" {
+		_, err := fmt.Println(string(output))
+		return err
+	}
+	return os.WriteFile(outputFile, output, 0644)
 }
 
 func logForRepo(repo *github.Repository) *logrus.Entry {
@@ -0,0 +1,146 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/yaml"
+)
+
+// orgsConfig is the part of the peribolos configuration that declares who
+// has access to the repositories of an org.
+type orgsConfig struct {
+	Orgs map[string]orgConfig `json:"orgs"`
+}
+
+type orgConfig struct {
+	Admins  []string              `json:"admins,omitempty"`
+	Members []string              `json:"members,omitempty"`
+	Repos   map[string]repoConfig `json:"repos,omitempty"`
+	Teams   map[string]teamConfig `json:"teams,omitempty"`
+}
+
+type repoConfig struct {
+	Archived *bool `json:"archived,omitempty"`
+	// Collaborators maps users to the permission they are granted directly
+	Collaborators map[string]github.RepoPermissionLevel `json:"collaborators,omitempty"`
+}
+
+type teamConfig struct {
+	Maintainers []string                              `json:"maintainers,omitempty"`
+	Members     []string                              `json:"members,omitempty"`
+	Repos       map[string]github.RepoPermissionLevel `json:"repos,omitempty"`
+	Children    map[string]teamConfig                 `json:"teams,omitempty"`
+}
+
+func loadOrgsConfig(path string) (*orgsConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config orgsConfig
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return nil, fmt.Errorf("could not parse %q: %w", path, err)
+	}
+	return &config, nil
+}
+
+// declaredAccess maps the normalized logins to the repositories to the
+// permission the org config grants them.
+type declaredAccess map[string]map[string]github.RepoPermissionLevel
+
+// collaboratorAccess returns the access granted to repository collaborators.
+func (c orgConfig) collaboratorAccess() declaredAccess {
+	access := declaredAccess{}
+	for repo, repoConfig := range c.Repos {
+		for user, permission := range repoConfig.Collaborators {
+			access.grant(user, repo, permission)
+		}
+	}
+	return access
+}
+
+// teamAccess returns the access granted to team maintainers and members,
+// where child teams inherit the repositories of their parents.
+func (c orgConfig) teamAccess() declaredAccess {
+	access := declaredAccess{}
+	var walk func(teams map[string]teamConfig, inherited map[string]github.RepoPermissionLevel)
+	walk = func(teams map[string]teamConfig, inherited map[string]github.RepoPermissionLevel) {
+		for _, team := range teams {
+			repos := map[string]github.RepoPermissionLevel{}
+			for repo, permission := range inherited {
+				repos[repo] = permission
+			}
+			for repo, permission := range team.Repos {
+				if permissionRank(permission) > permissionRank(repos[repo]) {
+					repos[repo] = permission
+				}
+			}
+			for _, user := range append(append([]string{}, team.Maintainers...), team.Members...) {
+				for repo, permission := range repos {
+					access.grant(user, repo, permission)
+				}
+			}
+			walk(team.Children, repos)
+		}
+	}
+	walk(c.Teams, nil)
+	return access
+}
+
+func (a declaredAccess) grant(user, repo string, permission github.RepoPermissionLevel) {
+	login := github.NormLogin(user)
+	if _, exists := a[login]; !exists {
+		a[login] = map[string]github.RepoPermissionLevel{}
+	}
+	if permissionRank(permission) > permissionRank(a[login][repo]) {
+		a[login][repo] = permission
+	}
+}
+
+// permission returns the permission the user has been granted on the
+// repository, or an empty string if none.
+func (a declaredAccess) permission(user, repo string) github.RepoPermissionLevel {
+	return a[github.NormLogin(user)][repo]
+}
+
+var permissionRanks = map[github.RepoPermissionLevel]int{
+	github.Read:     1,
+	github.Triage:   2,
+	github.Write:    3,
+	github.Maintain: 4,
+	github.Admin:    5,
+}
+
+func permissionRank(permission github.RepoPermissionLevel) int {
+	return permissionRanks[permission]
+}
+
+func contains(logins []string, login string) bool {
+	for _, l := range logins {
+		if github.NormLogin(l) == github.NormLogin(login) {
+			return true
+		}
+	}
+	return false
+}
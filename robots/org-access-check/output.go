@@ -0,0 +1,136 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	outputFormatYAML = "yaml"
+	outputFormatCSV  = "csv"
+	outputFormatHTML = "html"
+)
+
+var (
+	supportedOutputFormats = []string{outputFormatYAML, outputFormatCSV, outputFormatHTML}
+
+	//go:embed report.gohtml
+	reportTemplateText string
+
+	reportTemplate = template.Must(template.New("report").Parse(reportTemplateText))
+)
+
+type outputFormats []string
+
+func (f *outputFormats) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *outputFormats) Set(value string) error {
+	for _, format := range supportedOutputFormats {
+		if value == format {
+			*f = append(*f, value)
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported output format %q, expected one of %v", value, supportedOutputFormats)
+}
+
+// writeReport writes the report in the format to a new file in the output
+// directory.
+func writeReport(report *AccessReport, format, outputDir string) error {
+	switch format {
+	case outputFormatYAML:
+		accessPermissionsToRepositoriesToCollaborators, repositoriesToAccessPermissionsToCollaborators, collaboratorsToAccessPermissionsToRepositories := report.permissionMaps()
+		for pattern, view := range map[string]interface{}{
+			"org-accesspermissions-repos-collaborators*.yaml": accessPermissionsToRepositoriesToCollaborators,
+			"org-repos-accesspermissions-collaborators*.yaml": repositoriesToAccessPermissionsToCollaborators,
+			"org-collaborators-accesspermissions-repos*.yaml": collaboratorsToAccessPermissionsToRepositories,
+		} {
+			marshalled, err := yaml.Marshal(view)
+			if err != nil {
+				return err
+			}
+			if err := writeReportFile(outputDir, pattern, func(w io.Writer) error {
+				_, err := w.Write(marshalled)
+				return err
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	case outputFormatCSV:
+		return writeReportFile(outputDir, "org-access*.csv", report.writeCSV)
+	case outputFormatHTML:
+		return writeReportFile(outputDir, "org-access*.html", report.writeHTML)
+	default:
+		return fmt.Errorf("unsupported output format %q", format)
+	}
+}
+
+func writeReportFile(outputDir, pattern string, write func(w io.Writer) error) error {
+	temp, err := os.CreateTemp(outputDir, pattern)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = temp.Close() }()
+	if err := write(temp); err != nil {
+		return err
+	}
+	log().Infof("File written to: %s", temp.Name())
+	return nil
+}
+
+// writeCSV writes one line per access entry.
+func (r *AccessReport) writeCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"repository", "private", "user", "permission", "source", "outside collaborator"}); err != nil {
+		return err
+	}
+	for _, entry := range r.Entries {
+		err := writer.Write([]string{
+			entry.Repository,
+			strconv.FormatBool(entry.Private),
+			entry.User,
+			entry.Permission,
+			entry.Source,
+			strconv.FormatBool(entry.OutsideCollaborator),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func (r *AccessReport) writeHTML(w io.Writer) error {
+	return reportTemplate.Execute(w, r)
+}
@@ -0,0 +1,92 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/google/go-github/github"
+
+	"kubevirt.io/project-infra/pkg/github/ratelimit"
+)
+
+const perPage = 100
+
+// listAll fetches all pages of a list request, waiting for the rate limits to
+// reset if they are hit.
+func listAll[T any](ctx context.Context, list func(opts github.ListOptions) ([]T, *github.Response, error)) ([]T, error) {
+	var result []T
+	opts := github.ListOptions{PerPage: perPage}
+	for {
+		var items []T
+		var r *github.Response
+		err := ratelimit.Retry(ctx, func() error {
+			var err error
+			items, r, err = list(opts)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, items...)
+		if r == nil || r.NextPage == 0 {
+			return result, nil
+		}
+		opts.Page = r.NextPage
+	}
+}
+
+// listTeamRepos lists the repositories of a team, using the endpoint that
+// addresses the team by org and slug.
+func listTeamRepos(ctx context.Context, client *github.Client, org, team string, opts github.ListOptions) ([]*github.Repository, *github.Response, error) {
+	return get[*github.Repository](ctx, client, fmt.Sprintf("orgs/%s/teams/%s/repos", org, team), opts)
+}
+
+// listTeamMembers lists the members of a team, using the endpoint that
+// addresses the team by org and slug.
+func listTeamMembers(ctx context.Context, client *github.Client, org, team string, opts github.ListOptions) ([]*github.User, *github.Response, error) {
+	return get[*github.User](ctx, client, fmt.Sprintf("orgs/%s/teams/%s/members", org, team), opts)
+}
+
+func get[T any](ctx context.Context, client *github.Client, path string, opts github.ListOptions) ([]T, *github.Response, error) {
+	query := url.Values{}
+	if opts.Page > 0 {
+		query.Set("page", strconv.Itoa(opts.Page))
+	}
+	if opts.PerPage > 0 {
+		query.Set("per_page", strconv.Itoa(opts.PerPage))
+	}
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	request, err := client.NewRequest("GET", path, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	var result []T
+	r, err := client.Do(ctx, request, &result)
+	if err != nil {
+		return nil, r, err
+	}
+	return result, r, nil
+}
@@ -0,0 +1,176 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// removeInactiveMembers removes the users reported as inactive from the org
+// members and from the maintainers and members of its teams in the org config
+// file. Org admins are left untouched, since removing them requires a
+// decision by the other admins. Lines are removed in place, thus formatting
+// and comments of the file are preserved.
+//
+// It returns the users that have been removed.
+func removeInactiveMembers(path, org string, findings []Finding) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("could not parse %q: %w", path, err)
+	}
+	orgNode := mappingValue(mappingValue(documentRoot(&document), "orgs"), org)
+	if orgNode == nil {
+		return nil, fmt.Errorf("org %q is not configured in %q", org, path)
+	}
+
+	var admins, inactive []string
+	if adminsNode := mappingValue(orgNode, "admins"); adminsNode != nil {
+		for _, item := range adminsNode.Content {
+			admins = append(admins, item.Value)
+		}
+	}
+	for _, finding := range findings {
+		if finding.Check == checkInactiveUser && !contains(admins, finding.User) {
+			inactive = append(inactive, finding.User)
+		}
+	}
+	lines := map[int]struct{}{}
+	var removed []string
+	collect := func(sequence *yaml.Node) error {
+		if sequence == nil {
+			return nil
+		}
+		if sequence.Style&yaml.FlowStyle != 0 {
+			return fmt.Errorf("%s:%d: flow style sequences are not supported", path, sequence.Line)
+		}
+		for _, item := range sequence.Content {
+			if !contains(inactive, item.Value) {
+				continue
+			}
+			lines[item.Line-1] = struct{}{}
+			if !contains(removed, item.Value) {
+				removed = append(removed, item.Value)
+			}
+		}
+		return nil
+	}
+	if err := collect(mappingValue(orgNode, "members")); err != nil {
+		return nil, err
+	}
+	var walk func(teams *yaml.Node) error
+	walk = func(teams *yaml.Node) error {
+		if teams == nil {
+			return nil
+		}
+		for i := 1; i < len(teams.Content); i += 2 {
+			team := teams.Content[i]
+			for _, key := range []string{"maintainers", "members"} {
+				if err := collect(mappingValue(team, key)); err != nil {
+					return err
+				}
+			}
+			if err := walk(mappingValue(team, "teams")); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(mappingValue(orgNode, "teams")); err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	var result []string
+	for i, line := range strings.SplitAfter(string(content), "\n") {
+		if _, remove := lines[i]; !remove {
+			result = append(result, line)
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(result, "")), info.Mode()); err != nil {
+		return nil, err
+	}
+	sort.Strings(removed)
+	return removed, nil
+}
+
+func documentRoot(document *yaml.Node) *yaml.Node {
+	if document.Kind == yaml.DocumentNode && len(document.Content) > 0 {
+		return document.Content[0]
+	}
+	return nil
+}
+
+// mappingValue returns the value for the key if node is a mapping that
+// contains it, nil otherwise.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// prDescription describes the findings for the PR that applies the
+// remediation. Findings that can't be remediated through the org config are
+// listed for manual follow up.
+func prDescription(org string, findings []Finding, removed []string) string {
+	var description strings.Builder
+	fmt.Fprintf(&description, "Align the %s org config with the access on GitHub\n\n", org)
+	if len(removed) > 0 {
+		fmt.Fprintf(&description, "The following users have not been involved in any issue or PR of %s recently and are removed from the org and its teams:\n\n", org)
+		for _, user := range removed {
+			fmt.Fprintf(&description, "* @%s\n", user)
+		}
+		description.WriteString("\n")
+	}
+	var manual []Finding
+	for _, finding := range findings {
+		if finding.Check == checkInactiveUser && contains(removed, finding.User) {
+			continue
+		}
+		manual = append(manual, finding)
+	}
+	if len(manual) > 0 {
+		description.WriteString("The following findings need to be resolved manually, either by declaring the access in the org config or by revoking it on GitHub:\n\n")
+		for _, finding := range manual {
+			fmt.Fprintf(&description, "* %s: %s\n", finding.Check, finding.Message)
+		}
+	}
+	return strings.TrimSuffix(description.String(), "\n")
+}
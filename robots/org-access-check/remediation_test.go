@@ -0,0 +1,90 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("remediation", func() {
+
+	var (
+		orgsConfigPath string
+		original       string
+		findings       []Finding
+	)
+
+	BeforeEach(func() {
+		content, err := os.ReadFile("testdata/orgs.yaml")
+		Expect(err).ToNot(HaveOccurred())
+		original = string(content)
+		orgsConfigPath = filepath.Join(GinkgoT().TempDir(), "orgs.yaml")
+		Expect(os.WriteFile(orgsConfigPath, content, 0644)).To(Succeed())
+		findings = []Finding{
+			{Check: checkUndeclaredCollaborator, Repo: "kubevirt", User: "outsider", Message: "outsider is not declared"},
+			{Check: checkInactiveUser, User: "inactive-admin", Message: "inactive-admin is inactive"},
+			{Check: checkInactiveUser, User: "Inactive-Member", Message: "Inactive-Member is inactive"},
+		}
+	})
+
+	It("removes inactive members from the org and its teams", func() {
+		removed, err := removeInactiveMembers(orgsConfigPath, "kubevirt", findings)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(removed).To(Equal([]string{"Inactive-Member"}))
+
+		expected := strings.Replace(original, "      - Inactive-Member # inactive since ages\n", "", 1)
+		expected = strings.Replace(expected, "          - inactive-member\n", "", 1)
+		expected = strings.Replace(expected, "              - inactive-member\n", "", 1)
+		content, err := os.ReadFile(orgsConfigPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(Equal(expected))
+
+		config, err := loadOrgsConfig(orgsConfigPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(config.Orgs["kubevirt"].Admins).To(ContainElement("inactive-admin"))
+	})
+
+	It("leaves the org config untouched without inactive members", func() {
+		removed, err := removeInactiveMembers(orgsConfigPath, "kubevirt", findings[:2])
+		Expect(err).ToNot(HaveOccurred())
+		Expect(removed).To(BeEmpty())
+		content, err := os.ReadFile(orgsConfigPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(Equal(original))
+	})
+
+	It("describes removed users and findings to resolve manually", func() {
+		Expect(prDescription("kubevirt", findings, []string{"Inactive-Member"})).To(Equal(`Align the kubevirt org config with the access on GitHub
+
+The following users have not been involved in any issue or PR of kubevirt recently and are removed from the org and its teams:
+
+* @Inactive-Member
+
+The following findings need to be resolved manually, either by declaring the access in the org config or by revoking it on GitHub:
+
+* undeclared-collaborator: outsider is not declared
+* inactive-user: inactive-admin is inactive`))
+	})
+})
@@ -0,0 +1,254 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+const (
+	sourceDirect     = "direct"
+	sourceTeamPrefix = "team/"
+)
+
+// rankedPermissions are the permissions of the REST API, lowest first
+var rankedPermissions = []string{"pull", "triage", "push", "maintain", "admin"}
+
+// AccessEntry is the permission a user has on a repository through one
+// source, i.e. as direct collaborator or as member of a team.
+type AccessEntry struct {
+	Repository          string `json:"repository"`
+	Private             bool   `json:"private,omitempty"`
+	User                string `json:"user"`
+	Permission          string `json:"permission"`
+	Source              string `json:"source"`
+	OutsideCollaborator bool   `json:"outsideCollaborator,omitempty"`
+}
+
+// AccessReport is the access to the repositories of an org.
+type AccessReport struct {
+	Org                  string        `json:"org"`
+	Created              time.Time     `json:"created"`
+	OrgAdmins            []string      `json:"orgAdmins"`
+	OutsideCollaborators []string      `json:"outsideCollaborators"`
+	Repositories         []string      `json:"repositories"`
+	Entries              []AccessEntry `json:"entries"`
+}
+
+// collectAccess fetches the access to the repositories of the org from
+// GitHub. Archived repositories are skipped, private ones only included if
+// requested. Org admins are not recorded per repository, since they have
+// admin permission on all of them.
+func collectAccess(ctx context.Context, client *github.Client, org string, includePrivate bool) (*AccessReport, error) {
+	report := &AccessReport{Org: org, Created: time.Now()}
+
+	admins, err := listAll(ctx, func(opts github.ListOptions) ([]*github.User, *github.Response, error) {
+		return client.Organizations.ListMembers(ctx, org, &github.ListMembersOptions{Role: "admin", ListOptions: opts})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list admins of org %q: %w", org, err)
+	}
+	report.OrgAdmins = logins(admins)
+
+	outsideCollaborators, err := listAll(ctx, func(opts github.ListOptions) ([]*github.User, *github.Response, error) {
+		return client.Organizations.ListOutsideCollaborators(ctx, org, &github.ListOutsideCollaboratorsOptions{ListOptions: opts})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outside collaborators of org %q: %w", org, err)
+	}
+	report.OutsideCollaborators = logins(outsideCollaborators)
+
+	repoType := "public"
+	if includePrivate {
+		repoType = "all"
+	}
+	repos, err := listAll(ctx, func(opts github.ListOptions) ([]*github.Repository, *github.Response, error) {
+		return client.Repositories.ListByOrg(ctx, org, &github.RepositoryListByOrgOptions{Type: repoType, ListOptions: opts})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repositories of org %q: %w", org, err)
+	}
+	private := map[string]bool{}
+	for _, repo := range repos {
+		if repo.GetArchived() || (repo.GetPrivate() && !includePrivate) {
+			logForRepo(repo).Info("skipping repo since archived or private")
+			continue
+		}
+		report.Repositories = append(report.Repositories, repo.GetName())
+		private[repo.GetName()] = repo.GetPrivate()
+		logForRepo(repo).Info("checking permissions")
+		collaborators, err := listAll(ctx, func(opts github.ListOptions) ([]*github.User, *github.Response, error) {
+			return client.Repositories.ListCollaborators(ctx, org, repo.GetName(), &github.ListCollaboratorsOptions{Affiliation: "direct", ListOptions: opts})
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list collaborators of %s/%s: %w", org, repo.GetName(), err)
+		}
+		for _, collaborator := range collaborators {
+			report.add(repo.GetName(), repo.GetPrivate(), collaborator.GetLogin(), highestPermission(collaborator.GetPermissions()), sourceDirect)
+		}
+	}
+	sort.Strings(report.Repositories)
+
+	teams, err := listAll(ctx, func(opts github.ListOptions) ([]*github.Team, *github.Response, error) {
+		return client.Teams.ListTeams(ctx, org, &opts)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list teams of org %q: %w", org, err)
+	}
+	for _, team := range teams {
+		log().WithField("team", team.GetSlug()).Info("checking team permissions")
+		teamRepos, err := listAll(ctx, func(opts github.ListOptions) ([]*github.Repository, *github.Response, error) {
+			return listTeamRepos(ctx, client, org, team.GetSlug(), opts)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list repositories of team %q: %w", team.GetSlug(), err)
+		}
+		members, err := listAll(ctx, func(opts github.ListOptions) ([]*github.User, *github.Response, error) {
+			return listTeamMembers(ctx, client, org, team.GetSlug(), opts)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list members of team %q: %w", team.GetSlug(), err)
+		}
+		for _, teamRepo := range teamRepos {
+			if _, checked := private[teamRepo.GetName()]; !checked {
+				continue
+			}
+			permission := highestPermission(teamRepo.GetPermissions())
+			for _, member := range members {
+				report.add(teamRepo.GetName(), teamRepo.GetPrivate(), member.GetLogin(), permission, sourceTeamPrefix+team.GetSlug())
+			}
+		}
+	}
+
+	sort.SliceStable(report.Entries, func(i, j int) bool {
+		a, b := report.Entries[i], report.Entries[j]
+		if a.Repository != b.Repository {
+			return a.Repository < b.Repository
+		}
+		if a.Permission != b.Permission {
+			return permissionIndex(a.Permission) > permissionIndex(b.Permission)
+		}
+		if a.User != b.User {
+			return a.User < b.User
+		}
+		return a.Source < b.Source
+	})
+	return report, nil
+}
+
+func (r *AccessReport) add(repo string, private bool, user, permission, source string) {
+	if contains(r.OrgAdmins, user) {
+		return
+	}
+	r.Entries = append(r.Entries, AccessEntry{
+		Repository:          repo,
+		Private:             private,
+		User:                user,
+		Permission:          permission,
+		Source:              source,
+		OutsideCollaborator: contains(r.OutsideCollaborators, user),
+	})
+}
+
+// effectivePermissions returns the highest permission per repository and user
+// over all sources.
+func (r *AccessReport) effectivePermissions() map[string]map[string]string {
+	result := map[string]map[string]string{}
+	for _, entry := range r.Entries {
+		if _, exists := result[entry.Repository]; !exists {
+			result[entry.Repository] = map[string]string{}
+		}
+		if permissionIndex(entry.Permission) > permissionIndex(result[entry.Repository][entry.User]) {
+			result[entry.Repository][entry.User] = entry.Permission
+		}
+	}
+	return result
+}
+
+// permissionMaps returns the effective permissions from checkPermissions in
+// the three views written as YAML, where a user having a permission is
+// listed for all lower permissions as well.
+func (r *AccessReport) permissionMaps() (AccessPermissionsToRepositoriesToCollaborators, RepositoriesToAccessPermissionsToCollaborators, CollaboratorsToAccessPermissionsToRepositories) {
+	accessPermissionsToRepositoriesToCollaborators := AccessPermissionsToRepositoriesToCollaborators{
+		"admin": RepositoriesToCollaborators{},
+	}
+	repositoriesToAccessPermissionsToCollaborators := RepositoriesToAccessPermissionsToCollaborators{}
+	collaboratorsToAccessPermissionsToRepositories := CollaboratorsToAccessPermissionsToRepositories{}
+	for _, repo := range r.Repositories {
+		accessPermissionsToRepositoriesToCollaborators["admin"][repo] = Collaborators{}
+		repositoriesToAccessPermissionsToCollaborators[repo] = AccessPermissionsToCollaborators{}
+	}
+
+	effectivePermissions := r.effectivePermissions()
+	for _, repo := range r.Repositories {
+		for _, user := range sortedKeys(effectivePermissions[repo]) {
+			for _, perm := range checkPermissions {
+				if permissionIndex(effectivePermissions[repo][user]) < permissionIndex(perm) {
+					continue
+				}
+				if _, ok := accessPermissionsToRepositoriesToCollaborators[perm]; !ok {
+					accessPermissionsToRepositoriesToCollaborators[perm] = RepositoriesToCollaborators{}
+				}
+				if _, ok := collaboratorsToAccessPermissionsToRepositories[user]; !ok {
+					collaboratorsToAccessPermissionsToRepositories[user] = AccessPermissionsToRepositories{}
+				}
+				accessPermissionsToRepositoriesToCollaborators[perm][repo] = append(accessPermissionsToRepositoriesToCollaborators[perm][repo], user)
+				repositoriesToAccessPermissionsToCollaborators[repo][perm] = append(repositoriesToAccessPermissionsToCollaborators[repo][perm], user)
+				collaboratorsToAccessPermissionsToRepositories[user][perm] = append(collaboratorsToAccessPermissionsToRepositories[user][perm], repo)
+			}
+		}
+	}
+	return accessPermissionsToRepositoriesToCollaborators, repositoriesToAccessPermissionsToCollaborators, collaboratorsToAccessPermissionsToRepositories
+}
+
+// highestPermission returns the highest permission that is granted.
+func highestPermission(permissions map[string]bool) string {
+	highest := ""
+	for _, permission := range rankedPermissions {
+		if permissions[permission] {
+			highest = permission
+		}
+	}
+	return highest
+}
+
+// permissionIndex returns the rank of the permission, -1 if unknown.
+func permissionIndex(permission string) int {
+	for i, p := range rankedPermissions {
+		if p == permission {
+			return i
+		}
+	}
+	return -1
+}
+
+func logins(users []*github.User) []string {
+	var result []string
+	for _, user := range users {
+		result = append(result, user.GetLogin())
+	}
+	sort.Strings(result)
+	return result
+}
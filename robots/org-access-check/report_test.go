@@ -0,0 +1,255 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/project-infra/pkg/github/ratelimit"
+)
+
+// fakeGitHubAPI serves the pages of the list endpoints, optionally rejecting
+// the first request to a path because of the rate limit.
+type fakeGitHubAPI struct {
+	*httptest.Server
+	pages        map[string][]interface{}
+	rateLimited  map[string]bool
+	requestedURL []string
+}
+
+func newFakeGitHubAPI(pages map[string][]interface{}) *fakeGitHubAPI {
+	f := &fakeGitHubAPI{pages: pages, rateLimited: map[string]bool{}}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.handle))
+	return f
+}
+
+func (f *fakeGitHubAPI) handle(w http.ResponseWriter, r *http.Request) {
+	f.requestedURL = append(f.requestedURL, r.URL.String())
+	w.Header().Set("Content-Type", "application/json")
+	if f.rateLimited[r.URL.Path] {
+		f.rateLimited[r.URL.Path] = false
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Unix(), 10))
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"message": "API rate limit exceeded for user ID 1."}`))
+		return
+	}
+	pages, exists := f.pages[r.URL.Path]
+	if !exists {
+		_, _ = w.Write([]byte("[]"))
+		return
+	}
+	page := 1
+	if r.URL.Query().Get("page") != "" {
+		page, _ = strconv.Atoi(r.URL.Query().Get("page"))
+	}
+	if page < len(pages) {
+		next := *r.URL
+		query := next.Query()
+		query.Set("page", strconv.Itoa(page+1))
+		next.RawQuery = query.Encode()
+		w.Header().Set("Link", fmt.Sprintf(`<%s%s>; rel="next"`, f.URL, next.String()))
+	}
+	Expect(json.NewEncoder(w).Encode(pages[page-1])).To(Succeed())
+}
+
+func users(logins ...string) []map[string]interface{} {
+	var result []map[string]interface{}
+	for _, login := range logins {
+		result = append(result, map[string]interface{}{"login": login})
+	}
+	return result
+}
+
+func collaborator(login string, permissions ...string) map[string]interface{} {
+	return map[string]interface{}{"login": login, "permissions": permissionMap(permissions...)}
+}
+
+func repository(name string, private bool, permissions ...string) map[string]interface{} {
+	return map[string]interface{}{"name": name, "private": private, "permissions": permissionMap(permissions...)}
+}
+
+func permissionMap(permissions ...string) map[string]bool {
+	result := map[string]bool{}
+	for _, permission := range permissions {
+		result[permission] = true
+	}
+	return result
+}
+
+var _ = Describe("collectAccess", func() {
+
+	var (
+		api    *fakeGitHubAPI
+		client *github.Client
+		sleeps []time.Duration
+	)
+
+	BeforeEach(func() {
+		api = newFakeGitHubAPI(map[string][]interface{}{
+			"/orgs/kubevirt/members":               {users("org-admin")},
+			"/orgs/kubevirt/outside_collaborators": {users("outsider")},
+			"/orgs/kubevirt/repos": {
+				[]map[string]interface{}{repository("kubevirt", false)},
+				[]map[string]interface{}{
+					repository("secrets", true),
+					{"name": "old-repo", "archived": true},
+				},
+			},
+			"/repos/kubevirt/kubevirt/collaborators": {
+				[]map[string]interface{}{
+					collaborator("org-admin", "pull", "push", "maintain", "admin"),
+					collaborator("outsider", "pull", "triage", "push"),
+				},
+				[]map[string]interface{}{
+					collaborator("maintainer", "pull", "push", "maintain"),
+				},
+			},
+			"/repos/kubevirt/secrets/collaborators": {
+				[]map[string]interface{}{collaborator("outsider", "pull")},
+			},
+			"/orgs/kubevirt/teams": {
+				[]map[string]interface{}{{"id": 1, "slug": "kubevirt-maintainers"}},
+			},
+			"/orgs/kubevirt/teams/kubevirt-maintainers/repos": {
+				[]map[string]interface{}{
+					repository("kubevirt", false, "pull", "push", "maintain", "admin"),
+					repository("secrets", true, "pull", "push"),
+					repository("old-repo", false, "pull", "push"),
+				},
+			},
+			"/orgs/kubevirt/teams/kubevirt-maintainers/members": {users("maintainer", "org-admin")},
+		})
+		client = github.NewClient(nil)
+		baseURL, err := url.Parse(api.URL + "/")
+		Expect(err).ToNot(HaveOccurred())
+		client.BaseURL = baseURL
+
+		sleeps = nil
+		ratelimit.Sleep = func(ctx context.Context, d time.Duration) error {
+			sleeps = append(sleeps, d)
+			return nil
+		}
+	})
+
+	AfterEach(func() {
+		api.Close()
+	})
+
+	It("collects direct and team permissions of public repositories across all pages", func() {
+		report, err := collectAccess(context.Background(), client, "kubevirt", false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.OrgAdmins).To(Equal([]string{"org-admin"}))
+		Expect(report.OutsideCollaborators).To(Equal([]string{"outsider"}))
+		Expect(report.Repositories).To(Equal([]string{"kubevirt"}))
+		Expect(report.Entries).To(Equal([]AccessEntry{
+			{Repository: "kubevirt", User: "maintainer", Permission: "admin", Source: "team/kubevirt-maintainers"},
+			{Repository: "kubevirt", User: "maintainer", Permission: "maintain", Source: "direct"},
+			{Repository: "kubevirt", User: "outsider", Permission: "push", Source: "direct", OutsideCollaborator: true},
+		}))
+		Expect(api.requestedURL).To(ContainElement("/orgs/kubevirt/repos?page=2&per_page=100&type=public"))
+		Expect(api.requestedURL).To(ContainElement("/repos/kubevirt/kubevirt/collaborators?affiliation=direct&page=2&per_page=100"))
+	})
+
+	It("includes private repositories if requested", func() {
+		report, err := collectAccess(context.Background(), client, "kubevirt", true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Repositories).To(Equal([]string{"kubevirt", "secrets"}))
+		Expect(report.Entries).To(ContainElements(
+			AccessEntry{Repository: "secrets", Private: true, User: "maintainer", Permission: "push", Source: "team/kubevirt-maintainers"},
+			AccessEntry{Repository: "secrets", Private: true, User: "outsider", Permission: "pull", Source: "direct", OutsideCollaborator: true},
+		))
+		Expect(api.requestedURL).To(ContainElement("/orgs/kubevirt/repos?per_page=100&type=all"))
+	})
+
+	It("retries after hitting the rate limit", func() {
+		api.rateLimited["/orgs/kubevirt/teams"] = true
+		report, err := collectAccess(context.Background(), client, "kubevirt", false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sleeps).To(Equal([]time.Duration{ratelimit.MinRetryAfter}))
+		Expect(report.Entries).To(HaveLen(3))
+	})
+
+	It("writes the permission maps and the entries as csv and html", func() {
+		report, err := collectAccess(context.Background(), client, "kubevirt", false)
+		Expect(err).ToNot(HaveOccurred())
+
+		accessPermissionsToRepositoriesToCollaborators, repositoriesToAccessPermissionsToCollaborators, collaboratorsToAccessPermissionsToRepositories := report.permissionMaps()
+		Expect(accessPermissionsToRepositoriesToCollaborators).To(Equal(AccessPermissionsToRepositoriesToCollaborators{
+			"admin":    {"kubevirt": {"maintainer"}},
+			"maintain": {"kubevirt": {"maintainer"}},
+			"push":     {"kubevirt": {"maintainer", "outsider"}},
+		}))
+		Expect(repositoriesToAccessPermissionsToCollaborators["kubevirt"]["push"]).To(Equal(Collaborators{"maintainer", "outsider"}))
+		Expect(collaboratorsToAccessPermissionsToRepositories["outsider"]).To(Equal(AccessPermissionsToRepositories{"push": {"kubevirt"}}))
+
+		outputDir := GinkgoT().TempDir()
+		for _, format := range supportedOutputFormats {
+			Expect(writeReport(report, format, outputDir)).To(Succeed())
+		}
+		files, err := filepath.Glob(filepath.Join(outputDir, "*"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(files).To(HaveLen(5))
+
+		csvFiles, err := filepath.Glob(filepath.Join(outputDir, "org-access*.csv"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(csvFiles).To(HaveLen(1))
+		csvFile, err := os.Open(csvFiles[0])
+		Expect(err).ToNot(HaveOccurred())
+		defer csvFile.Close()
+		records, err := csv.NewReader(csvFile).ReadAll()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(records).To(HaveLen(4))
+		Expect(records[3]).To(Equal([]string{"kubevirt", "false", "outsider", "push", "direct", "true"}))
+
+		htmlFiles, err := filepath.Glob(filepath.Join(outputDir, "org-access*.html"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(htmlFiles).To(HaveLen(1))
+		html, err := os.ReadFile(htmlFiles[0])
+		Expect(err).ToNot(HaveOccurred())
+		Expect(strings.Count(string(html), `<tr class="outside">`)).To(Equal(1))
+		Expect(string(html)).To(ContainSubstring("<td>team/kubevirt-maintainers</td>"))
+	})
+})
+
+var _ = Describe("outputFormats", func() {
+	It("rejects unsupported formats", func() {
+		var formats outputFormats
+		Expect(formats.Set("csv")).To(Succeed())
+		Expect(formats.Set("pdf")).ToNot(Succeed())
+		Expect(formats).To(Equal(outputFormats{"csv"}))
+	})
+})
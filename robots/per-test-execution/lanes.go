@@ -0,0 +1,121 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	prowconfig "sigs.k8s.io/prow/pkg/config"
+)
+
+const kubevirtRepo = "kubevirt/kubevirt"
+
+// Lane is a job whose runs are reported on.
+type Lane struct {
+	Name string
+	Type prowv1.ProwJobType
+}
+
+// laneRegexesForK8sVersion compiles the lane regexes, where %s is replaced with the quoted k8s version.
+func laneRegexesForK8sVersion(laneRegexes []string, k8sVersion string) ([]*regexp.Regexp, error) {
+	var result []*regexp.Regexp
+	for _, laneRegex := range laneRegexes {
+		if strings.Contains(laneRegex, "%s") {
+			laneRegex = fmt.Sprintf(laneRegex, regexp.QuoteMeta(k8sVersion))
+		}
+		compiled, err := regexp.Compile(laneRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid lane regex %q: %w", laneRegex, err)
+		}
+		result = append(result, compiled)
+	}
+	return result, nil
+}
+
+// discoverLanes returns the periodic, postsubmit and presubmit jobs for kubevirt/kubevirt from the job config at
+// jobConfigPath whose names match any of the regexes, sorted by name.
+// If baseBranch is not empty, only jobs that run against the base branch are returned. Periodics without
+// a ref for kubevirt/kubevirt are returned regardless of the base branch.
+func discoverLanes(jobConfigPath string, laneRegexes []*regexp.Regexp, baseBranch string) ([]Lane, error) {
+	jobConfig, err := prowconfig.ReadJobConfig(jobConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read job config %q: %w", jobConfigPath, err)
+	}
+
+	matches := func(jobName string) bool {
+		for _, laneRegex := range laneRegexes {
+			if laneRegex.MatchString(jobName) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var lanes []Lane
+	for _, periodic := range jobConfig.Periodics {
+		if !matches(periodic.Name) || !periodicRunsAgainst(periodic, baseBranch) {
+			continue
+		}
+		lanes = append(lanes, Lane{Name: periodic.Name, Type: prowv1.PeriodicJob})
+	}
+
+	postsubmits := jobConfig.PostsubmitsStatic[kubevirtRepo]
+	if err := prowconfig.SetPostsubmitRegexes(postsubmits); err != nil {
+		return nil, fmt.Errorf("invalid postsubmit branches: %w", err)
+	}
+	for _, postsubmit := range postsubmits {
+		if !matches(postsubmit.Name) || (baseBranch != "" && !postsubmit.Brancher.ShouldRun(baseBranch)) {
+			continue
+		}
+		lanes = append(lanes, Lane{Name: postsubmit.Name, Type: prowv1.PostsubmitJob})
+	}
+
+	presubmits := jobConfig.PresubmitsStatic[kubevirtRepo]
+	if err := prowconfig.SetPresubmitRegexes(presubmits); err != nil {
+		return nil, fmt.Errorf("invalid presubmit branches: %w", err)
+	}
+	for _, presubmit := range presubmits {
+		if !matches(presubmit.Name) || (baseBranch != "" && !presubmit.Brancher.ShouldRun(baseBranch)) {
+			continue
+		}
+		lanes = append(lanes, Lane{Name: presubmit.Name, Type: prowv1.PresubmitJob})
+	}
+
+	sort.Slice(lanes, func(i, j int) bool { return lanes[i].Name < lanes[j].Name })
+	// jobs with the same name might be defined for several release branches
+	return slices.CompactFunc(lanes, func(a, b Lane) bool { return a.Name == b.Name }), nil
+}
+
+func periodicRunsAgainst(periodic prowconfig.Periodic, baseBranch string) bool {
+	if baseBranch == "" {
+		return true
+	}
+	for _, ref := range periodic.ExtraRefs {
+		if ref.Org+"/"+ref.Repo == kubevirtRepo {
+			return ref.BaseRef == baseBranch
+		}
+	}
+	return true
+}
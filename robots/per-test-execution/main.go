@@ -43,12 +43,16 @@ import (
 	gojunit "github.com/joshdk/go-junit"
 	log "github.com/sirupsen/logrus"
 	"kubevirt.io/project-infra/pkg/flakefinder"
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 	"sigs.k8s.io/yaml"
 )
 
 const (
 	BucketName             = "kubevirt-prow"
 	defaultOutputDirectory = "/tmp"
+
+	// firstBuildColumn is the index of the first column in the csv files holding the test results per build
+	firstBuildColumn = 6
 )
 
 var (
@@ -68,7 +72,14 @@ var (
 )
 
 type Config struct {
+	// Lanes are the names of periodic jobs, where %s is replaced with the k8s version.
+	// Only used if LaneRegexes is empty.
 	Lanes []string `yaml:"lanes"`
+
+	// JobConfigPath is the path to the prow job config, from which the jobs matching any of the LaneRegexes
+	// (where %s is replaced with the k8s version) are used as lanes.
+	JobConfigPath string   `yaml:"jobConfigPath"`
+	LaneRegexes   []string `yaml:"laneRegexes"`
 }
 
 func init() {
@@ -89,6 +100,8 @@ type options struct {
 	Days            int
 	K8sVersion      string
 	ConfigPath      string
+	JobConfigPath   string
+	BaseBranch      string
 	outputDirectory string
 }
 
@@ -162,6 +175,12 @@ func (o options) validate() error {
 	} else {
 		log.Infof("using default configuration\n%s", string(defaultConfig))
 	}
+	if o.JobConfigPath != "" {
+		config.JobConfigPath = o.JobConfigPath
+	}
+	if len(config.LaneRegexes) > 0 && config.JobConfigPath == "" {
+		return fmt.Errorf("laneRegexes require a jobConfigPath")
+	}
 	if o.outputDirectory != defaultOutputDirectory {
 		_, err := os.Stat(o.outputDirectory)
 		if os.IsNotExist(err) {
@@ -180,6 +199,32 @@ type TestExecutions struct {
 	LatestFailureURL string
 }
 
+// PassRate returns the percentage of passed executions.
+func (t *TestExecutions) PassRate() float64 {
+	if t.TotalExecutions == 0 {
+		return 0
+	}
+	return 100 * float64(t.TotalExecutions-t.FailedExecutions) / float64(t.TotalExecutions)
+}
+
+// PassRateInterval returns the 95% Wilson confidence interval of the pass rate in percent.
+func (t *TestExecutions) PassRateInterval() (lower, upper float64) {
+	lower, upper = wilsonInterval(t.TotalExecutions-t.FailedExecutions, t.TotalExecutions, z95)
+	return 100 * lower, 100 * upper
+}
+
+// PassRateLower returns the lower bound of the 95% Wilson confidence interval of the pass rate in percent.
+func (t *TestExecutions) PassRateLower() float64 {
+	lower, _ := t.PassRateInterval()
+	return lower
+}
+
+// PassRateUpper returns the upper bound of the 95% Wilson confidence interval of the pass rate in percent.
+func (t *TestExecutions) PassRateUpper() float64 {
+	_, upper := t.PassRateInterval()
+	return upper
+}
+
 type TopXTestExecutions struct {
 	PerLaneExecutions   map[string][]*TestExecutions
 	SortedLinkFilenames []string
@@ -216,6 +261,8 @@ func main() {
 	flag.IntVar(&opts.Days, "days", 0, "determines how many days in the past till today are covered (if > 0, used instead of months)")
 	flag.StringVar(&opts.K8sVersion, "kubernetes-version", "", "the k8s major.minor version for the target lane, i.e. 1.31")
 	flag.StringVar(&opts.ConfigPath, "config-path", "", "path to the config file")
+	flag.StringVar(&opts.JobConfigPath, "job-config-path", "", "path to the prow job config to derive the lanes from, overrides the jobConfigPath from the config")
+	flag.StringVar(&opts.BaseBranch, "base-branch", "main", "only report runs against this base branch, all runs if empty")
 	flag.StringVar(&opts.outputDirectory, "output-directory", defaultOutputDirectory, "path to the output directory - if set other than the default, it will expect it to exist")
 	flag.Parse()
 
@@ -258,14 +305,18 @@ func main() {
 
 	log.Infof("Running reports for %s - %s", startOfReport.Format(time.DateOnly), endOfReport.Format(time.DateOnly))
 
-	jobDir := "logs"
+	lanes, err := lanesToReport()
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	ctx := context.TODO()
 	storageClient, err := storage.NewClient(ctx)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	reportFilenames, err := writeReportFiles(ctx, storageClient, startOfReport, endOfReport, jobDir, reportDir)
+	reportFilenames, err := writeReportFiles(ctx, storageClient, lanes, startOfReport, endOfReport, reportDir)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -306,7 +357,9 @@ func readTopXLaneTestExecutionsFromReportFiles(reportFilenames []string, topX in
 	}
 	sort.Slice(sortedLinkFilenames, func(i, j int) bool {
 		i1, j1 := topXLaneTestExecutions[sortedLinkFilenames[i]], topXLaneTestExecutions[sortedLinkFilenames[j]]
-		calculatePercentage := func(testExecutions []*TestExecutions) (failureRate float32) {
+		// rank by the lower bound of the failure rate, so that lanes with few executions
+		// are not ranked as flakier than they are
+		calculateFailureRateLowerBound := func(testExecutions []*TestExecutions) (failureRate float64) {
 			total, failed := 0, 0
 			for _, perTestExecution := range testExecutions {
 				total += perTestExecution.TotalExecutions
@@ -315,9 +368,10 @@ func readTopXLaneTestExecutionsFromReportFiles(reportFilenames []string, topX in
 			if total == 0 {
 				return 0
 			}
-			return float32(failed) / float32(total)
+			lower, _ := wilsonInterval(failed, total, z95)
+			return lower
 		}
-		return calculatePercentage(i1) > calculatePercentage(j1)
+		return calculateFailureRateLowerBound(i1) > calculateFailureRateLowerBound(j1)
 	})
 
 	log.Debugf("test executions fetched")
@@ -372,7 +426,7 @@ func readTopXFromFile(filename string, topX int) (string, []*TestExecutions, err
 
 		var failureLink string
 		if failedExecutions > 0 {
-			for i := len(headers) - 1; i > 0; i-- {
+			for i := len(headers) - 1; i >= firstBuildColumn; i-- {
 				if record[i] == "f" {
 					failureLink = headers[i]
 					break
@@ -395,11 +449,11 @@ type writeReportFileResult struct {
 	err            error
 }
 
-func writeReportFiles(ctx context.Context, storageClient *storage.Client, startOfReport time.Time, endOfReport time.Time, jobDir string, reportDir string) ([]string, error) {
-	log.Debugf("writing report files for lanes: %v", config.Lanes)
+func writeReportFiles(ctx context.Context, storageClient *storage.Client, lanes []Lane, startOfReport time.Time, endOfReport time.Time, reportDir string) ([]string, error) {
+	log.Debugf("writing report files for lanes: %v", lanes)
 
 	writeReportFileResults := make(chan writeReportFileResult)
-	go doWriteReportFiles(ctx, storageClient, startOfReport, endOfReport, jobDir, writeReportFileResults, reportDir)
+	go doWriteReportFiles(ctx, storageClient, lanes, startOfReport, endOfReport, writeReportFileResults, reportDir)
 
 	var fileNames []string
 	for result := range writeReportFileResults {
@@ -413,24 +467,65 @@ func writeReportFiles(ctx context.Context, storageClient *storage.Client, startO
 	return fileNames, nil
 }
 
-func doWriteReportFiles(ctx context.Context, storageClient *storage.Client, startOfReport time.Time, endOfReport time.Time, jobDir string, writeReportFileResults chan writeReportFileResult, reportDir string) {
+func doWriteReportFiles(ctx context.Context, storageClient *storage.Client, lanes []Lane, startOfReport time.Time, endOfReport time.Time, writeReportFileResults chan writeReportFileResult, reportDir string) {
 	defer close(writeReportFileResults)
 
 	var wg sync.WaitGroup
-	wg.Add(len(config.Lanes))
-	for _, periodicJobDirPattern := range config.Lanes {
-		periodicJobDir := fmt.Sprintf(periodicJobDirPattern, opts.K8sVersion)
-		go writeReportFile(&wg, ctx, storageClient, startOfReport, endOfReport, jobDir, periodicJobDir, writeReportFileResults, reportDir)
+	wg.Add(len(lanes))
+	for _, lane := range lanes {
+		go writeReportFile(&wg, ctx, storageClient, startOfReport, endOfReport, lane, writeReportFileResults, reportDir)
 	}
 	wg.Wait()
 }
 
-func writeReportFile(wg *sync.WaitGroup, ctx context.Context, storageClient *storage.Client, startOfReport time.Time, endOfReport time.Time, jobDir string, periodicJobDir string, writeReportFileResults chan writeReportFileResult, reportDir string) {
+// lanesToReport returns the lanes derived from the job config if lane regexes are configured, otherwise the
+// periodic lanes from the config.
+func lanesToReport() ([]Lane, error) {
+	if len(config.LaneRegexes) == 0 {
+		var lanes []Lane
+		for _, periodicJobDirPattern := range config.Lanes {
+			lanes = append(lanes, Lane{Name: fmt.Sprintf(periodicJobDirPattern, opts.K8sVersion), Type: prowv1.PeriodicJob})
+		}
+		return lanes, nil
+	}
+	laneRegexes, err := laneRegexesForK8sVersion(config.LaneRegexes, opts.K8sVersion)
+	if err != nil {
+		return nil, err
+	}
+	lanes, err := discoverLanes(config.JobConfigPath, laneRegexes, opts.BaseBranch)
+	if err != nil {
+		return nil, err
+	}
+	if len(lanes) == 0 {
+		return nil, fmt.Errorf("no lanes found in %q matching %v", config.JobConfigPath, config.LaneRegexes)
+	}
+	return lanes, nil
+}
+
+// findJobResults returns the results of the runs of the lane within the report interval, together with the urls
+// of the builds.
+func findJobResults(ctx context.Context, storageClient *storage.Client, startOfReport time.Time, endOfReport time.Time, lane Lane) ([]*flakefinder.JobResult, map[int]string, error) {
+	if lane.Type == prowv1.PresubmitJob {
+		return findPresubmitJobResults(ctx, storageClient, BucketName, lane.Name, opts.BaseBranch, startOfReport, endOfReport)
+	}
+	results, err := flakefinder.FindUnitTestFilesForPeriodicJob(ctx, storageClient, BucketName, []string{"logs", lane.Name}, startOfReport, endOfReport)
+	if err != nil {
+		return nil, nil, err
+	}
+	buildURLs := map[int]string{}
+	for _, result := range results {
+		buildURLs[result.BuildNumber] = fmt.Sprintf("https://prow.ci.kubevirt.io/view/gcs/kubevirt-prow/logs/%s/%d", lane.Name, result.BuildNumber)
+	}
+	return results, buildURLs, nil
+}
+
+func writeReportFile(wg *sync.WaitGroup, ctx context.Context, storageClient *storage.Client, startOfReport time.Time, endOfReport time.Time, lane Lane, writeReportFileResults chan writeReportFileResult, reportDir string) {
 	defer wg.Done()
+	periodicJobDir := lane.Name
 	log.Debugf("writing file for %q", periodicJobDir)
-	results, err := flakefinder.FindUnitTestFilesForPeriodicJob(ctx, storageClient, BucketName, []string{jobDir, periodicJobDir}, startOfReport, endOfReport)
+	results, buildURLs, err := findJobResults(ctx, storageClient, startOfReport, endOfReport, lane)
 	if err != nil {
-		writeReportFileResults <- writeReportFileResult{err: fmt.Errorf("failed to load periodicJobDirs for %v: %v", fmt.Sprintf("%s*", periodicJobDir), fmt.Errorf("error listing gcs objects: %v", err))}
+		writeReportFileResults <- writeReportFileResult{err: fmt.Errorf("failed to load job results for %v: %v", periodicJobDir, fmt.Errorf("error listing gcs objects: %v", err))}
 		return
 	}
 
@@ -451,9 +546,9 @@ func writeReportFile(wg *sync.WaitGroup, ctx context.Context, storageClient *sto
 		return
 	}
 	writer := csv.NewWriter(file)
-	headers := []string{"test-name", "number-of-executions", "number-of-failures"}
+	headers := []string{"test-name", "number-of-executions", "number-of-failures", "pass-rate", "pass-rate-lower-bound", "pass-rate-upper-bound"}
 	for _, buildNumber := range buildNumbers {
-		headers = append(headers, fmt.Sprintf("https://prow.ci.kubevirt.io/view/gcs/kubevirt-prow/logs/%s/%d", periodicJobDir, buildNumber))
+		headers = append(headers, buildURLs[buildNumber])
 	}
 	err = writer.Write(headers)
 	if err != nil {
@@ -463,7 +558,15 @@ func writeReportFile(wg *sync.WaitGroup, ctx context.Context, storageClient *sto
 	reportFileName := file.Name()
 	log.Debugf("writing values to file %q", reportFileName)
 	for _, testExecution := range sortedTestExecutions {
-		values := []string{testExecution.Name, strconv.Itoa(testExecution.TotalExecutions), strconv.Itoa(testExecution.FailedExecutions)}
+		lower, upper := testExecution.PassRateInterval()
+		values := []string{
+			testExecution.Name,
+			strconv.Itoa(testExecution.TotalExecutions),
+			strconv.Itoa(testExecution.FailedExecutions),
+			strconv.FormatFloat(testExecution.PassRate(), 'f', 2, 64),
+			strconv.FormatFloat(lower, 'f', 2, 64),
+			strconv.FormatFloat(upper, 'f', 2, 64),
+		}
 		for _, buildNumber := range buildNumbers {
 			value := ""
 			if executionStatus, statusExists := perBuildTestExecutions[testExecution.Name][buildNumber]; statusExists {
@@ -22,6 +22,8 @@ package main
 import (
 	"testing"
 
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
@@ -62,4 +64,72 @@ var _ = Describe("per-test-execution", func() {
 			),
 		)
 	})
+
+	Context("wilsonInterval", func() {
+		DescribeTable("returns the interval",
+			func(successes, total int, expectedLower, expectedUpper float64) {
+				lower, upper := wilsonInterval(successes, total, z95)
+				Expect(lower).To(BeNumerically("~", expectedLower, 0.0001))
+				Expect(upper).To(BeNumerically("~", expectedUpper, 0.0001))
+			},
+			Entry("no executions", 0, 0, 0.0, 1.0),
+			Entry("all passed", 10, 10, 0.7225, 1.0),
+			Entry("none passed", 0, 10, 0.0, 0.2775),
+			Entry("half passed", 50, 100, 0.4038, 0.5962),
+		)
+
+		It("is wider for fewer executions", func() {
+			lowerFew, upperFew := wilsonInterval(9, 10, z95)
+			lowerMany, upperMany := wilsonInterval(900, 1000, z95)
+			Expect(upperFew - lowerFew).To(BeNumerically(">", upperMany-lowerMany))
+		})
+	})
+
+	Context("TestExecutions", func() {
+		It("calculates the pass rate", func() {
+			testExecutions := &TestExecutions{TotalExecutions: 4, FailedExecutions: 1}
+			Expect(testExecutions.PassRate()).To(BeNumerically("~", 75.0, 0.0001))
+			Expect(testExecutions.PassRateLower()).To(BeNumerically("<", 75.0))
+			Expect(testExecutions.PassRateUpper()).To(BeNumerically(">", 75.0))
+		})
+
+		It("has zero pass rate without executions", func() {
+			Expect((&TestExecutions{}).PassRate()).To(BeZero())
+		})
+	})
+
+	Context("discoverLanes", func() {
+		laneRegexes := []string{`^(periodic|pull)-kubevirt-e2e-k8s-%s-sig-(compute|storage)`}
+
+		DescribeTable("returns the lanes",
+			func(k8sVersion, baseBranch string, expected []Lane) {
+				regexes, err := laneRegexesForK8sVersion(laneRegexes, k8sVersion)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(discoverLanes("testdata/jobs", regexes, baseBranch)).To(Equal(expected))
+			},
+			Entry("for main", "1.35", "main", []Lane{
+				{Name: "periodic-kubevirt-e2e-k8s-1.35-sig-compute", Type: prowv1.PeriodicJob},
+				{Name: "pull-kubevirt-e2e-k8s-1.35-sig-compute", Type: prowv1.PresubmitJob},
+			}),
+			Entry("for a release branch", "1.35", "release-1.9", []Lane{
+				{Name: "periodic-kubevirt-e2e-k8s-1.35-sig-storage-release-1.9", Type: prowv1.PeriodicJob},
+				{Name: "pull-kubevirt-e2e-k8s-1.35-sig-storage", Type: prowv1.PresubmitJob},
+			}),
+			Entry("for all branches", "1.35", "", []Lane{
+				{Name: "periodic-kubevirt-e2e-k8s-1.35-sig-compute", Type: prowv1.PeriodicJob},
+				{Name: "periodic-kubevirt-e2e-k8s-1.35-sig-storage-release-1.9", Type: prowv1.PeriodicJob},
+				{Name: "pull-kubevirt-e2e-k8s-1.35-sig-compute", Type: prowv1.PresubmitJob},
+				{Name: "pull-kubevirt-e2e-k8s-1.35-sig-storage", Type: prowv1.PresubmitJob},
+			}),
+			Entry("for another k8s version", "1.34", "main", []Lane{
+				{Name: "periodic-kubevirt-e2e-k8s-1.34-sig-compute", Type: prowv1.PeriodicJob},
+			}),
+			Entry("not matching the k8s version as regex", "1.3.", "main", nil),
+		)
+
+		It("fails on an invalid regex", func() {
+			_, err := laneRegexesForK8sVersion([]string{"(%s"}, "1.35")
+			Expect(err).To(HaveOccurred())
+		})
+	})
 })
@@ -0,0 +1,197 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/storage"
+	gojunit "github.com/joshdk/go-junit"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/api/iterator"
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+
+	"kubevirt.io/project-infra/pkg/flakefinder"
+)
+
+const maxConcurrentRunReads = 10
+
+// buildLink is a link to the build directory of a presubmit or batch run, as stored by prow in
+// pr-logs/directory/<job>/<build>.txt
+type buildLink struct {
+	buildNumber int
+	object      string
+}
+
+// findPresubmitJobResults returns the results of the presubmit and batch runs of the job that have been started
+// within the report interval and have finished, together with the urls of the builds.
+// If baseBranch is not empty, only runs against that base branch are returned.
+func findPresubmitJobResults(ctx context.Context, client *storage.Client, bucket, jobName, baseBranch string, startOfReport, endOfReport time.Time) ([]*flakefinder.JobResult, map[int]string, error) {
+	links, err := listBuildLinks(ctx, client, bucket, jobName, startOfReport, endOfReport)
+	if err != nil {
+		return nil, nil, err
+	}
+	log.Debugf("found %d runs for %s", len(links), jobName)
+
+	var mutex sync.Mutex
+	var results []*flakefinder.JobResult
+	buildURLs := map[int]string{}
+	var errs []error
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, maxConcurrentRunReads)
+	for _, link := range links {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(link buildLink) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			result, buildDir, err := readPresubmitJobResult(ctx, client, bucket, jobName, baseBranch, link)
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			if result == nil {
+				return
+			}
+			results = append(results, result)
+			buildURLs[result.BuildNumber] = fmt.Sprintf("https://prow.ci.kubevirt.io/view/gcs/%s/%s", bucket, buildDir)
+		}(link)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return nil, nil, errors.Join(errs...)
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].BuildNumber > results[j].BuildNumber })
+	return results, buildURLs, nil
+}
+
+// listBuildLinks lists the links to the build directories of the job, where the link has been created within the
+// report interval. Since prow creates the link when the run starts, this is the start time of the run.
+func listBuildLinks(ctx context.Context, client *storage.Client, bucket, jobName string, startOfReport, endOfReport time.Time) ([]buildLink, error) {
+	prefix := path.Join("pr-logs", "directory", jobName) + "/"
+	objects := client.Bucket(bucket).Objects(ctx, &storage.Query{Prefix: prefix, Delimiter: "/"})
+	var links []buildLink
+	for {
+		attrs, err := objects.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error listing gcs objects gs://%s/%s: %w", bucket, prefix, err)
+		}
+		if attrs.Name == "" || !strings.HasSuffix(attrs.Name, ".txt") {
+			continue
+		}
+		if attrs.Created.Before(startOfReport) || attrs.Created.After(endOfReport) {
+			continue
+		}
+		buildNumber, err := strconv.Atoi(strings.TrimSuffix(path.Base(attrs.Name), ".txt"))
+		if err != nil {
+			continue
+		}
+		links = append(links, buildLink{buildNumber: buildNumber, object: attrs.Name})
+	}
+	return links, nil
+}
+
+// readPresubmitJobResult reads the result of the run the link points to. It returns nil if the run has not
+// finished, has no junit results or doesn't match the base branch.
+func readPresubmitJobResult(ctx context.Context, client *storage.Client, bucket, jobName, baseBranch string, link buildLink) (*flakefinder.JobResult, string, error) {
+	content, err := readObject(ctx, client, bucket, link.object)
+	if err != nil {
+		return nil, "", err
+	}
+	buildDir := strings.TrimPrefix(strings.TrimSpace(string(content)), "gs://"+bucket+"/")
+
+	content, err = readObject(ctx, client, bucket, path.Join(buildDir, prowv1.ProwJobFile))
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	var prowJob prowv1.ProwJob
+	if err := json.Unmarshal(content, &prowJob); err != nil {
+		return nil, "", fmt.Errorf("failed to parse %s in %s: %w", prowv1.ProwJobFile, buildDir, err)
+	}
+	refs := prowJob.Spec.Refs
+	if refs == nil || (baseBranch != "" && refs.BaseRef != baseBranch) {
+		return nil, "", nil
+	}
+
+	_, err = flakefinder.ReadGcsObjectAttrs(ctx, client, bucket, path.Join(buildDir, "finished.json"))
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		// build still running
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	data, err := readObject(ctx, client, bucket, path.Join(buildDir, "artifacts", "junit.functest.xml"))
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		log.Debugf("no junit results for %s", buildDir)
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	suites, err := gojunit.Ingest(data)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to ingest junit results of %s: %w", buildDir, err)
+	}
+
+	result := &flakefinder.JobResult{Job: jobName, JUnit: suites, BuildNumber: link.buildNumber}
+	switch prowJob.Spec.Type {
+	case prowv1.BatchJob:
+		for _, pull := range refs.Pulls {
+			result.BatchPRs = append(result.BatchPRs, pull.Number)
+		}
+	default:
+		if len(refs.Pulls) > 0 {
+			result.PR = refs.Pulls[0].Number
+			result.CommitID = refs.Pulls[0].SHA
+		}
+	}
+	return result, buildDir, nil
+}
+
+func readObject(ctx context.Context, client *storage.Client, bucket, object string) ([]byte, error) {
+	reader, err := client.Bucket(bucket).Object(object).NewReader(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gs://%s/%s: %w", bucket, object, err)
+	}
+	defer func() { _ = reader.Close() }()
+	return io.ReadAll(reader)
+}
@@ -0,0 +1,42 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import "math"
+
+// z95 is the z-score for a 95% confidence level
+const z95 = 1.96
+
+// wilsonInterval returns the Wilson score interval for the proportion of successes out of total trials at the
+// confidence level given by z. Other than the plain proportion it accounts for the number of trials, thus a
+// proportion from a few trials results in a wide interval.
+// See https://en.wikipedia.org/wiki/Binomial_proportion_confidence_interval#Wilson_score_interval
+func wilsonInterval(successes, total int, z float64) (lower, upper float64) {
+	if total == 0 {
+		return 0, 1
+	}
+	n := float64(total)
+	p := float64(successes) / n
+	z2 := z * z
+	denominator := 1 + z2/n
+	center := (p + z2/(2*n)) / denominator
+	margin := z * math.Sqrt(p*(1-p)/n+z2/(4*n*n)) / denominator
+	return math.Max(0, center-margin), math.Min(1, center+margin)
+}
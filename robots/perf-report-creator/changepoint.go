@@ -0,0 +1,177 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+type changePointOpts struct {
+	// minSegmentSize is the minimum number of runs before and after a change point
+	minSegmentSize int
+	// minRelativeChange is the minimum change of the mean relative to the mean before the change point
+	minRelativeChange float64
+	// minTStatistic is the minimum absolute value of the Welch t-statistic of the runs before and after the
+	// change point
+	minTStatistic float64
+}
+
+// changePoint is a shift of the mean of a series, where Index is the index of the first value after the shift.
+type changePoint struct {
+	Index      int
+	MeanBefore float64
+	MeanAfter  float64
+	TStatistic float64
+}
+
+// detectChangePoints finds the shifts of the mean in the series by binary segmentation. The candidate for a
+// change point in a segment is where the cumulative sum of the deviations from the mean of the segment (CUSUM)
+// has its maximum absolute value. The candidate is accepted if the means before and after it differ
+// significantly, then both parts are searched for further change points. Otherwise, since a shift that is
+// reverted within the segment doesn't show at a single split, the pair of candidates where the cumulative sum
+// has its largest range is tested the same way, comparing the values in between them with the values outside.
+// The returned change points are ordered by index, their means and t-statistic are those of the values up to
+// the neighbouring change points.
+func detectChangePoints(values []float64, opts changePointOpts) []changePoint {
+	minSegmentSize := max(opts.minSegmentSize, 2)
+
+	var indexes []int
+	var search func(start, end int)
+	search = func(start, end int) {
+		segment := values[start:end]
+		if candidate, found := cusumCandidate(segment, minSegmentSize); found && isSignificantShift(segment[:candidate], segment[candidate:], opts) {
+			split := start + candidate
+			indexes = append(indexes, split)
+			search(start, split)
+			search(split, end)
+			return
+		}
+		first, second, found := epidemicCandidates(segment, minSegmentSize)
+		if !found {
+			return
+		}
+		outside := append(append([]float64{}, segment[:first]...), segment[second:]...)
+		if !isSignificantShift(outside, segment[first:second], opts) {
+			return
+		}
+		first, second = start+first, start+second
+		indexes = append(indexes, first, second)
+		search(start, first)
+		search(first, second)
+		search(second, end)
+	}
+	search(0, len(values))
+	sort.Ints(indexes)
+
+	var changePoints []changePoint
+	for i, index := range indexes {
+		start, end := 0, len(values)
+		if i > 0 {
+			start = indexes[i-1]
+		}
+		if i < len(indexes)-1 {
+			end = indexes[i+1]
+		}
+		before, after := values[start:index], values[index:end]
+		changePoints = append(changePoints, changePoint{
+			Index:      index,
+			MeanBefore: mean(before),
+			MeanAfter:  mean(after),
+			TStatistic: welchTStatistic(before, after),
+		})
+	}
+	return changePoints
+}
+
+// cusumCandidate returns the index of the first value after the most likely shift of the mean, leaving at least
+// minSegmentSize values on both sides.
+func cusumCandidate(values []float64, minSegmentSize int) (int, bool) {
+	if len(values) < 2*minSegmentSize {
+		return 0, false
+	}
+	m := mean(values)
+	candidate, maxDeviation := 0, -1.0
+	sum := 0.0
+	for i := 0; i <= len(values)-minSegmentSize; i++ {
+		if i >= minSegmentSize && math.Abs(sum) > maxDeviation {
+			candidate, maxDeviation = i, math.Abs(sum)
+		}
+		sum += values[i] - m
+	}
+	return candidate, true
+}
+
+// epidemicCandidates returns the indexes of the first value after a shift of the mean and of the first value after
+// the shift has been reverted, leaving at least minSegmentSize values before, in between and after them.
+func epidemicCandidates(values []float64, minSegmentSize int) (int, int, bool) {
+	if len(values) < 3*minSegmentSize {
+		return 0, 0, false
+	}
+	m := mean(values)
+	sums := make([]float64, len(values)+1)
+	for i, value := range values {
+		sums[i+1] = sums[i] + value - m
+	}
+	first, second, maxRange := 0, 0, -1.0
+	for i := minSegmentSize; i <= len(values)-2*minSegmentSize; i++ {
+		for j := i + minSegmentSize; j <= len(values)-minSegmentSize; j++ {
+			if r := math.Abs(sums[j] - sums[i]); r > maxRange {
+				first, second, maxRange = i, j, r
+			}
+		}
+	}
+	return first, second, true
+}
+
+func isSignificantShift(before, after []float64, opts changePointOpts) bool {
+	return math.Abs(welchTStatistic(before, after)) >= opts.minTStatistic &&
+		relativeChange(mean(before), mean(after)) >= opts.minRelativeChange
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	return sum / float64(len(values))
+}
+
+// variance returns the sample variance of the values.
+func variance(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	m := mean(values)
+	sum := 0.0
+	for _, value := range values {
+		sum += (value - m) * (value - m)
+	}
+	return sum / float64(len(values)-1)
+}
+
+// welchTStatistic returns the t-statistic for the difference of the means of after and before, which is infinite
+// if the means differ while both samples have no variance.
+func welchTStatistic(before, after []float64) float64 {
+	difference := mean(after) - mean(before)
+	standardError := math.Sqrt(variance(before)/float64(len(before)) + variance(after)/float64(len(after)))
+	if standardError == 0 {
+		if difference == 0 {
+			return 0
+		}
+		return math.Copysign(math.Inf(1), difference)
+	}
+	return difference / standardError
+}
+
+// relativeChange returns the absolute change from before to after relative to before.
+func relativeChange(before, after float64) float64 {
+	if before == 0 {
+		if after == 0 {
+			return 0
+		}
+		return math.Inf(1)
+	}
+	return math.Abs(after-before) / math.Abs(before)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("change point detection", func() {
+	opts := changePointOpts{minSegmentSize: 5, minRelativeChange: 0.1, minTStatistic: 3}
+
+	indexes := func(changePoints []changePoint) []int {
+		var result []int
+		for _, cp := range changePoints {
+			result = append(result, cp.Index)
+		}
+		return result
+	}
+
+	It("finds no change point in a noisy flat series", func() {
+		values := []float64{10, 11, 10, 9, 10, 11, 10, 9, 10, 11, 10, 9}
+		Expect(detectChangePoints(values, opts)).To(BeEmpty())
+	})
+
+	It("finds a single shift", func() {
+		values := []float64{10, 11, 10, 9, 10, 11, 20, 21, 20, 19, 20, 21}
+		changePoints := detectChangePoints(values, opts)
+		Expect(indexes(changePoints)).To(Equal([]int{6}))
+		Expect(changePoints[0].MeanBefore).To(BeNumerically("~", 10.1667, 0.001))
+		Expect(changePoints[0].MeanAfter).To(BeNumerically("~", 20.1667, 0.001))
+		Expect(changePoints[0].TStatistic).To(BeNumerically(">", 3))
+	})
+
+	It("finds a shift back", func() {
+		values := []float64{10, 11, 10, 9, 10, 20, 21, 20, 19, 20, 10, 11, 10, 9, 10}
+		Expect(indexes(detectChangePoints(values, opts))).To(Equal([]int{5, 10}))
+	})
+
+	It("ignores a shift that is too small", func() {
+		values := []float64{100, 100, 100, 100, 100, 105, 105, 105, 105, 105}
+		Expect(detectChangePoints(values, opts)).To(BeEmpty())
+	})
+
+	It("ignores a shift with too few runs after it", func() {
+		values := []float64{10, 11, 10, 9, 10, 11, 10, 9, 20, 21}
+		Expect(detectChangePoints(values, opts)).To(BeEmpty())
+	})
+
+	It("finds a shift in a series without noise", func() {
+		values := []float64{1, 1, 1, 1, 1, 2, 2, 2, 2, 2}
+		Expect(indexes(detectChangePoints(values, opts))).To(Equal([]int{5}))
+	})
+})
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	grob "github.com/MetalBlueberry/go-plotly/graph_objects"
+)
+
+//go:embed dashboard.gohtml
+var dashboardTemplate string
+
+const dashboardDateTimeFormat = "2006-01-02 15:04:05"
+
+type dashboardOpts struct {
+	resultsDir       string
+	outputDir        string
+	jobs             string
+	excludeJobsRegex string
+	since            string
+	releaseConfig    string
+	metricsSchema    string
+	scrape           bool
+	scrapeSince      time.Duration
+	credentialsFile  string
+}
+
+type dashboardPage struct {
+	Title     string
+	Created   time.Time
+	Jobs      []string
+	Resources []dashboardResource
+}
+
+type dashboardResource struct {
+	Name    string
+	Metrics []dashboardMetric
+}
+
+type dashboardMetric struct {
+	ID     string
+	Name   ResultType
+	Figure template.JS
+}
+
+// runDashboard writes a static site showing the values of every metric for each job, optionally collecting the
+// results of the jobs before.
+func runDashboard(d dashboardOpts) error {
+	jobs := splitList(d.jobs)
+	if d.scrape {
+		if len(jobs) == 0 {
+			return fmt.Errorf("jobs are required to collect results")
+		}
+		for _, job := range jobs {
+			err := runResults(resultOpts{
+				outputDir:          d.resultsDir,
+				performanceJobName: job,
+				since:              d.scrapeSince,
+				credentialsFile:    d.credentialsFile,
+				metricsSchema:      d.metricsSchema,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to collect results for %s: %v", job, err)
+			}
+		}
+	}
+
+	var err error
+	if len(jobs) == 0 {
+		jobs, err = listJobDirs(d.resultsDir)
+		if err != nil {
+			return err
+		}
+	}
+	if d.excludeJobsRegex != "" {
+		excludeJobsRegex, err := regexp.Compile(d.excludeJobsRegex)
+		if err != nil {
+			return fmt.Errorf("invalid exclude-jobs-regex: %v", err)
+		}
+		jobs = filterJobs(jobs, excludeJobsRegex)
+	}
+
+	schema, err := loadMetricSchema(d.metricsSchema)
+	if err != nil {
+		return err
+	}
+
+	page := dashboardPage{Title: "KubeVirt performance", Created: time.Now(), Jobs: jobs}
+	var since time.Time
+	var lineShapes []*LineShape
+	if d.releaseConfig != "" {
+		config, err := parseReleaseConfig(d.releaseConfig)
+		if err != nil {
+			return fmt.Errorf("failed to parse release config: %v", err)
+		}
+		since, err = time.Parse(DateFormat, config.SinceDate)
+		if err != nil {
+			return fmt.Errorf("failed to parse sinceDate from release config: %v", err)
+		}
+		lineShapes = config.LineShapes
+		page.Title = fmt.Sprintf("KubeVirt performance %s", config.ReleaseVersion)
+	} else if d.since != "" {
+		since, err = time.Parse(DateFormat, d.since)
+		if err != nil {
+			return fmt.Errorf("failed to parse since: %v", err)
+		}
+	}
+
+	collections := map[string]*Collection{}
+	for _, job := range jobs {
+		collection, err := readCollection(filepath.Join(d.resultsDir, job))
+		if err != nil {
+			return fmt.Errorf("failed to read results of %s: %v", job, err)
+		}
+		collections[job] = collection
+	}
+
+	page.Resources, err = dashboardResources(jobs, collections, schema, since, lineShapes)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(d.outputDir, 0755)
+	if err != nil {
+		return err
+	}
+	return writeToFile(filepath.Join(d.outputDir, "index.html"), func(w io.Writer) error {
+		return writeDashboard(w, page)
+	})
+}
+
+// dashboardResources returns per resource a figure for every metric, comparing the values of the jobs.
+func dashboardResources(jobs []string, collections map[string]*Collection, schema *MetricSchema, since time.Time, lineShapes []*LineShape) ([]dashboardResource, error) {
+	metricsPerResource := map[string]map[string]struct{}{}
+	for _, collection := range collections {
+		for _, resource := range collection.resources() {
+			if metricsPerResource[resource] == nil {
+				metricsPerResource[resource] = map[string]struct{}{}
+			}
+			for _, metric := range collection.metrics(resource) {
+				metricsPerResource[resource][metric] = struct{}{}
+			}
+		}
+	}
+
+	var resources []dashboardResource
+	for _, resource := range sortedKeys(setOf(metricsPerResource)) {
+		dr := dashboardResource{Name: resource}
+		for _, metric := range sortedKeys(metricsPerResource[resource]) {
+			series := map[string][]RunValue{}
+			aggregations := map[string]Aggregation{}
+			for _, job := range jobs {
+				series[job] = filterSince(getMetricSeries(collections[job], job, resource, ResultType(metric))[ResultType(metric)], since)
+				aggregations[job] = schema.aggregationFor(job, resource, ResultType(metric))
+			}
+			fig := dashboardFigure(fmt.Sprintf("%s for %s", metric, resource), jobs, series, aggregations, lineShapes)
+			figure, err := json.Marshal(fig)
+			if err != nil {
+				return nil, err
+			}
+			dr.Metrics = append(dr.Metrics, dashboardMetric{
+				ID:     fmt.Sprintf("%s-%s", resource, metric),
+				Name:   ResultType(metric),
+				Figure: template.JS(figure),
+			})
+		}
+		resources = append(resources, dr)
+	}
+	return resources, nil
+}
+
+// dashboardFigure returns a figure showing per job the value of each run, linking to the run, and the weekly
+// aggregate, together with the threshold of the latest run and the release markers.
+func dashboardFigure(title string, jobs []string, series map[string][]RunValue, aggregations map[string]Aggregation, lineShapes []*LineShape) *grob.Fig {
+	fig := &grob.Fig{
+		Layout: &grob.Layout{
+			Title:     &grob.LayoutTitle{Text: title},
+			Xaxis:     &grob.LayoutXaxis{Type: grob.LayoutXaxisTypeDate},
+			Hovermode: grob.LayoutHovermodeClosest,
+		},
+	}
+	shapes := releaseShapes(lineShapes)
+	for _, job := range jobs {
+		runs := series[job]
+		if len(runs) == 0 {
+			continue
+		}
+		var x []string
+		var y []float64
+		var urls []string
+		for _, run := range runs {
+			x = append(x, run.Date.Format(dashboardDateTimeFormat))
+			y = append(y, run.Value)
+			urls = append(urls, run.URL)
+		}
+		fig.Data = append(fig.Data, &grob.Scatter{
+			Name:          job,
+			Legendgroup:   job,
+			Mode:          grob.ScatterModeMarkers,
+			X:             x,
+			Y:             y,
+			Customdata:    urls,
+			Hovertemplate: "%{x}<br>%{y}<br>%{customdata}<extra>" + job + "</extra>",
+		})
+
+		weekX, weekY := weeklyAggregates(runs, aggregations[job])
+		fig.Data = append(fig.Data, &grob.Scatter{
+			Name:        fmt.Sprintf("%s weekly %s", job, aggregations[job]),
+			Legendgroup: job,
+			Mode:        grob.ScatterModeLines,
+			X:           weekX,
+			Y:           weekY,
+		})
+
+		latest := runs[len(runs)-1]
+		if latest.ThresholdValue != 0 {
+			shapes = append(shapes, map[string]interface{}{
+				"type": "line",
+				"xref": "paper",
+				"x0":   0,
+				"x1":   1,
+				"y0":   latest.ThresholdValue,
+				"y1":   latest.ThresholdValue,
+				"line": map[string]interface{}{
+					"color": "red",
+					"width": 1,
+					"dash":  "dash",
+				},
+				"label": map[string]string{
+					"text":         fmt.Sprintf("threshold %s", job),
+					"textposition": "end",
+					"yanchor":      "bottom",
+				},
+			})
+		}
+	}
+	if len(shapes) > 0 {
+		fig.Layout.Shapes = shapes
+	}
+	return fig
+}
+
+// weeklyAggregates returns the Monday of each week and the aggregate of the values of the runs in that week.
+func weeklyAggregates(runs []RunValue, aggregation Aggregation) ([]string, []float64) {
+	valuesPerWeek := map[string][]float64{}
+	for _, run := range runs {
+		monday := mondayOf(run.Date).Format(DateFormat)
+		valuesPerWeek[monday] = append(valuesPerWeek[monday], run.Value)
+	}
+	mondays := sortedKeys(setOf(valuesPerWeek))
+	values := make([]float64, 0, len(mondays))
+	for _, monday := range mondays {
+		values = append(values, aggregate(valuesPerWeek[monday], aggregation))
+	}
+	return mondays, values
+}
+
+// mondayOf returns the start of the Monday of the week of the date.
+func mondayOf(date time.Time) time.Time {
+	daysSinceMonday := (int(date.Weekday()) + 6) % 7
+	return time.Date(date.Year(), date.Month(), date.Day()-daysSinceMonday, 0, 0, 0, 0, date.Location())
+}
+
+func writeDashboard(w io.Writer, page dashboardPage) error {
+	tmpl, err := template.New("dashboard").Parse(dashboardTemplate)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, page); err != nil {
+		return err
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// listJobDirs returns the names of the job directories in the results directory.
+func listJobDirs(resultsDir string) ([]string, error) {
+	entries, err := os.ReadDir(resultsDir)
+	if err != nil {
+		return nil, err
+	}
+	var jobs []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			jobs = append(jobs, entry.Name())
+		}
+	}
+	return jobs, nil
+}
+
+func filterJobs(jobs []string, excludeJobsRegex *regexp.Regexp) []string {
+	var result []string
+	for _, job := range jobs {
+		if !excludeJobsRegex.MatchString(job) {
+			result = append(result, job)
+		}
+	}
+	return result
+}
+
+func filterSince(runs []RunValue, since time.Time) []RunValue {
+	var result []RunValue
+	for _, run := range runs {
+		if !run.Date.Before(since) {
+			result = append(result, run)
+		}
+	}
+	return result
+}
+
+func setOf[V any](m map[string]V) map[string]struct{} {
+	set := map[string]struct{}{}
+	for key := range m {
+		set[key] = struct{}{}
+	}
+	return set
+}
+
+func splitList(list string) []string {
+	var result []string
+	for _, entry := range strings.Split(list, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("dashboard", func() {
+	const (
+		job     = "periodic-kubevirt-e2e-k8s-1.33-sig-performance"
+		kwokJob = "periodic-kubevirt-e2e-k8s-1.33-sig-performance-kwok"
+	)
+
+	var resultsDir, outputDir string
+
+	newCollection := func(start time.Time, values ...float64) *Collection {
+		c := Collection{}
+		for i, value := range values {
+			c[fmt.Sprintf("%d", 1000+i)] = JobRun{
+				JobDirCreationTime: start.Add(time.Duration(i) * 24 * time.Hour),
+				Results: map[string]*Result{"vmi": {Values: map[ResultType]ResultValue{
+					ResultTypeVMICreationToRunningP95: {Value: value, ThresholdResult: &ThresholdResult{ThresholdValue: 33}},
+				}}},
+			}
+		}
+		return &c
+	}
+
+	BeforeEach(func() {
+		resultsDir = GinkgoT().TempDir()
+		outputDir = filepath.Join(GinkgoT().TempDir(), "dashboard")
+		start := time.Date(2025, 1, 6, 12, 0, 0, 0, time.UTC)
+		Expect(writeCollection(newCollection(start, 10, 20, 30), resultsDir, job)).To(Succeed())
+		Expect(writeCollection(newCollection(start, 1, 2), resultsDir, kwokJob)).To(Succeed())
+	})
+
+	readDashboard := func() string {
+		content, err := os.ReadFile(filepath.Join(outputDir, "index.html"))
+		Expect(err).ToNot(HaveOccurred())
+		return string(content)
+	}
+
+	It("shows the runs of all jobs", func() {
+		Expect(runDashboard(dashboardOpts{resultsDir: resultsDir, outputDir: outputDir})).To(Succeed())
+		dashboard := readDashboard()
+		Expect(dashboard).To(ContainSubstring(`<a href="#vmi-vmiCreationToRunningSecondsP95">vmiCreationToRunningSecondsP95</a>`))
+		Expect(dashboard).To(ContainSubstring(`"name":"` + job + `"`))
+		Expect(dashboard).To(ContainSubstring(`"name":"` + kwokJob + `"`))
+		Expect(dashboard).To(ContainSubstring(`"name":"` + job + ` weekly avg"`))
+		Expect(dashboard).To(ContainSubstring(`https://prow.ci.kubevirt.io/view/gcs/kubevirt-prow/logs/` + job + `/1002`))
+		Expect(dashboard).To(ContainSubstring(`"text":"threshold ` + job + `"`))
+	})
+
+	It("excludes jobs", func() {
+		Expect(runDashboard(dashboardOpts{resultsDir: resultsDir, outputDir: outputDir, excludeJobsRegex: "kwok"})).To(Succeed())
+		Expect(readDashboard()).ToNot(ContainSubstring(kwokJob))
+	})
+
+	It("shows the release markers and only the runs since the release config date", func() {
+		releaseConfig := filepath.Join(GinkgoT().TempDir(), "shape.yaml")
+		Expect(os.WriteFile(releaseConfig, []byte(`
+releaseVersion: "v1-5"
+sinceDate: "2025-01-07"
+lineShapes:
+  - type: line
+    x0: "2025-01-07"
+    x1: "2025-01-07"
+    y0: 0
+    y1: 1
+    yref: paper
+    label:
+      text: v1.5.0
+`), 0644)).To(Succeed())
+		Expect(runDashboard(dashboardOpts{resultsDir: resultsDir, outputDir: outputDir, jobs: job, releaseConfig: releaseConfig})).To(Succeed())
+		dashboard := readDashboard()
+		Expect(dashboard).To(ContainSubstring("<title>KubeVirt performance v1-5</title>"))
+		Expect(dashboard).To(ContainSubstring(`"text":"v1.5.0"`))
+		Expect(dashboard).ToNot(ContainSubstring(`/1000"`))
+		Expect(dashboard).To(ContainSubstring(`/1001"`))
+	})
+
+	It("requires jobs to scrape", func() {
+		Expect(runDashboard(dashboardOpts{resultsDir: resultsDir, outputDir: outputDir, scrape: true})).ToNot(Succeed())
+	})
+
+	It("aggregates the runs per week", func() {
+		runs := []RunValue{
+			{Date: time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC), Value: 1},
+			{Date: time.Date(2025, 1, 8, 0, 0, 0, 0, time.UTC), Value: 3},
+			{Date: time.Date(2025, 1, 13, 0, 0, 0, 0, time.UTC), Value: 10},
+		}
+		x, y := weeklyAggregates(runs, AggregationMax)
+		Expect(x).To(Equal([]string{"2025-01-06", "2025-01-13"}))
+		Expect(y).To(Equal([]float64{3, 10}))
+	})
+})
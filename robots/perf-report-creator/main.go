@@ -8,13 +8,16 @@ import (
 	"io"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	. "kubevirt.io/project-infra/pkg/flakefinder"
 
 	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 	"k8s.io/apimachinery/pkg/util/errors"
 )
@@ -24,6 +27,7 @@ type resultOpts struct {
 	performanceJobName string
 	since              time.Duration
 	credentialsFile    string
+	metricsSchema      string
 }
 
 type weeklyReportOpts struct {
@@ -32,6 +36,7 @@ type weeklyReportOpts struct {
 	outputDir      string
 	vmMetricsList  string
 	vmiMetricsList string
+	metricsSchema  string
 }
 
 type weeklyGraphOpts struct {
@@ -44,6 +49,23 @@ type weeklyGraphOpts struct {
 	releaseConfig    string
 }
 
+type regressionOpts struct {
+	ctx                 context.Context
+	resultsDir          string
+	outputDir           string
+	resources           string
+	metricsList         string
+	minSegmentSize      int
+	minRelativeChange   float64
+	minTStatistic       float64
+	includeImprovements bool
+	skipPullRequests    bool
+	githubTokenPath     string
+	org                 string
+	repo                string
+	metricsSchema       string
+}
+
 func resultsFlagOpts(subcommands []string) resultOpts {
 	fs := flag.NewFlagSet("results", flag.ExitOnError)
 	r := resultOpts{}
@@ -51,6 +73,7 @@ func resultsFlagOpts(subcommands []string) resultOpts {
 	fs.StringVar(&r.performanceJobName, "performance-job-name", "periodic-kubevirt-e2e-k8s-1.25-sig-performance", "usuage, name of the performance job for which data is collected")
 	fs.StringVar(&r.outputDir, "output-dir", "output/results", "the output directory were json data will be written")
 	fs.StringVar(&r.credentialsFile, "credentials-file", "", "the credentials json file for GCS storage client")
+	fs.StringVar(&r.metricsSchema, "metrics-schema", "", "path to the yaml file declaring the metrics extracted from the artifacts, the built-in schema if empty")
 	err := fs.Parse(subcommands)
 	if err != nil {
 		fmt.Printf("error parsing flags: %+v\n", err)
@@ -64,8 +87,9 @@ func weeklyReportFlagOpts(subcommands []string) weeklyReportOpts {
 	fs := flag.NewFlagSet("weekly-report", flag.ExitOnError)
 	fs.DurationVar(&w.since, "since", 24*time.Hour, "Filter the periodic job in the time window")
 	fs.StringVar(&w.resultsDir, "results-dir", "output/results/periodic-kubevirt-e2e-k8s-1.25-sig-performance", "usuage, name of the performance job for which data is collected")
-	fs.StringVar(&w.vmMetricsList, "vm-metrics-list", string(ResultTypeVMICreationToRunningP95), "comma separated list of metrics to be extracted for vms")
-	fs.StringVar(&w.vmiMetricsList, "vmi-metrics-list", string(ResultTypeVMICreationToRunningP95), "comma separated list of metrics to be extracted for vmis")
+	fs.StringVar(&w.vmMetricsList, "vm-metrics-list", "", "comma separated list of metrics to be extracted for vms, all metrics if empty")
+	fs.StringVar(&w.vmiMetricsList, "vmi-metrics-list", "", "comma separated list of metrics to be extracted for vmis, all metrics if empty")
+	fs.StringVar(&w.metricsSchema, "metrics-schema", "", "path to the yaml file declaring the aggregation of the metrics, the built-in schema if empty")
 	fs.StringVar(&w.outputDir, "output-dir", "output/weekly", "the output directory were json data will be written")
 	err := fs.Parse(subcommands)
 	if err != nil {
@@ -78,7 +102,7 @@ func weeklyReportFlagOpts(subcommands []string) weeklyReportOpts {
 func weeklyGraphFlagOpts(subcommands []string) weeklyGraphOpts {
 	w := weeklyGraphOpts{}
 	fs := flag.NewFlagSet("weekly-graph", flag.ExitOnError)
-	fs.StringVar(&w.metricList, "metrics-list", string(ResultTypeVMICreationToRunningP95), "comma separated list of metrics to be plotted")
+	fs.StringVar(&w.metricList, "metrics-list", "", "comma separated list of metrics to be plotted, all metrics with weekly reports if empty")
 	fs.BoolVar(&w.isDuringRelease, "is-during-release", false, "boolean for selecting if the graph is plotted during a release")
 	fs.StringVar(&w.resource, "resource", "vmi", "resource for which the graph will be plotted")
 	fs.StringVar(&w.weeklyReportsDir, "weekly-reports-dir", "output/weekly", "the output directory from which weekly json data will be read")
@@ -93,6 +117,51 @@ func weeklyGraphFlagOpts(subcommands []string) weeklyGraphOpts {
 	return w
 }
 
+func regressionsFlagOpts(subcommands []string) regressionOpts {
+	r := regressionOpts{ctx: context.Background()}
+	fs := flag.NewFlagSet("regressions", flag.ExitOnError)
+	fs.StringVar(&r.resultsDir, "results-dir", "output/results/periodic-kubevirt-e2e-k8s-1.25-sig-performance", "the directory of the performance job from which the json data of the runs will be read")
+	fs.StringVar(&r.outputDir, "output-dir", "output/regressions", "the output directory were regressions.json and regressions.md will be written")
+	fs.StringVar(&r.resources, "resources", "", "comma separated list of resources for which regressions are detected, all resources if empty")
+	fs.StringVar(&r.metricsList, "metrics-list", "", "comma separated list of metrics for which regressions are detected, all metrics if empty")
+	fs.IntVar(&r.minSegmentSize, "min-segment-size", 5, "the minimum number of runs before and after a change point")
+	fs.Float64Var(&r.minRelativeChange, "min-relative-change", 0.1, "the minimum change of the mean at a change point, relative to the mean before")
+	fs.Float64Var(&r.minTStatistic, "min-t-statistic", 3, "the minimum absolute value of the Welch t-statistic of the runs before and after a change point")
+	fs.BoolVar(&r.includeImprovements, "include-improvements", false, "whether to also report change points where the metric got better")
+	fs.BoolVar(&r.skipPullRequests, "skip-pull-requests", false, "whether to skip looking up the pull requests merged in between the last good and the first bad run")
+	fs.StringVar(&r.githubTokenPath, "github-token-path", "", "path to the file containing the GitHub token used to look up the pull requests")
+	fs.StringVar(&r.org, "org", "kubevirt", "the GitHub org of the repository the performance job tests")
+	fs.StringVar(&r.repo, "repo", "kubevirt", "the GitHub repository the performance job tests")
+	fs.StringVar(&r.metricsSchema, "metrics-schema", "", "path to the yaml file declaring whether lower or higher values of the metrics are better, the built-in schema if empty")
+	err := fs.Parse(subcommands)
+	if err != nil {
+		fmt.Printf("error parsing flags: %+v\n", err)
+		os.Exit(1)
+	}
+	return r
+}
+
+func dashboardFlagOpts(subcommands []string) dashboardOpts {
+	d := dashboardOpts{}
+	fs := flag.NewFlagSet("dashboard", flag.ExitOnError)
+	fs.StringVar(&d.resultsDir, "results-dir", "output/results", "the directory holding the json data of the runs per job")
+	fs.StringVar(&d.outputDir, "output-dir", "output/dashboard", "the output directory were the dashboard will be written")
+	fs.StringVar(&d.jobs, "jobs", "", "comma separated list of the jobs shown on the dashboard, all jobs in the results directory if empty")
+	fs.StringVar(&d.excludeJobsRegex, "exclude-jobs-regex", "", "regex for jobs that are not shown on the dashboard")
+	fs.StringVar(&d.since, "since", "", "Specify the date from which runs are shown (format: yyyy-mm-dd), all runs if empty")
+	fs.StringVar(&d.releaseConfig, "release-config", "", "Path to release configuration file (contains shape.yaml), overrides since with the sinceDate and adds the release markers")
+	fs.StringVar(&d.metricsSchema, "metrics-schema", "", "path to the yaml file declaring the metrics, the built-in schema if empty")
+	fs.BoolVar(&d.scrape, "scrape", false, "whether to collect the results of the jobs into the results directory before")
+	fs.DurationVar(&d.scrapeSince, "scrape-since", 168*time.Hour, "the time window of the runs collected if scrape is set")
+	fs.StringVar(&d.credentialsFile, "credentials-file", "", "the credentials json file for GCS storage client")
+	err := fs.Parse(subcommands)
+	if err != nil {
+		fmt.Printf("error parsing flags: %+v\n", err)
+		os.Exit(1)
+	}
+	return d
+}
+
 func main() {
 	fs := flag.NewFlagSet("perf-report-creator", flag.ExitOnError)
 	err := fs.Parse(os.Args[1:])
@@ -125,6 +194,18 @@ func main() {
 			fmt.Printf("unable to plot graph for given configuration, err: %+v\n", err)
 			os.Exit(1)
 		}
+	case "dashboard":
+		err := runDashboard(dashboardFlagOpts(fs.Args()[1:]))
+		if err != nil {
+			fmt.Printf("unable to create dashboard, err: %+v\n", err)
+			os.Exit(1)
+		}
+	case "regressions":
+		err := runRegressions(regressionsFlagOpts(fs.Args()[1:]))
+		if err != nil {
+			fmt.Printf("unable to detect regressions, err: %+v\n", err)
+			os.Exit(1)
+		}
 	}
 
 	log.Println("Successfully finished")
@@ -144,6 +225,15 @@ func runResults(r resultOpts) error {
 		return fmt.Errorf("Failed to create new storage client: %v.\n", err)
 	}
 
+	schema, err := loadMetricSchema(r.metricsSchema)
+	if err != nil {
+		return err
+	}
+	resources := schema.resourcesForJob(r.performanceJobName)
+	if len(resources) == 0 {
+		return fmt.Errorf("no metrics declared for job %s", r.performanceJobName)
+	}
+
 	jobsDirs, err := listAllRunsForJob(ctx, storageClient, r.performanceJobName)
 	if err != nil {
 		log.Fatal(err)
@@ -154,7 +244,7 @@ func runResults(r resultOpts) error {
 	since := time.Now().Add(-r.since)
 
 	// convert to perfStats
-	collection, err := extractCollectionFromAuditFiles(ctx, storageClient, jobsDirs, since, r.performanceJobName)
+	collection, err := extractCollectionFromAuditFiles(ctx, storageClient, jobsDirs, since, r.performanceJobName, resources)
 	if err != nil {
 		log.Printf("error getting job collection %+v\n", err)
 	}
@@ -197,11 +287,7 @@ func readCollection(resultsDir string) (*Collection, error) {
 		if err != nil {
 			return nil, err
 		}
-		record := struct {
-			JobDirCreationTime time.Time
-			VMIResult          *Result
-			VMResult           *Result
-		}{}
+		record := JobRun{}
 		d := json.NewDecoder(f)
 		err = d.Decode(&record)
 		if err != nil {
@@ -217,24 +303,30 @@ func runWeeklyReports(w weeklyReportOpts) error {
 	if err != nil {
 		return err
 	}
-	weeklyVMIResults, err := getWeeklyVMIResults(collection)
-	if err != nil {
-		log.Fatalf("error getting weekly vmi collection %#+v\n", err)
-	}
-
-	weeklyVMResults, err := getWeeklyVMResults(collection)
+	schema, err := loadMetricSchema(w.metricsSchema)
 	if err != nil {
-		log.Fatalf("error getting weekly vm results %#+v\n", err)
+		return err
 	}
+	jobName := filepath.Base(w.resultsDir)
 
-	err = calculateAVGAndWriteOutput(weeklyVMIResults, "vmi", w.outputDir, strings.Split(w.vmiMetricsList, ",")...)
-	if err != nil {
-		log.Fatalf("error writing vmi avg results to json file %#+v\n", err)
-	}
+	metricsLists := map[string]string{"vmi": w.vmiMetricsList, "vm": w.vmMetricsList}
+	for _, resource := range collection.resources() {
+		weeklyResults, err := getWeeklyResults(collection, resource)
+		if err != nil {
+			log.Fatalf("error getting weekly %s results %#+v\n", resource, err)
+		}
 
-	err = calculateAVGAndWriteOutput(weeklyVMResults, "vm", w.outputDir, strings.Split(w.vmMetricsList, ",")...)
-	if err != nil {
-		log.Fatalf("error writing vm avg results to json file %#+v\n", err)
+		metrics := collection.metrics(resource)
+		if metricsLists[resource] != "" {
+			metrics = strings.Split(metricsLists[resource], ",")
+		}
+		aggregationFor := func(metric string) Aggregation {
+			return schema.aggregationFor(jobName, resource, ResultType(metric))
+		}
+		err = calculateAggregatesAndWriteOutput(weeklyResults, resource, w.outputDir, aggregationFor, metrics...)
+		if err != nil {
+			log.Fatalf("error writing %s aggregated results to json file %#+v\n", resource, err)
+		}
 	}
 	return nil
 }
@@ -244,10 +336,80 @@ type YearWeek struct {
 	Week int
 }
 
-type Collection map[string]struct {
+// JobRun holds the results of a run of the performance job.
+type JobRun struct {
 	JobDirCreationTime time.Time
-	VMIResult          *Result
-	VMResult           *Result
+	// Revision is the kubevirt commit the run tested, as recorded in started.json
+	Revision string `json:",omitempty"`
+	// Results holds the result per resource declared in the metrics schema
+	Results map[string]*Result `json:",omitempty"`
+
+	// VMIResult and VMResult hold the results of runs collected before Results was introduced
+	VMIResult *Result `json:",omitempty"`
+	VMResult  *Result `json:",omitempty"`
+}
+
+// ResultFor returns the result of the run for the resource, nil if there is none.
+func (j JobRun) ResultFor(resource string) *Result {
+	if result, exists := j.Results[resource]; exists {
+		return result
+	}
+	switch resource {
+	case "vmi":
+		return j.VMIResult
+	case "vm":
+		return j.VMResult
+	}
+	return nil
+}
+
+func (j JobRun) resources() []string {
+	var resources []string
+	for resource := range j.Results {
+		resources = append(resources, resource)
+	}
+	if j.VMIResult != nil && j.Results["vmi"] == nil {
+		resources = append(resources, "vmi")
+	}
+	if j.VMResult != nil && j.Results["vm"] == nil {
+		resources = append(resources, "vm")
+	}
+	return resources
+}
+
+type Collection map[string]JobRun
+
+// resources returns the names of the resources any run has a result for, in lexical order.
+func (c *Collection) resources() []string {
+	names := map[string]struct{}{}
+	for _, run := range *c {
+		for _, resource := range run.resources() {
+			names[resource] = struct{}{}
+		}
+	}
+	return sortedKeys(names)
+}
+
+// metrics returns the names of the metrics any run has a value for the resource, in lexical order.
+func (c *Collection) metrics(resource string) []string {
+	names := map[string]struct{}{}
+	for _, run := range *c {
+		if result := run.ResultFor(resource); result != nil {
+			for metric := range result.Values {
+				names[string(metric)] = struct{}{}
+			}
+		}
+	}
+	return sortedKeys(names)
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 func listAllRunsForJob(ctx context.Context, client *storage.Client, jobName string) ([]string, error) {
@@ -259,7 +421,7 @@ func listAllRunsForJob(ctx context.Context, client *storage.Client, jobName stri
 	return jobDirs, nil
 }
 
-func extractCollectionFromAuditFiles(ctx context.Context, storageClient *storage.Client, jobResults []string, since time.Time, performanceJobName string) (Collection, error) {
+func extractCollectionFromAuditFiles(ctx context.Context, storageClient *storage.Client, jobResults []string, since time.Time, performanceJobName string, resources []ResourceMetrics) (Collection, error) {
 	r := Collection{}
 	errs := []error{}
 	for _, j := range jobResults {
@@ -274,34 +436,32 @@ func extractCollectionFromAuditFiles(ctx context.Context, storageClient *storage
 			continue
 		}
 
-		vmiResult, err := getVMIResult(ctx, storageClient, j, performanceJobName)
-		if err != nil {
-			log.Printf("job: %s, error getting VMI Result. %+v\n", j, err)
-			errs = append(errs, err)
-		}
-		var vmResult *Result
-		if !strings.Contains(performanceJobName, "density") {
-			vmResult, err = getVMResult(ctx, storageClient, j, performanceJobName)
+		results := map[string]*Result{}
+		for _, resource := range resources {
+			result, err := getResultForResource(ctx, storageClient, j, performanceJobName, resource)
 			if err != nil {
-				log.Printf("job: %s, error getting VM Result. %+v\n", j, err)
+				log.Printf("job: %s, error getting %s Result. %+v\n", j, resource.Name, err)
 				errs = append(errs, err)
+				continue
 			}
+			results[resource.Name] = result
 		}
 
-		r[j] = struct {
-			JobDirCreationTime time.Time
-			VMIResult          *Result
-			VMResult           *Result
-		}{
-			creationTime,
-			vmiResult,
-			vmResult,
+		revision, err := getRevisionForJob(ctx, storageClient, j, performanceJobName)
+		if err != nil {
+			log.Printf("job: %s, error getting revision from started.json. %+v\n", j, err)
+		}
+
+		r[j] = JobRun{
+			JobDirCreationTime: creationTime,
+			Revision:           revision,
+			Results:            results,
 		}
 	}
 	return r, errors.NewAggregate(errs)
 }
 
-func getWeeklyVMIResults(results *Collection) (map[YearWeek][]ResultWithDate, error) {
+func getWeeklyResults(results *Collection, resource string) (map[YearWeek][]ResultWithDate, error) {
 	// todo: aggregate error if needed
 	//errs := []error{}
 	weeklyData := map[YearWeek][]ResultWithDate{}
@@ -310,16 +470,12 @@ func getWeeklyVMIResults(results *Collection) (map[YearWeek][]ResultWithDate, er
 		value := value
 		year, week := value.JobDirCreationTime.ISOWeek() // get the Year and Week number of the date
 		yw := YearWeek{Year: year, Week: week}
-		_, ok := weeklyData[yw]
-		if value.VMIResult == nil {
-			log.Printf("VMIResult for date %s is empty, skipping\n", value.JobDirCreationTime)
+		result := value.ResultFor(resource)
+		if result == nil {
+			log.Printf("%s result for date %s is empty, skipping\n", resource, value.JobDirCreationTime)
 			continue
 		}
-		if ok {
-			weeklyData[yw] = append(weeklyData[yw], ResultWithDate{Values: value.VMIResult.Values, Date: &value.JobDirCreationTime})
-			continue
-		} // add the value to the weekly map
-		weeklyData[yw] = []ResultWithDate{{Values: value.VMIResult.Values, Date: &value.JobDirCreationTime}}
+		weeklyData[yw] = append(weeklyData[yw], ResultWithDate{Values: result.Values, Date: &value.JobDirCreationTime})
 	}
 	return weeklyData, nil
 }
@@ -334,73 +490,81 @@ func getDateForJob(ctx context.Context, client *storage.Client, jobID string, pe
 	return attrs.Created, err
 }
 
-func getVMIResult(ctx context.Context, client *storage.Client, jobID string, performanceJobName string) (*Result, error) {
-	prefixedFileName := ""
-	if strings.Contains(performanceJobName, "density") {
-		prefixedFileName = "perfscale-audit-results.json"
-	} else {
-		prefixedFileName = "VMI-perf-audit-results.json"
-	}
-	reader, err := getAuditFileReaderForJob(ctx, client, jobID, performanceJobName, prefixedFileName)
+func getRevisionForJob(ctx context.Context, client *storage.Client, jobID string, performanceJobName string) (string, error) {
+	objPath := filepath.Join("logs", performanceJobName, jobID, "started.json")
+	reader, err := client.Bucket(BucketName).Object(objPath).NewReader(ctx)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-
-	return getResult(reader)
+	defer reader.Close()
+
+	started := struct {
+		RepoCommit  string `json:"repo-commit"`
+		RepoVersion string `json:"repo-version"`
+	}{}
+	if err := json.NewDecoder(reader).Decode(&started); err != nil {
+		return "", err
+	}
+	if started.RepoCommit != "" {
+		return started.RepoCommit, nil
+	}
+	return started.RepoVersion, nil
 }
 
-func getVMResult(ctx context.Context, client *storage.Client, jobID string, performanceJobName string) (*Result, error) {
-	reader, err := getAuditFileReaderForJob(ctx, client, jobID, performanceJobName, "VM-perf-audit-results.json")
-	if err != nil {
-		log.Printf("job: %s, error getting BuildLogReaderForJob. %+v\n", jobID, err)
-		return nil, err
+// getResultForResource extracts the metrics declared for the resource from the first artifact of the run that
+// matches the artifact pattern.
+func getResultForResource(ctx context.Context, client *storage.Client, jobID string, performanceJobName string, resource ResourceMetrics) (*Result, error) {
+	artifactsDir := path.Join("logs", performanceJobName, jobID, "artifacts") + "/"
+	objPath := artifactsDir + resource.Artifact
+	if isGlobPattern(resource.Artifact) {
+		artifacts, err := listArtifacts(ctx, client, artifactsDir)
+		if err != nil {
+			return nil, err
+		}
+		objPath = ""
+		for _, artifact := range artifacts {
+			if matched, _ := path.Match(resource.Artifact, artifact); matched {
+				objPath = artifactsDir + artifact
+				break
+			}
+		}
+		if objPath == "" {
+			return nil, fmt.Errorf("no artifact matching %s found", resource.Artifact)
+		}
 	}
 
-	return getResult(reader)
-}
-
-func getResult(reader io.Reader) (*Result, error) {
-	data, err := io.ReadAll(reader)
+	reader, err := client.Bucket(BucketName).Object(objPath).NewReader(ctx)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to read %s: %v", objPath, err)
 	}
-
-	r := &Result{}
-	err = json.Unmarshal(data, r)
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
 	if err != nil {
-		log.Printf("error unmarshaling json: %+v\ntext: %v\n", err, string(data))
 		return nil, err
 	}
-	return r, nil
+	return resource.extract(data)
 }
 
-func getAuditFileReaderForJob(ctx context.Context, client *storage.Client, jobID, performanceJobName, prefixedFileName string) (io.Reader, error) {
-	objPath := filepath.Join("logs", performanceJobName, jobID, "artifacts", prefixedFileName)
-	return client.Bucket(BucketName).Object(objPath).NewReader(ctx)
-}
-
-func getWeeklyVMResults(results *Collection) (map[YearWeek][]ResultWithDate, error) {
-	// todo: aggregate error if needed
-	//errs := []error{}
-	weeklyData := map[YearWeek][]ResultWithDate{}
-	// loop over the original map and aggregate the values for each Week
-	for _, value := range *results {
-		value := value
-		year, week := value.JobDirCreationTime.ISOWeek() // get the Year and Week number of the date
-		yw := YearWeek{Year: year, Week: week}
-		_, ok := weeklyData[yw]
-		if value.VMResult == nil {
-			log.Printf("VMResult for date %s is empty, skipping\n", value.JobDirCreationTime)
-			continue
+// listArtifacts returns the names of the objects below the artifacts directory relative to it, in lexical order.
+func listArtifacts(ctx context.Context, client *storage.Client, artifactsDir string) ([]string, error) {
+	var artifacts []string
+	it := client.Bucket(BucketName).Objects(ctx, &storage.Query{Prefix: artifactsDir})
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
 		}
-		if ok {
-			weeklyData[yw] = append(weeklyData[yw], ResultWithDate{Values: value.VMResult.Values, Date: &value.JobDirCreationTime})
-			continue
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %v", artifactsDir, err)
 		}
-		// add the value to the weekly map
-		weeklyData[yw] = []ResultWithDate{{Values: value.VMResult.Values, Date: &value.JobDirCreationTime}}
+		artifacts = append(artifacts, strings.TrimPrefix(attrs.Name, artifactsDir))
 	}
-	return weeklyData, nil
+	sort.Strings(artifacts)
+	return artifacts, nil
+}
+
+func isGlobPattern(pattern string) bool {
+	return strings.ContainsAny(pattern, `*?[\`)
 }
 
 func getMondayOfWeekDate(year, week int) string {
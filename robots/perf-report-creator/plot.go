@@ -118,7 +118,10 @@ func gatherPlotData(basePath string, resource string, metric ResultType, since *
 			addMetricRangeResults(record, curves)
 
 			curves[1].X = append(curves[1].X, record.StartDate)
-			curves[1].Y = append(curves[1].Y, record.Data.Average)
+			curves[1].Y = append(curves[1].Y, record.Data.AggregatedValue())
+			if record.Data.Aggregation != "" {
+				curves[1].Title = fmt.Sprintf("weekly %s", record.Data.Aggregation)
+			}
 
 			return nil
 		}
@@ -212,7 +215,7 @@ func figFromData(data PlotData, isDuringRelease bool, lineShapes []*LineShape) *
 				Yaxis: data.YAxisLabel,
 			},
 			&grob.Scatter{
-				Name:  "weekly averages",
+				Name:  weeklyCurveName(data.Curves[1]),
 				X:     data.Curves[1].X,
 				Y:     data.Curves[1].Y,
 				Mode:  "lines",
@@ -229,31 +232,42 @@ func figFromData(data PlotData, isDuringRelease bool, lineShapes []*LineShape) *
 	}
 
 	if isDuringRelease && len(lineShapes) > 0 {
-		shapes := make([]interface{}, 0, len(lineShapes))
-		for _, shape := range lineShapes {
-			shapes = append(shapes, map[string]interface{}{
-				"type":     shape.Type,
-				"x0":       shape.X0,
-				"x1":       shape.X1,
-				"y0":       shape.Y0,
-				"y1":       shape.Y1,
-				"yref":     shape.Yref,
-				"editable": shape.Editable,
-				"line": map[string]interface{}{
-					"color": shape.Line.Color,
-					"width": shape.Line.Width,
-					"dash":  shape.Line.Dash,
-				},
-				"label": shape.Label,
-			})
-		}
-
-		fig.Layout.Shapes = shapes
+		fig.Layout.Shapes = releaseShapes(lineShapes)
 	}
 
 	return fig
 }
 
+// releaseShapes converts the line shapes of the release config to plotly shapes.
+func releaseShapes(lineShapes []*LineShape) []interface{} {
+	shapes := make([]interface{}, 0, len(lineShapes))
+	for _, shape := range lineShapes {
+		shapes = append(shapes, map[string]interface{}{
+			"type":     shape.Type,
+			"x0":       shape.X0,
+			"x1":       shape.X1,
+			"y0":       shape.Y0,
+			"y1":       shape.Y1,
+			"yref":     shape.Yref,
+			"editable": shape.Editable,
+			"line": map[string]interface{}{
+				"color": shape.Line.Color,
+				"width": shape.Line.Width,
+				"dash":  shape.Line.Dash,
+			},
+			"label": shape.Label,
+		})
+	}
+	return shapes
+}
+
+func weeklyCurveName(curve Curve) string {
+	if curve.Title != "" {
+		return curve.Title
+	}
+	return "weekly averages"
+}
+
 func transformForStaticGraph(x []string, y []float64) (plotter.XYs, error) {
 	pts := make(plotter.XYs, len(x))
 
@@ -276,6 +290,13 @@ func plotWeeklyGraph(opts weeklyGraphOpts) error {
 	var errs []error
 	var figs []*grob.Fig
 	metrics := strings.Split(opts.metricList, ",")
+	if opts.metricList == "" {
+		var err error
+		metrics, err = listMetricDirs(filepath.Join(opts.weeklyReportsDir, opts.resource))
+		if err != nil {
+			return err
+		}
+	}
 
 	var (
 		since      time.Time
@@ -339,6 +360,21 @@ func plotWeeklyGraph(opts weeklyGraphOpts) error {
 	return errors.NewAggregate(errs)
 }
 
+// listMetricDirs returns the names of the metrics weekly reports have been written for.
+func listMetricDirs(resourceDir string) ([]string, error) {
+	entries, err := os.ReadDir(resourceDir)
+	if err != nil {
+		return nil, err
+	}
+	var metrics []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			metrics = append(metrics, entry.Name())
+		}
+	}
+	return metrics, nil
+}
+
 // ToHtml saves the figure as standalone HTML. It still requires internet to load plotly.js from CDN.
 func ToHtml(figs []*grob.Fig, path string) {
 	buf := figToBuffer(figs)
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v28/github"
+	"golang.org/x/oauth2"
+)
+
+var (
+	// mergeCommitRegex matches the subject of the merge commits created by tide
+	mergeCommitRegex = regexp.MustCompile(`^Merge pull request #(\d+) from \S+`)
+	// squashCommitRegex matches the subject of squashed commits
+	squashCommitRegex = regexp.MustCompile(`^(.*) \(#(\d+)\)$`)
+)
+
+type PullRequest struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+}
+
+// pullRequestSource provides the pull requests merged in between two commits.
+type pullRequestSource interface {
+	// MergedPullRequests returns the pull requests merged after base up to and including head
+	MergedPullRequests(base, head string) ([]PullRequest, error)
+}
+
+type githubPullRequestSource struct {
+	ctx    context.Context
+	client *github.Client
+	org    string
+	repo   string
+}
+
+// newGitHubPullRequestSource returns a source using an unauthenticated client if no token is given.
+func newGitHubPullRequestSource(ctx context.Context, tokenPath, org, repo string) (*githubPullRequestSource, error) {
+	client := github.NewClient(nil)
+	if tokenPath != "" {
+		token, err := os.ReadFile(tokenPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read github token from %s: %v", tokenPath, err)
+		}
+		client = github.NewClient(oauth2.NewClient(ctx, oauth2.StaticTokenSource(
+			&oauth2.Token{AccessToken: strings.TrimSpace(string(token))},
+		)))
+	}
+	return &githubPullRequestSource{ctx: ctx, client: client, org: org, repo: repo}, nil
+}
+
+func (s *githubPullRequestSource) MergedPullRequests(base, head string) ([]PullRequest, error) {
+	comparison, _, err := s.client.Repositories.CompareCommits(s.ctx, s.org, s.repo, base, head)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare %s...%s: %v", base, head, err)
+	}
+	if comparison.GetTotalCommits() > len(comparison.Commits) {
+		log.Printf("only %d of %d commits between %s and %s are considered\n", len(comparison.Commits), comparison.GetTotalCommits(), base, head)
+	}
+	var messages []string
+	for _, commit := range comparison.Commits {
+		messages = append(messages, commit.GetCommit().GetMessage())
+	}
+	return pullRequestsFromCommitMessages(s.org, s.repo, messages), nil
+}
+
+// pullRequestsFromCommitMessages returns the pull requests referenced by merge or squashed commits, in the order
+// of the commits.
+func pullRequestsFromCommitMessages(org, repo string, messages []string) []PullRequest {
+	var pullRequests []PullRequest
+	seen := map[int]struct{}{}
+	for _, message := range messages {
+		lines := strings.Split(strings.TrimSpace(message), "\n")
+		var number int
+		var title string
+		if matches := mergeCommitRegex.FindStringSubmatch(lines[0]); matches != nil {
+			number, _ = strconv.Atoi(matches[1])
+			// the title of the pull request follows after an empty line
+			for _, line := range lines[1:] {
+				if strings.TrimSpace(line) != "" {
+					title = strings.TrimSpace(line)
+					break
+				}
+			}
+		} else if matches := squashCommitRegex.FindStringSubmatch(lines[0]); matches != nil {
+			number, _ = strconv.Atoi(matches[2])
+			title = matches[1]
+		} else {
+			continue
+		}
+		if _, exists := seen[number]; exists {
+			continue
+		}
+		seen[number] = struct{}{}
+		pullRequests = append(pullRequests, PullRequest{
+			Number: number,
+			Title:  title,
+			URL:    fmt.Sprintf("https://github.com/%s/%s/pull/%d", org, repo, number),
+		})
+	}
+	return pullRequests
+}
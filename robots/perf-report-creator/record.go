@@ -15,8 +15,11 @@ type Record struct {
 }
 
 type RecordData struct {
-	Average    float64
-	DataPoints []RecordDataPoint
+	Average float64
+	// Aggregation and Value hold the aggregate of the data points, if the metric isn't aggregated by average
+	Aggregation Aggregation `json:",omitempty"`
+	Value       float64     `json:",omitempty"`
+	DataPoints  []RecordDataPoint
 }
 
 type RecordDataPoint struct {
@@ -39,7 +42,31 @@ func NewRecordDateWithAverage(rdps []RecordDataPoint) RecordData {
 	return r
 }
 
-func calculateAVGAndWriteOutput(results map[YearWeek][]ResultWithDate, objType string, outputDir string, metrics ...string) error {
+// NewRecordData returns the data points with their average and, if the aggregation is not the average, their
+// aggregate.
+func NewRecordData(rdps []RecordDataPoint, aggregation Aggregation) RecordData {
+	r := NewRecordDateWithAverage(rdps)
+	if aggregation == "" || aggregation == AggregationAverage {
+		return r
+	}
+	values := make([]float64, 0, len(rdps))
+	for _, rdp := range rdps {
+		values = append(values, rdp.Value)
+	}
+	r.Aggregation = aggregation
+	r.Value = aggregate(values, aggregation)
+	return r
+}
+
+// AggregatedValue returns the aggregate of the data points.
+func (r RecordData) AggregatedValue() float64 {
+	if r.Aggregation == "" || r.Aggregation == AggregationAverage {
+		return r.Average
+	}
+	return r.Value
+}
+
+func calculateAggregatesAndWriteOutput(results map[YearWeek][]ResultWithDate, objType string, outputDir string, aggregationFor func(metric string) Aggregation, metrics ...string) error {
 	for _, metric := range metrics {
 		for yw := range results {
 			record := Record{
@@ -56,13 +83,17 @@ func calculateAVGAndWriteOutput(results map[YearWeek][]ResultWithDate, objType s
 			rdp := []RecordDataPoint{}
 			for _, result := range results[yw] {
 				result := result
+				value, exists := result.Values[ResultType(metric)]
+				if !exists {
+					continue
+				}
 				rdp = append(rdp, RecordDataPoint{
-					Value: result.Values[ResultType(metric)].Value,
+					Value: value.Value,
 					Date:  result.Date,
 				})
 			}
 			record.NumberOfDays = 7
-			record.Data = NewRecordDateWithAverage(rdp)
+			record.Data = NewRecordData(rdp, aggregationFor(metric))
 			f, err := os.Create(outputPath)
 			if err != nil {
 				return err
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"kubevirt.io/project-infra/pkg/flakefinder"
+)
+
+const (
+	directionIncrease = "increase"
+	directionDecrease = "decrease"
+)
+
+// RunValue is the value of a metric in a run of the performance job.
+type RunValue struct {
+	BuildID           string    `json:"buildID"`
+	URL               string    `json:"url"`
+	Date              time.Time `json:"date"`
+	Revision          string    `json:"revision,omitempty"`
+	Value             float64   `json:"value"`
+	ThresholdValue    float64   `json:"thresholdValue,omitempty"`
+	ThresholdExceeded bool      `json:"thresholdExceeded,omitempty"`
+}
+
+// Regression is a shift of the value of a metric in between two consecutive runs.
+type Regression struct {
+	Resource       string     `json:"resource"`
+	Metric         ResultType `json:"metric"`
+	Direction      string     `json:"direction"`
+	MeanBefore     float64    `json:"meanBefore"`
+	MeanAfter      float64    `json:"meanAfter"`
+	RelativeChange float64    `json:"relativeChange"`
+	TStatistic     float64    `json:"tStatistic"`
+	LastGoodRun    RunValue   `json:"lastGoodRun"`
+	FirstBadRun    RunValue   `json:"firstBadRun"`
+	// CompareURL shows the commits in between the last good and the first bad run
+	CompareURL   string        `json:"compareURL,omitempty"`
+	PullRequests []PullRequest `json:"pullRequests,omitempty"`
+}
+
+type RegressionReport struct {
+	JobName     string       `json:"jobName"`
+	Created     time.Time    `json:"created"`
+	Regressions []Regression `json:"regressions"`
+}
+
+// getMetricSeries returns the values per metric of the resource, ordered by the creation time of the runs.
+// If metrics are given, only these are returned.
+func getMetricSeries(collection *Collection, jobName, resource string, metrics ...ResultType) map[ResultType][]RunValue {
+	var buildIDs []string
+	for buildID := range *collection {
+		buildIDs = append(buildIDs, buildID)
+	}
+	sort.Slice(buildIDs, func(i, j int) bool {
+		return (*collection)[buildIDs[i]].JobDirCreationTime.Before((*collection)[buildIDs[j]].JobDirCreationTime)
+	})
+
+	wanted := map[ResultType]struct{}{}
+	for _, metric := range metrics {
+		wanted[metric] = struct{}{}
+	}
+
+	series := map[ResultType][]RunValue{}
+	for _, buildID := range buildIDs {
+		run := (*collection)[buildID]
+		result := run.ResultFor(resource)
+		if result == nil {
+			continue
+		}
+		for metric, value := range result.Values {
+			if _, ok := wanted[metric]; len(wanted) > 0 && !ok {
+				continue
+			}
+			runValue := RunValue{
+				BuildID:  buildID,
+				URL:      fmt.Sprintf("https://prow.ci.kubevirt.io/view/gcs/%s/logs/%s/%s", flakefinder.BucketName, jobName, buildID),
+				Date:     run.JobDirCreationTime,
+				Revision: run.Revision,
+				Value:    value.Value,
+			}
+			if value.ThresholdResult != nil {
+				runValue.ThresholdValue = value.ThresholdResult.ThresholdValue
+				runValue.ThresholdExceeded = value.ThresholdResult.ThresholdExceeded
+			}
+			series[metric] = append(series[metric], runValue)
+		}
+	}
+	return series
+}
+
+// detectRegressions returns the change points of the series where the metric got worse, i.e. increased if lower
+// values are better and decreased if higher values are better. Change points where the metric got better are only
+// returned if includeImprovements is set.
+func detectRegressions(resource string, metric ResultType, series []RunValue, opts changePointOpts, betterWhen BetterWhen, includeImprovements bool) []Regression {
+	values := make([]float64, len(series))
+	for i, run := range series {
+		values[i] = run.Value
+	}
+	var regressions []Regression
+	for _, cp := range detectChangePoints(values, opts) {
+		direction := directionIncrease
+		if cp.MeanAfter < cp.MeanBefore {
+			direction = directionDecrease
+		}
+		improved := (direction == directionDecrease) == (betterWhen != BetterWhenHigher)
+		if improved && !includeImprovements {
+			continue
+		}
+		regressions = append(regressions, Regression{
+			Resource:       resource,
+			Metric:         metric,
+			Direction:      direction,
+			MeanBefore:     cp.MeanBefore,
+			MeanAfter:      cp.MeanAfter,
+			RelativeChange: finite(relativeChange(cp.MeanBefore, cp.MeanAfter)),
+			TStatistic:     finite(cp.TStatistic),
+			LastGoodRun:    series[cp.Index-1],
+			FirstBadRun:    series[cp.Index],
+		})
+	}
+	return regressions
+}
+
+// addPullRequests adds the pull requests merged in between the last good and the first bad run to the
+// regressions. The commit range is unknown for runs that have no revision recorded.
+func addPullRequests(regressions []Regression, source pullRequestSource, org, repo string) error {
+	pullRequestsPerRange := map[string][]PullRequest{}
+	for i := range regressions {
+		base, head := regressions[i].LastGoodRun.Revision, regressions[i].FirstBadRun.Revision
+		if base == "" || head == "" || base == head {
+			continue
+		}
+		commitRange := base + "..." + head
+		regressions[i].CompareURL = fmt.Sprintf("https://github.com/%s/%s/compare/%s", org, repo, commitRange)
+		if source == nil {
+			continue
+		}
+		if _, exists := pullRequestsPerRange[commitRange]; !exists {
+			pullRequests, err := source.MergedPullRequests(base, head)
+			if err != nil {
+				return err
+			}
+			pullRequestsPerRange[commitRange] = pullRequests
+		}
+		regressions[i].PullRequests = pullRequestsPerRange[commitRange]
+	}
+	return nil
+}
+
+func runRegressions(r regressionOpts) error {
+	schema, err := loadMetricSchema(r.metricsSchema)
+	if err != nil {
+		return err
+	}
+	collection, err := readCollection(r.resultsDir)
+	if err != nil {
+		return err
+	}
+	jobName := filepath.Base(r.resultsDir)
+
+	var metrics []ResultType
+	for _, metric := range strings.Split(r.metricsList, ",") {
+		if metric != "" {
+			metrics = append(metrics, ResultType(metric))
+		}
+	}
+	opts := changePointOpts{
+		minSegmentSize:    r.minSegmentSize,
+		minRelativeChange: r.minRelativeChange,
+		minTStatistic:     r.minTStatistic,
+	}
+
+	report := RegressionReport{JobName: jobName, Created: time.Now(), Regressions: []Regression{}}
+	resources := collection.resources()
+	if r.resources != "" {
+		resources = strings.Split(r.resources, ",")
+	}
+	for _, resource := range resources {
+		series := getMetricSeries(collection, jobName, resource, metrics...)
+		var seriesMetrics []ResultType
+		for metric := range series {
+			seriesMetrics = append(seriesMetrics, metric)
+		}
+		sort.Slice(seriesMetrics, func(i, j int) bool { return seriesMetrics[i] < seriesMetrics[j] })
+		for _, metric := range seriesMetrics {
+			report.Regressions = append(report.Regressions, detectRegressions(resource, metric, series[metric], opts, schema.betterWhenFor(jobName, resource, metric), r.includeImprovements)...)
+		}
+	}
+	log.Printf("found %d regressions for %s\n", len(report.Regressions), jobName)
+
+	var source pullRequestSource
+	if !r.skipPullRequests {
+		source, err = newGitHubPullRequestSource(r.ctx, r.githubTokenPath, r.org, r.repo)
+		if err != nil {
+			return err
+		}
+	}
+	if err := addPullRequests(report.Regressions, source, r.org, r.repo); err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(r.outputDir, 0755)
+	if err != nil {
+		return err
+	}
+	err = writeToFile(filepath.Join(r.outputDir, "regressions.json"), func(w io.Writer) error {
+		e := json.NewEncoder(w)
+		e.SetIndent("", "  ")
+		return e.Encode(&report)
+	})
+	if err != nil {
+		return err
+	}
+	return writeToFile(filepath.Join(r.outputDir, "regressions.md"), func(w io.Writer) error {
+		return writeRegressionsMarkdown(w, report)
+	})
+}
+
+func writeToFile(path string, write func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fmt.Println("writing output to", path)
+	return write(f)
+}
+
+func writeRegressionsMarkdown(w io.Writer, report RegressionReport) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Performance regressions of %s\n\n", report.JobName)
+	if len(report.Regressions) == 0 {
+		b.WriteString("No regressions found.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	b.WriteString("| resource | metric | change | last good run | first bad run | commits | pull requests |\n")
+	b.WriteString("|----------|--------|--------|---------------|---------------|---------|---------------|\n")
+	for _, regression := range report.Regressions {
+		sign := "+"
+		if regression.Direction == directionDecrease {
+			sign = "-"
+		}
+		change := fmt.Sprintf("%.4g → %.4g (%s%.1f%%)", regression.MeanBefore, regression.MeanAfter, sign, 100*regression.RelativeChange)
+		if regression.MeanBefore == 0 {
+			change = fmt.Sprintf("%.4g → %.4g", regression.MeanBefore, regression.MeanAfter)
+		}
+		if regression.FirstBadRun.ThresholdExceeded {
+			change += " ⚠️ threshold exceeded"
+		}
+		commits := "unknown"
+		if regression.CompareURL != "" {
+			commits = fmt.Sprintf("[%s...%s](%s)", shortRevision(regression.LastGoodRun.Revision), shortRevision(regression.FirstBadRun.Revision), regression.CompareURL)
+		}
+		var pullRequests []string
+		for _, pullRequest := range regression.PullRequests {
+			pullRequests = append(pullRequests, fmt.Sprintf("[#%d](%s) %s", pullRequest.Number, pullRequest.URL, escapeMarkdownTableCell(pullRequest.Title)))
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s | %s |\n",
+			regression.Resource,
+			regression.Metric,
+			change,
+			runLink(regression.LastGoodRun),
+			runLink(regression.FirstBadRun),
+			commits,
+			strings.Join(pullRequests, "<br>"),
+		)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// finite replaces infinite values, which can't be encoded to json, with the largest float.
+func finite(value float64) float64 {
+	if math.IsInf(value, 0) {
+		return math.Copysign(math.MaxFloat64, value)
+	}
+	return value
+}
+
+func runLink(run RunValue) string {
+	return fmt.Sprintf("[%s](%s) (%s)", run.BuildID, run.URL, run.Date.Format(DateFormat))
+}
+
+func shortRevision(revision string) string {
+	if len(revision) > 7 {
+		return revision[:7]
+	}
+	return revision
+}
+
+func escapeMarkdownTableCell(text string) string {
+	return strings.ReplaceAll(text, "|", "\\|")
+}
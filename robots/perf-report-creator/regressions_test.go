@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type fakePullRequestSource struct {
+	calls int
+}
+
+func (f *fakePullRequestSource) MergedPullRequests(base, head string) ([]PullRequest, error) {
+	f.calls++
+	return []PullRequest{{Number: 42, Title: "slow things down | a bit", URL: "https://github.com/kubevirt/kubevirt/pull/42"}}, nil
+}
+
+var _ = Describe("regressions", func() {
+	const metric = ResultTypeVMICreationToRunningP95
+
+	newCollection := func(values ...float64) *Collection {
+		start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		c := Collection{}
+		for i, value := range values {
+			c[fmt.Sprintf("%d", 1000+i)] = JobRun{
+				JobDirCreationTime: start.Add(time.Duration(i) * 24 * time.Hour),
+				Revision:           fmt.Sprintf("rev%d", i),
+				VMIResult: &Result{Values: map[ResultType]ResultValue{
+					metric:                    {Value: value},
+					ResultTypeCreatePodsCount: {Value: 100},
+				}},
+			}
+		}
+		return &c
+	}
+	opts := changePointOpts{minSegmentSize: 5, minRelativeChange: 0.1, minTStatistic: 3}
+
+	It("orders the series by creation time", func() {
+		series := getMetricSeries(newCollection(1, 2, 3), "job", "vmi", metric)
+		Expect(series).To(HaveLen(1))
+		Expect(series[metric]).To(HaveLen(3))
+		Expect(series[metric][0].BuildID).To(Equal("1000"))
+		Expect(series[metric][2].Value).To(Equal(3.0))
+		Expect(series[metric][0].URL).To(Equal("https://prow.ci.kubevirt.io/view/gcs/kubevirt-prow/logs/job/1000"))
+	})
+
+	It("returns no series for a resource without results", func() {
+		Expect(getMetricSeries(newCollection(1), "job", "pod")).To(BeEmpty())
+	})
+
+	It("reports the last good and first bad run of an increase", func() {
+		series := getMetricSeries(newCollection(10, 11, 10, 9, 10, 20, 21, 20, 19, 20), "job", "vmi", metric)
+		regressions := detectRegressions("vmi", metric, series[metric], opts, BetterWhenLower, false)
+		Expect(regressions).To(HaveLen(1))
+		Expect(regressions[0].Direction).To(Equal(directionIncrease))
+		Expect(regressions[0].LastGoodRun.Revision).To(Equal("rev4"))
+		Expect(regressions[0].FirstBadRun.Revision).To(Equal("rev5"))
+		Expect(regressions[0].RelativeChange).To(BeNumerically("~", 1.0, 0.001))
+	})
+
+	It("reports improvements only if asked to", func() {
+		series := getMetricSeries(newCollection(20, 21, 20, 19, 20, 10, 11, 10, 9, 10), "job", "vmi", metric)
+		Expect(detectRegressions("vmi", metric, series[metric], opts, BetterWhenLower, false)).To(BeEmpty())
+		regressions := detectRegressions("vmi", metric, series[metric], opts, BetterWhenLower, true)
+		Expect(regressions).To(HaveLen(1))
+		Expect(regressions[0].Direction).To(Equal(directionDecrease))
+	})
+
+	It("reports decreases of metrics where higher values are better", func() {
+		series := getMetricSeries(newCollection(20, 21, 20, 19, 20, 10, 11, 10, 9, 10), "job", "vmi", metric)
+		regressions := detectRegressions("vmi", metric, series[metric], opts, BetterWhenHigher, false)
+		Expect(regressions).To(HaveLen(1))
+		Expect(regressions[0].Direction).To(Equal(directionDecrease))
+
+		series = getMetricSeries(newCollection(10, 11, 10, 9, 10, 20, 21, 20, 19, 20), "job", "vmi", metric)
+		Expect(detectRegressions("vmi", metric, series[metric], opts, BetterWhenHigher, false)).To(BeEmpty())
+		Expect(detectRegressions("vmi", metric, series[metric], opts, BetterWhenHigher, true)).To(HaveLen(1))
+	})
+
+	It("adds the pull requests once per commit range", func() {
+		regressions := []Regression{
+			{LastGoodRun: RunValue{Revision: "a"}, FirstBadRun: RunValue{Revision: "b"}},
+			{LastGoodRun: RunValue{Revision: "a"}, FirstBadRun: RunValue{Revision: "b"}},
+			{LastGoodRun: RunValue{}, FirstBadRun: RunValue{Revision: "b"}},
+		}
+		source := &fakePullRequestSource{}
+		Expect(addPullRequests(regressions, source, "kubevirt", "kubevirt")).To(Succeed())
+		Expect(source.calls).To(Equal(1))
+		Expect(regressions[0].CompareURL).To(Equal("https://github.com/kubevirt/kubevirt/compare/a...b"))
+		Expect(regressions[1].PullRequests).To(HaveLen(1))
+		Expect(regressions[2].CompareURL).To(BeEmpty())
+		Expect(regressions[2].PullRequests).To(BeEmpty())
+	})
+
+	It("writes the markdown report", func() {
+		report := RegressionReport{JobName: "job", Regressions: []Regression{{
+			Resource:       "vmi",
+			Metric:         metric,
+			Direction:      directionIncrease,
+			MeanBefore:     10,
+			MeanAfter:      20,
+			RelativeChange: 1,
+			LastGoodRun:    RunValue{BuildID: "1", URL: "https://prow/1", Revision: "0123456789"},
+			FirstBadRun:    RunValue{BuildID: "2", URL: "https://prow/2", Revision: "abcdef0123", ThresholdExceeded: true},
+			CompareURL:     "https://github.com/kubevirt/kubevirt/compare/0123456789...abcdef0123",
+			PullRequests:   []PullRequest{{Number: 42, Title: "slow | down", URL: "https://github.com/kubevirt/kubevirt/pull/42"}},
+		}}}
+		var b bytes.Buffer
+		Expect(writeRegressionsMarkdown(&b, report)).To(Succeed())
+		Expect(b.String()).To(ContainSubstring("| vmi | vmiCreationToRunningSecondsP95 | 10 → 20 (+100.0%) ⚠️ threshold exceeded | [1](https://prow/1)"))
+		Expect(b.String()).To(ContainSubstring("[0123456...abcdef0](https://github.com/kubevirt/kubevirt/compare/0123456789...abcdef0123)"))
+		Expect(b.String()).To(ContainSubstring(`[#42](https://github.com/kubevirt/kubevirt/pull/42) slow \| down`))
+	})
+
+	It("writes that there are no regressions", func() {
+		var b bytes.Buffer
+		Expect(writeRegressionsMarkdown(&b, RegressionReport{JobName: "job"})).To(Succeed())
+		Expect(b.String()).To(ContainSubstring("No regressions found."))
+	})
+
+	DescribeTable("extracts pull requests from commit messages",
+		func(messages []string, expected []PullRequest) {
+			Expect(pullRequestsFromCommitMessages("kubevirt", "kubevirt", messages)).To(Equal(expected))
+		},
+		Entry("merge commit", []string{"Merge pull request #123 from someone/branch\n\nFix the thing\n\nmore details"},
+			[]PullRequest{{Number: 123, Title: "Fix the thing", URL: "https://github.com/kubevirt/kubevirt/pull/123"}}),
+		Entry("squashed commit", []string{"Fix the other thing (#456)\n\nSigned-off-by: someone"},
+			[]PullRequest{{Number: 456, Title: "Fix the other thing", URL: "https://github.com/kubevirt/kubevirt/pull/456"}}),
+		Entry("commits of a pull request", []string{"Fix the thing\n\nSigned-off-by: someone"}, nil),
+		Entry("duplicates", []string{"Merge pull request #123 from someone/branch\n\nFix", "Merge pull request #123 from someone/branch\n\nFix"},
+			[]PullRequest{{Number: 123, Title: "Fix", URL: "https://github.com/kubevirt/kubevirt/pull/123"}}),
+	)
+})
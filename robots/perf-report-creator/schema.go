@@ -0,0 +1,341 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed metrics.yaml
+var defaultMetricSchema []byte
+
+type Aggregation string
+
+const (
+	AggregationAverage Aggregation = "avg"
+	AggregationMin     Aggregation = "min"
+	AggregationMax     Aggregation = "max"
+	AggregationMedian  Aggregation = "median"
+	AggregationP95     Aggregation = "p95"
+	AggregationSum     Aggregation = "sum"
+)
+
+// BetterWhen is whether lower or higher values of a metric are better.
+type BetterWhen string
+
+const (
+	BetterWhenLower  BetterWhen = "lower"
+	BetterWhenHigher BetterWhen = "higher"
+)
+
+// MetricSchema declares which metrics are extracted from the artifacts of the performance jobs and how their
+// values are aggregated in the weekly reports.
+type MetricSchema struct {
+	// Jobs are matched against the name of a job in order, the first match is used
+	Jobs []JobMetrics `yaml:"jobs"`
+}
+
+type JobMetrics struct {
+	JobNameRegex string            `yaml:"jobNameRegex"`
+	Resources    []ResourceMetrics `yaml:"resources"`
+
+	jobNameRegex *regexp.Regexp
+}
+
+// ResourceMetrics declares the metrics extracted from an artifact, which are reported for the resource.
+type ResourceMetrics struct {
+	Name string `yaml:"name"`
+	// Artifact is the file name pattern of the json artifact, relative to the artifacts directory of a run, see
+	// path.Match. If several files match, the first one in lexical order is used.
+	Artifact string             `yaml:"artifact"`
+	Metrics  []MetricDefinition `yaml:"metrics"`
+}
+
+// MetricDefinition declares where the value of a metric is found in the artifact. Paths are dot separated object
+// keys or array indexes, where object keys are matched case-insensitive if there is no exact match.
+type MetricDefinition struct {
+	// Name is the name of the metric. If empty, the value at Path must be an object, where every key is the name
+	// of a metric.
+	Name string `yaml:"name,omitempty"`
+	Path string `yaml:"path"`
+	// ValuePath is the path of the value relative to the value at Path, or relative to each entry of the object
+	// at Path if Name is empty
+	ValuePath string `yaml:"valuePath,omitempty"`
+	// ThresholdPath is the path of the threshold result, relative to the same value as ValuePath
+	ThresholdPath string `yaml:"thresholdPath,omitempty"`
+	// Aggregation is how the values of a week are aggregated, defaults to avg
+	Aggregation Aggregation `yaml:"aggregation,omitempty"`
+	// BetterWhen is whether lower or higher values are better, i.e. which change is a regression, defaults to lower
+	BetterWhen BetterWhen `yaml:"betterWhen,omitempty"`
+}
+
+// loadMetricSchema reads the schema from the file at schemaPath, or the default schema if the path is empty.
+func loadMetricSchema(schemaPath string) (*MetricSchema, error) {
+	data := defaultMetricSchema
+	if schemaPath != "" {
+		var err error
+		data, err = os.ReadFile(schemaPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read metrics schema %s: %v", schemaPath, err)
+		}
+	}
+	schema := &MetricSchema{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(schema); err != nil {
+		return nil, fmt.Errorf("failed to parse metrics schema: %v", err)
+	}
+	if err := schema.validate(); err != nil {
+		return nil, fmt.Errorf("invalid metrics schema: %v", err)
+	}
+	return schema, nil
+}
+
+func (s *MetricSchema) validate() error {
+	for i := range s.Jobs {
+		job := &s.Jobs[i]
+		var err error
+		job.jobNameRegex, err = regexp.Compile(job.JobNameRegex)
+		if err != nil {
+			return fmt.Errorf("jobNameRegex %q: %v", job.JobNameRegex, err)
+		}
+		resourceNames := map[string]struct{}{}
+		for _, resource := range job.Resources {
+			if resource.Name == "" || resource.Artifact == "" {
+				return fmt.Errorf("jobNameRegex %q: name and artifact are required for resources", job.JobNameRegex)
+			}
+			if _, exists := resourceNames[resource.Name]; exists {
+				return fmt.Errorf("jobNameRegex %q: resource %q is declared twice", job.JobNameRegex, resource.Name)
+			}
+			resourceNames[resource.Name] = struct{}{}
+			for _, metric := range resource.Metrics {
+				if metric.Path == "" {
+					return fmt.Errorf("jobNameRegex %q, resource %q: path is required for metrics", job.JobNameRegex, resource.Name)
+				}
+				if !isValidAggregation(metric.Aggregation) {
+					return fmt.Errorf("jobNameRegex %q, resource %q: unknown aggregation %q", job.JobNameRegex, resource.Name, metric.Aggregation)
+				}
+				if !isValidBetterWhen(metric.BetterWhen) {
+					return fmt.Errorf("jobNameRegex %q, resource %q: betterWhen must be lower or higher, got %q", job.JobNameRegex, resource.Name, metric.BetterWhen)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// resourcesForJob returns the resources declared by the first entry matching the job name.
+func (s *MetricSchema) resourcesForJob(jobName string) []ResourceMetrics {
+	for _, job := range s.Jobs {
+		if job.jobNameRegex.MatchString(jobName) {
+			return job.Resources
+		}
+	}
+	return nil
+}
+
+// aggregationFor returns the aggregation of the metric of the resource declared for the job.
+func (s *MetricSchema) aggregationFor(jobName, resource string, metric ResultType) Aggregation {
+	aggregation := metricProperty(s, jobName, resource, metric, func(d MetricDefinition) Aggregation { return d.Aggregation })
+	if aggregation == "" {
+		return AggregationAverage
+	}
+	return aggregation
+}
+
+// betterWhenFor returns whether lower or higher values are better for the metric of the resource declared for
+// the job.
+func (s *MetricSchema) betterWhenFor(jobName, resource string, metric ResultType) BetterWhen {
+	betterWhen := metricProperty(s, jobName, resource, metric, func(d MetricDefinition) BetterWhen { return d.BetterWhen })
+	if betterWhen == "" {
+		return BetterWhenLower
+	}
+	return betterWhen
+}
+
+// metricProperty returns the property of the metric of the resource declared for the job, empty if it isn't set.
+// Metrics declared by name take precedence over metrics declared by an object.
+func metricProperty[T ~string](s *MetricSchema, jobName, resource string, metric ResultType, property func(MetricDefinition) T) T {
+	var value T
+	for _, r := range s.resourcesForJob(jobName) {
+		if r.Name != resource {
+			continue
+		}
+		for _, definition := range r.Metrics {
+			if property(definition) == "" {
+				continue
+			}
+			if definition.Name == string(metric) {
+				return property(definition)
+			}
+			if definition.Name == "" {
+				value = property(definition)
+			}
+		}
+	}
+	return value
+}
+
+// extract returns the values of the metrics declared for the resource from the json artifact.
+func (r ResourceMetrics) extract(data []byte) (*Result, error) {
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse artifact %s: %v", r.Artifact, err)
+	}
+
+	result := &Result{Values: map[ResultType]ResultValue{}}
+	for _, definition := range r.Metrics {
+		value, found := lookupPath(document, definition.Path)
+		if !found {
+			return nil, fmt.Errorf("path %q not found in artifact %s", definition.Path, r.Artifact)
+		}
+		if definition.Name != "" {
+			resultValue, err := definition.resultValue(value)
+			if err != nil {
+				return nil, fmt.Errorf("metric %s: %v", definition.Name, err)
+			}
+			result.Values[ResultType(definition.Name)] = resultValue
+			continue
+		}
+		entries, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("path %q in artifact %s is not an object", definition.Path, r.Artifact)
+		}
+		for name, entry := range entries {
+			resultValue, err := definition.resultValue(entry)
+			if err != nil {
+				// entries that are no metric values are skipped
+				continue
+			}
+			result.Values[ResultType(name)] = resultValue
+		}
+	}
+	return result, nil
+}
+
+func (d MetricDefinition) resultValue(value interface{}) (ResultValue, error) {
+	number, found := lookupPath(value, d.ValuePath)
+	if !found {
+		return ResultValue{}, fmt.Errorf("path %q not found", d.ValuePath)
+	}
+	v, err := toFloat(number)
+	if err != nil {
+		return ResultValue{}, err
+	}
+	resultValue := ResultValue{Value: v}
+	if d.ThresholdPath == "" {
+		return resultValue, nil
+	}
+	if threshold, found := lookupPath(value, d.ThresholdPath); found && threshold != nil {
+		data, err := json.Marshal(threshold)
+		if err != nil {
+			return ResultValue{}, err
+		}
+		resultValue.ThresholdResult = &ThresholdResult{}
+		if err := json.Unmarshal(data, resultValue.ThresholdResult); err != nil {
+			return ResultValue{}, fmt.Errorf("invalid threshold result: %v", err)
+		}
+	}
+	return resultValue, nil
+}
+
+// lookupPath returns the value at the dot separated path, the value itself for an empty path.
+func lookupPath(value interface{}, path string) (interface{}, bool) {
+	if path == "" {
+		return value, true
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			entry, found := v[key]
+			if !found {
+				for k, e := range v {
+					if strings.EqualFold(k, key) {
+						entry, found = e, true
+						break
+					}
+				}
+			}
+			if !found {
+				return nil, false
+			}
+			value = entry
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, false
+			}
+			value = v[index]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+func toFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	default:
+		return 0, fmt.Errorf("value %v is not a number", value)
+	}
+}
+
+func isValidAggregation(aggregation Aggregation) bool {
+	switch aggregation {
+	case "", AggregationAverage, AggregationMin, AggregationMax, AggregationMedian, AggregationP95, AggregationSum:
+		return true
+	}
+	return false
+}
+
+func isValidBetterWhen(betterWhen BetterWhen) bool {
+	switch betterWhen {
+	case "", BetterWhenLower, BetterWhenHigher:
+		return true
+	}
+	return false
+}
+
+// aggregate returns the aggregated values, where percentiles are calculated with the nearest rank method.
+func aggregate(values []float64, aggregation Aggregation) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	percentile := func(p float64) float64 {
+		rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+		return sorted[max(rank, 1)-1]
+	}
+	switch aggregation {
+	case AggregationMin:
+		return sorted[0]
+	case AggregationMax:
+		return sorted[len(sorted)-1]
+	case AggregationMedian:
+		return percentile(50)
+	case AggregationP95:
+		return percentile(95)
+	case AggregationSum:
+		sum := 0.0
+		for _, value := range values {
+			sum += value
+		}
+		return sum
+	default:
+		return mean(values)
+	}
+}
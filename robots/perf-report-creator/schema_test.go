@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("metric schema", func() {
+	const auditResults = `{
+  "Values": {
+    "vmiCreationToRunningSecondsP95": {
+      "value": 42.5,
+      "thresholdResult": {"thresholdValue": 40, "thresholdExceeded": true}
+    },
+    "CREATE-pods-count": {"value": 100},
+    "comment": "not a metric"
+  }
+}`
+
+	writeSchema := func(content string) string {
+		schemaPath := filepath.Join(GinkgoT().TempDir(), "metrics.yaml")
+		Expect(os.WriteFile(schemaPath, []byte(content), 0644)).To(Succeed())
+		return schemaPath
+	}
+
+	Context("default schema", func() {
+		var schema *MetricSchema
+
+		BeforeEach(func() {
+			var err error
+			schema, err = loadMetricSchema("")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("declares vmi and vm for the performance jobs", func() {
+			resources := schema.resourcesForJob("periodic-kubevirt-e2e-k8s-1.33-sig-performance")
+			Expect(resources).To(HaveLen(2))
+			Expect(resources[0].Name).To(Equal("vmi"))
+			Expect(resources[0].Artifact).To(Equal("VMI-perf-audit-results.json"))
+			Expect(resources[1].Name).To(Equal("vm"))
+			Expect(resources[1].Artifact).To(Equal("VM-perf-audit-results.json"))
+		})
+
+		It("declares only vmi for the density jobs", func() {
+			resources := schema.resourcesForJob("periodic-kubevirt-e2e-k8s-1.33-cluster-100-density-test")
+			Expect(resources).To(HaveLen(1))
+			Expect(resources[0].Artifact).To(Equal("perfscale-audit-results.json"))
+		})
+
+		It("extracts the values of the audit results", func() {
+			result, err := schema.resourcesForJob("periodic-kubevirt-e2e-k8s-1.33-sig-performance")[0].extract([]byte(auditResults))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Values).To(Equal(map[ResultType]ResultValue{
+				ResultTypeVMICreationToRunningP95: {Value: 42.5, ThresholdResult: &ThresholdResult{ThresholdValue: 40, ThresholdExceeded: true}},
+				ResultTypeCreatePodsCount:         {Value: 100},
+			}))
+		})
+
+		It("matches object keys case-insensitive", func() {
+			result, err := schema.resourcesForJob("sig-performance")[0].extract([]byte(`{"values": {"vmiCreationToRunningSecondsP95": {"value": 1}}}`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Values).To(HaveKeyWithValue(ResultTypeVMICreationToRunningP95, ResultValue{Value: 1}))
+		})
+
+		It("aggregates by average", func() {
+			Expect(schema.aggregationFor("sig-performance", "vmi", ResultTypeVMICreationToRunningP95)).To(Equal(AggregationAverage))
+		})
+	})
+
+	Context("custom schema", func() {
+		var schema *MetricSchema
+
+		BeforeEach(func() {
+			var err error
+			schema, err = loadMetricSchema(writeSchema(`
+jobs:
+- jobNameRegex: sig-compute-migrations
+  resources:
+  - name: migration
+    artifact: "migration-*.json"
+    metrics:
+    - name: migrationThroughput
+      path: results.0.throughput
+      aggregation: median
+      betterWhen: higher
+    - path: latencies
+      aggregation: max
+    - name: hotplugLatency
+      path: hotplug
+      valuePath: seconds
+`))
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns no resources for an undeclared job", func() {
+			Expect(schema.resourcesForJob("sig-performance")).To(BeEmpty())
+		})
+
+		It("extracts named metrics and objects", func() {
+			result, err := schema.resourcesForJob("periodic-sig-compute-migrations")[0].extract([]byte(`{
+  "results": [{"throughput": "12.5"}],
+  "latencies": {"p50": 1, "p99": 3},
+  "hotplug": {"seconds": 7}
+}`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Values).To(Equal(map[ResultType]ResultValue{
+				"migrationThroughput": {Value: 12.5},
+				"p50":                 {Value: 1},
+				"p99":                 {Value: 3},
+				"hotplugLatency":      {Value: 7},
+			}))
+		})
+
+		It("fails if a declared path is missing", func() {
+			_, err := schema.resourcesForJob("sig-compute-migrations")[0].extract([]byte(`{"results": []}`))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("returns the aggregation of named metrics before the one of objects", func() {
+			Expect(schema.aggregationFor("sig-compute-migrations", "migration", "migrationThroughput")).To(Equal(AggregationMedian))
+			Expect(schema.aggregationFor("sig-compute-migrations", "migration", "p99")).To(Equal(AggregationMax))
+			Expect(schema.aggregationFor("sig-compute-migrations", "other", "p99")).To(Equal(AggregationAverage))
+		})
+
+		It("returns whether lower or higher values are better, lower by default", func() {
+			Expect(schema.betterWhenFor("sig-compute-migrations", "migration", "migrationThroughput")).To(Equal(BetterWhenHigher))
+			Expect(schema.betterWhenFor("sig-compute-migrations", "migration", "p99")).To(Equal(BetterWhenLower))
+			Expect(schema.betterWhenFor("sig-compute-migrations", "migration", "hotplugLatency")).To(Equal(BetterWhenLower))
+		})
+	})
+
+	DescribeTable("rejects invalid schemas",
+		func(content string) {
+			_, err := loadMetricSchema(writeSchema(content))
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("invalid regex", "jobs:\n- jobNameRegex: '('\n"),
+		Entry("missing artifact", "jobs:\n- jobNameRegex: a\n  resources:\n  - name: vmi\n"),
+		Entry("duplicate resource", "jobs:\n- jobNameRegex: a\n  resources:\n  - {name: vmi, artifact: a.json}\n  - {name: vmi, artifact: b.json}\n"),
+		Entry("missing path", "jobs:\n- jobNameRegex: a\n  resources:\n  - name: vmi\n    artifact: a.json\n    metrics:\n    - name: m\n"),
+		Entry("unknown aggregation", "jobs:\n- jobNameRegex: a\n  resources:\n  - name: vmi\n    artifact: a.json\n    metrics:\n    - path: Values\n      aggregation: mode\n"),
+		Entry("unknown betterWhen", "jobs:\n- jobNameRegex: a\n  resources:\n  - name: vmi\n    artifact: a.json\n    metrics:\n    - path: Values\n      betterWhen: faster\n"),
+		Entry("unknown field", "jobs:\n- jobNameRegex: a\n  resources:\n  - name: vmi\n    artifact: a.json\n    metrics:\n    - path: Values\n      aggregaton: max\n"),
+	)
+
+	DescribeTable("aggregates",
+		func(aggregation Aggregation, expected float64) {
+			Expect(aggregate([]float64{4, 1, 3, 2, 10}, aggregation)).To(Equal(expected))
+		},
+		Entry("average", AggregationAverage, 4.0),
+		Entry("default", Aggregation(""), 4.0),
+		Entry("min", AggregationMin, 1.0),
+		Entry("max", AggregationMax, 10.0),
+		Entry("median", AggregationMedian, 3.0),
+		Entry("p95", AggregationP95, 10.0),
+		Entry("sum", AggregationSum, 20.0),
+	)
+
+	It("keeps the average and adds the aggregate of the record data", func() {
+		data := NewRecordData([]RecordDataPoint{{Value: 1}, {Value: 5}}, AggregationMax)
+		Expect(data.Average).To(Equal(3.0))
+		Expect(data.AggregatedValue()).To(Equal(5.0))
+		Expect(NewRecordData([]RecordDataPoint{{Value: 1}, {Value: 5}}, AggregationAverage).AggregatedValue()).To(Equal(3.0))
+	})
+
+	It("reads results of runs collected before the schema", func() {
+		run := JobRun{}
+		Expect(json.Unmarshal([]byte(`{"VMIResult": {"Values": {"vmiCreationToRunningSecondsP95": {"value": 1}}}, "VMResult": null}`), &run)).To(Succeed())
+		Expect(run.ResultFor("vmi")).ToNot(BeNil())
+		Expect(run.ResultFor("vm")).To(BeNil())
+		c := Collection{"1": run, "2": JobRun{Results: map[string]*Result{"migration": {Values: map[ResultType]ResultValue{"throughput": {Value: 2}}}}}}
+		Expect(c.resources()).To(Equal([]string{"migration", "vmi"}))
+		Expect(c.metrics("vmi")).To(Equal([]string{"vmiCreationToRunningSecondsP95"}))
+	})
+})
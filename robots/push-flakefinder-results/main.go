@@ -20,132 +20,116 @@
 package main
 
 import (
-	"encoding/csv"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
-	"strconv"
+	"os"
 	"time"
 
 	"kubevirt.io/project-infra/pkg/flakefinder"
-
-	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/push"
-)
-
-const (
-	testName = iota
-	testLane
-	severity
-	failed
-	succeeded
-	skipped
 )
 
-const (
-	namespace = "flakefinder"
-	subsystem = "report"
-)
+type options struct {
+	pushgatewayURL      string
+	remoteWriteURL      string
+	remoteWriteBatch    int
+	dateRange           string
+	org                 string
+	repo                string
+	reportDate          string
+	reportPath          string
+	maxMalformedEntries int
+}
 
-func main() {
-	var pushgatewayURL, dateRange, org, repo string
-	flag.StringVar(&pushgatewayURL, "pushgateway-url", "http://localhost:8080/", "pushgateway url to push values to")
-	flag.StringVar(&dateRange, "date-range", flakefinder.DateRange24h, "daterange of the report (one of 024h, 168h, 672h)")
-	flag.StringVar(&org, "github-org", "kubevirt", "github org")
-	flag.StringVar(&repo, "github-repo", "kubevirt", "github repo")
+func flagOptions() options {
+	o := options{}
+	flag.StringVar(&o.pushgatewayURL, "pushgateway-url", "http://localhost:8080/", "pushgateway url to push values to, ignored if remote-write-url is set")
+	flag.StringVar(&o.remoteWriteURL, "remote-write-url", "", "prometheus remote write url to send values to, timestamped at the end of the report period")
+	flag.IntVar(&o.remoteWriteBatch, "remote-write-batch-size", 1000, "maximum number of samples per remote write request")
+	flag.StringVar(&o.dateRange, "date-range", flakefinder.DateRange24h, "daterange of the report (one of 024h, 168h, 672h)")
+	flag.StringVar(&o.org, "github-org", "kubevirt", "github org")
+	flag.StringVar(&o.repo, "github-repo", "kubevirt", "github repo")
+	flag.StringVar(&o.reportDate, "report-date", "", "date of the report to export (format 2006-01-02), default is yesterday")
+	flag.StringVar(&o.reportPath, "report-path", "", "path to a local flakefinder json report to export instead of fetching the report")
+	flag.IntVar(&o.maxMalformedEntries, "max-malformed-entries", 100, "number of malformed report entries that are tolerated before failing")
 	flag.Parse()
+	return o
+}
 
-	pusher := push.New(pushgatewayURL, fmt.Sprintf("flakefinder_results_%s", dateRange))
-
-	yesterday := time.Now().Add(-24 * time.Hour)
-	fileType := "csv"
-	reportCSVURL, err := flakefinder.GenerateReportURL(org, repo, yesterday, dateRange, fileType)
-	if err != nil {
-		log.Fatalf("failed to generate report url: %v", err)
+func (o *options) validate() error {
+	if !flakefinder.IsAllowedDateRange(o.dateRange) {
+		return fmt.Errorf("date-range %q is not allowed", o.dateRange)
 	}
-	log.Printf("fetching report %q", reportCSVURL)
-	response, err := http.DefaultClient.Get(reportCSVURL)
-	if err != nil {
-		log.Fatalf("error fetching report %q", reportCSVURL)
+	if o.reportDate != "" {
+		if _, err := time.Parse("2006-01-02", o.reportDate); err != nil {
+			return fmt.Errorf("report-date %q is invalid: %w", o.reportDate, err)
+		}
 	}
-	if response.StatusCode != http.StatusOK {
-		log.Fatalf("error %s fetching report %q", response.Status, reportCSVURL)
+	if o.remoteWriteURL != "" && o.remoteWriteBatch < 1 {
+		return fmt.Errorf("remote-write-batch-size must be at least 1")
 	}
-	defer func() { _ = response.Body.Close() }()
+	return nil
+}
 
-	csvReader := csv.NewReader(response.Body)
-	values, err := csvReader.ReadAll()
-	if err != nil {
-		log.Fatalf("error reading csv %q", reportCSVURL)
+func (o *options) sink() sink {
+	if o.remoteWriteURL != "" {
+		return &remoteWriteSink{url: o.remoteWriteURL, client: http.DefaultClient, batchSize: o.remoteWriteBatch}
 	}
-	for index, row := range values {
-		// skip headers
-		if index == 0 {
-			continue
-		}
+	return &pushgatewaySink{url: o.pushgatewayURL, jobName: fmt.Sprintf("flakefinder_results_%s", o.dateRange)}
+}
 
-		labels := prometheus.Labels{
-			"org":        org,
-			"repo":       repo,
-			"date_range": dateRange,
-			"test_name":  row[testName],
-			"test_lane":  row[testLane],
-			"severity":   row[severity],
-		}
+func main() {
+	opts := flagOptions()
+	if err := opts.validate(); err != nil {
+		log.Fatalf("validating command line options failed: %v", err)
+	}
 
-		pusher.Collector(
-			newTestFailedGauge(labels, convertToIntOrDie(row[failed]))).Collector(
-			newTestSucceededGauge(labels, convertToIntOrDie(row[succeeded]))).Collector(
-			newTestSkippedGauge(labels, convertToIntOrDie(row[skipped])))
+	reportDate := time.Now().Add(-24 * time.Hour)
+	if opts.reportDate != "" {
+		reportDate, _ = time.Parse("2006-01-02", opts.reportDate)
 	}
 
-	err = pusher.Push()
+	data, err := readReport(opts, reportDate)
 	if err != nil {
-		log.Fatalf("push to %s failed: %v", pushgatewayURL, err)
+		log.Fatalf("failed to read report: %v", err)
+	}
+	r, err := parseReport(data)
+	if err != nil {
+		log.Fatalf("failed to read report: %v", err)
 	}
-}
-
-func newTestSucceededGauge(labels prometheus.Labels, timesTestSucceeded int) prometheus.Gauge {
-	testSucceededGauge := prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace:   namespace,
-		Subsystem:   subsystem,
-		Name:        "e2e_test_succeeded",
-		Help:        "number of succeeded tests on presubmit job for merged PRs observed over the report period",
-		ConstLabels: labels,
-	})
-	testSucceededGauge.Set(float64(timesTestSucceeded))
-	return testSucceededGauge
-}
 
-func newTestFailedGauge(labels prometheus.Labels, timesTestFailed int) prometheus.Gauge {
-	testFailedGauge := prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace:   namespace,
-		Subsystem:   subsystem,
-		Name:        "e2e_test_failed",
-		Help:        "number of failed tests on presubmit jobs for all merged PRs observed over the report period",
-		ConstLabels: labels,
-	})
-	testFailedGauge.Set(float64(timesTestFailed))
-	return testFailedGauge
-}
+	samples, errs := r.samples(opts.org, opts.repo, opts.dateRange)
+	for _, err := range errs {
+		log.Print(err)
+	}
+	if len(errs) > opts.maxMalformedEntries {
+		log.Fatalf("too many malformed report entries: %d", len(errs))
+	}
 
-func newTestSkippedGauge(labels prometheus.Labels, timesTestSkipped int) prometheus.Gauge {
-	testSkippedGauge := prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace:   namespace,
-		Subsystem:   subsystem,
-		Name:        "e2e_test_skipped",
-		Help:        "number of skipped tests on presubmit job for merged PRs observed over the report period",
-		ConstLabels: labels,
-	})
-	testSkippedGauge.Set(float64(timesTestSkipped))
-	return testSkippedGauge
+	log.Printf("exporting %d samples", len(samples))
+	if err := opts.sink().Write(samples, r.timestamp(reportDate)); err != nil {
+		log.Fatalf("export failed: %v", err)
+	}
 }
 
-func convertToIntOrDie(failed string) int {
-	timesTestFailed, err := strconv.Atoi(failed)
+func readReport(opts options, reportDate time.Time) ([]byte, error) {
+	if opts.reportPath != "" {
+		return os.ReadFile(opts.reportPath)
+	}
+	reportJSONURL, err := flakefinder.GenerateReportURL(opts.org, opts.repo, reportDate, opts.dateRange, "json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate report url: %w", err)
+	}
+	log.Printf("fetching report %q", reportJSONURL)
+	response, err := http.DefaultClient.Get(reportJSONURL)
 	if err != nil {
-		log.Fatalf("cannot convert %s to int", failed)
+		return nil, fmt.Errorf("error fetching report %q: %w", reportJSONURL, err)
+	}
+	defer func() { _ = response.Body.Close() }()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error %s fetching report %q", response.Status, reportJSONURL)
 	}
-	return timesTestFailed
+	return io.ReadAll(response.Body)
 }
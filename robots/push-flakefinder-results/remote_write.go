@@ -0,0 +1,149 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2022 Red Hat, Inc.
+ *
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// remoteWriteSink sends the samples via the Prometheus remote write protocol (version 1.0), where every sample
+// carries the given timestamp. Note that the receiver needs to accept samples out of order if the timestamp is
+// older than its head block, i.e. for Prometheus `storage.tsdb.out_of_order_time_window` has to be configured.
+type remoteWriteSink struct {
+	url       string
+	client    *http.Client
+	batchSize int
+}
+
+func (s *remoteWriteSink) Write(samples []sample, timestamp time.Time) error {
+	for start := 0; start < len(samples); start += s.batchSize {
+		end := start + s.batchSize
+		if end > len(samples) {
+			end = len(samples)
+		}
+		if err := s.send(encodeWriteRequest(samples[start:end], timestamp)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *remoteWriteSink) send(writeRequest []byte) error {
+	request, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(snappyEncode(writeRequest)))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Encoding", "snappy")
+	request.Header.Set("Content-Type", "application/x-protobuf")
+	request.Header.Set("User-Agent", "push-flakefinder-results")
+	request.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	response, err := s.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("remote write to %s failed: %w", s.url, err)
+	}
+	defer func() { _ = response.Body.Close() }()
+	if response.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+		return fmt.Errorf("remote write to %s failed: %s: %s", s.url, response.Status, body)
+	}
+	return nil
+}
+
+// encodeWriteRequest encodes the samples as a prometheus.WriteRequest protobuf message, with one time series per
+// sample:
+//
+//	message WriteRequest { repeated TimeSeries timeseries = 1; }
+//	message TimeSeries { repeated Label labels = 1; repeated Sample samples = 2; }
+//	message Label { string name = 1; string value = 2; }
+//	message Sample { double value = 1; int64 timestamp = 2; }
+func encodeWriteRequest(samples []sample, timestamp time.Time) []byte {
+	var writeRequest []byte
+	for _, s := range samples {
+		var timeSeries []byte
+		for _, label := range sortedLabels(s) {
+			var l []byte
+			l = protowire.AppendTag(l, 1, protowire.BytesType)
+			l = protowire.AppendString(l, label[0])
+			l = protowire.AppendTag(l, 2, protowire.BytesType)
+			l = protowire.AppendString(l, label[1])
+			timeSeries = protowire.AppendTag(timeSeries, 1, protowire.BytesType)
+			timeSeries = protowire.AppendBytes(timeSeries, l)
+		}
+		var v []byte
+		v = protowire.AppendTag(v, 1, protowire.Fixed64Type)
+		v = protowire.AppendFixed64(v, math.Float64bits(s.value))
+		v = protowire.AppendTag(v, 2, protowire.VarintType)
+		v = protowire.AppendVarint(v, uint64(timestamp.UnixMilli()))
+		timeSeries = protowire.AppendTag(timeSeries, 2, protowire.BytesType)
+		timeSeries = protowire.AppendBytes(timeSeries, v)
+
+		writeRequest = protowire.AppendTag(writeRequest, 1, protowire.BytesType)
+		writeRequest = protowire.AppendBytes(writeRequest, timeSeries)
+	}
+	return writeRequest
+}
+
+// sortedLabels returns the name/value pairs of the labels including the metric name, sorted by name as required
+// by the remote write protocol. Labels with empty values are omitted.
+func sortedLabels(s sample) [][2]string {
+	labels := [][2]string{{"__name__", s.name}}
+	for name, value := range s.labels {
+		if value == "" {
+			continue
+		}
+		labels = append(labels, [2]string{name, value})
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i][0] < labels[j][0] })
+	return labels
+}
+
+// snappyEncode encodes the data in the snappy block format using literals only, which is valid snappy, although
+// without compression.
+func snappyEncode(data []byte) []byte {
+	encoded := binary.AppendUvarint(nil, uint64(len(data)))
+	const maxLiteralLength = 1 << 16
+	for len(data) > 0 {
+		literal := data
+		if len(literal) > maxLiteralLength {
+			literal = literal[:maxLiteralLength]
+		}
+		n := len(literal) - 1
+		switch {
+		case n < 60:
+			encoded = append(encoded, byte(n)<<2)
+		case n < 1<<8:
+			encoded = append(encoded, 60<<2, byte(n))
+		default:
+			encoded = append(encoded, 61<<2, byte(n), byte(n>>8))
+		}
+		encoded = append(encoded, literal...)
+		data = data[len(literal):]
+	}
+	return encoded
+}
@@ -0,0 +1,176 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2022 Red Hat, Inc.
+ *
+ */
+
+package main
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// snappyDecodeLiterals decodes a snappy block that only contains literals
+func snappyDecodeLiterals(encoded []byte) []byte {
+	length, n := binary.Uvarint(encoded)
+	encoded = encoded[n:]
+	var decoded []byte
+	for len(encoded) > 0 {
+		tag := encoded[0]
+		Expect(tag&0x03).To(BeZero(), "only literals are expected")
+		var literalLength int
+		switch tag >> 2 {
+		case 60:
+			literalLength = int(encoded[1]) + 1
+			encoded = encoded[2:]
+		case 61:
+			literalLength = int(encoded[1]) | int(encoded[2])<<8 + 1
+			encoded = encoded[3:]
+		default:
+			literalLength = int(tag>>2) + 1
+			encoded = encoded[1:]
+		}
+		decoded = append(decoded, encoded[:literalLength]...)
+		encoded = encoded[literalLength:]
+	}
+	Expect(decoded).To(HaveLen(int(length)))
+	return decoded
+}
+
+type decodedSample struct {
+	labels    map[string]string
+	value     float64
+	timestamp int64
+}
+
+// decodeWriteRequest decodes the time series of a prometheus.WriteRequest
+func decodeWriteRequest(data []byte) []decodedSample {
+	var result []decodedSample
+	forEachField(data, func(_ protowire.Number, timeSeries []byte) {
+		s := decodedSample{labels: map[string]string{}}
+		forEachField(timeSeries, func(number protowire.Number, value []byte) {
+			switch number {
+			case 1:
+				var name, labelValue string
+				forEachField(value, func(number protowire.Number, v []byte) {
+					if number == 1 {
+						name = string(v)
+					} else {
+						labelValue = string(v)
+					}
+				})
+				s.labels[name] = labelValue
+			case 2:
+				f, _, n := protowire.ConsumeTag(value)
+				Expect(f).To(BeEquivalentTo(1))
+				bits, m := protowire.ConsumeFixed64(value[n:])
+				s.value = math.Float64frombits(bits)
+				_, _, k := protowire.ConsumeTag(value[n+m:])
+				timestamp, _ := protowire.ConsumeVarint(value[n+m+k:])
+				s.timestamp = int64(timestamp)
+			}
+		})
+		result = append(result, s)
+	})
+	return result
+}
+
+func forEachField(data []byte, f func(protowire.Number, []byte)) {
+	for len(data) > 0 {
+		number, typ, n := protowire.ConsumeTag(data)
+		Expect(n).To(BeNumerically(">", 0))
+		Expect(typ).To(Equal(protowire.BytesType))
+		value, m := protowire.ConsumeBytes(data[n:])
+		Expect(m).To(BeNumerically(">", 0))
+		f(number, value)
+		data = data[n+m:]
+	}
+}
+
+var _ = Describe("remote write", func() {
+
+	DescribeTable("snappyEncode",
+		func(length int) {
+			data := make([]byte, length)
+			for i := range data {
+				data[i] = byte(i)
+			}
+			Expect(snappyDecodeLiterals(snappyEncode(data))).To(Equal(data))
+		},
+		Entry("short literal", 1),
+		Entry("one byte length literal", 100),
+		Entry("two byte length literal", 1000),
+		Entry("several literals", 200000),
+	)
+
+	It("encodes a single byte as expected", func() {
+		Expect(snappyEncode([]byte("a"))).To(Equal([]byte{0x01, 0x00, 'a'}))
+	})
+
+	It("sends the samples in batches with the timestamp", func() {
+		var requests [][]decodedSample
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			Expect(r.Header.Get("Content-Encoding")).To(Equal("snappy"))
+			Expect(r.Header.Get("Content-Type")).To(Equal("application/x-protobuf"))
+			body, err := io.ReadAll(r.Body)
+			Expect(err).ToNot(HaveOccurred())
+			requests = append(requests, decodeWriteRequest(snappyDecodeLiterals(body)))
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		timestamp := time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC)
+		s := &remoteWriteSink{url: server.URL, client: server.Client(), batchSize: 2}
+		err := s.Write([]sample{
+			{name: metricTestFailed, labels: map[string]string{"test_name": "test1", "sig": ""}, value: 1},
+			{name: metricTestSucceeded, labels: map[string]string{"test_name": "test1"}, value: 2},
+			{name: metricTestSkipped, labels: map[string]string{"test_name": "test1"}, value: 3},
+		}, timestamp)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(requests).To(HaveLen(2))
+		Expect(requests[0]).To(HaveLen(2))
+		Expect(requests[0][0]).To(Equal(decodedSample{
+			labels:    map[string]string{"__name__": metricTestFailed, "test_name": "test1"},
+			value:     1,
+			timestamp: timestamp.UnixMilli(),
+		}))
+		Expect(requests[1]).To(HaveLen(1))
+		Expect(requests[1][0].value).To(BeEquivalentTo(3))
+	})
+
+	It("fails if the receiver rejects the samples", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "out of order sample", http.StatusBadRequest)
+		}))
+		defer server.Close()
+
+		s := &remoteWriteSink{url: server.URL, client: server.Client(), batchSize: 10}
+		err := s.Write([]sample{{name: metricTestFailed, value: 1}}, time.Now())
+
+		Expect(err).To(MatchError(ContainSubstring("out of order sample")))
+	})
+})
@@ -0,0 +1,151 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2022 Red Hat, Inc.
+ *
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"kubevirt.io/project-infra/pkg/flakefinder"
+)
+
+const (
+	namespace = "flakefinder"
+	subsystem = "report"
+)
+
+const (
+	metricTestFailed    = namespace + "_" + subsystem + "_e2e_test_failed"
+	metricTestSucceeded = namespace + "_" + subsystem + "_e2e_test_succeeded"
+	metricTestSkipped   = namespace + "_" + subsystem + "_e2e_test_skipped"
+)
+
+var metricHelp = map[string]string{
+	metricTestFailed:    "number of failed tests on presubmit jobs for all merged PRs observed over the report period",
+	metricTestSucceeded: "number of succeeded tests on presubmit job for merged PRs observed over the report period",
+	metricTestSkipped:   "number of skipped tests on presubmit job for merged PRs observed over the report period",
+}
+
+// sample is the value of a metric for a set of labels.
+type sample struct {
+	name   string
+	labels map[string]string
+	value  float64
+}
+
+// report is the part of the flakefinder json report that is exported. Test details are kept raw, so that a
+// malformed entry doesn't prevent exporting the others.
+type report struct {
+	EndOfReport string                                `json:"endOfReport"`
+	Data        map[string]map[string]json.RawMessage `json:"data"`
+}
+
+func parseReport(data []byte) (*report, error) {
+	var r report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse flakefinder report: %w", err)
+	}
+	return &r, nil
+}
+
+// timestamp returns the end of the report period, or the fallback if the report doesn't contain a valid one.
+func (r *report) timestamp(fallback time.Time) time.Time {
+	endOfReport, err := time.Parse(time.RFC3339, r.EndOfReport)
+	if err != nil {
+		return fallback
+	}
+	return endOfReport
+}
+
+var laneSIGRegex = regexp.MustCompile(`sig-[a-z]+`)
+var laneProviderVersionRegex = regexp.MustCompile(`-k8s-[0-9]+\.[0-9]+`)
+
+// samples converts the test details of the report into samples, sorted by test and lane. Entries that can't be
+// converted are skipped and returned as errors.
+func (r *report) samples(org, repo, dateRange string) ([]sample, []error) {
+	var samples []sample
+	var errs []error
+
+	testNames := make([]string, 0, len(r.Data))
+	for testName := range r.Data {
+		testNames = append(testNames, testName)
+	}
+	sort.Strings(testNames)
+
+	for _, testName := range testNames {
+		sig, quarantined := testAttributes(testName)
+		lanes := make([]string, 0, len(r.Data[testName]))
+		for lane := range r.Data[testName] {
+			lanes = append(lanes, lane)
+		}
+		sort.Strings(lanes)
+		for _, lane := range lanes {
+			var details flakefinder.Details
+			if err := json.Unmarshal(r.Data[testName][lane], &details); err != nil {
+				errs = append(errs, fmt.Errorf("skipping malformed entry for test %q on lane %q: %w", testName, lane, err))
+				continue
+			}
+			if details.Failed < 0 || details.Succeeded < 0 || details.Skipped < 0 {
+				errs = append(errs, fmt.Errorf("skipping entry with negative values for test %q on lane %q", testName, lane))
+				continue
+			}
+
+			testSIG := sig
+			if testSIG == "" {
+				testSIG = laneSIGRegex.FindString(lane)
+			}
+			labels := map[string]string{
+				"org":         org,
+				"repo":        repo,
+				"date_range":  dateRange,
+				"test_name":   testName,
+				"test_lane":   lane,
+				"lane":        laneProviderVersionRegex.ReplaceAllString(lane, ""),
+				"sig":         testSIG,
+				"quarantined": fmt.Sprintf("%t", quarantined),
+				"severity":    details.Severity,
+			}
+			samples = append(samples,
+				sample{name: metricTestFailed, labels: labels, value: float64(details.Failed)},
+				sample{name: metricTestSucceeded, labels: labels, value: float64(details.Succeeded)},
+				sample{name: metricTestSkipped, labels: labels, value: float64(details.Skipped)},
+			)
+		}
+	}
+	return samples, errs
+}
+
+// testAttributes returns the SIG and whether the test is quarantined from the attributes in the test name.
+func testAttributes(testName string) (sig string, quarantined bool) {
+	for _, attribute := range flakefinder.NewTestAttributes(testName) {
+		switch attribute.AttributeType {
+		case flakefinder.TestAttributeTypeSIG:
+			if sig == "" {
+				sig = attribute.Name
+			}
+		case flakefinder.TestAttributeTypeQuarantine:
+			quarantined = true
+		}
+	}
+	return sig, quarantined
+}
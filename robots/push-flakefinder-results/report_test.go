@@ -0,0 +1,90 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2022 Red Hat, Inc.
+ *
+ */
+
+package main
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("report", func() {
+
+	const reportJSON = `{
+  "startOfReport": "2024-06-01T00:00:00Z",
+  "endOfReport": "2024-06-02T00:00:00Z",
+  "data": {
+    "[sig-network] test1": {
+      "pull-kubevirt-e2e-k8s-1.30-sig-network": {"succeeded": 9, "skipped": 0, "failed": 1, "severity": "yellow"}
+    },
+    "[QUARANTINE] test2": {
+      "pull-kubevirt-e2e-k8s-1.30-sig-compute": {"succeeded": 1, "skipped": 2, "failed": 3, "severity": "red"},
+      "pull-kubevirt-e2e-k8s-1.30-sig-storage": {"succeeded": "many", "failed": 3}
+    }
+  }
+}`
+
+	It("exports all well-formed entries with lane, sig and quarantine labels", func() {
+		r, err := parseReport([]byte(reportJSON))
+		Expect(err).ToNot(HaveOccurred())
+
+		samples, errs := r.samples("kubevirt", "kubevirt", "024h")
+
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].Error()).To(ContainSubstring("pull-kubevirt-e2e-k8s-1.30-sig-storage"))
+		Expect(samples).To(HaveLen(6))
+		Expect(samples[0]).To(Equal(sample{
+			name: metricTestFailed,
+			labels: map[string]string{
+				"org":         "kubevirt",
+				"repo":        "kubevirt",
+				"date_range":  "024h",
+				"test_name":   "[QUARANTINE] test2",
+				"test_lane":   "pull-kubevirt-e2e-k8s-1.30-sig-compute",
+				"lane":        "pull-kubevirt-e2e-sig-compute",
+				"sig":         "sig-compute",
+				"quarantined": "true",
+				"severity":    "red",
+			},
+			value: 3,
+		}))
+		Expect(samples[1].name).To(Equal(metricTestSucceeded))
+		Expect(samples[1].value).To(BeEquivalentTo(1))
+		Expect(samples[2].name).To(Equal(metricTestSkipped))
+		Expect(samples[2].value).To(BeEquivalentTo(2))
+		Expect(samples[3].labels).To(HaveKeyWithValue("sig", "sig-network"))
+		Expect(samples[3].labels).To(HaveKeyWithValue("quarantined", "false"))
+	})
+
+	It("fails on a report that is not json", func() {
+		_, err := parseReport([]byte("test,lane,severity"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	DescribeTable("timestamp",
+		func(endOfReport string, expected time.Time) {
+			r := &report{EndOfReport: endOfReport}
+			Expect(r.timestamp(time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC))).To(BeTemporally("==", expected))
+		},
+		Entry("end of report", "2024-06-02T00:00:00Z", time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC)),
+		Entry("fallback if missing", "", time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)),
+	)
+})
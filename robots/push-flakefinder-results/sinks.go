@@ -0,0 +1,53 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2022 Red Hat, Inc.
+ *
+ */
+
+package main
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// sink exports the samples of a report.
+type sink interface {
+	Write(samples []sample, timestamp time.Time) error
+}
+
+// pushgatewaySink pushes the samples as gauges to a pushgateway, replacing all values of the previous push.
+// The pushgateway doesn't support timestamps, thus the timestamp is ignored.
+type pushgatewaySink struct {
+	url     string
+	jobName string
+}
+
+func (s *pushgatewaySink) Write(samples []sample, _ time.Time) error {
+	pusher := push.New(s.url, s.jobName)
+	for _, sample := range samples {
+		gauge := prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        sample.name,
+			Help:        metricHelp[sample.name],
+			ConstLabels: sample.labels,
+		})
+		gauge.Set(sample.value)
+		pusher.Collector(gauge)
+	}
+	return pusher.Push()
+}
@@ -0,0 +1,113 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/joshdk/go-junit"
+	log "github.com/sirupsen/logrus"
+)
+
+// backfill processes all finished builds of the job that are newer than the last processed build recorded in the
+// checkpoint, oldest first. It stops at the first build that has not finished yet, so that build is picked up by
+// the next run, unless the build has been started more than abandonAfter ago, in which case it is skipped: such a
+// build will never finish, e.g. because its pod got deleted. The same holds for a build that did not record its start.
+// If the job has never been processed, only the latest
+// maxBuildsOnFirstRun builds are considered.
+//
+// For each processed build the test runtimes are observed in the returned metrics, and the runtimes of passed tests
+// are recorded in the checkpoint. A build with malformed junit results is processed without test results. The
+// returned metrics are nil if no build has been processed. On error the metrics of the builds processed so far are
+// returned together with the error.
+func backfill(source buildSource, c *checkpoint, jobName string, maxBuildsOnFirstRun int, abandonAfter time.Duration) (*jobMetrics, error) {
+	buildIDs, err := source.BuildIDs(jobName)
+	if err != nil {
+		return nil, err
+	}
+
+	job := c.job(jobName)
+	var pending []uint64
+	for _, buildID := range buildIDs {
+		if buildID > job.LastProcessedBuild {
+			pending = append(pending, buildID)
+		}
+	}
+	if job.LastProcessedBuild == 0 && len(pending) > maxBuildsOnFirstRun {
+		pending = pending[len(pending)-maxBuildsOnFirstRun:]
+	}
+
+	jLog := log.WithField("job_name", jobName)
+	var metrics *jobMetrics
+	for _, buildID := range pending {
+		finished, finishedAt, err := source.Finished(jobName, buildID)
+		if err != nil {
+			return metrics, err
+		}
+		if !finished {
+			started, err := source.Started(jobName, buildID)
+			if err != nil {
+				return metrics, err
+			}
+			if !started.IsZero() && time.Since(started) < abandonAfter {
+				jLog.Infof("build %d has not finished yet, stopping", buildID)
+				break
+			}
+			jLog.Warnf("build %d has not finished within %s, skipping", buildID, abandonAfter)
+			job.LastProcessedBuild = buildID
+			continue
+		}
+
+		suites, err := source.JUnit(jobName, buildID)
+		if errors.Is(err, errMalformedJUnit) {
+			jLog.WithError(err).Warnf("skipping test results of build %d", buildID)
+			suites = nil
+		} else if err != nil {
+			return metrics, fmt.Errorf("failed to read junit of %s/%d: %w", jobName, buildID, err)
+		} else if suites == nil {
+			jLog.Infof("build %d has no junit results", buildID)
+		}
+		if metrics == nil {
+			metrics = newJobMetrics(jobName)
+		}
+		for _, suite := range suites {
+			for _, test := range suite.Tests {
+				if test.Status == junit.StatusSkipped {
+					continue
+				}
+				metrics.observe(test)
+				if test.Status != junit.StatusPassed {
+					// failed tests often run into timeouts, thus their durations would distort the percentiles
+					continue
+				}
+				job.Durations[test.Name] = append(job.Durations[test.Name], sample{
+					Build:     buildID,
+					Timestamp: finishedAt.Unix(),
+					Seconds:   test.Duration.Seconds(),
+				})
+			}
+		}
+		job.LastProcessedBuild = buildID
+		jLog.Infof("processed build %d", buildID)
+	}
+	return metrics, nil
+}
@@ -0,0 +1,226 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/joshdk/go-junit"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type fakeBuild struct {
+	startedAt  time.Time
+	finishedAt *time.Time
+	suites     []junit.Suite
+	junitErr   error
+}
+
+type fakeBuildSource map[string]map[uint64]fakeBuild
+
+func (f fakeBuildSource) BuildIDs(jobName string) ([]uint64, error) {
+	var buildIDs []uint64
+	for buildID := range f[jobName] {
+		buildIDs = append(buildIDs, buildID)
+	}
+	sort.Slice(buildIDs, func(i, j int) bool { return buildIDs[i] < buildIDs[j] })
+	return buildIDs, nil
+}
+
+func (f fakeBuildSource) Started(jobName string, buildID uint64) (time.Time, error) {
+	return f[jobName][buildID].startedAt, nil
+}
+
+func (f fakeBuildSource) Finished(jobName string, buildID uint64) (bool, time.Time, error) {
+	finishedAt := f[jobName][buildID].finishedAt
+	if finishedAt == nil {
+		return false, time.Time{}, nil
+	}
+	return true, *finishedAt, nil
+}
+
+func (f fakeBuildSource) JUnit(jobName string, buildID uint64) ([]junit.Suite, error) {
+	return f[jobName][buildID].suites, f[jobName][buildID].junitErr
+}
+
+func finishedBuild(finishedAt time.Time, tests ...junit.Test) fakeBuild {
+	return fakeBuild{finishedAt: &finishedAt, suites: []junit.Suite{{Name: "Tests Suite", Tests: tests}}}
+}
+
+var _ = Describe("backfill", func() {
+
+	const (
+		jobName      = "periodic-kubevirt-e2e-k8s-1.30-sig-compute"
+		abandonAfter = 24 * time.Hour
+	)
+
+	var now time.Time
+
+	BeforeEach(func() {
+		now = time.Now()
+	})
+
+	It("processes every build since the last processed build", func() {
+		source := fakeBuildSource{
+			jobName: {
+				1: finishedBuild(now.Add(-3*time.Hour), junit.Test{Name: "test", Status: junit.StatusPassed, Duration: time.Second}),
+				2: finishedBuild(now.Add(-2*time.Hour), junit.Test{Name: "test", Status: junit.StatusPassed, Duration: 2 * time.Second}),
+				3: finishedBuild(now.Add(-1*time.Hour), junit.Test{Name: "test", Status: junit.StatusPassed, Duration: 3 * time.Second}),
+			},
+		}
+		c := newCheckpoint()
+		c.job(jobName).LastProcessedBuild = 1
+
+		metrics, err := backfill(source, c, jobName, 10, abandonAfter)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(metrics).ToNot(BeNil())
+		Expect(c.Jobs[jobName].LastProcessedBuild).To(BeEquivalentTo(3))
+		Expect(c.Jobs[jobName].Durations["test"]).To(Equal([]sample{
+			{Build: 2, Timestamp: now.Add(-2 * time.Hour).Unix(), Seconds: 2},
+			{Build: 3, Timestamp: now.Add(-1 * time.Hour).Unix(), Seconds: 3},
+		}))
+	})
+
+	It("stops at the first build that has not finished", func() {
+		source := fakeBuildSource{
+			jobName: {
+				1: finishedBuild(now.Add(-3*time.Hour), junit.Test{Name: "test", Status: junit.StatusPassed}),
+				2: {startedAt: now.Add(-2 * time.Hour)},
+				3: finishedBuild(now.Add(-1*time.Hour), junit.Test{Name: "test", Status: junit.StatusPassed}),
+			},
+		}
+		c := newCheckpoint()
+
+		_, err := backfill(source, c, jobName, 10, abandonAfter)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.Jobs[jobName].LastProcessedBuild).To(BeEquivalentTo(1))
+	})
+
+	DescribeTable("skips builds that will never finish",
+		func(unfinished fakeBuild) {
+			source := fakeBuildSource{
+				jobName: {
+					1: finishedBuild(now.Add(-50*time.Hour), junit.Test{Name: "test", Status: junit.StatusPassed, Duration: time.Second}),
+					2: unfinished,
+					3: finishedBuild(now.Add(-1*time.Hour), junit.Test{Name: "test", Status: junit.StatusPassed, Duration: 3 * time.Second}),
+				},
+			}
+			c := newCheckpoint()
+
+			_, err := backfill(source, c, jobName, 10, abandonAfter)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(c.Jobs[jobName].LastProcessedBuild).To(BeEquivalentTo(3))
+			Expect(c.Jobs[jobName].Durations["test"]).To(HaveLen(2))
+		},
+		Entry("started before the cutoff", fakeBuild{startedAt: now.Add(-48 * time.Hour)}),
+		Entry("without start", fakeBuild{}),
+	)
+
+	It("processes builds with malformed junit results without test results", func() {
+		source := fakeBuildSource{
+			jobName: {
+				1: {finishedAt: &now, junitErr: fmt.Errorf("%w of %s/1: unexpected EOF", errMalformedJUnit, jobName)},
+				2: finishedBuild(now, junit.Test{Name: "test", Status: junit.StatusPassed}),
+			},
+		}
+		c := newCheckpoint()
+
+		metrics, err := backfill(source, c, jobName, 10, abandonAfter)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(metrics).ToNot(BeNil())
+		Expect(c.Jobs[jobName].LastProcessedBuild).To(BeEquivalentTo(2))
+		Expect(c.Jobs[jobName].Durations["test"]).To(HaveLen(1))
+	})
+
+	It("returns the metrics of the processed builds on error", func() {
+		source := fakeBuildSource{
+			jobName: {
+				1: finishedBuild(now, junit.Test{Name: "test", Status: junit.StatusPassed}),
+				2: {finishedAt: &now, junitErr: fmt.Errorf("failed to read gs://kubevirt-prow/logs/%s/2/artifacts/junit.functest.xml", jobName)},
+			},
+		}
+		c := newCheckpoint()
+
+		metrics, err := backfill(source, c, jobName, 10, abandonAfter)
+
+		Expect(err).To(HaveOccurred())
+		Expect(metrics).ToNot(BeNil())
+		Expect(c.Jobs[jobName].LastProcessedBuild).To(BeEquivalentTo(1))
+	})
+
+	It("limits the builds of a job that has not been processed before", func() {
+		source := fakeBuildSource{
+			jobName: {
+				1: finishedBuild(now.Add(-3*time.Hour), junit.Test{Name: "test", Status: junit.StatusPassed}),
+				2: finishedBuild(now.Add(-2*time.Hour), junit.Test{Name: "test", Status: junit.StatusPassed}),
+				3: finishedBuild(now.Add(-1*time.Hour), junit.Test{Name: "test", Status: junit.StatusPassed}),
+			},
+		}
+		c := newCheckpoint()
+
+		_, err := backfill(source, c, jobName, 2, abandonAfter)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.Jobs[jobName].LastProcessedBuild).To(BeEquivalentTo(3))
+		Expect(c.Jobs[jobName].Durations["test"]).To(HaveLen(2))
+	})
+
+	It("records only durations of passed tests", func() {
+		source := fakeBuildSource{
+			jobName: {
+				1: finishedBuild(now,
+					junit.Test{Name: "passed", Status: junit.StatusPassed},
+					junit.Test{Name: "failed", Status: junit.StatusFailed},
+					junit.Test{Name: "skipped", Status: junit.StatusSkipped},
+				),
+			},
+		}
+		c := newCheckpoint()
+
+		metrics, err := backfill(source, c, jobName, 10, abandonAfter)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.Jobs[jobName].Durations).To(HaveKey("passed"))
+		Expect(c.Jobs[jobName].Durations).To(HaveLen(1))
+		Expect(metrics.summaries).To(HaveLen(2))
+	})
+
+	It("returns no metrics if there is no new build", func() {
+		source := fakeBuildSource{
+			jobName: {
+				1: finishedBuild(now, junit.Test{Name: "test", Status: junit.StatusPassed}),
+			},
+		}
+		c := newCheckpoint()
+		c.job(jobName).LastProcessedBuild = 1
+
+		metrics, err := backfill(source, c, jobName, 10, abandonAfter)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(metrics).To(BeNil())
+	})
+})
@@ -0,0 +1,146 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/joshdk/go-junit"
+
+	"kubevirt.io/project-infra/pkg/flakefinder"
+)
+
+// buildSource provides the builds of a job and their test results.
+type buildSource interface {
+	// BuildIDs returns the ids of all builds of the job in ascending order
+	BuildIDs(jobName string) ([]uint64, error)
+
+	// Started returns when the build has started, or the zero time if it has not recorded its start
+	Started(jobName string, buildID uint64) (time.Time, error)
+
+	// Finished returns whether the build has finished, and if so, when
+	Finished(jobName string, buildID uint64) (bool, time.Time, error)
+
+	// JUnit returns the test results of the build, or nil if the build has none. If the results can't be parsed,
+	// the error wraps errMalformedJUnit.
+	JUnit(jobName string, buildID uint64) ([]junit.Suite, error)
+}
+
+var errMalformedJUnit = errors.New("malformed junit")
+
+// gcsBuildSource reads the builds from the prow uploads to GCS, i.e. logs/<job>/<build>.
+type gcsBuildSource struct {
+	ctx           context.Context
+	client        *storage.Client
+	bucket        string
+	junitFileName string
+}
+
+func newGCSBuildSource(ctx context.Context, client *storage.Client, bucket, junitFileName string) buildSource {
+	return &gcsBuildSource{ctx: ctx, client: client, bucket: bucket, junitFileName: junitFileName}
+}
+
+func (s *gcsBuildSource) BuildIDs(jobName string) ([]uint64, error) {
+	builds, err := flakefinder.ListGcsObjects(s.ctx, s.client, s.bucket, path.Join("logs", jobName)+"/", "/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list builds of %s: %w", jobName, err)
+	}
+	var buildIDs []uint64
+	for _, build := range builds {
+		buildID, err := strconv.ParseUint(build, 10, 64)
+		if err != nil {
+			continue
+		}
+		buildIDs = append(buildIDs, buildID)
+	}
+	sort.Slice(buildIDs, func(i, j int) bool { return buildIDs[i] < buildIDs[j] })
+	return buildIDs, nil
+}
+
+func (s *gcsBuildSource) Started(jobName string, buildID uint64) (time.Time, error) {
+	data, err := s.read(path.Join(s.buildDir(jobName, buildID), "started.json"))
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	var started struct {
+		Timestamp int64 `json:"timestamp"`
+	}
+	if err := json.Unmarshal(data, &started); err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse started.json of %s/%d: %w", jobName, buildID, err)
+	}
+	return time.Unix(started.Timestamp, 0), nil
+}
+
+func (s *gcsBuildSource) Finished(jobName string, buildID uint64) (bool, time.Time, error) {
+	data, err := s.read(path.Join(s.buildDir(jobName, buildID), "finished.json"))
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return false, time.Time{}, nil
+	}
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	var finished struct {
+		Timestamp int64 `json:"timestamp"`
+	}
+	if err := json.Unmarshal(data, &finished); err != nil {
+		return false, time.Time{}, fmt.Errorf("failed to parse finished.json of %s/%d: %w", jobName, buildID, err)
+	}
+	return true, time.Unix(finished.Timestamp, 0), nil
+}
+
+func (s *gcsBuildSource) JUnit(jobName string, buildID uint64) ([]junit.Suite, error) {
+	data, err := s.read(path.Join(s.buildDir(jobName, buildID), "artifacts", s.junitFileName))
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	suites, err := junit.Ingest(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w of %s/%d: %v", errMalformedJUnit, jobName, buildID, err)
+	}
+	return suites, nil
+}
+
+func (s *gcsBuildSource) buildDir(jobName string, buildID uint64) string {
+	return path.Join("logs", jobName, strconv.FormatUint(buildID, 10))
+}
+
+func (s *gcsBuildSource) read(object string) ([]byte, error) {
+	reader, err := s.client.Bucket(s.bucket).Object(object).NewReader(s.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gs://%s/%s: %w", s.bucket, object, err)
+	}
+	defer func() { _ = reader.Close() }()
+	return io.ReadAll(reader)
+}
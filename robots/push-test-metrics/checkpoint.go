@@ -0,0 +1,182 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+)
+
+// checkpoint records the last processed build per job, together with the recent test durations that are
+// required to detect duration regressions.
+type checkpoint struct {
+	Jobs map[string]*jobCheckpoint `json:"jobs"`
+}
+
+type jobCheckpoint struct {
+	// LastProcessedBuild is the id of the last build whose results have been pushed
+	LastProcessedBuild uint64 `json:"lastProcessedBuild"`
+
+	// Durations holds the duration samples per test name
+	Durations map[string][]sample `json:"durations"`
+}
+
+// sample is the duration of a test in a build.
+type sample struct {
+	Build     uint64  `json:"build"`
+	Timestamp int64   `json:"timestamp"`
+	Seconds   float64 `json:"seconds"`
+}
+
+func newCheckpoint() *checkpoint {
+	return &checkpoint{Jobs: map[string]*jobCheckpoint{}}
+}
+
+func (c *checkpoint) job(jobName string) *jobCheckpoint {
+	if _, exists := c.Jobs[jobName]; !exists {
+		c.Jobs[jobName] = &jobCheckpoint{Durations: map[string][]sample{}}
+	}
+	if c.Jobs[jobName].Durations == nil {
+		c.Jobs[jobName].Durations = map[string][]sample{}
+	}
+	return c.Jobs[jobName]
+}
+
+// prune removes all samples older than the given time, and the tests that have no samples left.
+func (c *checkpoint) prune(olderThan time.Time) {
+	for _, job := range c.Jobs {
+		for testName, samples := range job.Durations {
+			var keep []sample
+			for _, s := range samples {
+				if time.Unix(s.Timestamp, 0).Before(olderThan) {
+					continue
+				}
+				keep = append(keep, s)
+			}
+			if len(keep) == 0 {
+				delete(job.Durations, testName)
+				continue
+			}
+			job.Durations[testName] = keep
+		}
+	}
+}
+
+// checkpointStore loads and saves the checkpoint. Load returns an empty checkpoint if none has been saved yet.
+type checkpointStore interface {
+	Load(ctx context.Context) (*checkpoint, error)
+	Save(ctx context.Context, c *checkpoint) error
+}
+
+// newCheckpointStore returns a store for the location, which is either a gs://bucket/object url or a local file path.
+func newCheckpointStore(client *storage.Client, location string) (checkpointStore, error) {
+	if !strings.HasPrefix(location, "gs://") {
+		return &fileCheckpointStore{path: location}, nil
+	}
+	bucket, object, found := strings.Cut(strings.TrimPrefix(location, "gs://"), "/")
+	if !found || bucket == "" || object == "" {
+		return nil, fmt.Errorf("invalid gcs location %q, expected gs://bucket/object", location)
+	}
+	return &gcsCheckpointStore{client: client, bucket: bucket, object: object}, nil
+}
+
+type fileCheckpointStore struct {
+	path string
+}
+
+func (s *fileCheckpointStore) Load(_ context.Context) (*checkpoint, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return newCheckpoint(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint %q: %w", s.path, err)
+	}
+	return unmarshalCheckpoint(data)
+}
+
+func (s *fileCheckpointStore) Save(_ context.Context, c *checkpoint) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write checkpoint %q: %w", s.path, err)
+	}
+	return nil
+}
+
+type gcsCheckpointStore struct {
+	client *storage.Client
+	bucket string
+	object string
+}
+
+func (s *gcsCheckpointStore) Load(ctx context.Context) (*checkpoint, error) {
+	reader, err := s.client.Bucket(s.bucket).Object(s.object).NewReader(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return newCheckpoint(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint gs://%s/%s: %w", s.bucket, s.object, err)
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint gs://%s/%s: %w", s.bucket, s.object, err)
+	}
+	return unmarshalCheckpoint(data)
+}
+
+func (s *gcsCheckpointStore) Save(ctx context.Context, c *checkpoint) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	writer := s.client.Bucket(s.bucket).Object(s.object).NewWriter(ctx)
+	writer.ContentType = "application/json"
+	if _, err := writer.Write(data); err != nil {
+		_ = writer.Close()
+		return fmt.Errorf("failed to write checkpoint gs://%s/%s: %w", s.bucket, s.object, err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to write checkpoint gs://%s/%s: %w", s.bucket, s.object, err)
+	}
+	return nil
+}
+
+func unmarshalCheckpoint(data []byte) (*checkpoint, error) {
+	c := newCheckpoint()
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint: %w", err)
+	}
+	if c.Jobs == nil {
+		c.Jobs = map[string]*jobCheckpoint{}
+	}
+	return c, nil
+}
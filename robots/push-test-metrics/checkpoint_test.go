@@ -0,0 +1,87 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("checkpoint", func() {
+
+	It("returns an empty checkpoint if none has been stored", func() {
+		store, err := newCheckpointStore(nil, filepath.Join(GinkgoT().TempDir(), "checkpoint.json"))
+		Expect(err).ToNot(HaveOccurred())
+
+		c, err := store.Load(context.Background())
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.Jobs).To(BeEmpty())
+	})
+
+	It("loads the stored checkpoint", func() {
+		store, err := newCheckpointStore(nil, filepath.Join(GinkgoT().TempDir(), "checkpoint.json"))
+		Expect(err).ToNot(HaveOccurred())
+		c := newCheckpoint()
+		c.job("job").LastProcessedBuild = 42
+		c.job("job").Durations["test"] = []sample{{Build: 42, Timestamp: 1700000000, Seconds: 1.5}}
+
+		Expect(store.Save(context.Background(), c)).To(Succeed())
+		loaded, err := store.Load(context.Background())
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loaded).To(Equal(c))
+	})
+
+	DescribeTable("newCheckpointStore",
+		func(location string, expected checkpointStore, expectError bool) {
+			store, err := newCheckpointStore(nil, location)
+			if expectError {
+				Expect(err).To(HaveOccurred())
+				return
+			}
+			Expect(err).ToNot(HaveOccurred())
+			Expect(store).To(Equal(expected))
+		},
+		Entry("local file", "checkpoint.json", &fileCheckpointStore{path: "checkpoint.json"}, false),
+		Entry("gcs object", "gs://kubevirt-prow/reports/push-test-metrics/checkpoint.json", &gcsCheckpointStore{bucket: "kubevirt-prow", object: "reports/push-test-metrics/checkpoint.json"}, false),
+		Entry("gcs without object", "gs://kubevirt-prow", nil, true),
+	)
+
+	It("prunes old samples and tests without samples", func() {
+		now := time.Now()
+		c := newCheckpoint()
+		c.job("job").Durations["old test"] = []sample{{Timestamp: now.Add(-48 * time.Hour).Unix()}}
+		c.job("job").Durations["test"] = []sample{
+			{Build: 1, Timestamp: now.Add(-48 * time.Hour).Unix()},
+			{Build: 2, Timestamp: now.Add(-1 * time.Hour).Unix()},
+		}
+
+		c.prune(now.Add(-24 * time.Hour))
+
+		Expect(c.Jobs["job"].Durations).To(Equal(map[string][]sample{
+			"test": {{Build: 2, Timestamp: now.Add(-1 * time.Hour).Unix()}},
+		}))
+	})
+})
@@ -0,0 +1,78 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/prow/pkg/config"
+)
+
+// discoverJobNames returns the sorted names of all periodic and postsubmit jobs from the job config files found
+// at jobConfigPath (either a file or a directory that is searched recursively) that match the regex.
+// Only periodics and postsubmits are considered since their runs are stored under logs/<job-name>/ in the bucket.
+func discoverJobNames(jobConfigPath string, jobNameRegex *regexp.Regexp) ([]string, error) {
+	var jobConfigFiles []string
+	err := filepath.WalkDir(jobConfigPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !(strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml")) {
+			return nil
+		}
+		jobConfigFiles = append(jobConfigFiles, path)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find job config files in %q: %w", jobConfigPath, err)
+	}
+
+	jobNamesSet := map[string]struct{}{}
+	for _, jobConfigFile := range jobConfigFiles {
+		jobConfig, err := config.ReadJobConfig(jobConfigFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read job config %q: %w", jobConfigFile, err)
+		}
+		for _, periodic := range jobConfig.Periodics {
+			if jobNameRegex.MatchString(periodic.Name) {
+				jobNamesSet[periodic.Name] = struct{}{}
+			}
+		}
+		for _, postsubmits := range jobConfig.PostsubmitsStatic {
+			for _, postsubmit := range postsubmits {
+				if jobNameRegex.MatchString(postsubmit.Name) {
+					jobNamesSet[postsubmit.Name] = struct{}{}
+				}
+			}
+		}
+	}
+
+	var jobNames []string
+	for jobName := range jobNamesSet {
+		jobNames = append(jobNames, jobName)
+	}
+	sort.Strings(jobNames)
+	return jobNames, nil
+}
@@ -0,0 +1,51 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"regexp"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("discoverJobNames", func() {
+
+	DescribeTable("discovers periodics and postsubmits matching the regex",
+		func(jobNameRegex string, expected []string) {
+			Expect(discoverJobNames("testdata", regexp.MustCompile(jobNameRegex))).To(Equal(expected))
+		},
+		Entry("periodic e2e lanes", "^periodic-kubevirt-e2e-", []string{
+			"periodic-kubevirt-e2e-k8s-1.30-sig-compute",
+			"periodic-kubevirt-e2e-k8s-1.30-sig-network",
+		}),
+		Entry("all e2e lanes", "-kubevirt-e2e-", []string{
+			"periodic-kubevirt-e2e-k8s-1.30-sig-compute",
+			"periodic-kubevirt-e2e-k8s-1.30-sig-network",
+			"post-kubevirt-e2e-k8s-1.30-sig-storage",
+		}),
+		Entry("no match", "^nothing$", nil),
+	)
+
+	It("fails on a missing job config path", func() {
+		_, err := discoverJobNames("testdata/missing", regexp.MustCompile(".*"))
+		Expect(err).To(HaveOccurred())
+	})
+})
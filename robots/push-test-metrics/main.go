@@ -21,45 +21,106 @@ package main
 
 import (
 	"context"
-	"errors"
 	"flag"
 	"fmt"
-	"io"
-	"path"
-	"sort"
+	"os"
+	"regexp"
 	"strings"
+	"time"
 
 	"cloud.google.com/go/storage"
-	"github.com/joshdk/go-junit"
-	"github.com/prometheus/client_golang/prometheus"
-	log "github.com/sirupsen/logrus"
-	"google.golang.org/api/iterator"
-
 	"github.com/prometheus/client_golang/prometheus/push"
+	log "github.com/sirupsen/logrus"
 )
 
 type jobNames []string
 
-func (j jobNames) String() string {
-	return strings.Join(j, ",")
+func (j *jobNames) String() string {
+	return strings.Join(*j, ",")
 }
-func (j jobNames) Set(string) error {
+func (j *jobNames) Set(value string) error {
+	*j = append(*j, value)
 	return nil
 }
 
-func main() {
-	var pushgatewayURL string
-	var jobNamesToScan jobNames
-	flag.StringVar(&pushgatewayURL, "pushgateway-url", "http://localhost:8080/", "pushgateway url to push values to")
-	flag.Var(jobNamesToScan, "job-name", "periodic job names to scan for values")
+type options struct {
+	pushgatewayURL      string
+	jobNamesToScan      jobNames
+	jobConfigPath       string
+	jobNameRegex        string
+	bucket              string
+	junitFileName       string
+	checkpoint          string
+	maxBuildsOnFirstRun int
+	abandonAfter        time.Duration
+	reportPath          string
+	dryRun              bool
+	regression          regressionOptions
+}
+
+func flagOptions() options {
+	o := options{}
+	flag.StringVar(&o.pushgatewayURL, "pushgateway-url", "http://localhost:8080/", "pushgateway url to push values to")
+	flag.Var(&o.jobNamesToScan, "job-name", "job names to scan for values, may be given several times - if given, no jobs are discovered from the job config")
+	flag.StringVar(&o.jobConfigPath, "job-config-path", "github/ci/prow-deploy/files/jobs/kubevirt/kubevirt", "job config file or directory to discover the jobs from")
+	flag.StringVar(&o.jobNameRegex, "job-name-regex", "^periodic-kubevirt-e2e-", "regular expression the names of the discovered jobs must match")
+	flag.StringVar(&o.bucket, "bucket", "kubevirt-prow", "bucket where the job results are stored")
+	flag.StringVar(&o.junitFileName, "junit-file-name", "junit.functest.xml", "name of the junit file within the artifacts of a build")
+	flag.StringVar(&o.checkpoint, "checkpoint", "push-test-metrics-checkpoint.json", "where to store the last processed build per job and the recent test durations, either a local file or gs://bucket/object")
+	flag.IntVar(&o.maxBuildsOnFirstRun, "max-builds-on-first-run", 10, "maximum number of builds to process for a job that has not been processed before")
+	flag.DurationVar(&o.abandonAfter, "abandon-unfinished-builds-after", 24*time.Hour, "time after the start of a build that has not finished yet after which it is skipped instead of waited for")
+	flag.StringVar(&o.reportPath, "report-path", "", "path of the Markdown report about test duration regressions, no report is written if empty")
+	flag.BoolVar(&o.dryRun, "dry-run", false, "neither push the metrics nor store the checkpoint")
+	flag.DurationVar(&o.regression.window, "window", 7*24*time.Hour, "time span up to now for which the current p90 test runtime is calculated")
+	flag.DurationVar(&o.regression.baselineWindow, "baseline-window", 28*24*time.Hour, "time span preceding the window for which the baseline p90 test runtime is calculated")
+	flag.Float64Var(&o.regression.threshold, "regression-threshold", 1.5, "minimum ratio of current to baseline p90 test runtime to flag a test")
+	flag.DurationVar(&o.regression.minIncrease, "regression-min-increase", 30*time.Second, "minimum absolute increase of the p90 test runtime to flag a test")
+	flag.IntVar(&o.regression.minSamples, "regression-min-samples", 3, "minimum number of samples in each window to consider a test")
 	flag.Parse()
+	return o
+}
+
+func (o *options) validate() error {
+	if len(o.jobNamesToScan) == 0 {
+		if o.jobConfigPath == "" {
+			return fmt.Errorf("either job-name or job-config-path is required")
+		}
+		if _, err := regexp.Compile(o.jobNameRegex); err != nil {
+			return fmt.Errorf("job-name-regex is invalid: %w", err)
+		}
+	}
+	if o.checkpoint == "" {
+		return fmt.Errorf("checkpoint is required")
+	}
+	if o.maxBuildsOnFirstRun < 1 {
+		return fmt.Errorf("max-builds-on-first-run must be at least 1")
+	}
+	if o.abandonAfter <= 0 {
+		return fmt.Errorf("abandon-unfinished-builds-after must be positive")
+	}
+	if o.regression.window <= 0 || o.regression.baselineWindow <= 0 {
+		return fmt.Errorf("window and baseline-window must be positive")
+	}
+	if o.regression.minSamples < 1 {
+		return fmt.Errorf("regression-min-samples must be at least 1")
+	}
+	return nil
+}
+
+func main() {
+	opts := flagOptions()
+	if err := opts.validate(); err != nil {
+		log.Fatalf("validating command line options failed: %v", err)
+	}
+
+	jobNamesToScan := opts.jobNamesToScan
 	if len(jobNamesToScan) == 0 {
-		jobNamesToScan = jobNames{
-			"periodic-kubevirt-e2e-k8s-1.29-sig-compute",
-			"periodic-kubevirt-e2e-k8s-1.29-sig-storage",
-			"periodic-kubevirt-e2e-k8s-1.29-sig-network",
-			"periodic-kubevirt-e2e-k8s-1.29-sig-operator",
+		var err error
+		jobNamesToScan, err = discoverJobNames(opts.jobConfigPath, regexp.MustCompile(opts.jobNameRegex))
+		if err != nil {
+			log.Fatalf("failed to discover jobs: %v", err)
 		}
+		log.Infof("discovered %d jobs matching %q: %s", len(jobNamesToScan), opts.jobNameRegex, jobNamesToScan.String())
 	}
 
 	ctx := context.Background()
@@ -67,98 +128,67 @@ func main() {
 	if err != nil {
 		log.Fatalf("error creating gcs client: %v", err)
 	}
-	bucket := client.Bucket("kubevirt-prow")
-	pusher := push.New(pushgatewayURL, "ci_test_results")
+	store, err := newCheckpointStore(client, opts.checkpoint)
+	if err != nil {
+		log.Fatalf("error creating checkpoint store: %v", err)
+	}
+	c, err := store.Load(ctx)
+	if err != nil {
+		log.Fatalf("error loading checkpoint: %v", err)
+	}
+
+	source := newGCSBuildSource(ctx, client, opts.bucket, opts.junitFileName)
+	var failedJobs []string
 	for _, jobName := range jobNamesToScan {
-		jobRunIterator := bucket.Objects(ctx, &storage.Query{
-			Delimiter: "/",
-			Prefix:    fmt.Sprintf("logs/%s/", jobName),
-		})
-		var jobRuns []string
-		for {
-			attrs, err := jobRunIterator.Next()
-			if errors.Is(err, iterator.Done) {
-				break
-			}
-			if err != nil {
-				log.Fatalf("err: %v", err)
-
-			}
-			if attrs.Prefix != "" {
-				jobRuns = append(jobRuns, path.Base(attrs.Prefix))
-			}
+		// the builds processed before the error are kept in the checkpoint, thus their metrics need to be pushed
+		metrics, err := backfill(source, c, jobName, opts.maxBuildsOnFirstRun, opts.abandonAfter)
+		if err != nil {
+			log.WithError(err).Errorf("failed to process builds of %s", jobName)
+			failedJobs = append(failedJobs, jobName)
 		}
-		sort.Sort(sort.Reverse(sort.StringSlice(jobRuns)))
-		var junitBytes []byte
-		for jobRunIndex := 0; jobRunIndex < len(jobRuns); jobRunIndex++ {
-			junitGCSPath := fmt.Sprintf("logs/%s/%s/artifacts/junit.functest.xml", jobName, jobRuns[jobRunIndex])
-			latestJunitXML := client.Bucket("kubevirt-prow").Object(junitGCSPath)
-			reader, err := latestJunitXML.NewReader(ctx)
-			if errors.Is(err, storage.ErrObjectNotExist) {
-				continue
-			}
-			if err != nil {
-				log.Fatalf("failed to fetch %s: %v", junitGCSPath, err)
-			}
-			junitBytes, err = io.ReadAll(reader)
-			if err != nil {
-				log.Fatalf("failed to fetch %s: %v", junitGCSPath, err)
-			}
-			log.Infof("jobName: %s, read %s", jobName, junitGCSPath)
-			break
+		if metrics == nil || opts.dryRun {
+			continue
 		}
-		suites, err := junit.Ingest(junitBytes)
-		if err != nil {
-			log.Fatalf("failed to ingest JUnit xml: %v", err)
+		// pushing per job keeps the metrics of the jobs without new builds
+		pusher := push.New(opts.pushgatewayURL, "ci_test_results").Grouping("lane", jobName)
+		for _, collector := range metrics.collectors() {
+			pusher.Collector(collector)
 		}
-		buckets := []float64{
-			1.0,
-			10.0,
-			60.0,
-			120.0,
-			300.0,
-			600.0,
+		if err := pusher.Push(); err != nil {
+			log.Fatalf("push to %s failed: %v", opts.pushgatewayURL, err)
 		}
-		ciTestsExecutionSummary := prometheus.NewHistogram(
-			prometheus.HistogramOpts{
-				Namespace: "ci",
-				Subsystem: "test",
-				Name:      "runtime_seconds_total",
-				Help:      "time in seconds all tests took on ci",
-				ConstLabels: prometheus.Labels{
-					"job_name": jobName,
-				},
-				Buckets: buckets,
-			},
-		)
-		for _, suite := range suites {
-			for _, test := range suite.Tests {
-				if test.Status == junit.StatusSkipped {
-					continue
-				}
-				ciTestsExecutionSummary.Observe(test.Duration.Seconds())
-				labels := prometheus.Labels{
-					"job_name":    jobName,
-					"test_status": string(test.Status),
-					"test_name":   test.Name,
-				}
-				ciTestExecutionSummary := prometheus.NewSummary(
-					prometheus.SummaryOpts{
-						Namespace:   "ci",
-						Subsystem:   "test",
-						Name:        "runtime_seconds",
-						Help:        "time in seconds the test took on ci",
-						ConstLabels: labels,
-					})
-				ciTestExecutionSummary.Observe(test.Duration.Seconds())
-				pusher.Collector(ciTestExecutionSummary)
-				log.Debugf("jobName: %s, test: %s, duration: %s", jobName, test.Name, test.Duration)
-			}
+	}
+
+	now := time.Now()
+	c.prune(now.Add(-opts.regression.window - opts.regression.baselineWindow))
+	regressions := detectRegressions(c, now, opts.regression)
+	log.Infof("found %d test duration regressions", len(regressions))
+
+	if !opts.dryRun {
+		pusher := push.New(opts.pushgatewayURL, "ci_test_duration_regressions")
+		for _, collector := range regressionCollectors(regressions) {
+			pusher.Collector(collector)
+		}
+		if err := pusher.Push(); err != nil {
+			log.Fatalf("push to %s failed: %v", opts.pushgatewayURL, err)
+		}
+		if err := store.Save(ctx, c); err != nil {
+			log.Fatalf("error saving checkpoint: %v", err)
 		}
-		pusher.Collector(ciTestsExecutionSummary)
 	}
-	err = pusher.Push()
-	if err != nil {
-		log.Fatalf("push to %s failed: %v", pushgatewayURL, err)
+
+	if opts.reportPath != "" {
+		report, err := os.Create(opts.reportPath)
+		if err != nil {
+			log.Fatalf("failed to create report %q: %v", opts.reportPath, err)
+		}
+		defer report.Close()
+		if err := writeMarkdownReport(report, regressions, opts.regression, now); err != nil {
+			log.Fatalf("failed to write report %q: %v", opts.reportPath, err)
+		}
+	}
+
+	if len(failedJobs) > 0 {
+		log.Fatalf("failed to process builds of %d jobs: %s", len(failedJobs), strings.Join(failedJobs, ", "))
 	}
 }
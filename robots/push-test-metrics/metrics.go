@@ -0,0 +1,121 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"github.com/joshdk/go-junit"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var runtimeBuckets = []float64{
+	1.0,
+	10.0,
+	60.0,
+	120.0,
+	300.0,
+	600.0,
+}
+
+type testSummaryKey struct {
+	testName   string
+	testStatus junit.Status
+}
+
+// jobMetrics collects the test runtime metrics of the processed builds of a job.
+type jobMetrics struct {
+	jobName   string
+	histogram prometheus.Histogram
+	summaries map[testSummaryKey]prometheus.Summary
+}
+
+func newJobMetrics(jobName string) *jobMetrics {
+	return &jobMetrics{
+		jobName: jobName,
+		histogram: prometheus.NewHistogram(
+			prometheus.HistogramOpts{
+				Namespace: "ci",
+				Subsystem: "test",
+				Name:      "runtime_seconds_total",
+				Help:      "time in seconds all tests took on ci",
+				ConstLabels: prometheus.Labels{
+					"job_name": jobName,
+				},
+				Buckets: runtimeBuckets,
+			},
+		),
+		summaries: map[testSummaryKey]prometheus.Summary{},
+	}
+}
+
+func (m *jobMetrics) observe(test junit.Test) {
+	m.histogram.Observe(test.Duration.Seconds())
+	key := testSummaryKey{testName: test.Name, testStatus: test.Status}
+	if _, exists := m.summaries[key]; !exists {
+		m.summaries[key] = prometheus.NewSummary(
+			prometheus.SummaryOpts{
+				Namespace: "ci",
+				Subsystem: "test",
+				Name:      "runtime_seconds",
+				Help:      "time in seconds the test took on ci",
+				ConstLabels: prometheus.Labels{
+					"job_name":    m.jobName,
+					"test_status": string(test.Status),
+					"test_name":   test.Name,
+				},
+			})
+	}
+	m.summaries[key].Observe(test.Duration.Seconds())
+}
+
+func (m *jobMetrics) collectors() []prometheus.Collector {
+	collectors := []prometheus.Collector{m.histogram}
+	for _, summary := range m.summaries {
+		collectors = append(collectors, summary)
+	}
+	return collectors
+}
+
+// regressionCollectors returns the gauges describing the detected duration regressions.
+func regressionCollectors(regressions []regression) []prometheus.Collector {
+	ratio := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "ci",
+			Subsystem: "test",
+			Name:      "runtime_p90_regression_ratio",
+			Help:      "ratio of the p90 test runtime in the current window to the p90 test runtime in the baseline window",
+		},
+		[]string{"job_name", "test_name"},
+	)
+	p90Seconds := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "ci",
+			Subsystem: "test",
+			Name:      "runtime_p90_seconds",
+			Help:      "p90 test runtime in seconds per window for tests whose runtime regressed",
+		},
+		[]string{"job_name", "test_name", "window"},
+	)
+	for _, r := range regressions {
+		ratio.WithLabelValues(r.JobName, r.TestName).Set(r.Ratio())
+		p90Seconds.WithLabelValues(r.JobName, r.TestName, "baseline").Set(r.BaselineP90)
+		p90Seconds.WithLabelValues(r.JobName, r.TestName, "current").Set(r.CurrentP90)
+	}
+	return []prometheus.Collector{ratio, p90Seconds}
+}
@@ -0,0 +1,155 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+type regressionOptions struct {
+	// window is the time span up to now for which the current p90 runtime is calculated
+	window time.Duration
+
+	// baselineWindow is the time span preceding the window for which the baseline p90 runtime is calculated
+	baselineWindow time.Duration
+
+	// threshold is the minimum ratio of current to baseline p90 runtime for a test to be flagged
+	threshold float64
+
+	// minIncrease is the minimum absolute increase of the p90 runtime for a test to be flagged
+	minIncrease time.Duration
+
+	// minSamples is the minimum number of samples required in each window
+	minSamples int
+}
+
+// regression describes a test whose p90 runtime in the current window has grown compared to the baseline window.
+type regression struct {
+	JobName         string
+	TestName        string
+	BaselineP90     float64
+	CurrentP90      float64
+	BaselineSamples int
+	CurrentSamples  int
+}
+
+func (r regression) Ratio() float64 {
+	return r.CurrentP90 / r.BaselineP90
+}
+
+// detectRegressions compares the p90 runtimes per test and job from the checkpoint in the current window with
+// those in the baseline window. Regressions are sorted by ratio, highest first.
+func detectRegressions(c *checkpoint, now time.Time, o regressionOptions) []regression {
+	startOfWindow := now.Add(-o.window)
+	startOfBaseline := startOfWindow.Add(-o.baselineWindow)
+
+	var regressions []regression
+	for jobName, job := range c.Jobs {
+		for testName, samples := range job.Durations {
+			var baseline, current []float64
+			for _, s := range samples {
+				timestamp := time.Unix(s.Timestamp, 0)
+				switch {
+				case timestamp.After(startOfWindow):
+					current = append(current, s.Seconds)
+				case timestamp.After(startOfBaseline):
+					baseline = append(baseline, s.Seconds)
+				}
+			}
+			if len(baseline) < o.minSamples || len(current) < o.minSamples {
+				continue
+			}
+			r := regression{
+				JobName:         jobName,
+				TestName:        testName,
+				BaselineP90:     p90(baseline),
+				CurrentP90:      p90(current),
+				BaselineSamples: len(baseline),
+				CurrentSamples:  len(current),
+			}
+			if r.BaselineP90 <= 0 {
+				continue
+			}
+			if r.Ratio() < o.threshold || r.CurrentP90-r.BaselineP90 < o.minIncrease.Seconds() {
+				continue
+			}
+			regressions = append(regressions, r)
+		}
+	}
+	sort.Slice(regressions, func(i, j int) bool {
+		if regressions[i].Ratio() != regressions[j].Ratio() {
+			return regressions[i].Ratio() > regressions[j].Ratio()
+		}
+		if regressions[i].JobName != regressions[j].JobName {
+			return regressions[i].JobName < regressions[j].JobName
+		}
+		return regressions[i].TestName < regressions[j].TestName
+	})
+	return regressions
+}
+
+// p90 returns the 90th percentile of the values using the nearest-rank method.
+func p90(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(0.9 * float64(len(sorted))))
+	return sorted[rank-1]
+}
+
+// writeMarkdownReport writes the regressions as a Markdown document.
+func writeMarkdownReport(w io.Writer, regressions []regression, o regressionOptions, now time.Time) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Test duration regressions\n\n")
+	fmt.Fprintf(&b, "Created %s. Compares the p90 test runtime of the last %s with the %s before, ", now.UTC().Format(time.RFC3339), o.window, o.baselineWindow)
+	fmt.Fprintf(&b, "flagging tests whose p90 grew by a factor of at least %.2f and at least %s.\n\n", o.threshold, o.minIncrease)
+	if len(regressions) == 0 {
+		fmt.Fprintf(&b, "No regressions found.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	fmt.Fprintf(&b, "| Job | Test | Baseline p90 | Current p90 | Ratio | Samples (baseline/current) |\n")
+	fmt.Fprintf(&b, "|-----|------|-------------:|------------:|------:|---------------------------:|\n")
+	for _, r := range regressions {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %.2f | %d/%d |\n",
+			r.JobName,
+			strings.ReplaceAll(r.TestName, "|", "\\|"),
+			formatSeconds(r.BaselineP90),
+			formatSeconds(r.CurrentP90),
+			r.Ratio(),
+			r.BaselineSamples,
+			r.CurrentSamples,
+		)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func formatSeconds(seconds float64) string {
+	return (time.Duration(seconds * float64(time.Second))).Round(100 * time.Millisecond).String()
+}
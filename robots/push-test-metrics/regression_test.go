@@ -0,0 +1,129 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package main
+
+import (
+	"bytes"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("regression", func() {
+
+	DescribeTable("p90",
+		func(values []float64, expected float64) {
+			Expect(p90(values)).To(Equal(expected))
+		},
+		Entry("no values", nil, 0.0),
+		Entry("one value", []float64{3}, 3.0),
+		Entry("ten values", []float64{10, 1, 9, 2, 8, 3, 7, 4, 6, 5}, 9.0),
+		Entry("eleven values", []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}, 10.0),
+	)
+
+	Context("detectRegressions", func() {
+
+		const jobName = "periodic-kubevirt-e2e-k8s-1.30-sig-compute"
+
+		var now time.Time
+		var opts regressionOptions
+
+		samplesAt := func(age time.Duration, seconds ...float64) []sample {
+			var samples []sample
+			for _, s := range seconds {
+				samples = append(samples, sample{Timestamp: now.Add(-age).Unix(), Seconds: s})
+			}
+			return samples
+		}
+
+		BeforeEach(func() {
+			now = time.Now()
+			opts = regressionOptions{
+				window:         7 * 24 * time.Hour,
+				baselineWindow: 28 * 24 * time.Hour,
+				threshold:      1.5,
+				minIncrease:    30 * time.Second,
+				minSamples:     3,
+			}
+		})
+
+		It("flags a test whose p90 grew significantly", func() {
+			c := newCheckpoint()
+			c.job(jobName).Durations["slow test"] = append(samplesAt(14*24*time.Hour, 100, 110, 120), samplesAt(24*time.Hour, 200, 210, 220)...)
+
+			Expect(detectRegressions(c, now, opts)).To(Equal([]regression{
+				{
+					JobName:         jobName,
+					TestName:        "slow test",
+					BaselineP90:     120,
+					CurrentP90:      220,
+					BaselineSamples: 3,
+					CurrentSamples:  3,
+				},
+			}))
+		})
+
+		It("does not flag a test whose p90 grew below the threshold", func() {
+			c := newCheckpoint()
+			c.job(jobName).Durations["test"] = append(samplesAt(14*24*time.Hour, 100, 110, 120), samplesAt(24*time.Hour, 120, 130, 140)...)
+
+			Expect(detectRegressions(c, now, opts)).To(BeEmpty())
+		})
+
+		It("does not flag a short test whose absolute increase is small", func() {
+			c := newCheckpoint()
+			c.job(jobName).Durations["test"] = append(samplesAt(14*24*time.Hour, 1, 1, 1), samplesAt(24*time.Hour, 10, 10, 10)...)
+
+			Expect(detectRegressions(c, now, opts)).To(BeEmpty())
+		})
+
+		It("does not flag a test without enough samples", func() {
+			c := newCheckpoint()
+			c.job(jobName).Durations["test"] = append(samplesAt(14*24*time.Hour, 100, 110), samplesAt(24*time.Hour, 200, 210, 220)...)
+
+			Expect(detectRegressions(c, now, opts)).To(BeEmpty())
+		})
+
+		It("ignores samples older than the baseline window", func() {
+			c := newCheckpoint()
+			c.job(jobName).Durations["test"] = append(samplesAt(60*24*time.Hour, 100, 110, 120), samplesAt(24*time.Hour, 200, 210, 220)...)
+
+			Expect(detectRegressions(c, now, opts)).To(BeEmpty())
+		})
+	})
+
+	It("writes a Markdown report", func() {
+		var buffer bytes.Buffer
+		err := writeMarkdownReport(&buffer, []regression{
+			{
+				JobName:         "periodic-kubevirt-e2e-k8s-1.30-sig-compute",
+				TestName:        "test with | in name",
+				BaselineP90:     120,
+				CurrentP90:      240,
+				BaselineSamples: 20,
+				CurrentSamples:  7,
+			},
+		}, regressionOptions{window: time.Hour, baselineWindow: 2 * time.Hour, threshold: 1.5, minIncrease: 30 * time.Second}, time.Now())
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(buffer.String()).To(ContainSubstring("| periodic-kubevirt-e2e-k8s-1.30-sig-compute | test with \\| in name | 2m0s | 4m0s | 2.00 | 20/7 |"))
+	})
+})
@@ -46,7 +46,7 @@ const (
 	longAutoTest  = shortAutoTest + `.
 
 Uses the data from the flake stats, combines that with
-scraped results from search.ci and then modifies all tests matching quarantine
+results from search.ci and then modifies all tests matching quarantine
 criteria so that they are recognized by automation as being quarantined.`
 )
 
@@ -62,6 +62,8 @@ var (
 	autoQuarantinePRDescriptionTemplate string
 
 	reportTemplate *template.Template
+
+	sigOrWGMatcher = ginkgo.NewRegexLabelMatcher(`^(sig|wg)-[a-z][-a-z0-9]*$`)
 )
 
 func init() {
@@ -254,22 +256,27 @@ func quarantineTests(testsToQuarantine []*TestToQuarantine) error {
 }
 
 func resolveTestProwCommands(testsToQuarantine []*TestToQuarantine, validSIGs, validWGs map[string]bool) {
-	sigOrWGMatcher := ginkgo.NewRegexLabelMatcher(`^(sig|wg)-[a-z][-a-z0-9]*$`)
 	for _, testToQuarantine := range testsToQuarantine {
-		labels := ginkgo.ExtractLabels(*testToQuarantine.SpecReport, sigOrWGMatcher)
-		if len(labels) == 0 {
-			testToQuarantine.ProwCommands = []string{defaultProwCommand}
-			continue
-		}
-		seen := map[string]bool{}
-		for _, label := range labels {
-			cmd := resolveProwCommand(label, validSIGs, validWGs)
-			if !seen[cmd] {
-				seen[cmd] = true
-				testToQuarantine.ProwCommands = append(testToQuarantine.ProwCommands, cmd)
-			}
+		testToQuarantine.ProwCommands = resolveProwCommands(*testToQuarantine.SpecReport, validSIGs, validWGs)
+	}
+}
+
+// resolveProwCommands returns the deduplicated Prow commands for the SIG and WG labels of the spec
+func resolveProwCommands(specReport types.SpecReport, validSIGs, validWGs map[string]bool) []string {
+	labels := ginkgo.ExtractLabels(specReport, sigOrWGMatcher)
+	if len(labels) == 0 {
+		return []string{defaultProwCommand}
+	}
+	var prowCommands []string
+	seen := map[string]bool{}
+	for _, label := range labels {
+		cmd := resolveProwCommand(label, validSIGs, validWGs)
+		if !seen[cmd] {
+			seen[cmd] = true
+			prowCommands = append(prowCommands, cmd)
 		}
 	}
+	return prowCommands
 }
 
 func writePRDescriptionToFile(outputFileName string, testsToQuarantine []*TestToQuarantine) error {
@@ -0,0 +1,286 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package cmd
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/joshdk/go-junit"
+	"github.com/onsi/ginkgo/v2/types"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"kubevirt.io/project-infra/pkg/flakefinder"
+	"kubevirt.io/project-infra/pkg/ginkgo"
+	"kubevirt.io/project-infra/pkg/options"
+)
+
+const (
+	defaultCheckLaneRegexString = `^periodic-kubevirt-e2e-k8s-.*`
+
+	shortDequarantineTest = "Dequarantines quarantined tests that have proven to be stable"
+	longDequarantineTest  = shortDequarantineTest + `.
+
+Determines all quarantined tests from the test source using the ginkgo
+dry-run mechanism. Then fetches the results of the check lanes (the periodic
+lanes that also run quarantined tests) from the Prow GCS bucket for the given
+time window.
+
+Every quarantined test that has not failed in any of the check lanes and has
+passed at least the minimum number of times is modified so that it is no longer
+recognized by automation as being quarantined, i.e. the quarantine prefix is
+removed from the node text and the quarantine decorator is removed from the
+node.
+
+Finally a PR description is written that contains the evidence for each test.`
+)
+
+var (
+	dequarantineCmd = &cobra.Command{
+		Use:   "dequarantine",
+		Short: shortDequarantineTest,
+		Long:  longDequarantineTest,
+		RunE:  AutoDequarantine,
+	}
+
+	//go:embed dequarantine-pr-description.gomd
+	dequarantinePRDescriptionTemplate string
+
+	dequarantineReportTemplate *template.Template
+)
+
+func init() {
+	dequarantineCmd.PersistentFlags().IntVar(&quarantineOpts.daysInThePast, "days-in-the-past", 14, "the number of days in the past to look at check lane results")
+	dequarantineCmd.PersistentFlags().StringVar(&quarantineOpts.checkLaneRegexString, "check-lane-regex", defaultCheckLaneRegexString, "the regular expression that the lanes running the quarantined tests need to match")
+	dequarantineCmd.PersistentFlags().IntVar(&quarantineOpts.minPassedRuns, "min-passed-runs", 10, "the minimum number of passed runs required for a test to be dequarantined")
+	dequarantineCmd.PersistentFlags().IntVar(&quarantineOpts.maxTestsToDequarantine, "max-tests-to-dequarantine", 5, "the overall number of tests that are going to be dequarantined in one run, or zero for no limit")
+	dequarantineCmd.PersistentFlags().StringVar(&quarantineOpts.labelsYAMLPath, "labels-yaml", "github/ci/prow-deploy/kustom/base/configs/current/labels/labels.yaml", "path to the Prow labels.yaml file used to determine valid SIG/WG labels")
+	quarantineOpts.dequarantinePRDescriptionOutputFileOpts = options.NewOutputFileOptions(
+		"pr-description-*.md",
+		options.WithOverwrite(),
+	)
+	dequarantineCmd.PersistentFlags().StringVar(&quarantineOpts.dequarantinePRDescriptionOutputFileOpts.OutputFile, "pr-description-output-file", "", "the path to the output file to write the PR description into, or if unset a temp file will be generated")
+
+	var err error
+	dequarantineReportTemplate, err = template.New("dequarantinePRDescription").Parse(dequarantinePRDescriptionTemplate)
+	if err != nil {
+		log.Fatalf("could not read template: %v", err)
+	}
+}
+
+func AutoDequarantine(_ *cobra.Command, _ []string) error {
+	err := quarantineOpts.dequarantinePRDescriptionOutputFileOpts.Validate()
+	if err != nil {
+		return err
+	}
+	checkLaneRegex, err := regexp.Compile(quarantineOpts.checkLaneRegexString)
+	if err != nil {
+		return fmt.Errorf("could not compile check lane regex %q: %w", quarantineOpts.checkLaneRegexString, err)
+	}
+
+	validSIGs, validWGs, err := loadValidGroupsFromLabelsFile(quarantineOpts.labelsYAMLPath)
+	if err != nil {
+		return fmt.Errorf("could not load valid SIG/WG labels: %w", err)
+	}
+
+	reports, _, err := ginkgo.DryRun(quarantineOpts.testSourcePath)
+	if err != nil {
+		return fmt.Errorf("could not fetch ginkgo test reports: %w", err)
+	}
+	if reports == nil {
+		return fmt.Errorf("could not find ginkgo test reports: %w", err)
+	}
+	quarantinedSpecReports := ginkgo.FilterSpecReports(reports, ginkgo.IsQuarantined, -1)
+	if len(quarantinedSpecReports) == 0 {
+		log.Infof("no quarantined tests found")
+		return nil
+	}
+	log.Infof("found %d quarantined tests", len(quarantinedSpecReports))
+
+	endOfReport := time.Now()
+	startOfReport := endOfReport.Add(-time.Duration(quarantineOpts.daysInThePast) * 24 * time.Hour)
+	jobResults, err := fetchCheckLaneJobResults(checkLaneRegex, startOfReport, endOfReport)
+	if err != nil {
+		return fmt.Errorf("could not fetch check lane results: %w", err)
+	}
+	log.Infof("fetched %d check lane results", len(jobResults))
+
+	testsToDequarantine := determineTestsForDequarantine(quarantinedSpecReports, jobResults)
+	if len(testsToDequarantine) == 0 {
+		log.Infof("no tests to dequarantine")
+		return nil
+	}
+
+	err = dequarantineTests(testsToDequarantine)
+	if err != nil {
+		return err
+	}
+
+	for _, testToDequarantine := range testsToDequarantine {
+		testToDequarantine.ProwCommands = resolveProwCommands(*testToDequarantine.SpecReport, validSIGs, validWGs)
+	}
+
+	return writeDequarantinePRDescriptionToFile(quarantineOpts.dequarantinePRDescriptionOutputFileOpts.OutputFile, testsToDequarantine)
+}
+
+var fetchCheckLaneJobResults = fetchCheckLaneJobResultsFromGCS
+
+func fetchCheckLaneJobResultsFromGCS(checkLaneRegex *regexp.Regexp, startOfReport, endOfReport time.Time) ([]*flakefinder.JobResult, error) {
+	ctx := context.Background()
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not create storage client: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	const jobDir = "logs"
+	jobDirs, err := flakefinder.ListGcsObjects(ctx, client, flakefinder.BucketName, jobDir+"/", "/")
+	if err != nil {
+		return nil, fmt.Errorf("could not list job directories: %w", err)
+	}
+	var jobResults []*flakefinder.JobResult
+	for _, job := range jobDirs {
+		if !checkLaneRegex.MatchString(job) {
+			continue
+		}
+		results, err := flakefinder.FindUnitTestFilesForPeriodicJob(ctx, client, flakefinder.BucketName, []string{jobDir, job}, startOfReport, endOfReport)
+		if err != nil {
+			return nil, fmt.Errorf("could not fetch results for job %q: %w", job, err)
+		}
+		jobResults = append(jobResults, results...)
+	}
+	return jobResults, nil
+}
+
+// determineTestsForDequarantine returns the quarantined tests that did not
+// fail in any of the job results and passed at least the minimum number of
+// times.
+func determineTestsForDequarantine(quarantinedSpecReports []types.SpecReport, jobResults []*flakefinder.JobResult) []*TestToDequarantine {
+	laneResultsPerSpec := make([]map[string]*LaneResult, len(quarantinedSpecReports))
+	for i := range quarantinedSpecReports {
+		laneResultsPerSpec[i] = map[string]*LaneResult{}
+	}
+
+	for _, jobResult := range jobResults {
+		for _, suite := range jobResult.JUnit {
+			for _, test := range suite.Tests {
+				if test.Status == junit.StatusSkipped {
+					continue
+				}
+				matchesTestName := ginkgo.ByName(test.Name)
+				for i := range quarantinedSpecReports {
+					if !matchesTestName(quarantinedSpecReports[i]) {
+						continue
+					}
+					laneResult, exists := laneResultsPerSpec[i][jobResult.Job]
+					if !exists {
+						laneResult = &LaneResult{Job: jobResult.Job}
+						laneResultsPerSpec[i][jobResult.Job] = laneResult
+					}
+					switch test.Status {
+					case junit.StatusPassed:
+						laneResult.Passed++
+					default:
+						laneResult.Failed++
+						laneResult.FailedBuilds = append(laneResult.FailedBuilds, jobResult.BuildNumber)
+					}
+				}
+			}
+		}
+	}
+
+	var testsToDequarantine []*TestToDequarantine
+	for i := range quarantinedSpecReports {
+		specReport := quarantinedSpecReports[i]
+		testToDequarantine := &TestToDequarantine{SpecReport: &specReport}
+		for _, laneResult := range laneResultsPerSpec[i] {
+			testToDequarantine.LaneResults = append(testToDequarantine.LaneResults, laneResult)
+		}
+		sort.Slice(testToDequarantine.LaneResults, func(i, j int) bool {
+			return testToDequarantine.LaneResults[i].Job < testToDequarantine.LaneResults[j].Job
+		})
+
+		testLogger := log.WithField("test", specReport.FullText())
+		if !strings.Contains(specReport.LeafNodeText, "[QUARANTINE]") {
+			testLogger.Warnf("test stays in quarantine, quarantine is not set on the leaf node")
+			continue
+		}
+		if failed := testToDequarantine.Failed(); failed > 0 {
+			testLogger.Infof("test stays in quarantine, %d failures seen", failed)
+			continue
+		}
+		if passed := testToDequarantine.Passed(); passed < quarantineOpts.minPassedRuns {
+			testLogger.Infof("test stays in quarantine, expected %d passes, only %d seen", quarantineOpts.minPassedRuns, passed)
+			continue
+		}
+		testLogger.Infof("test is stable, %d passes seen", testToDequarantine.Passed())
+		testsToDequarantine = append(testsToDequarantine, testToDequarantine)
+
+		if quarantineOpts.maxTestsToDequarantine > 0 &&
+			len(testsToDequarantine) >= quarantineOpts.maxTestsToDequarantine {
+			log.Infof("ceiling (%d) of tests to dequarantine reached", quarantineOpts.maxTestsToDequarantine)
+			break
+		}
+	}
+	return testsToDequarantine
+}
+
+func dequarantineTests(testsToDequarantine []*TestToDequarantine) error {
+	var testsDequarantined []string
+	for _, testToDequarantine := range testsToDequarantine {
+		err := ginkgo.DequarantineTest(testToDequarantine.SpecReport)
+		if err != nil {
+			return fmt.Errorf("could not dequarantine test %q: %w", testToDequarantine.SpecReport.FullText(), err)
+		}
+		testsDequarantined = append(testsDequarantined, testToDequarantine.SpecReport.FullText())
+	}
+	log.Infof("Tests dequarantined:\n%s", strings.Join(testsDequarantined, "\n"))
+	return nil
+}
+
+func writeDequarantinePRDescriptionToFile(outputFileName string, testsToDequarantine []*TestToDequarantine) error {
+	if outputFileName == "" {
+		return fmt.Errorf("output file name must not be empty")
+	}
+	outputFile, err := os.Create(outputFileName)
+	if err != nil {
+		return fmt.Errorf("could not create file: %w", err)
+	}
+	defer func() {
+		err2 := outputFile.Close()
+		if err2 != nil {
+			log.Errorf("failed to write output file: %v", err2)
+		}
+	}()
+	err = dequarantineReportTemplate.Execute(outputFile, testsToDequarantine)
+	if err != nil {
+		return fmt.Errorf("could not execute template: %w", err)
+	}
+	log.Infof("report written to %q", outputFileName)
+	return nil
+}
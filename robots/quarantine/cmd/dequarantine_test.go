@@ -0,0 +1,172 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/joshdk/go-junit"
+	. "github.com/onsi/ginkgo/v2"
+	"github.com/onsi/ginkgo/v2/types"
+	. "github.com/onsi/gomega"
+	"kubevirt.io/project-infra/pkg/flakefinder"
+)
+
+var _ = Describe("dequarantine", func() {
+
+	const (
+		stableTestName   = "[sig-compute]VM [QUARANTINE]should be stable"
+		unstableTestName = "[sig-compute]VM [QUARANTINE]should be unstable"
+	)
+
+	var (
+		quarantinedSpecReports = []types.SpecReport{
+			{
+				ContainerHierarchyTexts: []string{"VM"},
+				LeafNodeText:            "[QUARANTINE]should be stable",
+				LeafNodeLabels:          []string{"Quarantine"},
+			},
+			{
+				ContainerHierarchyTexts: []string{"VM"},
+				LeafNodeText:            "[QUARANTINE]should be unstable",
+				LeafNodeLabels:          []string{"Quarantine"},
+			},
+		}
+		originalDequarantineOptions dequarantineOptions
+	)
+
+	newJobResult := func(job string, buildNumber int, tests ...junit.Test) *flakefinder.JobResult {
+		return &flakefinder.JobResult{
+			Job:         job,
+			BuildNumber: buildNumber,
+			JUnit:       []junit.Suite{{Tests: tests}},
+		}
+	}
+
+	BeforeEach(func() {
+		originalDequarantineOptions = quarantineOpts.dequarantineOptions
+		quarantineOpts.minPassedRuns = 2
+		quarantineOpts.maxTestsToDequarantine = 0
+	})
+
+	AfterEach(func() {
+		quarantineOpts.dequarantineOptions = originalDequarantineOptions
+	})
+
+	When("determining tests for dequarantine", func() {
+
+		It("selects tests without failures that passed often enough", func() {
+			jobResults := []*flakefinder.JobResult{
+				newJobResult("periodic-kubevirt-e2e-k8s-1.35-sig-compute", 1,
+					junit.Test{Name: stableTestName, Status: junit.StatusPassed},
+					junit.Test{Name: unstableTestName, Status: junit.StatusPassed},
+				),
+				newJobResult("periodic-kubevirt-e2e-k8s-1.34-sig-compute", 2,
+					junit.Test{Name: stableTestName, Status: junit.StatusPassed},
+					junit.Test{Name: unstableTestName, Status: junit.StatusFailed},
+				),
+			}
+
+			testsToDequarantine := determineTestsForDequarantine(quarantinedSpecReports, jobResults)
+
+			Expect(testsToDequarantine).To(HaveLen(1))
+			Expect(testsToDequarantine[0].SpecReport.LeafNodeText).To(Equal("[QUARANTINE]should be stable"))
+			Expect(testsToDequarantine[0].Passed()).To(Equal(2))
+			Expect(testsToDequarantine[0].LaneResults).To(HaveLen(2))
+			Expect(testsToDequarantine[0].LaneResults[0].Job).To(Equal("periodic-kubevirt-e2e-k8s-1.34-sig-compute"))
+		})
+
+		It("keeps tests that did not pass often enough", func() {
+			jobResults := []*flakefinder.JobResult{
+				newJobResult("periodic-kubevirt-e2e-k8s-1.35-sig-compute", 1,
+					junit.Test{Name: stableTestName, Status: junit.StatusPassed},
+				),
+				newJobResult("periodic-kubevirt-e2e-k8s-1.35-sig-compute", 2,
+					junit.Test{Name: stableTestName, Status: junit.StatusSkipped},
+				),
+			}
+
+			Expect(determineTestsForDequarantine(quarantinedSpecReports, jobResults)).To(BeEmpty())
+		})
+
+		It("keeps tests where the quarantine is not set on the leaf node", func() {
+			jobResults := []*flakefinder.JobResult{
+				newJobResult("periodic-kubevirt-e2e-k8s-1.35-sig-compute", 1,
+					junit.Test{Name: "[QUARANTINE]VM should be stable", Status: junit.StatusPassed},
+				),
+				newJobResult("periodic-kubevirt-e2e-k8s-1.35-sig-compute", 2,
+					junit.Test{Name: "[QUARANTINE]VM should be stable", Status: junit.StatusPassed},
+				),
+			}
+			specReports := []types.SpecReport{
+				{
+					ContainerHierarchyTexts: []string{"[QUARANTINE]VM"},
+					LeafNodeText:            "should be stable",
+				},
+			}
+
+			Expect(determineTestsForDequarantine(specReports, jobResults)).To(BeEmpty())
+		})
+
+		It("respects maxTestsToDequarantine ceiling", func() {
+			quarantineOpts.minPassedRuns = 1
+			quarantineOpts.maxTestsToDequarantine = 1
+			jobResults := []*flakefinder.JobResult{
+				newJobResult("periodic-kubevirt-e2e-k8s-1.35-sig-compute", 1,
+					junit.Test{Name: stableTestName, Status: junit.StatusPassed},
+					junit.Test{Name: unstableTestName, Status: junit.StatusPassed},
+				),
+			}
+
+			Expect(determineTestsForDequarantine(quarantinedSpecReports, jobResults)).To(HaveLen(1))
+		})
+	})
+
+	When("writing pr description", func() {
+		var tempFile *os.File
+		BeforeEach(func() {
+			var err error
+			tempFile, err = os.CreateTemp("", "dequarantine-pr-description-test-*.md")
+			Expect(err).ToNot(HaveOccurred())
+		})
+		AfterEach(func() {
+			Expect(os.Remove(tempFile.Name())).ToNot(HaveOccurred())
+		})
+
+		It("contains test, lane evidence and prow commands", func() {
+			Expect(writeDequarantinePRDescriptionToFile(tempFile.Name(), []*TestToDequarantine{
+				{
+					SpecReport: &quarantinedSpecReports[0],
+					LaneResults: []*LaneResult{
+						{Job: "periodic-kubevirt-e2e-k8s-1.35-sig-compute", Passed: 12},
+					},
+					ProwCommands: []string{"sig compute"},
+				},
+			})).To(Succeed())
+
+			content, err := os.ReadFile(tempFile.Name())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(content)).To(ContainSubstring("VM [QUARANTINE]should be stable"))
+			Expect(string(content)).To(ContainSubstring("**12** passed runs"))
+			Expect(string(content)).To(ContainSubstring("[periodic-kubevirt-e2e-k8s-1.35-sig-compute](https://prow.ci.kubevirt.io/job-history/gs/kubevirt-prow/logs/periodic-kubevirt-e2e-k8s-1.35-sig-compute): 12 passed"))
+			Expect(string(content)).To(ContainSubstring("/sig compute"))
+		})
+	})
+})
@@ -0,0 +1,501 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/onsi/ginkgo/v2/types"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"kubevirt.io/project-infra/pkg/ginkgo"
+	"kubevirt.io/project-infra/pkg/options"
+	"kubevirt.io/project-infra/pkg/searchci"
+	"sigs.k8s.io/prow/pkg/config/secret"
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+const (
+	quarantineIssueLabel    = "kind/flake"
+	autoQuarantinePRLabel   = "kind/auto-quarantine"
+	maxIssueTitleLength     = 256
+	quarantineIssueTitleFmt = "Quarantined test: %s"
+	quarantineDigestTitle   = "Quarantined tests digest for %s"
+
+	shortIssues = "Tracks quarantined tests with GitHub issues"
+
+	shortSyncIssues = "Creates, updates and closes the GitHub issues tracking quarantined tests"
+	longSyncIssues  = shortSyncIssues + `.
+
+Determines all quarantined tests from the test source using the ginkgo
+dry-run mechanism. For every quarantined test one GitHub issue is created or
+updated. The issue carries the SIG/WG labels of the test, the failures
+found on search.ci together with their impact and a link to the PR that
+quarantined the test. Existing issues are only updated if the quarantine PR
+or the lanes the test failed on changed, not for changes of the impact alone.
+
+Issues for tests that are no longer quarantined are closed.`
+
+	shortDigestIssues = "Creates a digest of the open quarantine issues per SIG"
+	longDigestIssues  = shortDigestIssues + `.
+
+Lists all open quarantine issues grouped by SIG/WG label together with their
+age. The digest is written to the output file and each SIG/WG digest is posted
+as a comment on the digest issue for that SIG/WG, which is created if it does
+not exist yet.`
+)
+
+var (
+	issuesCmd = &cobra.Command{
+		Use:   "issues",
+		Short: shortIssues,
+	}
+
+	syncIssuesCmd = &cobra.Command{
+		Use:   "sync",
+		Short: shortSyncIssues,
+		Long:  longSyncIssues,
+		RunE:  SyncQuarantineIssues,
+	}
+
+	digestIssuesCmd = &cobra.Command{
+		Use:   "digest",
+		Short: shortDigestIssues,
+		Long:  longDigestIssues,
+		RunE:  DigestQuarantineIssues,
+	}
+
+	//go:embed quarantine-issue.gomd
+	quarantineIssueTemplateText string
+
+	//go:embed quarantine-digest.gomd
+	quarantineDigestTemplateText string
+
+	quarantineIssueTemplate  *template.Template
+	quarantineDigestTemplate *template.Template
+
+	quarantineIssueMarker        = regexp.MustCompile(`<!-- quarantined-test: (.+) -->`)
+	quarantineIssueContentMarker = regexp.MustCompile(`<!-- quarantine-issue-content: ([0-9a-f]+) -->`)
+	quarantineDigestMarker       = regexp.MustCompile(`<!-- quarantine-digest: (.+) -->`)
+)
+
+func init() {
+	issuesCmd.PersistentFlags().StringVar(&quarantineOpts.githubTokenPath, "github-token-path", "/etc/github/oauth", "path to the file containing the GitHub token")
+	issuesCmd.PersistentFlags().StringVar(&quarantineOpts.githubEndpoint, "github-endpoint", github.DefaultAPIEndpoint, "GitHub's API endpoint")
+	issuesCmd.PersistentFlags().StringVar(&quarantineOpts.githubGraphQLEndpoint, "github-graphql-endpoint", github.DefaultGraphQLEndpoint, "GitHub's GraphQL API endpoint")
+	issuesCmd.PersistentFlags().StringVar(&quarantineOpts.issuesOrgRepo, "issues-org-repo", "kubevirt/kubevirt", "org/repo where the quarantine issues are tracked")
+	issuesCmd.PersistentFlags().BoolVar(&quarantineOpts.dryRun, "dry-run", true, "whether to only log the changes instead of applying them to GitHub")
+	issuesCmd.PersistentFlags().StringVar(&quarantineOpts.labelsYAMLPath, "labels-yaml", "github/ci/prow-deploy/kustom/base/configs/current/labels/labels.yaml", "path to the Prow labels.yaml file used to determine valid SIG/WG labels")
+
+	quarantineOpts.digestOutputFileOpts = options.NewOutputFileOptions(
+		"quarantine-digest-*.md",
+		options.WithOverwrite(),
+	)
+	digestIssuesCmd.PersistentFlags().StringVar(&quarantineOpts.digestOutputFileOpts.OutputFile, "digest-output-file", "", "the path to the output file to write the digest into, or if unset a temp file will be generated")
+
+	issuesCmd.AddCommand(syncIssuesCmd)
+	issuesCmd.AddCommand(digestIssuesCmd)
+
+	var err error
+	quarantineIssueTemplate, err = template.New("quarantineIssue").Parse(quarantineIssueTemplateText)
+	if err != nil {
+		log.Fatalf("could not read template: %v", err)
+	}
+	quarantineDigestTemplate, err = template.New("quarantineDigest").Parse(quarantineDigestTemplateText)
+	if err != nil {
+		log.Fatalf("could not read template: %v", err)
+	}
+}
+
+type issuesClient interface {
+	FindIssues(query, sort string, asc bool) ([]github.Issue, error)
+	CreateIssue(org, repo, title, body string, milestone int, labels, assignees []string) (int, error)
+	EditIssue(org, repo string, number int, issue *github.Issue) (*github.Issue, error)
+	CreateComment(owner, repo string, number int, comment string) error
+	CloseIssue(org, repo string, number int) error
+}
+
+var newIssuesClient = newIssuesClientDefault
+
+func newIssuesClientDefault() (issuesClient, error) {
+	if err := secret.Add(quarantineOpts.githubTokenPath); err != nil {
+		return nil, fmt.Errorf("could not start secrets agent: %w", err)
+	}
+	if quarantineOpts.dryRun {
+		return github.NewDryRunClient(secret.GetTokenGenerator(quarantineOpts.githubTokenPath), secret.Censor, quarantineOpts.githubGraphQLEndpoint, quarantineOpts.githubEndpoint)
+	}
+	return github.NewClient(secret.GetTokenGenerator(quarantineOpts.githubTokenPath), secret.Censor, quarantineOpts.githubGraphQLEndpoint, quarantineOpts.githubEndpoint)
+}
+
+func orgRepo() (string, string, error) {
+	org, repo, found := strings.Cut(quarantineOpts.issuesOrgRepo, "/")
+	if !found || org == "" || repo == "" {
+		return "", "", fmt.Errorf("invalid org/repo %q", quarantineOpts.issuesOrgRepo)
+	}
+	return org, repo, nil
+}
+
+func SyncQuarantineIssues(_ *cobra.Command, _ []string) error {
+	validSIGs, validWGs, err := loadValidGroupsFromLabelsFile(quarantineOpts.labelsYAMLPath)
+	if err != nil {
+		return fmt.Errorf("could not load valid SIG/WG labels: %w", err)
+	}
+
+	reports, _, err := ginkgo.DryRun(quarantineOpts.testSourcePath)
+	if err != nil {
+		return fmt.Errorf("could not fetch ginkgo test reports: %w", err)
+	}
+	if reports == nil {
+		return fmt.Errorf("could not find ginkgo test reports: %w", err)
+	}
+	quarantinedSpecReports := ginkgo.FilterSpecReports(reports, ginkgo.IsQuarantined, -1)
+	log.Infof("found %d quarantined tests", len(quarantinedSpecReports))
+
+	client, err := newIssuesClient()
+	if err != nil {
+		return fmt.Errorf("could not create GitHub client: %w", err)
+	}
+	return syncQuarantineIssues(client, quarantinedSpecReports, validSIGs, validWGs)
+}
+
+// syncQuarantineIssues makes sure that every quarantined test has exactly one
+// open issue with up-to-date evidence, and that the issues of tests that are
+// no longer quarantined are closed.
+func syncQuarantineIssues(client issuesClient, quarantinedSpecReports []types.SpecReport, validSIGs, validWGs map[string]bool) error {
+	org, repo, err := orgRepo()
+	if err != nil {
+		return err
+	}
+
+	openIssues, err := findOpenQuarantineIssues(client, org, repo)
+	if err != nil {
+		return err
+	}
+
+	quarantinePRs, err := client.FindIssues(fmt.Sprintf("repo:%s/%s is:pr is:merged label:%s", org, repo, autoQuarantinePRLabel), "created", false)
+	if err != nil {
+		return fmt.Errorf("could not find quarantine PRs: %w", err)
+	}
+
+	quarantinedTestNames := map[string]struct{}{}
+	for _, specReport := range quarantinedSpecReports {
+		testName := quarantinedTestName(specReport)
+		quarantinedTestNames[testName] = struct{}{}
+		testLogger := log.WithField("test", testName)
+
+		quarantineIssue, err := newQuarantineIssue(testName, specReport, validSIGs, validWGs, quarantinePRs)
+		if err != nil {
+			return err
+		}
+		body, err := renderTemplate(quarantineIssueTemplate, quarantineIssue)
+		if err != nil {
+			return err
+		}
+
+		issue, exists := openIssues[testName]
+		if !exists {
+			number, err := client.CreateIssue(org, repo, quarantineIssueTitle(testName), body, 0, quarantineIssue.Labels, nil)
+			if err != nil {
+				return fmt.Errorf("could not create issue for test %q: %w", testName, err)
+			}
+			testLogger.Infof("created issue #%d", number)
+			continue
+		}
+		if matches := quarantineIssueContentMarker.FindStringSubmatch(issue.Body); matches != nil && matches[1] == quarantineIssue.ContentHash() {
+			testLogger.Debugf("issue #%d is up to date", issue.Number)
+			continue
+		}
+		updatedIssue := issue
+		updatedIssue.Body = body
+		if _, err := client.EditIssue(org, repo, issue.Number, &updatedIssue); err != nil {
+			return fmt.Errorf("could not update issue #%d for test %q: %w", issue.Number, testName, err)
+		}
+		testLogger.Infof("updated issue #%d", issue.Number)
+	}
+
+	for testName, issue := range openIssues {
+		if _, stillQuarantined := quarantinedTestNames[testName]; stillQuarantined {
+			continue
+		}
+		comment := fmt.Sprintf("The test `%s` is no longer quarantined, closing.", testName)
+		if err := client.CreateComment(org, repo, issue.Number, comment); err != nil {
+			return fmt.Errorf("could not comment on issue #%d: %w", issue.Number, err)
+		}
+		if err := client.CloseIssue(org, repo, issue.Number); err != nil {
+			return fmt.Errorf("could not close issue #%d: %w", issue.Number, err)
+		}
+		log.WithField("test", testName).Infof("closed issue #%d", issue.Number)
+	}
+	return nil
+}
+
+// findOpenQuarantineIssues returns the open quarantine issues mapped by the
+// name of the test they are tracking.
+func findOpenQuarantineIssues(client issuesClient, org, repo string) (map[string]github.Issue, error) {
+	issues, err := client.FindIssues(fmt.Sprintf(`repo:%s/%s is:issue is:open label:%s in:body "quarantined-test"`, org, repo, quarantineIssueLabel), "created", true)
+	if err != nil {
+		return nil, fmt.Errorf("could not find quarantine issues: %w", err)
+	}
+	result := map[string]github.Issue{}
+	for _, issue := range issues {
+		if issue.IsPullRequest() || issue.State == "closed" {
+			continue
+		}
+		matches := quarantineIssueMarker.FindStringSubmatch(issue.Body)
+		if matches == nil {
+			continue
+		}
+		testName := matches[1]
+		if existing, exists := result[testName]; exists {
+			log.WithField("test", testName).Warnf("duplicate issues #%d and #%d, ignoring #%d", existing.Number, issue.Number, issue.Number)
+			continue
+		}
+		result[testName] = issue
+	}
+	return result, nil
+}
+
+var fetchSearchCIImpacts = fetchSearchCIImpactsDefault
+
+func fetchSearchCIImpactsDefault(testName string, timeRange searchci.TimeRange) ([]searchci.Impact, error) {
+	impacts, err := searchci.FetchImpacts(testName, timeRange)
+	if err != nil {
+		return nil, err
+	}
+	impacts = searchci.FilterImpactsBy(impacts,
+		isNotARehearsal(),
+		isNotAFlakeCheckRun(),
+		isNotADeQuarantineCheckRun(),
+	)
+	sort.Slice(impacts, func(i, j int) bool {
+		return impacts[i].Percent > impacts[j].Percent
+	})
+	return impacts, nil
+}
+
+func newQuarantineIssue(testName string, specReport types.SpecReport, validSIGs, validWGs map[string]bool, quarantinePRs []github.Issue) (*QuarantineIssue, error) {
+	impacts, err := fetchSearchCIImpacts(testName, searchci.FourteenDays)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch search.ci impacts for test %q: %w", testName, err)
+	}
+	quarantineIssue := &QuarantineIssue{
+		TestName:    testName,
+		Labels:      []string{quarantineIssueLabel},
+		TimeRange:   searchci.FourteenDays,
+		SearchCIURL: searchci.NewScrapeURL(testName, searchci.FourteenDays),
+		Impacts:     impacts,
+	}
+	for _, prowCommand := range resolveProwCommands(specReport, validSIGs, validWGs) {
+		quarantineIssue.Labels = append(quarantineIssue.Labels, strings.Replace(prowCommand, " ", "/", 1))
+	}
+	for _, pr := range quarantinePRs {
+		if pr.IsPullRequest() && strings.Contains(pr.Body, fmt.Sprintf("`%s`", testName)) {
+			quarantineIssue.QuarantinePRURL = pr.HTMLURL
+			break
+		}
+	}
+	return quarantineIssue, nil
+}
+
+// ContentHash returns the hash of the content of the issue that doesn't change
+// in between syncs, i.e. without the impacts and the failure intervals, so
+// that the issue is only updated if the quarantine PR or the lanes the test
+// failed on change.
+func (q *QuarantineIssue) ContentHash() string {
+	var lanes []string
+	for _, impact := range q.Impacts {
+		lanes = append(lanes, impact.URL)
+	}
+	sort.Strings(lanes)
+	hash := sha256.New()
+	for _, value := range append([]string{q.TestName, q.QuarantinePRURL, string(q.TimeRange)}, lanes...) {
+		hash.Write([]byte(value))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// quarantinedTestName returns the name of the test without the quarantine
+// prefix, which is how it appears in the results from before the quarantine.
+func quarantinedTestName(specReport types.SpecReport) string {
+	return strings.Join(strings.Fields(strings.ReplaceAll(specReport.FullText(), "[QUARANTINE]", "")), " ")
+}
+
+func quarantineIssueTitle(testName string) string {
+	title := []rune(fmt.Sprintf(quarantineIssueTitleFmt, testName))
+	if len(title) > maxIssueTitleLength {
+		return string(title[:maxIssueTitleLength-1]) + "…"
+	}
+	return string(title)
+}
+
+func DigestQuarantineIssues(_ *cobra.Command, _ []string) error {
+	err := quarantineOpts.digestOutputFileOpts.Validate()
+	if err != nil {
+		return err
+	}
+	org, repo, err := orgRepo()
+	if err != nil {
+		return err
+	}
+	client, err := newIssuesClient()
+	if err != nil {
+		return fmt.Errorf("could not create GitHub client: %w", err)
+	}
+	openIssues, err := findOpenQuarantineIssues(client, org, repo)
+	if err != nil {
+		return err
+	}
+
+	digests := newQuarantineDigests(openIssues, time.Now())
+	err = writeQuarantineDigestsToFile(quarantineOpts.digestOutputFileOpts.OutputFile, digests)
+	if err != nil {
+		return err
+	}
+	return postQuarantineDigests(client, org, repo, digests)
+}
+
+// newQuarantineDigests groups the open quarantine issues by their SIG/WG
+// labels, oldest issue first.
+func newQuarantineDigests(openIssues map[string]github.Issue, now time.Time) []*QuarantineDigest {
+	digestsByLabel := map[string]*QuarantineDigest{}
+	for testName, issue := range openIssues {
+		labels := groupLabels(issue)
+		if len(labels) == 0 {
+			labels = []string{strings.Replace(defaultProwCommand, " ", "/", 1)}
+		}
+		for _, label := range labels {
+			digest, exists := digestsByLabel[label]
+			if !exists {
+				digest = &QuarantineDigest{Label: label, Date: now}
+				digestsByLabel[label] = digest
+			}
+			digest.Issues = append(digest.Issues, &QuarantineDigestEntry{
+				Number:    issue.Number,
+				URL:       issue.HTMLURL,
+				TestName:  testName,
+				AgeInDays: int(now.Sub(issue.CreatedAt).Hours() / 24),
+			})
+		}
+	}
+
+	var digests []*QuarantineDigest
+	for _, digest := range digestsByLabel {
+		sort.Slice(digest.Issues, func(i, j int) bool {
+			if digest.Issues[i].AgeInDays != digest.Issues[j].AgeInDays {
+				return digest.Issues[i].AgeInDays > digest.Issues[j].AgeInDays
+			}
+			return digest.Issues[i].Number < digest.Issues[j].Number
+		})
+		digests = append(digests, digest)
+	}
+	sort.Slice(digests, func(i, j int) bool {
+		return digests[i].Label < digests[j].Label
+	})
+	return digests
+}
+
+func groupLabels(issue github.Issue) []string {
+	var labels []string
+	for _, label := range issue.Labels {
+		if strings.HasPrefix(label.Name, "sig/") || strings.HasPrefix(label.Name, "wg/") {
+			labels = append(labels, label.Name)
+		}
+	}
+	return labels
+}
+
+func writeQuarantineDigestsToFile(outputFileName string, digests []*QuarantineDigest) error {
+	if outputFileName == "" {
+		return fmt.Errorf("output file name must not be empty")
+	}
+	var content []string
+	for _, digest := range digests {
+		rendered, err := renderTemplate(quarantineDigestTemplate, digest)
+		if err != nil {
+			return err
+		}
+		content = append(content, rendered)
+	}
+	err := os.WriteFile(outputFileName, []byte(strings.Join(content, "\n")), 0644)
+	if err != nil {
+		return fmt.Errorf("could not write file: %w", err)
+	}
+	log.Infof("digest written to %q", outputFileName)
+	return nil
+}
+
+// postQuarantineDigests comments each digest on the digest issue of the
+// SIG/WG, creating that issue if required.
+func postQuarantineDigests(client issuesClient, org, repo string, digests []*QuarantineDigest) error {
+	issues, err := client.FindIssues(fmt.Sprintf(`repo:%s/%s is:issue is:open in:body "quarantine-digest"`, org, repo), "created", true)
+	if err != nil {
+		return fmt.Errorf("could not find digest issues: %w", err)
+	}
+	digestIssueNumbers := map[string]int{}
+	for _, issue := range issues {
+		if issue.IsPullRequest() || issue.State == "closed" {
+			continue
+		}
+		if matches := quarantineDigestMarker.FindStringSubmatch(issue.Body); matches != nil {
+			digestIssueNumbers[matches[1]] = issue.Number
+		}
+	}
+
+	for _, digest := range digests {
+		number, exists := digestIssueNumbers[digest.Label]
+		if !exists {
+			title := fmt.Sprintf(quarantineDigestTitle, digest.Label)
+			body := fmt.Sprintf("<!-- quarantine-digest: %s -->\nWeekly digest of the tests quarantined for %s.", digest.Label, digest.Label)
+			number, err = client.CreateIssue(org, repo, title, body, 0, []string{digest.Label}, nil)
+			if err != nil {
+				return fmt.Errorf("could not create digest issue for %q: %w", digest.Label, err)
+			}
+			log.Infof("created digest issue #%d for %q", number, digest.Label)
+		}
+		comment, err := renderTemplate(quarantineDigestTemplate, digest)
+		if err != nil {
+			return err
+		}
+		if err := client.CreateComment(org, repo, number, comment); err != nil {
+			return fmt.Errorf("could not comment digest on issue #%d: %w", number, err)
+		}
+		log.Infof("posted digest for %q on issue #%d", digest.Label, number)
+	}
+	return nil
+}
+
+func renderTemplate(tpl *template.Template, data any) (string, error) {
+	var buffer bytes.Buffer
+	if err := tpl.Execute(&buffer, data); err != nil {
+		return "", fmt.Errorf("could not execute template: %w", err)
+	}
+	return buffer.String(), nil
+}
@@ -0,0 +1,218 @@
+/*
+ * This file is part of the KubeVirt project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright the KubeVirt Authors.
+ *
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	"github.com/onsi/ginkgo/v2/types"
+	. "github.com/onsi/gomega"
+	"kubevirt.io/project-infra/pkg/searchci"
+	"kubevirt.io/project-infra/pkg/testutils"
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+var _ = Describe("quarantine issues", func() {
+
+	const testName = "[sig-network]VM should be reachable"
+
+	var (
+		fakeClient                   *testutils.FakeClient
+		originalIssuesOptions        issuesOptions
+		originalFetchSearchCIImpacts func(string, searchci.TimeRange) ([]searchci.Impact, error)
+		validSIGs                    = map[string]bool{"compute": true, "network": true}
+		quarantinedSpecReport        = types.SpecReport{
+			ContainerHierarchyTexts:  []string{"[sig-network]VM"},
+			ContainerHierarchyLabels: [][]string{{"sig-network"}},
+			LeafNodeText:             "[QUARANTINE]should be reachable",
+			LeafNodeLabels:           []string{"Quarantine"},
+		}
+	)
+
+	quarantineIssue := func(number int, testName string) *github.Issue {
+		return &github.Issue{
+			Number:    number,
+			HTMLURL:   fmt.Sprintf("https://github.com/kubevirt/kubevirt/issues/%d", number),
+			Body:      "<!-- quarantined-test: " + testName + " -->\nsome evidence",
+			Labels:    []github.Label{{Name: "kind/flake"}, {Name: "sig/network"}},
+			CreatedAt: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		}
+	}
+
+	BeforeEach(func() {
+		originalIssuesOptions = quarantineOpts.issuesOptions
+		quarantineOpts.issuesOrgRepo = "kubevirt/kubevirt"
+		originalFetchSearchCIImpacts = fetchSearchCIImpacts
+		fetchSearchCIImpacts = func(string, searchci.TimeRange) ([]searchci.Impact, error) {
+			return []searchci.Impact{
+				{
+					URL:          "https://search.ci.kubevirt.io/job-history/kubevirt-prow/pr-logs/directory/pull-kubevirt-e2e-k8s-1.35-sig-network",
+					URLToDisplay: "pull-kubevirt-e2e-k8s-1.35-sig-network",
+					Percent:      4.5,
+					BuildURLs: []searchci.JobBuildURL{
+						{URL: "https://prow.ci.kubevirt.io/view/gs/kubevirt-prow/pr-logs/pull/kubevirt_kubevirt/1/pull-kubevirt-e2e-k8s-1.35-sig-network/42", Interval: 2 * time.Hour},
+					},
+				},
+			}, nil
+		}
+		fakeClient = &testutils.FakeClient{
+			Issues:        map[int]*github.Issue{},
+			IssueComments: map[int][]github.IssueComment{},
+			IssueID:       10,
+		}
+	})
+
+	AfterEach(func() {
+		quarantineOpts.issuesOptions = originalIssuesOptions
+		fetchSearchCIImpacts = originalFetchSearchCIImpacts
+	})
+
+	When("syncing issues", func() {
+
+		It("creates an issue for a quarantined test", func() {
+			fakeClient.Issues[1] = &github.Issue{
+				Number:      1,
+				HTMLURL:     "https://github.com/kubevirt/kubevirt/pull/1",
+				Body:        "Auto-quarantine flaky tests\n\n### `" + testName + "`\n",
+				PullRequest: &struct{}{},
+			}
+
+			Expect(syncQuarantineIssues(fakeClient, []types.SpecReport{quarantinedSpecReport}, validSIGs, nil)).To(Succeed())
+
+			Expect(fakeClient.Issues).To(HaveKey(11))
+			issue := fakeClient.Issues[11]
+			Expect(issue.Title).To(Equal("Quarantined test: " + testName))
+			Expect(issue.Labels).To(ConsistOf(github.Label{Name: "kind/flake"}, github.Label{Name: "sig/network"}))
+			Expect(issue.Body).To(ContainSubstring("<!-- quarantined-test: " + testName + " -->"))
+			Expect(issue.Body).To(ContainSubstring("https://github.com/kubevirt/kubevirt/pull/1"))
+			Expect(issue.Body).To(ContainSubstring("[pull-kubevirt-e2e-k8s-1.35-sig-network](https://search.ci.kubevirt.io/job-history/kubevirt-prow/pr-logs/directory/pull-kubevirt-e2e-k8s-1.35-sig-network): **4.5%** impact"))
+			Expect(issue.Body).To(ContainSubstring("[2h0m0s](https://prow.ci.kubevirt.io/view/gs/kubevirt-prow/pr-logs/pull/kubevirt_kubevirt/1/pull-kubevirt-e2e-k8s-1.35-sig-network/42)"))
+		})
+
+		It("updates the existing issue instead of creating a new one", func() {
+			fakeClient.Issues[2] = quarantineIssue(2, testName)
+
+			Expect(syncQuarantineIssues(fakeClient, []types.SpecReport{quarantinedSpecReport}, validSIGs, nil)).To(Succeed())
+
+			Expect(fakeClient.Issues).To(HaveLen(1))
+			Expect(fakeClient.Issues[2].Body).To(ContainSubstring("**4.5%** impact"))
+			Expect(fakeClient.Issues[2].Body).To(ContainSubstring("_could not be determined_"))
+		})
+
+		It("keeps the issue if only the impacts and intervals changed", func() {
+			fakeClient.Issues[2] = quarantineIssue(2, testName)
+			Expect(syncQuarantineIssues(fakeClient, []types.SpecReport{quarantinedSpecReport}, validSIGs, nil)).To(Succeed())
+			fetchSearchCIImpacts = func(string, searchci.TimeRange) ([]searchci.Impact, error) {
+				return []searchci.Impact{
+					{
+						URL:          "https://search.ci.kubevirt.io/job-history/kubevirt-prow/pr-logs/directory/pull-kubevirt-e2e-k8s-1.35-sig-network",
+						URLToDisplay: "pull-kubevirt-e2e-k8s-1.35-sig-network",
+						Percent:      9,
+						BuildURLs: []searchci.JobBuildURL{
+							{URL: "https://prow.ci.kubevirt.io/view/gs/kubevirt-prow/pr-logs/pull/kubevirt_kubevirt/1/pull-kubevirt-e2e-k8s-1.35-sig-network/42", Interval: 3 * time.Hour},
+						},
+					},
+				}, nil
+			}
+
+			Expect(syncQuarantineIssues(fakeClient, []types.SpecReport{quarantinedSpecReport}, validSIGs, nil)).To(Succeed())
+
+			Expect(fakeClient.Issues[2].Body).To(ContainSubstring("**4.5%** impact"))
+		})
+
+		It("updates the issue if the test failed on another lane", func() {
+			fakeClient.Issues[2] = quarantineIssue(2, testName)
+			Expect(syncQuarantineIssues(fakeClient, []types.SpecReport{quarantinedSpecReport}, validSIGs, nil)).To(Succeed())
+			fetchSearchCIImpacts = func(string, searchci.TimeRange) ([]searchci.Impact, error) {
+				return []searchci.Impact{
+					{
+						URL:          "https://search.ci.kubevirt.io/job-history/kubevirt-prow/pr-logs/directory/pull-kubevirt-e2e-k8s-1.34-sig-network",
+						URLToDisplay: "pull-kubevirt-e2e-k8s-1.34-sig-network",
+						Percent:      9,
+					},
+				}, nil
+			}
+
+			Expect(syncQuarantineIssues(fakeClient, []types.SpecReport{quarantinedSpecReport}, validSIGs, nil)).To(Succeed())
+
+			Expect(fakeClient.Issues[2].Body).To(ContainSubstring("[pull-kubevirt-e2e-k8s-1.34-sig-network]"))
+		})
+
+		It("closes the issue of a test that was dequarantined", func() {
+			fakeClient.Issues[3] = quarantineIssue(3, "[sig-compute]VM should be stable")
+
+			Expect(syncQuarantineIssues(fakeClient, nil, validSIGs, nil)).To(Succeed())
+
+			Expect(fakeClient.Issues[3].State).To(Equal("closed"))
+			Expect(fakeClient.IssueComments[3]).To(HaveLen(1))
+			Expect(fakeClient.IssueComments[3][0].Body).To(ContainSubstring("no longer quarantined"))
+		})
+	})
+
+	When("creating the digest", func() {
+
+		It("groups open issues by SIG with their age, oldest first", func() {
+			older := quarantineIssue(4, "[sig-network]VM should be older")
+			older.CreatedAt = time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+			openIssues := map[string]github.Issue{
+				testName:                          *quarantineIssue(5, testName),
+				"[sig-network]VM should be older": *older,
+			}
+
+			digests := newQuarantineDigests(openIssues, time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC))
+
+			Expect(digests).To(HaveLen(1))
+			Expect(digests[0].Label).To(Equal("sig/network"))
+			Expect(digests[0].Issues).To(HaveLen(2))
+			Expect(digests[0].Issues[0].Number).To(Equal(4))
+			Expect(digests[0].Issues[0].AgeInDays).To(Equal(48))
+			Expect(digests[0].Issues[1].AgeInDays).To(Equal(18))
+		})
+
+		It("posts the digest on a newly created digest issue", func() {
+			digests := newQuarantineDigests(map[string]github.Issue{testName: *quarantineIssue(5, testName)}, time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC))
+
+			Expect(postQuarantineDigests(fakeClient, "kubevirt", "kubevirt", digests)).To(Succeed())
+
+			Expect(fakeClient.Issues).To(HaveKey(11))
+			Expect(fakeClient.Issues[11].Title).To(Equal("Quarantined tests digest for sig/network"))
+			Expect(fakeClient.IssueComments[11]).To(HaveLen(1))
+			Expect(fakeClient.IssueComments[11][0].Body).To(ContainSubstring("| `" + testName + "` | 18 |"))
+		})
+
+		It("writes the digest to file", func() {
+			tempFile, err := os.CreateTemp("", "quarantine-digest-test-*.md")
+			Expect(err).ToNot(HaveOccurred())
+			defer func() { Expect(os.Remove(tempFile.Name())).To(Succeed()) }()
+
+			Expect(writeQuarantineDigestsToFile(tempFile.Name(), []*QuarantineDigest{
+				{Label: "sig/compute", Date: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)},
+			})).To(Succeed())
+
+			content, err := os.ReadFile(tempFile.Name())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(content)).To(ContainSubstring("### Quarantined tests for sig/compute as of 2026-10-19"))
+			Expect(string(content)).To(ContainSubstring("_No open quarantine issues._"))
+		})
+	})
+})
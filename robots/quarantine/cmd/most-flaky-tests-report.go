@@ -51,7 +51,7 @@ var (
 		Long: shortReportHelp + ` using data from flake-stats and search.ci
 
 It fetches data for flake-stats from the last x days, then for each test it
-scrapes search.ci for impact on lanes.
+queries search.ci for impact on lanes.
 All tests that exceed either the 3 day or 14 day value are added to
 the report.
 All output is aggregated with links to sources into an html page.
@@ -202,12 +202,12 @@ func aggregateMostFlakyTestsBySIG(topXTests flakestats.TopXTests) (sigs []string
 var getQuarantineCandidate = getQuarantineCandidateDefault
 
 func getQuarantineCandidateDefault(topXTest *flakestats.TopXTest, timeRange searchci.TimeRange) (*TestToQuarantine, error) {
-	impacts, err := searchci.ScrapeImpacts(topXTest.Name, timeRange)
+	impacts, err := searchci.FetchImpacts(topXTest.Name, timeRange)
 	if err != nil {
-		return nil, fmt.Errorf("could not scrape results for test %q: %w", topXTest.Name, err)
+		return nil, fmt.Errorf("could not fetch search.ci results for test %q: %w", topXTest.Name, err)
 	}
 	if impacts == nil {
-		log.Infof("search.ci scrape found no matches for test %q", topXTest.Name)
+		log.Infof("search.ci found no matches for test %q", topXTest.Name)
 		return nil, nil
 	}
 	impacts = searchci.FilterImpactsBy(impacts,
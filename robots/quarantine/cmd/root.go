@@ -22,26 +22,37 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/onsi/ginkgo/v2/types"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"kubevirt.io/project-infra/pkg/ginkgo"
+	"kubevirt.io/project-infra/pkg/searchci"
 )
 
 var rootCmd = &cobra.Command{
 	Use: "quarantine",
+	PersistentPreRun: func(_ *cobra.Command, _ []string) {
+		if quarantineOpts.searchCICacheDir != "" {
+			searchci.DefaultClient = searchci.NewClient(searchci.WithCache(quarantineOpts.searchCICacheDir, quarantineOpts.searchCICacheTTL))
+		}
+	},
 }
 
 var quarantineOpts quarantineOptions
 
 func init() {
 	rootCmd.PersistentFlags().StringVar(&quarantineOpts.testSourcePath, "test-source-path", "../kubevirt/tests/", "the path to the Test source")
+	rootCmd.PersistentFlags().StringVar(&quarantineOpts.searchCICacheDir, "search-ci-cache-dir", "", "the directory to cache search.ci results in, or if unset results are not cached")
+	rootCmd.PersistentFlags().DurationVar(&quarantineOpts.searchCICacheTTL, "search-ci-cache-ttl", time.Hour, "the duration for which cached search.ci results are reused")
 
 	rootCmd.AddCommand(mostFlakyTestsReportCmd)
 	rootCmd.AddCommand(quarantineTestCmd)
 	rootCmd.AddCommand(infoTestCmd)
 	rootCmd.AddCommand(autoQuarantineCmd)
+	rootCmd.AddCommand(dequarantineCmd)
+	rootCmd.AddCommand(issuesCmd)
 }
 
 func Execute() {
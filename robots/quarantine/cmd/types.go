@@ -25,6 +25,7 @@ import (
 
 	"github.com/onsi/ginkgo/v2/types"
 	flakestats "kubevirt.io/project-infra/pkg/flake-stats"
+	"kubevirt.io/project-infra/pkg/flakefinder"
 	"kubevirt.io/project-infra/pkg/options"
 	"kubevirt.io/project-infra/pkg/searchci"
 )
@@ -51,9 +52,28 @@ func (a autoQuarantineOptions) MatchingLaneRegexString() string {
 	return fmt.Sprintf(a.matchingLaneRegexString, a.releaseLaneSuffix)
 }
 
+type dequarantineOptions struct {
+	checkLaneRegexString                    string
+	minPassedRuns                           int
+	maxTestsToDequarantine                  int
+	dequarantinePRDescriptionOutputFileOpts *options.OutputFileOptions
+}
+
+type issuesOptions struct {
+	githubTokenPath       string
+	githubEndpoint        string
+	githubGraphQLEndpoint string
+	issuesOrgRepo         string
+	dryRun                bool
+	digestOutputFileOpts  *options.OutputFileOptions
+}
+
 type quarantineOptions struct {
 	testSourcePath string
 
+	searchCICacheDir string
+	searchCICacheTTL time.Duration
+
 	daysInThePast int
 
 	filterPeriodicJobRunResults bool
@@ -63,6 +83,8 @@ type quarantineOptions struct {
 	testName string
 
 	autoQuarantineOptions
+	dequarantineOptions
+	issuesOptions
 }
 
 type TestToQuarantine struct {
@@ -75,6 +97,68 @@ type TestToQuarantine struct {
 	ProwCommands      []string
 }
 
+// TestToDequarantine holds a quarantined test together with the results from
+// the check lanes that serve as evidence for its stability.
+type TestToDequarantine struct {
+	SpecReport   *types.SpecReport
+	LaneResults  []*LaneResult
+	ProwCommands []string
+}
+
+func (t TestToDequarantine) Passed() int {
+	passed := 0
+	for _, laneResult := range t.LaneResults {
+		passed += laneResult.Passed
+	}
+	return passed
+}
+
+func (t TestToDequarantine) Failed() int {
+	failed := 0
+	for _, laneResult := range t.LaneResults {
+		failed += laneResult.Failed
+	}
+	return failed
+}
+
+// LaneResult aggregates the results of a test within the runs of one lane.
+type LaneResult struct {
+	Job    string
+	Passed int
+	Failed int
+	// FailedBuilds holds the build numbers where the test failed
+	FailedBuilds []int
+}
+
+func (l LaneResult) JobHistoryURL() string {
+	return fmt.Sprintf("https://prow.ci.kubevirt.io/job-history/gs/%s/logs/%s", flakefinder.BucketName, l.Job)
+}
+
+// QuarantineIssue holds the data for the GitHub issue that tracks a
+// quarantined test.
+type QuarantineIssue struct {
+	TestName        string
+	Labels          []string
+	TimeRange       searchci.TimeRange
+	SearchCIURL     string
+	Impacts         []searchci.Impact
+	QuarantinePRURL string
+}
+
+// QuarantineDigest lists the open quarantine issues of one SIG or WG.
+type QuarantineDigest struct {
+	Label  string
+	Date   time.Time
+	Issues []*QuarantineDigestEntry
+}
+
+type QuarantineDigestEntry struct {
+	Number    int
+	URL       string
+	TestName  string
+	AgeInDays int
+}
+
 type TestsPerSIG map[string][]*TestToQuarantine
 
 var (
@@ -35,9 +35,12 @@ import (
 	"time"
 
 	"kubevirt.io/project-infra/pkg/flakefinder"
+	"kubevirt.io/project-infra/pkg/ginkgo"
 	testreport "kubevirt.io/project-infra/pkg/test-report"
 
+	"cloud.google.com/go/storage"
 	"github.com/bndr/gojenkins"
+	"github.com/onsi/ginkgo/v2/types"
 	"github.com/sirupsen/logrus"
 	"sigs.k8s.io/yaml"
 
@@ -58,6 +61,17 @@ It constructs a matrix of test lanes by test names and shows for each test:
 
 Tests that are not run on any lane are especially marked in order to emphasize that fact.
 
+The lanes are taken from Jenkins (jobs matching 'jobNamePattern') and from the Prow job runs stored in GCS (if 'prow' is set, jobs
+below 'logs/' matching 'prow.jobNamePattern', reading 'artifacts/junit*.xml' of each run). Both sources are combined
+into one matrix, thus upstream and downstream coverage can be compared. If 'jobNamePattern' is empty, Jenkins is not
+contacted at all.
+
+Tests that are skipped everywhere, i.e. by the label filters of the lanes, never show up in the junit results. To
+detect them, pass the test sources of a kubevirt checkout using --test-source-path. The specs found by a ginkgo dry-run
+are then compared against the tests that have been run, and those that have not been run on any lane are reported,
+grouped by SIG and by the label filter that excludes them. The label filters per lane are taken from
+'jobNamePatternsToLabelFilters' of the configuration.
+
 Accompanying the html file a json data file is emitted for further consumption.
 
 Note: generating a default report can take a while and will emit a report of enormous size, therefore you can strip down
@@ -91,6 +105,7 @@ func init() {
 	executionCmd.PersistentFlags().BoolVar(&executionReportFlagOpts.overwrite, "overwrite", true, "overwrite output file")
 	executionCmd.PersistentFlags().BoolVar(&executionReportFlagOpts.dryRun, "dry-run", false, "only check which jobs would be considered, do not create an actual report")
 	executionCmd.PersistentFlags().StringVar(&executionReportFlagOpts.exportConfigFilePath, "config-file-export-path", "", "just export selected config as a file, do not create an actual report")
+	executionCmd.PersistentFlags().StringVar(&executionReportFlagOpts.testSourcePath, "test-source-path", "", "path to the test sources of a kubevirt checkout (i.e. ../kubevirt/tests/), if set the specs found by a ginkgo dry-run that have not been run on any lane are reported")
 }
 
 type executionReportFlagOptions struct {
@@ -102,6 +117,7 @@ type executionReportFlagOptions struct {
 	overwrite            bool
 	dryRun               bool
 	exportConfigFilePath string
+	testSourcePath       string
 }
 
 func (o *executionReportFlagOptions) Validate() error {
@@ -182,12 +198,16 @@ var storageConfigFileContent []byte
 //go:embed "ssp-config.yaml"
 var sspConfigFileContent []byte
 
+//go:embed "upstream-config.yaml"
+var upstreamConfigFileContent []byte
+
 var configs = map[string][]byte{
-	"default": defaultConfigFileContent,
-	"compute": computeConfigFileContent,
-	"network": networkConfigFileContent,
-	"storage": storageConfigFileContent,
-	"ssp":     sspConfigFileContent,
+	"default":  defaultConfigFileContent,
+	"compute":  computeConfigFileContent,
+	"network":  networkConfigFileContent,
+	"storage":  storageConfigFileContent,
+	"ssp":      sspConfigFileContent,
+	"upstream": upstreamConfigFileContent,
 }
 
 var config *testreport.Config
@@ -233,37 +253,64 @@ func runExecutionReport() error {
 
 	ctx := context.Background()
 
-	logger.Printf("Creating client for %s", executionReportFlagOpts.endpoint)
-	jenkins := gojenkins.CreateJenkins(client, executionReportFlagOpts.endpoint)
-	_, err = jenkins.Init(ctx)
-	if err != nil {
-		logger.Fatalf("failed to contact jenkins %s: %v", executionReportFlagOpts.endpoint, err)
-	}
+	var jobs []*gojenkins.Job
+	if config.JobNamePattern != "" {
+		logger.Printf("Creating client for %s", executionReportFlagOpts.endpoint)
+		jenkins := gojenkins.CreateJenkins(client, executionReportFlagOpts.endpoint)
+		_, err = jenkins.Init(ctx)
+		if err != nil {
+			logger.Fatalf("failed to contact jenkins %s: %v", executionReportFlagOpts.endpoint, err)
+		}
 
-	jobNamePattern := regexp.MustCompile(config.JobNamePattern)
+		jobNamePattern := regexp.MustCompile(config.JobNamePattern)
 
-	jobNames, err := jenkins.GetAllJobNames(ctx)
-	if err != nil {
-		logger.Fatalf("failed to get jobs: %v", err)
-	}
-	jobs, err := testreport.FilterMatchingJobsByJobNamePattern(ctx, jenkins, jobNames, jobNamePattern)
-	if err != nil {
-		logger.Fatalf("failed to filter matching jobs: %v", err)
+		jobNames, err := jenkins.GetAllJobNames(ctx)
+		if err != nil {
+			logger.Fatalf("failed to get jobs: %v", err)
+		}
+		jobs, err = testreport.FilterMatchingJobsByJobNamePattern(ctx, jenkins, jobNames, jobNamePattern)
+		if err != nil {
+			logger.Fatalf("failed to filter matching jobs: %v", err)
+		}
+		var filteredJobNames []string
+		for _, job := range jobs {
+			filteredJobNames = append(filteredJobNames, job.GetName())
+		}
+		logger.Infof("jobs that are being considered: %s", strings.Join(filteredJobNames, ", "))
 	}
-	var filteredJobNames []string
-	for _, job := range jobs {
-		filteredJobNames = append(filteredJobNames, job.GetName())
+
+	var storageClient *storage.Client
+	var prowJobNames []string
+	if config.Prow != nil {
+		storageClient, err = storage.NewClient(ctx)
+		if err != nil {
+			logger.Fatalf("failed to create storage client: %v", err)
+		}
+		prowJobNames, err = testreport.FilterMatchingProwJobNames(ctx, storageClient, config.Prow.GetBucket(), regexp.MustCompile(config.Prow.JobNamePattern))
+		if err != nil {
+			logger.Fatalf("failed to filter matching prow jobs: %v", err)
+		}
+		logger.Infof("prow jobs that are being considered: %s", strings.Join(prowJobNames, ", "))
 	}
-	logger.Infof("jobs that are being considered: %s", strings.Join(filteredJobNames, ", "))
+
 	if executionReportFlagOpts.dryRun {
 		logger.Warn("dry-run mode, exiting")
 		return nil
 	}
-	if len(jobs) == 0 {
+	if len(jobs) == 0 && len(prowJobNames) == 0 {
 		logger.Warn("no jobs left, nothing to do")
 		return nil
 	}
 
+	var specReports []types.Report
+	if executionReportFlagOpts.testSourcePath != "" {
+		var output []byte
+		specReports, output, err = ginkgo.DryRun(executionReportFlagOpts.testSourcePath)
+		if err != nil {
+			logger.Fatalf("failed to dry-run tests in %q: %v\n%s", executionReportFlagOpts.testSourcePath, err, string(output))
+		}
+	}
+
 	startOfReport := time.Now().Add(-1 * executionReportFlagOpts.startFrom)
 	endOfReport := time.Now()
 
@@ -271,7 +318,22 @@ func runExecutionReport() error {
 	if config.JobNamePatternForTestNames != "" {
 		jobNamePatternForTestNames = regexp.MustCompile(config.JobNamePatternForTestNames)
 	}
-	testNamesToJobNamesToExecutionStatus := testreport.GetTestNamesToJobNamesToTestExecutions(jobs, startOfReport, ctx, regexp.MustCompile(config.TestNamePattern), jobNamePatternForTestNames)
+	var results []map[string]map[string]int
+	if len(jobs) > 0 {
+		results = append(results, testreport.GetTestNamesToJobNamesToTestExecutions(jobs, startOfReport, ctx, nil, nil))
+	}
+	jobURLs := map[string]string{}
+	if len(prowJobNames) > 0 {
+		prowResult, err := testreport.GetTestNamesToJobNamesToTestExecutionsFromProw(ctx, storageClient, config.Prow.GetBucket(), prowJobNames, startOfReport, config.MaxConnsPerHost)
+		if err != nil {
+			logger.Fatalf("failed to get test executions from prow jobs: %v", err)
+		}
+		results = append(results, prowResult)
+		for _, jobName := range prowJobNames {
+			jobURLs[jobName] = testreport.ProwJobHistoryURL(config.Prow.GetBucket(), jobName)
+		}
+	}
+	testNamesToJobNamesToExecutionStatus := testreport.MergeTestNamesToJobNamesToTestExecutions(results, regexp.MustCompile(config.TestNamePattern), jobNamePatternForTestNames)
 
 	data := testreport.CreateReportData(jobNamePatternsToTestNameFilterRegexps, testNamesToJobNamesToExecutionStatus)
 
@@ -280,6 +342,20 @@ func runExecutionReport() error {
 		logger.Fatalf("failed to write json data file: %v", err)
 	}
 
+	if executionReportFlagOpts.testSourcePath != "" {
+		specs := ginkgo.FilterSpecReports(specReports, func(r types.SpecReport) bool { return r.LeafNodeType == types.NodeTypeIt }, -1)
+		neverRunSpecs, err := testreport.FindNeverRunSpecs(specReports, data.TestNamesToJobNamesToSkipped, data.LookedAtJobs, config.JobNamePatternsToLabelFilters)
+		if err != nil {
+			logger.Fatalf("failed to find specs that have not been run: %v", err)
+		}
+		logger.Infof("%d of %d specs have not been run on any lane", len(neverRunSpecs), len(specs))
+		data.SetNeverRunSpecs(len(specs), neverRunSpecs)
+		err = writeJsonFile("-never-run.json", neverRunSpecs)
+		if err != nil {
+			logger.Fatalf("failed to write json never run data file: %v", err)
+		}
+	}
+
 	data.SetDataRange(startOfReport, endOfReport)
 	reportConfig, err := yaml.Marshal(config)
 	if err != nil {
@@ -287,6 +363,7 @@ func runExecutionReport() error {
 	}
 	data.SetReportConfig(string(reportConfig))
 	data.SetReportConfigName(configName)
+	data.SetJobURLs(jobURLs)
 
 	err = writeHTMLReportToOutputFile(data)
 	if err != nil {
@@ -310,12 +387,17 @@ func writeHTMLReportToOutputFile(data testreport.Data) error {
 }
 
 func writeJsonBaseDataFile(testNamesToJobNamesToExecutionStatus map[string]map[string]int) error {
-	bytes, err := json.MarshalIndent(testNamesToJobNamesToExecutionStatus, "", "\t")
+	return writeJsonFile(".json", testNamesToJobNamesToExecutionStatus)
+}
+
+// writeJsonFile writes the data next to the html output file, replacing the ".html" suffix with the given one
+func writeJsonFile(suffix string, data interface{}) error {
+	bytes, err := json.MarshalIndent(data, "", "\t")
 	if err != nil {
 		return fmt.Errorf("failed to marshall result: %v", err)
 	}
 
-	jsonFileName := strings.TrimSuffix(executionReportFlagOpts.outputFile, ".html") + ".json"
+	jsonFileName := strings.TrimSuffix(executionReportFlagOpts.outputFile, ".html") + suffix
 	logger.Printf("Writing json to %q", jsonFileName)
 	jsonOutputWriter, err := os.OpenFile(jsonFileName, os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil && err != os.ErrNotExist {
@@ -22,10 +22,13 @@ package execution
 import (
 	"io"
 	"os"
+	"regexp"
 	"testing"
 
+	"github.com/onsi/ginkgo/v2/types"
 	"github.com/sirupsen/logrus"
 	testreport "kubevirt.io/project-infra/pkg/test-report"
+	"sigs.k8s.io/yaml"
 )
 
 func Test_writeHTMLReportToOutput(t *testing.T) {
@@ -87,3 +90,27 @@ func Test_writeHTMLReportToOutput(t *testing.T) {
 		})
 	}
 }
+
+func Test_configs(t *testing.T) {
+	for name, content := range configs {
+		t.Run(name, func(t *testing.T) {
+			var c testreport.Config
+			if err := yaml.Unmarshal(content, &c); err != nil {
+				t.Fatalf("failed to parse config: %v", err)
+			}
+			if c.Prow != nil {
+				if _, err := regexp.Compile(c.Prow.JobNamePattern); err != nil {
+					t.Errorf("invalid prow job name pattern: %v", err)
+				}
+			}
+			for _, labelFilter := range c.JobNamePatternsToLabelFilters {
+				if _, err := regexp.Compile(labelFilter.JobNamePattern); err != nil {
+					t.Errorf("invalid job name pattern: %v", err)
+				}
+				if _, err := types.ParseLabelFilter(labelFilter.LabelFilter); err != nil {
+					t.Errorf("invalid label filter %q: %v", labelFilter.LabelFilter, err)
+				}
+			}
+		})
+	}
+}